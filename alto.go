@@ -0,0 +1,198 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// xmlEscape escapes s for XML text and attribute values, dropping the
+// characters XML 1.0 does not allow, such as most C0 controls.
+func xmlEscape(s string) string {
+	return html.EscapeString(strings.Map(func(r rune) rune {
+		switch {
+		case r == '\t', r == '\n', r == '\r',
+			r >= 0x20 && r <= 0xD7FF,
+			r >= 0xE000 && r <= 0xFFFD,
+			r >= 0x10000 && r <= utf8.MaxRune:
+			return r
+		}
+		return -1
+	}, s))
+}
+
+// altoStyle is the key of an ALTO TextStyle.
+type altoStyle struct {
+	family                  string
+	generic                 string
+	size                    float64
+	bold, italic, underline bool
+	color                   Color
+}
+
+// An ALTOWriter writes a document as ALTO XML version 4. Each page becomes
+// a Page with a PrintSpace, each block a TextBlock, and each line and word
+// a TextLine and String, with a TextStyle for every font, size and style.
+// Since the styles precede the layout in ALTO, the pages are held until
+// Close writes the document.
+type ALTOWriter struct {
+	w      io.Writer
+	opts   OCROptions
+	layout bytes.Buffer
+	styles []altoStyle
+	ids    map[altoStyle]int
+	closed bool
+}
+
+// NewALTOWriter returns a writer of ALTO documents to w.
+func NewALTOWriter(w io.Writer, opts OCROptions) *ALTOWriter {
+	return &ALTOWriter{w: w, opts: opts, ids: make(map[altoStyle]int)}
+}
+
+// WritePage adds page p, numbered num, to the document.
+func (aw *ALTOWriter) WritePage(num int, p Page) error {
+	if aw.closed {
+		return ErrWriterClosed
+	}
+	layout, _, err := p.exportLayout(aw.opts.Dedup)
+	if err != nil {
+		return &PDFError{Op: "write ALTO", Page: num, Err: err}
+	}
+	aw.writePage(num, layout)
+	return nil
+}
+
+// Close writes the document. It does not close the underlying writer.
+func (aw *ALTOWriter) Close() error {
+	if aw.closed {
+		return nil
+	}
+	aw.closed = true
+
+	var sb strings.Builder
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<alto xmlns="http://www.loc.gov/standards/alto/ns-v4#" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:schemaLocation="http://www.loc.gov/standards/alto/ns-v4# http://www.loc.gov/alto/v4/alto-4-2.xsd">
+<Description>
+<MeasurementUnit>pixel</MeasurementUnit>
+</Description>
+`)
+	if len(aw.styles) > 0 {
+		sb.WriteString("<Styles>\n")
+		for i, s := range aw.styles {
+			aw.writeStyle(&sb, i, s)
+		}
+		sb.WriteString("</Styles>\n")
+	}
+	sb.WriteString("<Layout>\n")
+	if _, err := io.WriteString(aw.w, sb.String()); err != nil {
+		return err
+	}
+	if _, err := aw.layout.WriteTo(aw.w); err != nil {
+		return err
+	}
+	_, err := io.WriteString(aw.w, "</Layout>\n</alto>\n")
+	return err
+}
+
+func (aw *ALTOWriter) writeStyle(sb *strings.Builder, i int, s altoStyle) {
+	fmt.Fprintf(sb, "<TextStyle ID=\"font%d\"", i+1)
+	if s.family != "" {
+		fmt.Fprintf(sb, " FONTFAMILY=\"%s\"", xmlEscape(s.family))
+	}
+	switch s.generic {
+	case "serif", "sans-serif":
+		fmt.Fprintf(sb, " FONTTYPE=\"%s\"", s.generic)
+	case "monospace":
+		sb.WriteString(" FONTWIDTH=\"fixed\"")
+	}
+	fmt.Fprintf(sb, " FONTSIZE=\"%g\"", s.size)
+	if s.color != (Color{}) {
+		fmt.Fprintf(sb, " FONTCOLOR=\"%02X%02X%02X\"", s.color.R, s.color.G, s.color.B)
+	}
+	var styles []string
+	if s.bold {
+		styles = append(styles, "bold")
+	}
+	if s.italic {
+		styles = append(styles, "italics")
+	}
+	if s.underline {
+		styles = append(styles, "underline")
+	}
+	if len(styles) > 0 {
+		fmt.Fprintf(sb, " FONTSTYLE=\"%s\"", strings.Join(styles, " "))
+	}
+	sb.WriteString("/>\n")
+}
+
+// styleID returns the ID of the TextStyle of a glyph, adding it if new.
+func (aw *ALTOWriter) styleID(g Text) string {
+	family, generic := fontFamily(g.Font)
+	s := altoStyle{
+		family:    family,
+		generic:   generic,
+		size:      math.Round(math.Abs(g.FontSize)*10) / 10,
+		bold:      g.Bold,
+		italic:    g.Italic,
+		underline: g.Underline,
+		color:     g.Color,
+	}
+	i, ok := aw.ids[s]
+	if !ok {
+		i = len(aw.styles)
+		aw.ids[s] = i
+		aw.styles = append(aw.styles, s)
+	}
+	return fmt.Sprintf("font%d", i+1)
+}
+
+func (aw *ALTOWriter) writePage(num int, layout *PageLayout) {
+	buf := &aw.layout
+	page := layout.Page
+	w, h := aw.opts.pixelSize(page)
+	fmt.Fprintf(buf, "<Page ID=\"page_%d\" PHYSICAL_IMG_NR=\"%d\" WIDTH=\"%d\" HEIGHT=\"%d\">\n", num, num, w, h)
+	fmt.Fprintf(buf, "<PrintSpace HPOS=\"0\" VPOS=\"0\" WIDTH=\"%d\" HEIGHT=\"%d\">\n", w, h)
+
+	for _, block := range layout.Nodes(LayoutBlock) {
+		fmt.Fprintf(buf, "<TextBlock ID=\"block_%d_%d\"%s>\n", num, block.Index+1, aw.position(nodeBounds(block), page))
+		for _, line := range block.Children {
+			fmt.Fprintf(buf, "<TextLine ID=\"line_%d_%d\"%s>\n", num, line.Index+1, aw.position(line.Bounds, page))
+			for i, word := range line.Children {
+				if i > 0 {
+					// The space runs from the end of the previous word
+					prev := aw.opts.pixelBox(line.Children[i-1].Bounds, page)
+					next := aw.opts.pixelBox(word.Bounds, page)
+					fmt.Fprintf(buf, "<SP WIDTH=\"%d\" HPOS=\"%d\" VPOS=\"%d\"/>\n", max(next[0]-prev[2], 0), prev[2], prev[1])
+				}
+				fmt.Fprintf(buf, "<String ID=\"string_%d_%d\" CONTENT=\"%s\"%s STYLEREFS=\"%s\"/>\n",
+					num, word.Index+1, xmlEscape(word.Text), aw.position(word.Bounds, page), aw.styleID(wordStyle(word)))
+			}
+			buf.WriteString("</TextLine>\n")
+		}
+		buf.WriteString("</TextBlock>\n")
+	}
+	buf.WriteString("</PrintSpace>\n</Page>\n")
+}
+
+// position returns the HPOS, VPOS, WIDTH and HEIGHT attributes of r.
+func (aw *ALTOWriter) position(r, page Rect) string {
+	b := aw.opts.pixelBox(r, page)
+	return fmt.Sprintf(" HPOS=\"%d\" VPOS=\"%d\" WIDTH=\"%d\" HEIGHT=\"%d\"", b[0], b[1], b[2]-b[0], b[3]-b[1])
+}
+
+// ExtractALTO writes the selected pages to w as ALTO XML. The extractor's
+// dedup options apply unless opts sets its own.
+func (e *Extractor) ExtractALTO(w io.Writer, opts OCROptions) error {
+	if opts.Dedup == nil {
+		opts.Dedup = e.dedup
+	}
+	return e.writePages(NewALTOWriter(w, opts))
+}
@@ -0,0 +1,95 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// ocrTestPDF has a bold title, body text with markup characters and a
+// second page in red.
+func ocrTestPDF(t *testing.T) *Reader {
+	return openTextPDF(t,
+		"BT /F2 18 Tf 72 720 Td (Report) Tj ET "+
+			"BT /F1 10 Tf 72 700 Td (Hello <world> & more) Tj 0 -12 Td (second line) Tj ET",
+		"1 0 0 rg BT /F1 10 Tf 72 700 Td (Page two) Tj ET")
+}
+
+func TestALTOWriter(t *testing.T) {
+	var sb strings.Builder
+	if err := NewExtractor(ocrTestPDF(t)).ExtractALTO(&sb, OCROptions{DPI: 144}); err != nil {
+		t.Fatal(err)
+	}
+	out := sb.String()
+	for _, err := range validateALTO(t, []byte(out)) {
+		t.Error(err)
+	}
+
+	for _, want := range []string{
+		`<Page ID="page_1" PHYSICAL_IMG_NR="1" WIDTH="1224" HEIGHT="1584">`,
+		`<String ID="string_1_2" CONTENT="Hello" HPOS="144" VPOS="168" WIDTH="50" HEIGHT="20" STYLEREFS="font2"/>`,
+		`<SP WIDTH="10" HPOS="194" VPOS="168"/>`,
+		`CONTENT="&lt;world&gt;"`,
+		`CONTENT="&amp;"`,
+		`<TextStyle ID="font1" FONTFAMILY="Helvetica" FONTTYPE="sans-serif" FONTSIZE="18" FONTSTYLE="bold"/>`,
+		`<TextStyle ID="font3" FONTFAMILY="Helvetica" FONTTYPE="sans-serif" FONTSIZE="10" FONTCOLOR="FF0000"/>`,
+		`<Page ID="page_2" PHYSICAL_IMG_NR="2"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %s", want)
+		}
+	}
+	if strings.Index(out, "<Styles>") > strings.Index(out, "<Layout>") {
+		t.Error("styles must precede the layout")
+	}
+
+	aw := NewALTOWriter(io.Discard, OCROptions{})
+	if err := aw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := aw.WritePage(1, ocrTestPDF(t).Page(1)); !errors.Is(err, ErrWriterClosed) {
+		t.Errorf("WritePage after Close = %v, want ErrWriterClosed", err)
+	}
+}
+
+func TestALTOControlCharacters(t *testing.T) {
+	r := openTextPDF(t, `BT /F1 10 Tf 72 700 Td (ab\002c) Tj ET`)
+	var sb strings.Builder
+	if err := NewExtractor(r).ExtractALTO(&sb, OCROptions{}); err != nil {
+		t.Fatal(err)
+	}
+	out := sb.String()
+	for _, err := range validateALTO(t, []byte(out)) {
+		t.Error(err)
+	}
+	if !strings.Contains(out, `CONTENT="abc"`) {
+		t.Errorf("output lacks CONTENT=\"abc\":\n%s", out)
+	}
+}
+
+func TestALTOValidatorRejects(t *testing.T) {
+	// Guard against a validator that accepts anything
+	const doc = `<?xml version="1.0"?>
+<alto xmlns="http://www.loc.gov/standards/alto/ns-v4#">
+<Styles><TextStyle ID="f1" FONTSIZE="ten" FONTSTYLE="italic"/></Styles>
+<Layout><Page ID="p1" PHYSICAL_IMG_NR="1"><PrintSpace>
+<TextBlock ID="p1" HPOS="0" VPOS="0" WIDTH="1"><TextLine HPOS="0" VPOS="0" WIDTH="1" HEIGHT="1"><SP HPOS="0" VPOS="0"/></TextLine></TextBlock>
+</PrintSpace></Page></Layout></alto>`
+	errs := strings.Join(validateALTO(t, []byte(doc)), "\n")
+	for _, want := range []string{
+		`FONTSIZE="ten" is not a valid float`,
+		`FONTSTYLE="italic" is not a valid fontStylesType`,
+		"duplicate ID p1",
+		"TextBlock: missing required attribute HEIGHT",
+		`TextLine: children "SP " do not match`,
+	} {
+		if !strings.Contains(errs, want) {
+			t.Errorf("validator did not report %q; got:\n%s", want, errs)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+// An Annotation is an annotation of a page, such as a link or a note.
+type Annotation struct {
+	Subtype  string // the kind of annotation, such as Link, Text or Highlight
+	Rect     Rect   // the location on the page, in default user space
+	Contents string // the text of the annotation, if any
+	Author   string // the author of the annotation, if any
+	URI      string // the target of a link to a URI, if any
+}
+
+// Annotations returns the annotations of the page, in the order of its
+// Annots array. Popup annotations, which only display their parent's
+// text, are left out.
+func (p Page) Annotations() []Annotation {
+	annots := p.V.Key("Annots")
+	var out []Annotation
+	for i := 0; i < annots.Len(); i++ {
+		a := annots.Index(i)
+		if a.Kind() != Dict || a.Key("Subtype").Name() == "Popup" {
+			continue
+		}
+		rect, _ := rectFromValue(a.Key("Rect"))
+		annot := Annotation{
+			Subtype:  a.Key("Subtype").Name(),
+			Rect:     rect,
+			Contents: a.Key("Contents").Text(),
+			Author:   a.Key("T").Text(),
+		}
+		if action := a.Key("A"); action.Key("S").Name() == "URI" {
+			annot.URI = action.Key("URI").RawString()
+		}
+		out = append(out, annot)
+	}
+	return out
+}
@@ -0,0 +1,75 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// buildPDF returns a PDF file holding objs, numbered from 1, with a
+// trailer of the given entries besides Size.
+func buildPDF(trailer string, objs ...string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.7\n")
+	offsets := make([]int, len(objs))
+	for i, body := range objs {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, body)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d %s >>\nstartxref\n%d\n%%%%EOF\n", len(objs)+1, trailer, xref)
+	return buf.Bytes()
+}
+
+// annotatedPDF returns a one-page document with annotations, an outline
+// and document information.
+func annotatedPDF(t *testing.T) *Reader {
+	t.Helper()
+	content := "BT /F1 18 Tf 72 720 Td (Annual report) Tj ET " +
+		"1 0 0 rg BT /F1 10 Tf 72 690 Td (See the website) Tj ET"
+	widths := strings.TrimSpace(strings.Repeat("500 ", 95))
+	data := buildPDF("/Root 1 0 R /Info 13 0 R",
+		"<< /Type /Catalog /Pages 2 0 R /Outlines 6 0 R >>",
+		"<< /Type /Pages /Count 1 /Kids [3 0 R] >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /CropBox [10 10 600 780] /Rotate 90 "+
+			"/Resources << /Font << /F1 4 0 R >> >> /Contents 5 0 R /Annots [7 0 R 8 0 R 11 0 R] >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /FirstChar 32 /LastChar 126 /Widths ["+widths+"] >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+		"<< /Type /Outlines /First 9 0 R /Last 10 0 R /Count 2 >>",
+		"<< /Type /Annot /Subtype /Link /Rect [150 702 72 688] /A << /S /URI /URI (https://example.com/) >> >>",
+		"<< /Type /Annot /Subtype /Text /Rect [500 700 520 720] /Contents (Check the figures) /T (Reviewer) /Popup 11 0 R >>",
+		"<< /Title (Introduction) /Parent 6 0 R /Next 10 0 R /First 12 0 R /Last 12 0 R >>",
+		"<< /Title (Results) /Parent 6 0 R /Prev 9 0 R >>",
+		"<< /Type /Annot /Subtype /Popup /Rect [520 600 600 700] /Parent 8 0 R >>",
+		"<< /Title (Background) /Parent 9 0 R >>",
+		"<< /Title (Annual report) /Author (A. Writer) /Keywords (finance, 2024) /CreationDate (D:20240102030405Z) /Department (Audit) >>",
+	)
+	r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	return r
+}
+
+func TestPageAnnotations(t *testing.T) {
+	got := annotatedPDF(t).Page(1).Annotations()
+	want := []Annotation{
+		{Subtype: "Link", Rect: Rect{Point{72, 688}, Point{150, 702}}, URI: "https://example.com/"},
+		{Subtype: "Text", Rect: Rect{Point{500, 700}, Point{520, 720}}, Contents: "Check the figures", Author: "Reviewer"},
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Annotations() = %+v, want %+v", got, want)
+	}
+	if got := openTextPDF(t, "").Page(1).Annotations(); got != nil {
+		t.Errorf("Annotations() of a page without any = %v", got)
+	}
+}
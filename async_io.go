@@ -248,7 +248,7 @@ func (ar *AsyncReader) AsyncExtractTextWithContext(ctx context.Context, opts Ext
 					}
 
 					page := ar.Page(pageNum)
-					text, err := page.GetPlainText(context.Background(), nil)
+					text, err := page.plainText(context.Background(), nil, textRunsToPlain, opts.Dedup)
 
 					// Use select to ensure no blocking
 					select {
@@ -381,6 +381,9 @@ func NewCachedReader(reader *Reader, cache *ResultCache) *CachedReader {
 
 // CachedPage returns page content with caching
 func (cr *CachedReader) CachedPage(pageNum int) ([]Text, error) {
+	if err := cr.Reader.checkExtract(); err != nil {
+		return nil, err
+	}
 	key := cr.keyGenerator.GeneratePageContentKey(pageNum, cr.keyGenerator.GenerateReaderHash(cr.Reader))
 
 	if cached, found := cr.cache.Get(key); found {
@@ -0,0 +1,301 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"math"
+)
+
+// TextVisibility tells whether a Text lies inside the clipping region
+// and the page's crop box.
+type TextVisibility int
+
+const (
+	TextVisible       TextVisibility = iota // Entirely inside the clipping region
+	TextPartlyClipped                       // Partly cut off by the clipping region
+	TextClipped                             // Entirely outside the clipping region
+)
+
+// String returns the string representation of TextVisibility
+func (v TextVisibility) String() string {
+	switch v {
+	case TextVisible:
+		return "Visible"
+	case TextPartlyClipped:
+		return "PartlyClipped"
+	case TextClipped:
+		return "Clipped"
+	default:
+		return "Unknown"
+	}
+}
+
+// bezierSteps is the number of segments a Bézier curve is flattened into.
+const bezierSteps = 8
+
+// clipRegion is the intersection of the clipping paths in effect, in
+// device space. Regions are immutable so saved graphics states can share
+// them.
+type clipRegion struct {
+	bounds Rect       // intersection of the bounding boxes of all paths
+	paths  []clipPath // paths that are not axis-aligned rectangles
+	empty  bool       // nothing can be drawn
+}
+
+// clipPath is a non-rectangular clipping path, flattened into polygons.
+type clipPath struct {
+	polys   [][]Point
+	evenOdd bool
+}
+
+// newClipRegion returns the region of a rectangle.
+func newClipRegion(r Rect) *clipRegion {
+	return &clipRegion{bounds: r, empty: r.Max.X < r.Min.X || r.Max.Y < r.Min.Y}
+}
+
+// intersect returns the region clipped further by polys. A nil region
+// means no clipping.
+func (c *clipRegion) intersect(polys [][]Point, evenOdd bool) *clipRegion {
+	var pts []Point
+	for _, p := range polys {
+		pts = append(pts, p...)
+	}
+	if len(pts) == 0 {
+		// An empty clipping path leaves nothing visible
+		return &clipRegion{empty: true}
+	}
+
+	bounds := Rect{pts[0], pts[0]}
+	for _, p := range pts[1:] {
+		bounds = unionRect(bounds, Rect{p, p})
+	}
+
+	out := &clipRegion{bounds: bounds}
+	if c != nil {
+		out.bounds = Rect{
+			Min: Point{math.Max(c.bounds.Min.X, bounds.Min.X), math.Max(c.bounds.Min.Y, bounds.Min.Y)},
+			Max: Point{math.Min(c.bounds.Max.X, bounds.Max.X), math.Min(c.bounds.Max.Y, bounds.Max.Y)},
+		}
+		out.paths = c.paths
+		out.empty = c.empty
+	}
+	if out.bounds.Max.X < out.bounds.Min.X || out.bounds.Max.Y < out.bounds.Min.Y {
+		out.empty = true
+	}
+	if !isAxisRect(polys) {
+		// Copy so regions saved by q keep their own path list
+		out.paths = append(append([]clipPath(nil), out.paths...), clipPath{polys, evenOdd})
+	}
+	return out
+}
+
+// isAxisRect reports whether polys is a single axis-aligned rectangle,
+// which the bounding box describes exactly.
+func isAxisRect(polys [][]Point) bool {
+	if len(polys) != 1 {
+		return false
+	}
+	p := polys[0]
+	if len(p) == 5 && p[4] == p[0] {
+		p = p[:4]
+	}
+	if len(p) != 4 {
+		return false
+	}
+	for i := range p {
+		a, b := p[i], p[(i+1)%4]
+		if a.X != b.X && a.Y != b.Y {
+			return false
+		}
+	}
+	return true
+}
+
+// visibility classifies a box against the region.
+func (c *clipRegion) visibility(box Rect) TextVisibility {
+	if c == nil {
+		return TextVisible
+	}
+	b := c.bounds
+	if c.empty || box.Min.X > b.Max.X || box.Max.X < b.Min.X || box.Min.Y > b.Max.Y || box.Max.Y < b.Min.Y {
+		return TextClipped
+	}
+	inside := box.Min.X >= b.Min.X && box.Max.X <= b.Max.X && box.Min.Y >= b.Min.Y && box.Max.Y <= b.Max.Y
+	if len(c.paths) == 0 {
+		if inside {
+			return TextVisible
+		}
+		return TextPartlyClipped
+	}
+
+	// Sample the corners and centre of the box against every path
+	samples := [...]Point{
+		box.Min, box.Max, {box.Min.X, box.Max.Y}, {box.Max.X, box.Min.Y},
+		{(box.Min.X + box.Max.X) / 2, (box.Min.Y + box.Max.Y) / 2},
+	}
+	n := 0
+	for _, pt := range samples {
+		if c.contains(pt) {
+			n++
+		}
+	}
+	switch {
+	case n == 0:
+		return TextClipped
+	case n == len(samples) && inside:
+		return TextVisible
+	default:
+		return TextPartlyClipped
+	}
+}
+
+// contains reports whether pt lies inside every non-rectangular path.
+func (c *clipRegion) contains(pt Point) bool {
+	for _, p := range c.paths {
+		if !p.contains(pt) {
+			return false
+		}
+	}
+	return true
+}
+
+// contains applies the path's fill rule to pt.
+func (p clipPath) contains(pt Point) bool {
+	winding, crossings := 0, 0
+	for _, poly := range p.polys {
+		for i := range poly {
+			a, b := poly[i], poly[(i+1)%len(poly)]
+			if (a.Y <= pt.Y) == (b.Y <= pt.Y) {
+				continue
+			}
+			x := a.X + (pt.Y-a.Y)*(b.X-a.X)/(b.Y-a.Y)
+			if x <= pt.X {
+				continue
+			}
+			crossings++
+			if b.Y > a.Y {
+				winding++
+			} else {
+				winding--
+			}
+		}
+	}
+	if p.evenOdd {
+		return crossings%2 == 1
+	}
+	return winding != 0
+}
+
+// pathBuilder collects the current path of a content stream in device space.
+type pathBuilder struct {
+	polys [][]Point
+	cur   []Point
+	start Point // user space start of the current subpath
+	last  Point // user space current point
+}
+
+func (pb *pathBuilder) moveTo(ctm matrix, x, y float64) {
+	pb.closeSubpath()
+	pb.start, pb.last = Point{x, y}, Point{x, y}
+	pb.cur = append(pb.cur, devicePoint(ctm, x, y))
+}
+
+func (pb *pathBuilder) lineTo(ctm matrix, x, y float64) {
+	pb.last = Point{x, y}
+	pb.cur = append(pb.cur, devicePoint(ctm, x, y))
+}
+
+// curveTo flattens a cubic Bézier curve from the current point.
+func (pb *pathBuilder) curveTo(ctm matrix, x1, y1, x2, y2, x3, y3 float64) {
+	p0 := pb.last
+	for i := 1; i <= bezierSteps; i++ {
+		t := float64(i) / bezierSteps
+		u := 1 - t
+		x := u*u*u*p0.X + 3*u*u*t*x1 + 3*u*t*t*x2 + t*t*t*x3
+		y := u*u*u*p0.Y + 3*u*u*t*y1 + 3*u*t*t*y2 + t*t*t*y3
+		pb.cur = append(pb.cur, devicePoint(ctm, x, y))
+	}
+	pb.last = Point{x3, y3}
+}
+
+func (pb *pathBuilder) rect(ctm matrix, x, y, w, h float64) {
+	pb.moveTo(ctm, x, y)
+	pb.lineTo(ctm, x+w, y)
+	pb.lineTo(ctm, x+w, y+h)
+	pb.lineTo(ctm, x, y+h)
+	pb.closeSubpath()
+	pb.last = Point{x, y}
+}
+
+// closeSubpath ends the current subpath; polygons are implicitly closed.
+func (pb *pathBuilder) closeSubpath() {
+	if len(pb.cur) > 0 {
+		pb.polys = append(pb.polys, pb.cur)
+		pb.cur = nil
+	}
+	pb.last = pb.start
+}
+
+// finish returns the path and starts a new one.
+func (pb *pathBuilder) finish() [][]Point {
+	pb.closeSubpath()
+	polys := pb.polys
+	*pb = pathBuilder{}
+	return polys
+}
+
+func devicePoint(m matrix, x, y float64) Point {
+	px, py := applyMatrixToPoint(m, x, y)
+	return Point{px, py}
+}
+
+// rectPolygon returns the corners of r transformed by m, as a path.
+func rectPolygon(m matrix, r Rect) [][]Point {
+	return [][]Point{{
+		devicePoint(m, r.Min.X, r.Min.Y),
+		devicePoint(m, r.Max.X, r.Min.Y),
+		devicePoint(m, r.Max.X, r.Max.Y),
+		devicePoint(m, r.Min.X, r.Max.Y),
+	}}
+}
+
+// rectFromValue reads a PDF rectangle array, normalising its corners.
+func rectFromValue(v Value) (Rect, bool) {
+	if v.Kind() != Array || v.Len() < 4 {
+		return Rect{}, false
+	}
+	x0, y0, x1, y1 := v.Index(0).Float64(), v.Index(1).Float64(), v.Index(2).Float64(), v.Index(3).Float64()
+	return Rect{
+		Min: Point{math.Min(x0, x1), math.Min(y0, y1)},
+		Max: Point{math.Max(x0, x1), math.Max(y0, y1)},
+	}, true
+}
+
+// cropBox returns the page's crop box, which defaults to the media box.
+func (p Page) cropBox() (Rect, bool) {
+	if r, ok := rectFromValue(p.findInherited("CropBox")); ok {
+		return r, true
+	}
+	return rectFromValue(p.findInherited("MediaBox"))
+}
+
+// dropClippedText removes, in place, the texts that are entirely clipped.
+func dropClippedText(texts []Text) []Text {
+	out := texts[:0]
+	for _, t := range texts {
+		if t.Visibility != TextClipped {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// SetKeepClippedText controls whether text hidden by clipping paths or
+// lying outside the crop box is returned by extraction. By default such
+// text is dropped; text that is only partly clipped is always kept and
+// marked with TextPartlyClipped.
+func (r *Reader) SetKeepClippedText(keep bool) {
+	r.keepClippedText.Store(keep)
+}
@@ -0,0 +1,119 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"testing"
+)
+
+// visibilityOf returns the visibility of each text run keyed by its string.
+func visibilityOf(texts []Text) map[string]TextVisibility {
+	m := make(map[string]TextVisibility)
+	for _, t := range texts {
+		if v, ok := m[t.S]; !ok || t.Visibility > v {
+			m[t.S] = t.Visibility
+		}
+	}
+	return m
+}
+
+func TestContentClipRectangle(t *testing.T) {
+	r := openTextPDF(t, "q 100 600 200 100 re W n "+
+		"BT /F1 10 Tf 120 650 Td (a) Tj 177 0 Td (b) Tj 0 -300 Td (c) Tj ET Q "+
+		"BT /F1 10 Tf 72 300 Td (d) Tj 700 0 Td (e) Tj ET")
+
+	// b straddles the right edge, c is below the clip, e is off the page
+	if got := textString(r.Page(1).Content().Text); got != "abd" {
+		t.Errorf("visible text = %q, want %q", got, "abd")
+	}
+
+	r.SetKeepClippedText(true)
+	got := visibilityOf(r.Page(1).Content().Text)
+	want := map[string]TextVisibility{
+		"a": TextVisible,
+		"b": TextPartlyClipped,
+		"c": TextClipped,
+		"d": TextVisible,
+		"e": TextClipped,
+	}
+	for s, v := range want {
+		if got[s] != v {
+			t.Errorf("%s: visibility %v, want %v", s, got[s], v)
+		}
+	}
+}
+
+func TestContentClipPath(t *testing.T) {
+	// A triangle clipped with the even-odd rule, then the whole page
+	// emptied by a zero-area clip inside q/Q
+	r := openTextPDF(t, "q 100 100 m 300 100 l 200 300 l h W* n "+
+		"BT /F1 10 Tf 190 150 Td (a) Tj -80 120 Td (b) Tj ET Q "+
+		"q 0 0 0 0 re W n BT /F1 10 Tf 72 700 Td (c) Tj ET Q "+
+		"BT /F1 10 Tf 72 680 Td (d) Tj ET")
+	r.SetKeepClippedText(true)
+
+	got := visibilityOf(r.Page(1).Content().Text)
+	want := map[string]TextVisibility{
+		"a": TextVisible,
+		"b": TextClipped, // inside the bounding box but outside the triangle
+		"c": TextClipped,
+		"d": TextVisible,
+	}
+	for s, v := range want {
+		if got[s] != v {
+			t.Errorf("%s: visibility %v, want %v", s, got[s], v)
+		}
+	}
+}
+
+func TestContentMalformedPathOperators(t *testing.T) {
+	for _, ops := range []string{"10 m S", "1 2 3 c S", "1 2 3 v 1 2 y S"} {
+		r := openTextPDF(t, "BT /F1 10 Tf 72 700 Td (Hello) Tj ET "+ops+
+			" BT /F1 10 Tf 72 680 Td (World) Tj ET")
+		if got := textString(r.Page(1).Content().Text); got != "HelloWorld" {
+			t.Errorf("%s: text = %q, want %q", ops, got, "HelloWorld")
+		}
+	}
+}
+
+func TestClipRegionCurvesAndTransforms(t *testing.T) {
+	// A circle of radius 100 about (200, 200) drawn with four curves
+	const k = 55.23
+	var pb pathBuilder
+	pb.moveTo(ident, 300, 200)
+	pb.curveTo(ident, 300, 200+k, 200+k, 300, 200, 300)
+	pb.curveTo(ident, 200-k, 300, 100, 200+k, 100, 200)
+	pb.curveTo(ident, 100, 200-k, 200-k, 100, 200, 100)
+	pb.curveTo(ident, 200+k, 100, 300, 200-k, 300, 200)
+	clip := (*clipRegion)(nil).intersect(pb.finish(), false)
+
+	if v := clip.visibility(Rect{Point{190, 190}, Point{210, 210}}); v != TextVisible {
+		t.Errorf("centre box: %v", v)
+	}
+	if v := clip.visibility(Rect{Point{105, 285}, Point{115, 295}}); v != TextClipped {
+		t.Errorf("corner box: %v", v)
+	}
+	if v := clip.visibility(Rect{Point{290, 195}, Point{310, 205}}); v != TextPartlyClipped {
+		t.Errorf("edge box: %v", v)
+	}
+
+	// A rotated rectangle is not axis-aligned and keeps its exact shape
+	rot := matrix{{0.7071, 0.7071, 0}, {-0.7071, 0.7071, 0}, {0, 0, 1}}
+	diamond := (*clipRegion)(nil).intersect(rectPolygon(rot, Rect{Max: Point{100, 100}}), false)
+	if len(diamond.paths) != 1 {
+		t.Fatalf("rotated rectangle stored as %d paths", len(diamond.paths))
+	}
+	if v := diamond.visibility(Rect{Point{-65, 5}, Point{-60, 10}}); v != TextClipped {
+		t.Errorf("box outside the diamond: %v", v)
+	}
+
+	// The page box alone only needs its bounds
+	if c := newClipRegion(Rect{Max: Point{612, 792}}).intersect(rectPolygon(ident, Rect{Max: Point{10, 10}}), false); len(c.paths) != 0 || c.bounds.Max.X != 10 {
+		t.Errorf("rectangle intersection = %+v", c)
+	}
+	if TextPartlyClipped.String() != "PartlyClipped" {
+		t.Error("unexpected TextVisibility name")
+	}
+}
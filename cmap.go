@@ -925,6 +925,22 @@ func registerAdobeGB1CMaps() {
 	cmV.OptimizeCIDLookup() // Pre-optimize
 	RegisterPredefinedCMap("GBK-EUC-V", &PredefinedCMap{cmV})
 
+	// GB-EUC-H: GB 2312 EUC encoding to CID horizontal
+	gbH := NewCMap("GB-EUC-H", CMapTypeCID)
+	gbH.SetCIDSystemInfo("Adobe", "GB1", 0)
+	gbH.WMode = 0
+	gbH.AddCodeSpaceRange([]byte{0x00}, []byte{0x80})
+	gbH.AddCodeSpaceRange([]byte{0xA1, 0xA1}, []byte{0xFE, 0xFE})
+	RegisterPredefinedCMap("GB-EUC-H", &PredefinedCMap{gbH})
+
+	// GB-EUC-V: GB 2312 EUC encoding to CID vertical
+	gbV := NewCMap("GB-EUC-V", CMapTypeCID)
+	gbV.SetCIDSystemInfo("Adobe", "GB1", 0)
+	gbV.WMode = 1
+	gbV.AddCodeSpaceRange([]byte{0x00}, []byte{0x80})
+	gbV.AddCodeSpaceRange([]byte{0xA1, 0xA1}, []byte{0xFE, 0xFE})
+	RegisterPredefinedCMap("GB-EUC-V", &PredefinedCMap{gbV})
+
 	// UniGB-UCS2-H: Unicode to CID horizontal
 	uniH := NewCMap("UniGB-UCS2-H", CMapTypeCID)
 	uniH.SetCIDSystemInfo("Adobe", "GB1", 4)
@@ -975,6 +991,22 @@ func registerAdobeCNS1CMaps() {
 	cmV.AddCodeSpaceRange([]byte{0xA1, 0x40}, []byte{0xFE, 0xFE})
 	RegisterPredefinedCMap("B5-V", &PredefinedCMap{cmV})
 
+	// ETen-B5-H: Big5 with ETen extensions to CID horizontal
+	etenH := NewCMap("ETen-B5-H", CMapTypeCID)
+	etenH.SetCIDSystemInfo("Adobe", "CNS1", 0)
+	etenH.WMode = 0
+	etenH.AddCodeSpaceRange([]byte{0x00}, []byte{0x80})
+	etenH.AddCodeSpaceRange([]byte{0xA1, 0x40}, []byte{0xFE, 0xFE})
+	RegisterPredefinedCMap("ETen-B5-H", &PredefinedCMap{etenH})
+
+	// ETen-B5-V: Big5 with ETen extensions to CID vertical
+	etenV := NewCMap("ETen-B5-V", CMapTypeCID)
+	etenV.SetCIDSystemInfo("Adobe", "CNS1", 0)
+	etenV.WMode = 1
+	etenV.AddCodeSpaceRange([]byte{0x00}, []byte{0x80})
+	etenV.AddCodeSpaceRange([]byte{0xA1, 0x40}, []byte{0xFE, 0xFE})
+	RegisterPredefinedCMap("ETen-B5-V", &PredefinedCMap{etenV})
+
 	// UniCNS-UCS2-H: Unicode to CID horizontal
 	uniH := NewCMap("UniCNS-UCS2-H", CMapTypeCID)
 	uniH.SetCIDSystemInfo("Adobe", "CNS1", 3)
@@ -1035,6 +1067,24 @@ func registerAdobeJapan1CMaps() {
 	cm90V.AddCodeSpaceRange([]byte{0xE0, 0x40}, []byte{0xFC, 0xFC})
 	RegisterPredefinedCMap("90ms-RKSJ-V", &PredefinedCMap{cm90V})
 
+	// EUC-H: JIS X 0208 EUC encoding to CID horizontal
+	eucH := NewCMap("EUC-H", CMapTypeCID)
+	eucH.SetCIDSystemInfo("Adobe", "Japan1", 1)
+	eucH.WMode = 0
+	eucH.AddCodeSpaceRange([]byte{0x00}, []byte{0x80})
+	eucH.AddCodeSpaceRange([]byte{0x8E, 0xA0}, []byte{0x8E, 0xDF})
+	eucH.AddCodeSpaceRange([]byte{0xA1, 0xA1}, []byte{0xFE, 0xFE})
+	RegisterPredefinedCMap("EUC-H", &PredefinedCMap{eucH})
+
+	// EUC-V: JIS X 0208 EUC encoding to CID vertical
+	eucV := NewCMap("EUC-V", CMapTypeCID)
+	eucV.SetCIDSystemInfo("Adobe", "Japan1", 1)
+	eucV.WMode = 1
+	eucV.AddCodeSpaceRange([]byte{0x00}, []byte{0x80})
+	eucV.AddCodeSpaceRange([]byte{0x8E, 0xA0}, []byte{0x8E, 0xDF})
+	eucV.AddCodeSpaceRange([]byte{0xA1, 0xA1}, []byte{0xFE, 0xFE})
+	RegisterPredefinedCMap("EUC-V", &PredefinedCMap{eucV})
+
 	// UniJIS-UCS2-H: Unicode to CID horizontal
 	uniH := NewCMap("UniJIS-UCS2-H", CMapTypeCID)
 	uniH.SetCIDSystemInfo("Adobe", "Japan1", 4)
@@ -1138,6 +1188,11 @@ func LookupPredefinedCMap(name string) TextEncoding {
 		return enc
 	}
 
+	// Predefined CJK CMaps with embedded mapping data, loaded on first use
+	if enc := lookupPredefinedCMapData(name); enc != nil {
+		return enc
+	}
+
 	// Then check predefined CMap registry
 	if cm := GetPredefinedCMap(name); cm != nil {
 		return cm.CMap
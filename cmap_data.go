@@ -0,0 +1,319 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"compress/gzip"
+	"embed"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/fs"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// cmapDataFS holds the compressed predefined CMap tables written by
+// cmd/gencmap. Tables for legacy encodings are keyed by CMap name without the
+// -H/-V suffix; CID collection tables (e.g. Adobe-Japan1.gz) are generated
+// from Adobe's cid2code.txt.
+//
+//go:embed cmapdata/*.gz
+var cmapDataFS embed.FS
+
+// cmapTableRange maps n consecutive codes starting at code to consecutive
+// Unicode values starting at uni.
+type cmapTableRange struct {
+	code uint32
+	n    uint16
+	uni  uint32
+}
+
+// cmapTable is a sorted code to Unicode mapping loaded from cmapdata/.
+type cmapTable struct {
+	ranges []cmapTableRange
+}
+
+// lookup returns the Unicode value for code.
+func (t *cmapTable) lookup(code uint32) (rune, bool) {
+	i := sort.Search(len(t.ranges), func(i int) bool {
+		return t.ranges[i].code+uint32(t.ranges[i].n) > code
+	})
+	if i < len(t.ranges) && t.ranges[i].code <= code {
+		return rune(t.ranges[i].uni + code - t.ranges[i].code), true
+	}
+	return 0, false
+}
+
+// Len returns the number of mapped codes.
+func (t *cmapTable) Len() int {
+	n := 0
+	for _, r := range t.ranges {
+		n += int(r.n)
+	}
+	return n
+}
+
+var errBadCMapTable = errors.New("malformed cmap table")
+
+// parseCMapTable decodes a gzip-compressed PCM1 table.
+func parseCMapTable(data []byte) (*cmapTable, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) < 8 || string(raw[:4]) != "PCM1" {
+		return nil, errBadCMapTable
+	}
+	count := int(binary.BigEndian.Uint32(raw[4:]))
+	raw = raw[8:]
+	if len(raw) != count*10 {
+		return nil, errBadCMapTable
+	}
+
+	t := &cmapTable{ranges: make([]cmapTableRange, count)}
+	for i := range t.ranges {
+		rec := raw[i*10:]
+		t.ranges[i] = cmapTableRange{
+			code: binary.BigEndian.Uint32(rec),
+			n:    binary.BigEndian.Uint16(rec[4:]),
+			uni:  binary.BigEndian.Uint32(rec[6:]),
+		}
+	}
+	return t, nil
+}
+
+// cmapTableEntry lazily loads one embedded table.
+type cmapTableEntry struct {
+	once  sync.Once
+	table *cmapTable
+	err   error
+}
+
+var cmapTables sync.Map // file name -> *cmapTableEntry
+
+// loadCMapTable returns the embedded table stored under file, loading and
+// decompressing it on first use. It returns nil if no such table exists or
+// it cannot be parsed.
+func loadCMapTable(file string) *cmapTable {
+	return loadCMapTableEntry(file).table
+}
+
+func loadCMapTableEntry(file string) *cmapTableEntry {
+	v, _ := cmapTables.LoadOrStore(file, &cmapTableEntry{})
+	entry := v.(*cmapTableEntry)
+	entry.once.Do(func() {
+		data, err := cmapDataFS.ReadFile("cmapdata/" + file + ".gz")
+		if err != nil {
+			entry.err = err
+			return
+		}
+		entry.table, entry.err = parseCMapTable(data)
+	})
+	return entry
+}
+
+// cmapTableError returns why the embedded table stored under file cannot
+// be parsed, or nil if it parses or no such table exists.
+func cmapTableError(file string) error {
+	err := loadCMapTableEntry(file).err
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// unsupportedCMaps are the predefined CMaps of ISO 32000 and Adobe's CMap
+// resources for legacy encodings that have no embedded table, so codes shown
+// with them are not decoded through the CMap. Fonts using one record a
+// WarnCMap warning.
+var unsupportedCMaps = map[string]bool{
+	"H": true, "V": true,
+	"78-H": true, "78-V": true, "78-RKSJ-H": true, "78-RKSJ-V": true, "78ms-RKSJ-H": true, "78ms-RKSJ-V": true,
+	"90msp-RKSJ-H": true, "90msp-RKSJ-V": true, "90pv-RKSJ-H": true,
+	"Add-H": true, "Add-V": true, "Add-RKSJ-H": true, "Add-RKSJ-V": true,
+	"Ext-H": true, "Ext-V": true, "Ext-RKSJ-H": true, "Ext-RKSJ-V": true,
+	"NWP-H": true, "NWP-V": true,
+	"GBpc-EUC-H": true, "GBpc-EUC-V": true, "GBKp-EUC-H": true, "GBKp-EUC-V": true,
+	"GBK2K-H": true, "GBK2K-V": true, "GBT-EUC-H": true, "GBT-EUC-V": true, "GBTpc-EUC-H": true, "GBTpc-EUC-V": true,
+	"B5pc-H": true, "B5pc-V": true, "ETenms-B5-H": true, "ETenms-B5-V": true,
+	"HKscs-B5-H": true, "HKscs-B5-V": true, "CNS-EUC-H": true, "CNS-EUC-V": true,
+	"CNS1-H": true, "CNS1-V": true, "CNS2-H": true, "CNS2-V": true,
+	"KSCpc-EUC-H": true, "KSCpc-EUC-V": true, "KSCms-UHC-HW-H": true, "KSCms-UHC-HW-V": true,
+}
+
+// predefinedCMapTableName returns the table file for a predefined CMap name,
+// sharing one table between the horizontal and vertical variants.
+func predefinedCMapTableName(name string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(name, "-H"), "-V")
+}
+
+// unicodeCMapForm returns the encoding form of the codes of a predefined
+// Unicode CMap, which needs no table at all: "UTF16" for the Uni*-UCS2 and
+// Uni*-UTF16 families, "UTF8" or "UTF32". It returns "" for other CMaps.
+func unicodeCMapForm(name string) string {
+	if !strings.HasPrefix(name, "Uni") {
+		return ""
+	}
+	switch {
+	case strings.Contains(name, "-UCS2-"), strings.Contains(name, "-UTF16-"):
+		return "UTF16"
+	case strings.Contains(name, "-UTF8-"):
+		return "UTF8"
+	case strings.Contains(name, "-UTF32-"):
+		return "UTF32"
+	}
+	return ""
+}
+
+// predefinedCMapEncoder decodes text shown with a predefined CJK CMap.
+type predefinedCMapEncoder struct {
+	cmap  *CMap      // nil for Unicode CMaps
+	table *cmapTable // nil for Unicode CMaps
+	form  string     // the form of a Unicode CMap, see unicodeCMapForm
+}
+
+var predefinedCMapEncoders sync.Map // name -> *predefinedCMapEncoder
+
+// lookupPredefinedCMapData returns a decoding TextEncoding for a predefined
+// CMap backed by embedded data, or nil if the CMap has none.
+func lookupPredefinedCMapData(name string) TextEncoding {
+	if v, ok := predefinedCMapEncoders.Load(name); ok {
+		return v.(*predefinedCMapEncoder)
+	}
+
+	enc := &predefinedCMapEncoder{form: unicodeCMapForm(name)}
+	if enc.form == "" {
+		cm := GetPredefinedCMap(name)
+		if cm == nil {
+			return nil
+		}
+		enc.cmap = cm.CMap
+		enc.table = loadCMapTable(predefinedCMapTableName(name))
+		if enc.table == nil {
+			return nil
+		}
+	}
+	v, _ := predefinedCMapEncoders.LoadOrStore(name, enc)
+	return v.(*predefinedCMapEncoder)
+}
+
+// Decode implements TextEncoding.
+func (e *predefinedCMapEncoder) Decode(raw string) string {
+	switch e.form {
+	case "UTF16":
+		return decodeUTF16Codes(raw)
+	case "UTF8":
+		return strings.ToValidUTF8(raw, string(noRune))
+	case "UTF32":
+		return decodeUTF32Codes(raw)
+	}
+
+	var b strings.Builder
+	b.Grow(len(raw) * 2)
+	for len(raw) > 0 {
+		n := e.codeLength(raw)
+		if n == 0 {
+			// Outside every code space: skip a byte
+			b.WriteRune(noRune)
+			raw = raw[1:]
+			continue
+		}
+		var code uint32
+		for i := 0; i < n; i++ {
+			code = code<<8 | uint32(raw[i])
+		}
+		raw = raw[n:]
+		if r, ok := e.table.lookup(code); ok {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune(noRune)
+		}
+	}
+	return b.String()
+}
+
+// codeLength returns the length of the code at the start of raw according to
+// the CMap's code space ranges, or 0 if none match.
+func (e *predefinedCMapEncoder) codeLength(raw string) int {
+	for n := 1; n <= 4 && n <= len(raw); n++ {
+		if e.cmap.inCodeSpace([]byte(raw[:n])) {
+			return n
+		}
+	}
+	return 0
+}
+
+// decodeUTF16Codes decodes UTF-16BE codes, keeping surrogate pairs together.
+func decodeUTF16Codes(raw string) string {
+	u := make([]uint16, 0, len(raw)/2)
+	for i := 0; i+1 < len(raw); i += 2 {
+		u = append(u, uint16(raw[i])<<8|uint16(raw[i+1]))
+	}
+	s := string(utf16.Decode(u))
+	if len(raw)%2 == 1 {
+		s += string(utf8.RuneError)
+	}
+	return s
+}
+
+// decodeUTF32Codes decodes UTF-32BE codes.
+func decodeUTF32Codes(raw string) string {
+	r := make([]rune, 0, len(raw)/4+1)
+	for ; len(raw) >= 4; raw = raw[4:] {
+		c := rune(binary.BigEndian.Uint32([]byte(raw[:4])))
+		if !utf8.ValidRune(c) {
+			c = noRune
+		}
+		r = append(r, c)
+	}
+	if len(raw) > 0 {
+		r = append(r, noRune)
+	}
+	return string(r)
+}
+
+// cidCollectionEncoder maps CIDs of an Adobe character collection to Unicode.
+type cidCollectionEncoder struct {
+	table *cmapTable
+}
+
+// LookupCIDToUnicode returns a TextEncoding that decodes 2-byte CIDs of the
+// given character collection (for example "Adobe", "Japan1") to Unicode, or
+// nil if no table for the collection is embedded.
+func LookupCIDToUnicode(registry, ordering string) TextEncoding {
+	if registry == "" || ordering == "" {
+		return nil
+	}
+	table := loadCMapTable(registry + "-" + ordering)
+	if table == nil {
+		return nil
+	}
+	return &cidCollectionEncoder{table: table}
+}
+
+// Decode implements TextEncoding.
+func (e *cidCollectionEncoder) Decode(raw string) string {
+	r := make([]rune, 0, len(raw)/2)
+	for i := 0; i+1 < len(raw); i += 2 {
+		cid := uint32(raw[i])<<8 | uint32(raw[i+1])
+		if u, ok := e.table.lookup(cid); ok {
+			r = append(r, u)
+		} else {
+			r = append(r, noRune)
+		}
+	}
+	return string(r)
+}
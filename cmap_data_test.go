@@ -0,0 +1,174 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestPredefinedCMapDecode(t *testing.T) {
+	tests := []struct {
+		cmap string
+		raw  string
+		want string
+	}{
+		{"90ms-RKSJ-H", "\x82\xa0\x82\xa2A", "あいA"},
+		{"90ms-RKSJ-V", "\x93\xfa\x96\x7b", "日本"},
+		{"90ms-RKSJ-H", "\xb1", "ｱ"},
+		{"EUC-H", "\xc6\xfc\xcb\xdc", "日本"},
+		{"GBK-EUC-H", "\xc4\xe3\xba\xc3", "你好"},
+		{"GB-EUC-H", "\xd6\xd0\xce\xc4", "中文"},
+		{"B5-H", "\xa4\xa4\xa4\xe5", "中文"},
+		{"ETen-B5-H", "\xa4\xa4", "中"},
+		{"KSC-EUC-H", "\xc7\xd1\xb1\xdb", "한글"},
+		{"KSCms-UHC-H", "\xc7\xd1", "한"},
+		{"UniGB-UCS2-H", "\x4e\x2d\x65\x87", "中文"},
+		{"UniJIS-UTF16-H", "\xd8\x40\xdc\x0b", "\U0002000B"},
+		{"UniJIS-UTF8-H", "\xe3\x81\x82\xff", "あ\ufffd"},
+		{"UniGB-UTF32-V", "\x00\x00\x4e\x2d\x00\x02\x00\x0b", "中\U0002000B"},
+	}
+
+	for _, tt := range tests {
+		enc := LookupPredefinedCMap(tt.cmap)
+		if enc == nil {
+			t.Errorf("LookupPredefinedCMap(%s) returned nil", tt.cmap)
+			continue
+		}
+		if got := enc.Decode(tt.raw); got != tt.want {
+			t.Errorf("%s: Decode(%q) = %q, want %q", tt.cmap, tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestPredefinedCMapLazyLoad(t *testing.T) {
+	if v, ok := cmapTables.Load("KSCms-UHC"); ok && v.(*cmapTableEntry).table != nil {
+		t.Skip("table already loaded by another test")
+	}
+	enc := LookupPredefinedCMap("KSCms-UHC-V")
+	if enc == nil {
+		t.Fatal("expected encoder for KSCms-UHC-V")
+	}
+	v, ok := cmapTables.Load("KSCms-UHC")
+	if !ok || v.(*cmapTableEntry).table == nil {
+		t.Fatal("table not loaded after lookup")
+	}
+	if n := v.(*cmapTableEntry).table.Len(); n < 8000 {
+		t.Errorf("KSCms-UHC table has %d codes, want at least 8000", n)
+	}
+
+	// Horizontal and vertical variants share one table
+	h := LookupPredefinedCMap("KSCms-UHC-H").(*predefinedCMapEncoder)
+	if h.table != enc.(*predefinedCMapEncoder).table {
+		t.Error("expected -H and -V to share a table")
+	}
+}
+
+func TestPredefinedCMapUnmappedCodes(t *testing.T) {
+	enc := LookupPredefinedCMap("90ms-RKSJ-H")
+	// 0x85 0x40 is an unassigned cell, 0xFD is outside every code space
+	if got := enc.Decode("\x85\x40\xfdA"); got != "��A" {
+		t.Errorf("Decode = %q", got)
+	}
+}
+
+func TestLookupCIDToUnicode(t *testing.T) {
+	if enc := LookupCIDToUnicode("Adobe", "NoSuchOrdering"); enc != nil {
+		t.Error("expected nil for unknown collection")
+	}
+	if enc := LookupCIDToUnicode("", ""); enc != nil {
+		t.Error("expected nil for empty collection")
+	}
+
+	tests := []struct {
+		ordering string
+		cids     []uint16
+		want     string
+	}{
+		{"Japan1", []uint16{34, 843, 1125, 0}, "Aあ亜�"},
+		{"GB1", []uint16{34, 96, 4162}, "A\u3000一"},
+		{"CNS1", []uint16{595}, "一"},
+		{"Korea1", []uint16{1086}, "가"},
+	}
+	for _, tt := range tests {
+		enc := LookupCIDToUnicode("Adobe", tt.ordering)
+		if enc == nil {
+			t.Errorf("no table for Adobe-%s", tt.ordering)
+			continue
+		}
+		var raw []byte
+		for _, cid := range tt.cids {
+			raw = append(raw, byte(cid>>8), byte(cid))
+		}
+		if got := enc.Decode(string(raw)); got != tt.want {
+			t.Errorf("Adobe-%s: Decode(%v) = %q, want %q", tt.ordering, tt.cids, got, tt.want)
+		}
+	}
+}
+
+// TestIdentityCIDFontWithoutToUnicode tests that an Identity-H font of the
+// Adobe-Japan1 collection without ToUnicode decodes through the collection.
+func TestIdentityCIDFontWithoutToUnicode(t *testing.T) {
+	const content = "BT /F1 12 Tf 72 700 Td <034B0465> Tj ET"
+	data := buildPDF("/Root 1 0 R",
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Count 1 /Kids [3 0 R] >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 4 0 R >> >> /Contents 5 0 R >>",
+		"<< /Type /Font /Subtype /Type0 /BaseFont /KozMinPr6N-Regular /Encoding /Identity-H /DescendantFonts [6 0 R] >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+		"<< /Type /Font /Subtype /CIDFontType0 /BaseFont /KozMinPr6N-Regular "+
+			"/CIDSystemInfo << /Registry (Adobe) /Ordering (Japan1) /Supplement 6 >> /DW 1000 >>",
+	)
+	r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readerText(t, r); got != "あ亜" {
+		t.Errorf("text = %q, want あ亜", got)
+	}
+}
+
+func TestParseCMapTableInvalid(t *testing.T) {
+	if _, err := parseCMapTable([]byte("not gzip")); err == nil {
+		t.Error("expected error for non-gzip data")
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte("PCM1\x00\x00\x00\x02short"))
+	zw.Close()
+	if _, err := parseCMapTable(buf.Bytes()); err == nil {
+		t.Error("expected error for truncated table")
+	}
+}
+
+func TestUnsupportedCMapWarning(t *testing.T) {
+	for name := range unsupportedCMaps {
+		if loadCMapTable(predefinedCMapTableName(name)) != nil {
+			t.Errorf("%s is listed as unsupported but has a table", name)
+		}
+	}
+
+	data := buildPDF("/Root 1 0 R",
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Count 1 /Kids [3 0 R] >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 4 0 R >> >> >>",
+		"<< /Type /Font /Subtype /Type0 /BaseFont /Ryumin-Light /Encoding /90pv-RKSJ-H /DescendantFonts [<< /Type /Font "+
+			"/Subtype /CIDFontType0 /BaseFont /Ryumin-Light /CIDSystemInfo << /Registry (Adobe) /Ordering (Japan1) /Supplement 2 >> >>] >>",
+	)
+	var c WarningCollector
+	r, err := NewReaderWithOptions(bytes.NewReader(data), int64(len(data)), ReaderOptions{Warnings: &c})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := r.Page(1).Font("F1")
+	f.Encoder()
+	if w := c.Warnings(); len(w) != 1 || w[0].Code != WarnCMap || !strings.Contains(w[0].Message, "90pv-RKSJ-H") {
+		t.Errorf("Warnings = %v, want one for 90pv-RKSJ-H", w)
+	}
+}
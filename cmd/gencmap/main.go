@@ -0,0 +1,190 @@
+// Command gencmap converts character mapping tables into the compressed
+// range format embedded under cmapdata/.
+//
+// Two input formats are accepted:
+//
+//	gencmap -mapping CP932.TXT -o cmapdata/90ms-RKSJ.gz
+//	gencmap -cid2code cid2code.txt -column UniJIS-UCS2 -o cmapdata/Adobe-Japan1.gz
+//
+// The first reads Unicode.org style "0xCODE<TAB>0xUNICODE" lines. The second
+// reads an Adobe cid2code.txt table and emits the CID to Unicode mapping of
+// the named column.
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type entry struct {
+	code uint32
+	uni  uint32
+}
+
+func main() {
+	mapping := flag.String("mapping", "", "Unicode.org style code to Unicode mapping file")
+	cid2code := flag.String("cid2code", "", "Adobe cid2code.txt file")
+	column := flag.String("column", "", "cid2code column to extract (e.g. UniGB-UCS2)")
+	out := flag.String("o", "", "output file")
+	flag.Parse()
+
+	if *out == "" || (*mapping == "") == (*cid2code == "") {
+		fmt.Fprintln(os.Stderr, "Usage: gencmap (-mapping file | -cid2code file -column name) -o out.gz")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+
+	var (
+		entries []entry
+		err     error
+	)
+	if *mapping != "" {
+		entries, err = readMapping(*mapping)
+	} else {
+		entries, err = readCID2Code(*cid2code, *column)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := writeTable(*out, entries); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func readMapping(path string) ([]entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []entry
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := s.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		code, err1 := strconv.ParseUint(strings.TrimPrefix(fields[0], "0x"), 16, 32)
+		uni, err2 := strconv.ParseUint(strings.TrimPrefix(fields[1], "0x"), 16, 32)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		entries = append(entries, entry{uint32(code), uint32(uni)})
+	}
+	return entries, s.Err()
+}
+
+func readCID2Code(path, column string) ([]entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	col := -1
+	var entries []entry
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := s.Text()
+		if strings.HasPrefix(line, "#") || line == "" {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if col < 0 {
+			// First non-comment line is the header
+			for i, name := range fields {
+				if name == column {
+					col = i
+				}
+			}
+			if col < 0 {
+				return nil, fmt.Errorf("column %q not found in %s", column, path)
+			}
+			continue
+		}
+		if col >= len(fields) {
+			continue
+		}
+		cid, err := strconv.ParseUint(fields[0], 10, 32)
+		if err != nil {
+			continue
+		}
+		// Cells may list alternatives ("4E00,4E01") or carry a 'v' suffix
+		value := strings.TrimRight(strings.SplitN(fields[col], ",", 2)[0], "v")
+		uni, err := strconv.ParseUint(value, 16, 32)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, entry{uint32(cid), uint32(uni)})
+	}
+	return entries, s.Err()
+}
+
+// writeTable writes the "PCM1" format: a count followed by
+// (code uint32, length uint16, first Unicode uint32) ranges.
+func writeTable(path string, entries []entry) error {
+	sort.Slice(entries, func(i, j int) bool { return entries[i].code < entries[j].code })
+
+	type rng struct {
+		code uint32
+		n    uint16
+		uni  uint32
+	}
+	var ranges []rng
+	for i, e := range entries {
+		if i > 0 && e.code == entries[i-1].code {
+			continue
+		}
+		if n := len(ranges); n > 0 {
+			last := &ranges[n-1]
+			if last.n < 0xFFFF && e.code == last.code+uint32(last.n) && e.uni == last.uni+uint32(last.n) {
+				last.n++
+				continue
+			}
+		}
+		ranges = append(ranges, rng{e.code, 1, e.uni})
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	zw, err := gzip.NewWriterLevel(f, gzip.BestCompression)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	var w io.Writer = zw
+	buf := []byte("PCM1")
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(ranges)))
+	for _, r := range ranges {
+		buf = binary.BigEndian.AppendUint32(buf, r.code)
+		buf = binary.BigEndian.AppendUint16(buf, r.n)
+		buf = binary.BigEndian.AppendUint32(buf, r.uni)
+	}
+	if _, err := w.Write(buf); err != nil {
+		f.Close()
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
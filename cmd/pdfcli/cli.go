@@ -17,8 +17,13 @@ import (
 )
 
 func main() {
-	mode := flag.String("mode", "plain", "Extraction mode: plain, text, styled, rows, columns")
-	page := flag.Int("page", 0, "Page number (required for rows/columns, optional for plain)")
+	mode := flag.String("mode", "plain", "Extraction mode: plain, text, layout, styled, rows, columns, svg")
+	page := flag.Int("page", 0, "Page number (required for rows/columns/svg, optional for plain/layout)")
+	pitch := flag.Float64("pitch", 0, "Character width in points for layout mode (default: median glyph width)")
+	compress := flag.Bool("compress-blank", false, "Collapse runs of blank lines in layout mode")
+	separator := flag.String("page-sep", "\f", "Page separator for layout mode")
+	noSeparator := flag.Bool("no-page-sep", false, "Write nothing between pages in layout mode")
+	cluster := flag.String("cluster", "default", "Clustering drawn by svg mode: default, v3, ultrav2")
 	flag.Parse()
 
 	if flag.NArg() == 0 {
@@ -39,6 +44,13 @@ func main() {
 		handlePlain(reader, *page)
 	case "text":
 		handleText(reader, filePath)
+	case "layout":
+		handleLayout(reader, *page, pdf.LayoutTextOptions{
+			Pitch:              *pitch,
+			CompressBlankLines: *compress,
+			PageSeparator:      *separator,
+			NoPageSeparator:    *noSeparator,
+		})
 	case "styled":
 		handleStyled(reader)
 	case "rows":
@@ -47,6 +59,9 @@ func main() {
 	case "columns":
 		requirePage(*page)
 		handleColumns(reader, *page)
+	case "svg":
+		requirePage(*page)
+		handleSVG(reader, *page, *cluster)
 	default:
 		log.Fatalf("unknown mode %q", *mode)
 	}
@@ -92,6 +107,18 @@ func handleText(reader *pdf.Reader, filePath string) {
 	}
 }
 
+func handleLayout(reader *pdf.Reader, page int, opts pdf.LayoutTextOptions) {
+	e := pdf.NewExtractor(reader).LayoutOptions(opts)
+	if page > 0 {
+		e.Pages(page)
+	}
+	text, err := e.ExtractLayoutText()
+	if err != nil {
+		log.Fatalf("ExtractLayoutText: %v", err)
+	}
+	fmt.Print(text)
+}
+
 func handleStyled(reader *pdf.Reader) {
 	styled, err := reader.GetStyledTexts()
 	if err != nil {
@@ -131,6 +158,22 @@ func handleColumns(reader *pdf.Reader, page int) {
 	}
 }
 
+func handleSVG(reader *pdf.Reader, page int, cluster string) {
+	var opts pdf.SVGOptions
+	switch strings.ToLower(cluster) {
+	case "default":
+	case "v3":
+		opts.Cluster = pdf.ClusterTextBlocksV3
+	case "ultrav2":
+		opts.Cluster = pdf.ClusterTextBlocksUltraV2
+	default:
+		log.Fatalf("unknown clustering %q", cluster)
+	}
+	if err := reader.Page(page).RenderSVG(os.Stdout, opts); err != nil {
+		log.Fatalf("RenderSVG: %v", err)
+	}
+}
+
 func requirePage(page int) {
 	if page <= 0 {
 		log.Fatal("the -page flag must be specified for this mode")
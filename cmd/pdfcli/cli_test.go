@@ -26,8 +26,10 @@ func TestHandlersWithEmptyReader(t *testing.T) {
 		handlePlain(reader, 0)
 		handleStyled(reader)
 		handleText(reader, "")
+		handleLayout(reader, 0, pdf.LayoutTextOptions{})
 		handleRows(reader, 1)
 		handleColumns(reader, 1)
+		handleSVG(reader, 1, "v3")
 	})
 	if !isReadable("this text has many words") {
 		t.Fatalf("expected readable text")
@@ -0,0 +1,422 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	_ "crypto/sha1" // register the digests CMS signatures use
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// The subset of Cryptographic Message Syntax (RFC 5652) that PDF
+// signatures and the public-key security handler use.
+
+var (
+	oidData              = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidSignedData        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidAttrContentType   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidAttrMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidAttrSigningTime   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 5}
+	oidAttrSigningCert   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 12}
+	oidAttrSigningCertV2 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 47}
+
+	oidEnvelopedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 3}
+
+	oidRSAPSS        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 10}
+	oidRSAEncryption = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidRSAOAEP       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 7}
+
+	oidDESEDE3CBC = asn1.ObjectIdentifier{1, 2, 840, 113549, 3, 7}
+	oidAES128CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+// cmsHashes maps the digest algorithms of CMS, and the signature
+// algorithms that name a digest, to hashes.
+var cmsHashes = map[string]crypto.Hash{
+	"1.3.14.3.2.26":          crypto.SHA1,
+	"2.16.840.1.101.3.4.2.1": crypto.SHA256,
+	"2.16.840.1.101.3.4.2.2": crypto.SHA384,
+	"2.16.840.1.101.3.4.2.3": crypto.SHA512,
+	"2.16.840.1.101.3.4.2.4": crypto.SHA224,
+	"1.2.840.113549.1.1.5":   crypto.SHA1,   // sha1WithRSAEncryption
+	"1.2.840.113549.1.1.11":  crypto.SHA256, // sha256WithRSAEncryption
+	"1.2.840.113549.1.1.12":  crypto.SHA384,
+	"1.2.840.113549.1.1.13":  crypto.SHA512,
+	"1.2.840.113549.1.1.14":  crypto.SHA224,
+	"1.2.840.10045.4.1":      crypto.SHA1, // ecdsa-with-SHA1
+	"1.2.840.10045.4.3.1":    crypto.SHA224,
+	"1.2.840.10045.4.3.2":    crypto.SHA256,
+	"1.2.840.10045.4.3.3":    crypto.SHA384,
+	"1.2.840.10045.4.3.4":    crypto.SHA512,
+}
+
+type cmsContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+type cmsSignedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo cmsEncapContentInfo
+	Certificates     asn1.RawValue   `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue   `asn1:"optional,tag:1"`
+	SignerInfos      []cmsSignerInfo `asn1:"set"`
+}
+
+type cmsEncapContentInfo struct {
+	EContentType asn1.ObjectIdentifier
+	EContent     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+type cmsSignerInfo struct {
+	Version            int
+	SID                asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue `asn1:"optional,tag:0"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+	UnsignedAttrs      asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type cmsIssuerAndSerial struct {
+	Issuer asn1.RawValue
+	Serial *big.Int
+}
+
+type cmsAttribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue
+}
+
+// cmsSignature is a parsed SignedData with its first signer.
+type cmsSignature struct {
+	certs       []*x509.Certificate // the signer's first
+	signer      cmsSignerInfo
+	content     []byte // encapsulated content, if any
+	digest      []byte // messageDigest signed attribute
+	signingTime time.Time
+	signingCert []byte // ESS signing-certificate(-v2) signed attribute
+	signingV2   bool   // whether signingCert is the v2 attribute
+}
+
+// parseCMSSignature parses a DER ContentInfo holding SignedData. Bytes
+// after it, such as the zeros padding a PDF signature, are ignored.
+func parseCMSSignature(der []byte) (*cmsSignature, error) {
+	var ci cmsContentInfo
+	if _, err := asn1.Unmarshal(der, &ci); err != nil {
+		return nil, fmt.Errorf("malformed CMS: %v", err)
+	}
+	if !ci.ContentType.Equal(oidSignedData) {
+		return nil, fmt.Errorf("CMS content type %v is not signed data", ci.ContentType)
+	}
+	var sd cmsSignedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+		return nil, fmt.Errorf("malformed CMS signed data: %v", err)
+	}
+	if len(sd.SignerInfos) == 0 {
+		return nil, errors.New("CMS signed data has no signers")
+	}
+	s := &cmsSignature{signer: sd.SignerInfos[0]}
+	if len(sd.EncapContentInfo.EContent.Bytes) > 0 {
+		asn1.Unmarshal(sd.EncapContentInfo.EContent.Bytes, &s.content)
+	}
+	if len(sd.Certificates.Bytes) > 0 {
+		certs, err := x509.ParseCertificates(sd.Certificates.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("CMS certificates: %v", err)
+		}
+		s.certs = certs
+	}
+	// Put the signer first
+	for i, c := range s.certs {
+		if s.signedBy(c) {
+			s.certs[0], s.certs[i] = s.certs[i], s.certs[0]
+			break
+		}
+	}
+
+	for rest := s.signer.SignedAttrs.Bytes; len(rest) > 0; {
+		var attr cmsAttribute
+		var err error
+		if rest, err = asn1.Unmarshal(rest, &attr); err != nil {
+			return nil, fmt.Errorf("malformed CMS signed attributes: %v", err)
+		}
+		switch {
+		case attr.Type.Equal(oidAttrMessageDigest):
+			asn1.Unmarshal(attr.Values.Bytes, &s.digest)
+		case attr.Type.Equal(oidAttrSigningTime):
+			asn1.Unmarshal(attr.Values.Bytes, &s.signingTime)
+		case attr.Type.Equal(oidAttrSigningCert), attr.Type.Equal(oidAttrSigningCertV2):
+			s.signingCert = attr.Values.Bytes
+			s.signingV2 = attr.Type.Equal(oidAttrSigningCertV2)
+		}
+	}
+	return s, nil
+}
+
+// signedBy reports whether c is the certificate the signer identifies.
+func (s *cmsSignature) signedBy(c *x509.Certificate) bool {
+	return cmsIdentifies(s.signer.SID, c)
+}
+
+// cmsIdentifies reports whether the signer or recipient identifier id,
+// an issuer and serial number or a [0] subject key identifier, names c.
+func cmsIdentifies(id asn1.RawValue, c *x509.Certificate) bool {
+	if id.Class == asn1.ClassContextSpecific && id.Tag == 0 {
+		return len(c.SubjectKeyId) > 0 && bytes.Equal(c.SubjectKeyId, id.Bytes)
+	}
+	var ias cmsIssuerAndSerial
+	if _, err := asn1.Unmarshal(id.FullBytes, &ias); err != nil || ias.Serial == nil {
+		return false
+	}
+	return bytes.Equal(c.RawIssuer, ias.Issuer.FullBytes) && c.SerialNumber.Cmp(ias.Serial) == 0
+}
+
+// hash returns the digest algorithm of the signer.
+func (s *cmsSignature) hash() (crypto.Hash, error) {
+	h, ok := cmsHashes[s.signer.DigestAlgorithm.Algorithm.String()]
+	if !ok || !h.Available() {
+		return 0, fmt.Errorf("unsupported digest algorithm %v", s.signer.DigestAlgorithm.Algorithm)
+	}
+	return h, nil
+}
+
+// verify checks the signature of the signer over the digest of the
+// signed content, which is given. Old adbe.pkcs7.sha1 signatures
+// encapsulate that digest, and sign the encapsulated content in turn.
+// A signing-certificate attribute, if there is one, must name the signer
+// certificate.
+func (s *cmsSignature) verify(digest []byte) (digestErr, sigErr error) {
+	if len(s.certs) == 0 || !s.signedBy(s.certs[0]) {
+		return nil, errors.New("signer certificate not embedded")
+	}
+	h, err := s.hash()
+	if err != nil {
+		return err, err
+	}
+	if s.content != nil {
+		if !bytes.Equal(s.content, digest) {
+			digestErr = errors.New("digest of signed bytes does not match encapsulated digest")
+		}
+		hh := h.New()
+		hh.Write(s.content)
+		digest = hh.Sum(nil)
+	}
+	if len(s.signer.SignedAttrs.FullBytes) == 0 {
+		// The signature is over the content itself
+		return digestErr, verifySignature(s.certs[0].PublicKey, s.signer.SignatureAlgorithm.Algorithm, h, digest, s.content, s.signer.Signature)
+	}
+	if digestErr == nil && !bytes.Equal(s.digest, digest) {
+		digestErr = errors.New("digest of signed bytes does not match message digest")
+	}
+	if s.signingCert != nil {
+		if err := s.checkSigningCert(); err != nil {
+			return digestErr, err
+		}
+	}
+	// The signed attributes are signed as an explicit SET OF
+	attrs := append([]byte{0x31}, s.signer.SignedAttrs.FullBytes[1:]...)
+	hh := h.New()
+	hh.Write(attrs)
+	return digestErr, verifySignature(s.certs[0].PublicKey, s.signer.SignatureAlgorithm.Algorithm, h, hh.Sum(nil), attrs, s.signer.Signature)
+}
+
+// checkSigningCert checks that the first certificate of the ESS
+// signing-certificate attribute (RFC 2634 and RFC 5035) is the signer's.
+// The certificate hash of a v1 attribute is SHA-1, and that of a v2
+// attribute SHA-256 unless it names another.
+func (s *cmsSignature) checkSigningCert() error {
+	if s.signingCert == nil {
+		return errors.New("no signing certificate attribute")
+	}
+	var sc struct {
+		Certs    []asn1.RawValue
+		Policies asn1.RawValue `asn1:"optional"`
+	}
+	if _, err := asn1.Unmarshal(s.signingCert, &sc); err != nil || len(sc.Certs) == 0 {
+		return errors.New("malformed signing certificate attribute")
+	}
+	rest := sc.Certs[0].Bytes
+	h := crypto.SHA1
+	if s.signingV2 {
+		h = crypto.SHA256
+		var alg pkix.AlgorithmIdentifier
+		if r, err := asn1.Unmarshal(rest, &alg); err == nil {
+			var ok bool
+			if h, ok = cmsHashes[alg.Algorithm.String()]; !ok || !h.Available() {
+				return fmt.Errorf("unsupported signing certificate hash %v", alg.Algorithm)
+			}
+			rest = r
+		}
+	}
+	var sum []byte
+	if _, err := asn1.Unmarshal(rest, &sum); err != nil {
+		return errors.New("malformed signing certificate attribute")
+	}
+	hh := h.New()
+	hh.Write(s.certs[0].Raw)
+	if !bytes.Equal(hh.Sum(nil), sum) {
+		return errors.New("signing certificate attribute does not name the signer certificate")
+	}
+	return nil
+}
+
+// verifySignature verifies sig over a message with the given digest by
+// pub. Ed25519 signs the message itself.
+func verifySignature(pub crypto.PublicKey, alg asn1.ObjectIdentifier, h crypto.Hash, digest, msg, sig []byte) error {
+	var ok bool
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+		if alg.Equal(oidRSAPSS) {
+			return rsa.VerifyPSS(pub, h, digest, sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto})
+		}
+		return rsa.VerifyPKCS1v15(pub, h, digest, sig)
+	case *ecdsa.PublicKey:
+		ok = ecdsa.VerifyASN1(pub, digest, sig)
+	case ed25519.PublicKey:
+		if msg == nil {
+			return errors.New("Ed25519 signature without signed attributes")
+		}
+		ok = ed25519.Verify(pub, msg, sig)
+	default:
+		return fmt.Errorf("unsupported public key type %T", pub)
+	}
+	if !ok {
+		return errors.New("signature verification failed")
+	}
+	return nil
+}
+
+type cmsEnvelopedData struct {
+	Version              int
+	OriginatorInfo       asn1.RawValue   `asn1:"optional,tag:0"`
+	RecipientInfos       []asn1.RawValue `asn1:"set"`
+	EncryptedContentInfo cmsEncryptedContentInfo
+	UnprotectedAttrs     asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type cmsEncryptedContentInfo struct {
+	ContentType                asn1.ObjectIdentifier
+	ContentEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedContent           asn1.RawValue `asn1:"optional,tag:0"`
+}
+
+type cmsKeyTransRecipientInfo struct {
+	Version                int
+	RID                    asn1.RawValue
+	KeyEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedKey           []byte
+}
+
+// openCMSEnvelope decrypts the content of a DER ContentInfo holding
+// EnvelopedData for the recipient with certificate cert and private key
+// key. Only key transport recipients are recognized. It returns
+// ErrNotRecipient if cert is not one of them.
+func openCMSEnvelope(der []byte, cert *x509.Certificate, key crypto.Decrypter) ([]byte, error) {
+	var ci cmsContentInfo
+	if _, err := asn1.Unmarshal(der, &ci); err != nil {
+		return nil, fmt.Errorf("malformed CMS: %v", err)
+	}
+	if !ci.ContentType.Equal(oidEnvelopedData) {
+		return nil, fmt.Errorf("CMS content type %v is not enveloped data", ci.ContentType)
+	}
+	var ed cmsEnvelopedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &ed); err != nil {
+		return nil, fmt.Errorf("malformed CMS enveloped data: %v", err)
+	}
+
+	var ktri *cmsKeyTransRecipientInfo
+	for _, ri := range ed.RecipientInfos {
+		// Other kinds of recipient have context-specific tags
+		if ri.Class != asn1.ClassUniversal || ri.Tag != asn1.TagSequence {
+			continue
+		}
+		var k cmsKeyTransRecipientInfo
+		if _, err := asn1.Unmarshal(ri.FullBytes, &k); err == nil && cmsIdentifies(k.RID, cert) {
+			ktri = &k
+			break
+		}
+	}
+	if ktri == nil {
+		return nil, ErrNotRecipient
+	}
+
+	var opts crypto.DecrypterOpts
+	switch alg := ktri.KeyEncryptionAlgorithm.Algorithm; {
+	case alg.Equal(oidRSAEncryption):
+	case alg.Equal(oidRSAOAEP):
+		// Only the default parameters, SHA-1 with MGF1-SHA-1
+		opts = &rsa.OAEPOptions{Hash: crypto.SHA1}
+	default:
+		return nil, fmt.Errorf("unsupported key encryption algorithm %v", alg)
+	}
+	cek, err := key.Decrypt(rand.Reader, ktri.EncryptedKey, opts)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting content encryption key: %v", err)
+	}
+
+	eci := ed.EncryptedContentInfo
+	var block cipher.Block
+	switch alg := eci.ContentEncryptionAlgorithm.Algorithm; {
+	case alg.Equal(oidDESEDE3CBC):
+		block, err = des.NewTripleDESCipher(cek)
+	case alg.Equal(oidAES128CBC), alg.Equal(oidAES192CBC), alg.Equal(oidAES256CBC):
+		block, err = aes.NewCipher(cek)
+	default:
+		return nil, fmt.Errorf("unsupported content encryption algorithm %v", alg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("content encryption key: %v", err)
+	}
+	var iv []byte
+	if _, err := asn1.Unmarshal(eci.ContentEncryptionAlgorithm.Parameters.FullBytes, &iv); err != nil || len(iv) != block.BlockSize() {
+		return nil, errors.New("malformed CMS content encryption IV")
+	}
+
+	// The content is an implicit OCTET STRING, which BER lets a
+	// constructed encoding split into pieces.
+	content := eci.EncryptedContent.Bytes
+	if eci.EncryptedContent.IsCompound {
+		var pieces []byte
+		for rest := content; len(rest) > 0; {
+			var piece []byte
+			var err error
+			if rest, err = asn1.Unmarshal(rest, &piece); err != nil {
+				return nil, fmt.Errorf("malformed CMS encrypted content: %v", err)
+			}
+			pieces = append(pieces, piece...)
+		}
+		content = pieces
+	}
+	if len(content) == 0 || len(content)%block.BlockSize() != 0 {
+		return nil, errors.New("malformed CMS encrypted content")
+	}
+	out := make([]byte, len(content))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(out, content)
+	pad := int(out[len(out)-1])
+	if pad == 0 || pad > block.BlockSize() || !bytes.Equal(out[len(out)-pad:], bytes.Repeat([]byte{byte(pad)}, pad)) {
+		return nil, errors.New("CMS encrypted content has bad padding")
+	}
+	return out[:len(out)-pad], nil
+}
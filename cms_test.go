@@ -0,0 +1,289 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testPKI is a root CA with an RSA and an ECDSA leaf certificate it issued.
+type testPKI struct {
+	ca, rsaLeaf, ecLeaf *x509.Certificate
+	rsaKey, ecKey       crypto.Signer
+}
+
+var (
+	testPKIOnce sync.Once
+	testPKIVal  testPKI
+)
+
+var testCertTime = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func newTestPKI(t *testing.T) testPKI {
+	t.Helper()
+	testPKIOnce.Do(func() {
+		caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ca := &x509.Certificate{
+			SerialNumber:          big.NewInt(1),
+			Subject:               pkix.Name{CommonName: "Test Root"},
+			NotBefore:             testCertTime.AddDate(-1, 0, 0),
+			NotAfter:              testCertTime.AddDate(10, 0, 0),
+			KeyUsage:              x509.KeyUsageCertSign,
+			BasicConstraintsValid: true,
+			IsCA:                  true,
+		}
+		der, err := x509.CreateCertificate(rand.Reader, ca, ca, caKey.Public(), caKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if testPKIVal.ca, err = x509.ParseCertificate(der); err != nil {
+			t.Fatal(err)
+		}
+
+		leaf := func(serial int64, key crypto.Signer) *x509.Certificate {
+			tmpl := &x509.Certificate{
+				SerialNumber:   big.NewInt(serial),
+				Subject:        pkix.Name{CommonName: "Alice"},
+				SubjectKeyId:   []byte{byte(serial), 1, 2, 3},
+				NotBefore:      testCertTime.AddDate(0, -1, 0),
+				NotAfter:       testCertTime.AddDate(1, 0, 0),
+				KeyUsage:       x509.KeyUsageDigitalSignature,
+				AuthorityKeyId: testPKIVal.ca.SubjectKeyId,
+			}
+			der, err := x509.CreateCertificate(rand.Reader, tmpl, testPKIVal.ca, key.Public(), caKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c, err := x509.ParseCertificate(der)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return c
+		}
+		rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testPKIVal.rsaKey, testPKIVal.ecKey = rsaKey, ecKey
+		testPKIVal.rsaLeaf = leaf(2, rsaKey)
+		testPKIVal.ecLeaf = leaf(3, ecKey)
+	})
+	if testPKIVal.ecLeaf == nil {
+		t.Fatal("test PKI not created")
+	}
+	return testPKIVal
+}
+
+func mustMarshal(t *testing.T, v any) []byte {
+	t.Helper()
+	b, err := asn1.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func cmsSet(b []byte) asn1.RawValue {
+	return asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: b}
+}
+
+// essSigningCertV2 is the value of an ESS signing-certificate-v2 attribute
+// naming a certificate by its SHA-256 hash.
+type essSigningCertV2 struct {
+	Certs []struct{ CertHash []byte }
+}
+
+func signingCertV2(c *x509.Certificate) essSigningCertV2 {
+	return essSigningCertV2{[]struct{ CertHash []byte }{{sha256Of(c.Raw)}}}
+}
+
+// signCMS returns a detached CMS SignedData signature by key of the content
+// with the given SHA-256 digest. With attrs it signs content type, message
+// digest, signing time and signing-certificate-v2 attributes; otherwise it
+// signs the digest. The
+// signer is identified by subject key identifier if ski is set, and by
+// issuer and serial number otherwise. certs are embedded in the given order.
+func signCMS(t *testing.T, key crypto.Signer, signer *x509.Certificate, certs []*x509.Certificate, digest []byte, attrs, ski bool) []byte {
+	t.Helper()
+	sha256ID := pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}}
+	si := cmsSignerInfo{
+		Version:            1,
+		DigestAlgorithm:    sha256ID,
+		SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}},
+	}
+	if _, ok := key.Public().(*ecdsa.PublicKey); ok {
+		si.SignatureAlgorithm.Algorithm = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+	}
+	if ski {
+		si.Version = 3
+		si.SID = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, Bytes: signer.SubjectKeyId}
+	} else {
+		si.SID = asn1.RawValue{FullBytes: mustMarshal(t, cmsIssuerAndSerial{
+			Issuer: asn1.RawValue{FullBytes: signer.RawIssuer},
+			Serial: signer.SerialNumber,
+		})}
+	}
+
+	signed := digest
+	if attrs {
+		var b []byte
+		for _, a := range []struct {
+			oid asn1.ObjectIdentifier
+			v   any
+		}{
+			{oidAttrContentType, oidData},
+			{oidAttrMessageDigest, digest},
+			{oidAttrSigningTime, testCertTime},
+			{oidAttrSigningCertV2, signingCertV2(signer)},
+		} {
+			b = append(b, mustMarshal(t, cmsAttribute{Type: a.oid, Values: cmsSet(mustMarshal(t, a.v))})...)
+		}
+		si.SignedAttrs = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: b}
+		h := crypto.SHA256.New()
+		h.Write(mustMarshal(t, cmsSet(b)))
+		signed = h.Sum(nil)
+	}
+	sig, err := key.Sign(rand.Reader, signed, crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	si.Signature = sig
+
+	var raw []byte
+	for _, c := range certs {
+		raw = append(raw, c.Raw...)
+	}
+	sd := cmsSignedData{
+		Version:          1,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{sha256ID},
+		EncapContentInfo: cmsEncapContentInfo{EContentType: oidData},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: raw},
+		SignerInfos:      []cmsSignerInfo{si},
+	}
+	return mustMarshal(t, cmsContentInfo{
+		ContentType: oidSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: mustMarshal(t, sd)},
+	})
+}
+
+// encapsulate puts content into the SignedData of der, as adbe.pkcs7.sha1
+// signatures carry the digest of the signed bytes.
+func encapsulate(t *testing.T, der, content []byte) []byte {
+	t.Helper()
+	var ci cmsContentInfo
+	var sd cmsSignedData
+	if _, err := asn1.Unmarshal(der, &ci); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+		t.Fatal(err)
+	}
+	sd.EncapContentInfo.EContent = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: mustMarshal(t, content)}
+	ci.Content = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: mustMarshal(t, sd)}
+	return mustMarshal(t, ci)
+}
+
+func TestParseCMSSignature(t *testing.T) {
+	pki := newTestPKI(t)
+	digest := crypto.SHA256.New().Sum(nil)
+	for _, tt := range []struct {
+		name       string
+		attrs, ski bool
+	}{
+		{"attributes, issuer and serial", true, false},
+		{"attributes, key identifier", true, true},
+		{"digest only", false, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			der := signCMS(t, pki.ecKey, pki.ecLeaf, []*x509.Certificate{pki.ca, pki.ecLeaf}, digest, tt.attrs, tt.ski)
+			// Trailing zeros pad signatures in PDF files
+			s, err := parseCMSSignature(append(der, make([]byte, 64)...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(s.certs) != 2 || !s.certs[0].Equal(pki.ecLeaf) {
+				t.Error("signer certificate not first")
+			}
+			if got := !s.signingTime.IsZero(); got != tt.attrs {
+				t.Errorf("signing time %v", s.signingTime)
+			}
+			if dErr, sErr := s.verify(digest); dErr != nil || sErr != nil {
+				t.Errorf("verify = %v, %v", dErr, sErr)
+			}
+			other := crypto.SHA256.New()
+			other.Write([]byte("other"))
+			if dErr, sErr := s.verify(other.Sum(nil)); dErr == nil && sErr == nil {
+				t.Error("verify of another digest succeeded")
+			}
+			if !tt.attrs {
+				return
+			}
+			s.signingCert = mustMarshal(t, signingCertV2(pki.ca))
+			if _, sErr := s.verify(digest); sErr == nil {
+				t.Error("verify with a signing certificate attribute naming another certificate succeeded")
+			}
+		})
+	}
+
+	for _, der := range [][]byte{nil, []byte("not DER"), mustMarshal(t, cmsContentInfo{ContentType: oidData})} {
+		if _, err := parseCMSSignature(der); err == nil {
+			t.Errorf("parseCMSSignature(%q) succeeded", der)
+		}
+	}
+	s, err := parseCMSSignature(signCMS(t, pki.ecKey, pki.ecLeaf, []*x509.Certificate{pki.ca}, digest, true, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, sErr := s.verify(digest); sErr == nil {
+		t.Error("verify without the signer certificate succeeded")
+	}
+}
+
+func TestVerifySignatureUnsupported(t *testing.T) {
+	err := verifySignature("key", nil, crypto.SHA256, nil, nil, nil)
+	if err == nil || !bytes.Contains([]byte(err.Error()), []byte("unsupported")) {
+		t.Errorf("verifySignature with an unknown key = %v", err)
+	}
+}
+
+func TestCMSEncapsulatedDigest(t *testing.T) {
+	pki := newTestPKI(t)
+	h := crypto.SHA1.New()
+	h.Write([]byte("signed bytes"))
+	digest := h.Sum(nil)
+	for _, attrs := range []bool{false, true} {
+		// The signature is over the encapsulated digest, not the bytes
+		der := encapsulate(t, signCMS(t, pki.rsaKey, pki.rsaLeaf, []*x509.Certificate{pki.rsaLeaf}, sha256Of(digest), attrs, false), digest)
+		s, err := parseCMSSignature(der)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dErr, sErr := s.verify(digest); dErr != nil || sErr != nil {
+			t.Errorf("attrs %v: verify = %v, %v", attrs, dErr, sErr)
+		}
+		if dErr, _ := s.verify(make([]byte, len(digest))); dErr == nil {
+			t.Errorf("attrs %v: verify of another digest succeeded", attrs)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"fmt"
+	"math"
+)
+
+// A Color is an RGB colour. The zero value is black, the initial fill
+// colour of every content stream; it also stands for colours in spaces
+// that have no RGB conversion, such as Separation and Indexed.
+type Color struct {
+	R, G, B uint8
+}
+
+// String returns the colour in CSS hexadecimal notation, such as "#ff0000".
+func (c Color) String() string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// colorFromArgs converts the operands of a colour operator in a space of
+// n components to RGB: one for gray, three for RGB and four for CMYK.
+// Pattern names, other spaces and a wrong number of operands report false.
+func colorFromArgs(args []Value, n int) (Color, bool) {
+	if len(args) != n {
+		return Color{}, false
+	}
+	comp := make([]float64, 0, 4)
+	for _, a := range args {
+		switch a.Kind() {
+		case Integer, Real:
+			comp = append(comp, math.Min(math.Max(a.Float64(), 0), 1))
+		default:
+			return Color{}, false
+		}
+	}
+
+	channel := func(v float64) uint8 {
+		return uint8(math.Round(v * 255))
+	}
+	switch len(comp) {
+	case 1:
+		v := channel(comp[0])
+		return Color{v, v, v}, true
+	case 3:
+		return Color{channel(comp[0]), channel(comp[1]), channel(comp[2])}, true
+	case 4:
+		c, m, y, k := comp[0], comp[1], comp[2], comp[3]
+		return Color{channel((1 - c) * (1 - k)), channel((1 - m) * (1 - k)), channel((1 - y) * (1 - k))}, true
+	}
+	return Color{}, false
+}
+
+// colorSpaceComponents returns the number of components of the colour
+// space named by the operand of cs, looking it up in the ColorSpace
+// resources. Only the device spaces, CalGray, CalRGB and ICCBased profiles
+// of one, three or four components convert to RGB; the rest report 0.
+func colorSpaceComponents(resources, cs Value) int {
+	// A resource name may itself name a device space
+	for i := 0; i < 2 && cs.Kind() == Name; i++ {
+		switch cs.Name() {
+		case "DeviceGray", "G":
+			return 1
+		case "DeviceRGB", "RGB":
+			return 3
+		case "DeviceCMYK", "CMYK":
+			return 4
+		}
+		cs = resources.Key("ColorSpace").Key(cs.Name())
+	}
+	if cs.Kind() != Array {
+		return 0
+	}
+	switch cs.Index(0).Name() {
+	case "CalGray":
+		return 1
+	case "CalRGB":
+		return 3
+	case "ICCBased":
+		switch n := int(cs.Index(1).Key("N").Int64()); n {
+		case 1, 3, 4:
+			return n
+		}
+	}
+	return 0
+}
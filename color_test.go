@@ -0,0 +1,87 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestContentFillColor(t *testing.T) {
+	r := openTextPDF(t, "0 0 1 rg BT /F1 10 Tf 72 700 Td (a) Tj ET "+
+		"q 0.5 g BT /F1 10 Tf 72 680 Td (b) Tj ET Q "+
+		"BT /F1 10 Tf 72 660 Td (c) Tj ET "+
+		"0 1 1 0 k BT /F1 10 Tf 72 640 Td (d) Tj ET "+
+		"/Pattern cs /P0 scn BT /F1 10 Tf 72 620 Td (e) Tj ET "+
+		"/DeviceRGB cs 1 0 0 sc BT /F1 10 Tf 72 600 Td (f) Tj ET")
+
+	want := map[string]Color{
+		"a": {0, 0, 255},
+		"b": {128, 128, 128},
+		"c": {0, 0, 255}, // restored by Q
+		"d": {255, 0, 0},
+		"e": {},
+		"f": {255, 0, 0},
+	}
+	for _, tt := range r.Page(1).Content().Text {
+		if c, ok := want[tt.S]; ok && tt.Color != c {
+			t.Errorf("%s: colour %v, want %v", tt.S, tt.Color, c)
+		}
+	}
+	if s := (Color{255, 128, 0}).String(); s != "#ff8000" {
+		t.Errorf("Color.String() = %q", s)
+	}
+}
+
+func TestContentFillColorSpaces(t *testing.T) {
+	content := "/CS0 cs 1 scn BT /F1 10 Tf 72 700 Td (a) Tj ET " +
+		"/CS1 cs 5 sc BT /F1 10 Tf 72 680 Td (b) Tj ET " +
+		"/CS2 cs 1 0 0 sc BT /F1 10 Tf 72 660 Td (c) Tj ET " +
+		"/CS3 cs 0.5 sc BT /F1 10 Tf 72 640 Td (d) Tj ET " +
+		"/CS4 cs 0 0 1 0 scn BT /F1 10 Tf 72 620 Td (e) Tj ET " +
+		"/CS5 cs 0 1 0 sc BT /F1 10 Tf 72 600 Td (f) Tj ET " +
+		"/CS0 cs 0 0 1 sc BT /F1 10 Tf 72 580 Td (g) Tj ET"
+	widths := strings.TrimSpace(strings.Repeat("500 ", 95))
+	data := buildPDF("/Root 1 0 R",
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Count 1 /Kids [3 0 R] >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 4 0 R >> "+
+			"/ColorSpace << /CS0 [/Separation /Spot /DeviceCMYK 6 0 R] /CS1 [/Indexed /DeviceRGB 255 <ffffff>] "+
+			"/CS2 [/CalRGB << /WhitePoint [0.95 1 1.09] >>] /CS3 [/CalGray << /WhitePoint [0.95 1 1.09] >>] "+
+			"/CS4 [/ICCBased 7 0 R] /CS5 /DeviceRGB >> >> /Contents 5 0 R >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /FirstChar 32 /LastChar 126 /Widths ["+widths+"] >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+		"<< /FunctionType 2 /Domain [0 1] /C0 [0 0 0 0] /C1 [0 1 0 0] /N 1 >>",
+		"<< /N 4 /Length 0 >>\nstream\n\nendstream",
+	)
+	r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+
+	want := map[string]Color{
+		"a": {}, // Separation: unknown, not white
+		"b": {}, // Indexed: unknown, not white
+		"c": {255, 0, 0},
+		"d": {128, 128, 128},
+		"e": {255, 255, 0},
+		"f": {0, 255, 0},
+		"g": {}, // wrong number of operands for the space
+	}
+	seen := 0
+	for _, tt := range r.Page(1).Content().Text {
+		if c, ok := want[tt.S]; ok {
+			seen++
+			if tt.Color != c {
+				t.Errorf("%s: colour %v, want %v", tt.S, tt.Color, c)
+			}
+		}
+	}
+	if seen != len(want) {
+		t.Errorf("found %d of %d texts", seen, len(want))
+	}
+}
@@ -177,7 +177,8 @@ func hasForms(data []byte) bool {
 	return strings.Contains(dataStr, "/AcroForm") || strings.Contains(dataStr, "/FT")
 }
 
-// ValidatePDFA validates PDF/A compliance
+// ValidatePDFA validates PDF/A compliance by searching the raw bytes for
+// keywords. Reader.CheckPDFA checks the parsed document against the rules.
 func ValidatePDFA(data []byte) ([]string, error) {
 	var warnings []string
 	dataStr := string(data)
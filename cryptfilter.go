@@ -0,0 +1,110 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import "fmt"
+
+// cryptFilter is how a crypt filter of an encrypted document encrypts the
+// strings or streams it applies to.
+type cryptFilter int
+
+const (
+	cryptIdentity cryptFilter = iota // not encrypted
+	cryptRC4                         // RC4 (CFM V2, and all of V 1 and 2)
+	cryptAES                         // AES-CBC (CFM AESV2 and AESV3)
+)
+
+// initCryptFilters sets the crypt filters of the document from its
+// encryption dictionary. See PDF 32000-1:2008, §7.6.5.
+func (r *Reader) initCryptFilters(encrypt Value) error {
+	r.cryptFilters = map[string]cryptFilter{"Identity": cryptIdentity}
+	r.clearMetadata = false
+	if encrypt.Key("V").Int64() < 4 {
+		r.stmF, r.strF, r.effF = cryptRC4, cryptRC4, cryptRC4
+		return nil
+	}
+
+	cf := encrypt.Key("CF")
+	for _, name := range cf.Keys() {
+		if name == "Identity" {
+			continue // reserved; cannot be redefined
+		}
+		switch cfm := cf.Key(name).Key("CFM").Name(); cfm {
+		case "", "None":
+			// None leaves decryption to the application
+			r.cryptFilters[name] = cryptIdentity
+		case "V2":
+			r.cryptFilters[name] = cryptRC4
+		case "AESV2", "AESV3":
+			r.cryptFilters[name] = cryptAES
+		default:
+			return fmt.Errorf("unsupported PDF: crypt filter method %q", cfm)
+		}
+	}
+	lookup := func(key string, def cryptFilter) (cryptFilter, error) {
+		v := encrypt.Key(key)
+		if v.Kind() == Null {
+			return def, nil
+		}
+		f, ok := r.cryptFilters[v.Name()]
+		if !ok {
+			return 0, fmt.Errorf("malformed PDF: %s names undefined crypt filter %q", key, v.Name())
+		}
+		return f, nil
+	}
+	var err error
+	if r.stmF, err = lookup("StmF", cryptIdentity); err != nil {
+		return err
+	}
+	if r.strF, err = lookup("StrF", cryptIdentity); err != nil {
+		return err
+	}
+	if r.effF, err = lookup("EFF", r.stmF); err != nil {
+		return err
+	}
+	if m := encrypt.Key("EncryptMetadata"); m.Kind() == Bool && !m.Bool() {
+		r.clearMetadata = true
+	}
+	return nil
+}
+
+// streamCrypt returns the crypt filter that applies to the stream v: the
+// one its own Crypt filter names, or else the default for its type.
+func (r *Reader) streamCrypt(v Value) (cryptFilter, error) {
+	filter := v.Key("Filter")
+	param := v.Key("DecodeParms")
+	if filter.Kind() == Name && filter.Name() == "Crypt" {
+		return r.namedCrypt(param)
+	}
+	// Crypt is the first of the filters if it is there at all
+	if filter.Kind() == Array && filter.Index(0).Name() == "Crypt" {
+		return r.namedCrypt(param.Index(0))
+	}
+	switch v.Key("Type").Name() {
+	case "XRef":
+		return cryptIdentity, nil
+	case "Metadata":
+		if r.clearMetadata {
+			return cryptIdentity, nil
+		}
+	case "EmbeddedFile":
+		return r.effF, nil
+	}
+	return r.stmF, nil
+}
+
+// namedCrypt returns the crypt filter named by the decode parameters of a
+// Crypt filter.
+func (r *Reader) namedCrypt(param Value) (cryptFilter, error) {
+	name := param.Key("Name").Name()
+	if name == "" {
+		name = "Identity"
+	}
+	f, ok := r.cryptFilters[name]
+	if !ok {
+		return 0, fmt.Errorf("undefined crypt filter %q", name)
+	}
+	return f, nil
+}
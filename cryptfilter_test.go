@@ -0,0 +1,231 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/md5"
+	"crypto/rc4"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+var cryptTestID = []byte("0123456789abcdef")
+
+// standardR4 returns the O and U entries and the file key of the standard
+// security handler, revision 4, for a 128-bit key and the empty user
+// password.
+func standardR4(p int32, clearMetadata bool) (o, u, key []byte) {
+	o = bytes.Repeat([]byte{0x42}, 32)
+	h := md5.New()
+	h.Write(passwordPad)
+	h.Write(o)
+	h.Write([]byte{byte(p), byte(p >> 8), byte(p >> 16), byte(p >> 24)})
+	h.Write(cryptTestID)
+	if clearMetadata {
+		h.Write([]byte{0xff, 0xff, 0xff, 0xff})
+	}
+	key = h.Sum(nil)
+	for range 50 {
+		sum := md5.Sum(key[:16])
+		key = sum[:]
+	}
+
+	h.Reset()
+	h.Write(passwordPad)
+	h.Write(cryptTestID)
+	u = h.Sum(nil)
+	for i := range 20 {
+		k := append([]byte(nil), key...)
+		for j := range k {
+			k[j] ^= byte(i)
+		}
+		c, _ := rc4.NewCipher(k)
+		c.XORKeyStream(u, u)
+	}
+	return o, append(u, make([]byte, 16)...), key
+}
+
+// cryptObject is an object of a crypt filter test document. Its stream
+// data or string is encrypted with the named crypt filter.
+type cryptObject struct {
+	dict   string // dictionary, less Length
+	data   string // stream data, if a stream
+	str    string // string to put in place of %s in dict, if not a stream
+	filter string // crypt filter: StdCF (AESV2), RC4CF (V2) or Identity
+}
+
+// cryptPDF returns a document encrypted by the standard security handler
+// with crypt filters StdCF (AESV2) and RC4CF (V2), whose encryption
+// dictionary has the given extra entries. Its objects are the catalog, the
+// page tree, a page, its font, and then objs, numbered from 5; the first
+// of objs is the content stream of the page.
+func cryptPDF(entries string, clearMetadata bool, objs ...cryptObject) []byte {
+	o, u, key := standardR4(-4, clearMetadata)
+	widths := strings.TrimSpace(strings.Repeat("500 ", 95))
+	bodies := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Count 1 /Kids [3 0 R] >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 4 0 R >> >> /Contents 5 0 R >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /FirstChar 32 /LastChar 126 /Widths [" + widths + "] >>",
+	}
+	for i, obj := range objs {
+		num := len(bodies) + 1
+		enc := func(b []byte) []byte {
+			switch obj.filter {
+			case "StdCF":
+				return encryptObject(key, true, num, b)
+			case "RC4CF":
+				return encryptObject(key, false, num, b)
+			}
+			return b
+		}
+		if obj.data == "" && i > 0 {
+			bodies = append(bodies, fmt.Sprintf(obj.dict, "<"+hex.EncodeToString(enc([]byte(obj.str)))+">"))
+			continue
+		}
+		data := enc([]byte(obj.data))
+		bodies = append(bodies, fmt.Sprintf("%s /Length %d >>\nstream\n%s\nendstream", strings.TrimSuffix(obj.dict, ">>"), len(data), data))
+	}
+	bodies = append(bodies, fmt.Sprintf("<< /Filter /Standard /V 4 /R 4 /Length 128 /P -4 /O <%x> /U <%x> "+
+		"/CF << /StdCF << /CFM /AESV2 /AuthEvent /DocOpen /Length 16 >> /RC4CF << /CFM /V2 /Length 16 >> >> %s >>", o, u, entries))
+	return buildPDF(fmt.Sprintf("/Root 1 0 R /Info %d 0 R /Encrypt %d 0 R /ID [<%x> <%x>]", len(bodies)-1, len(bodies), cryptTestID, cryptTestID),
+		bodies...)
+}
+
+func flate(s string) string {
+	var b bytes.Buffer
+	w := zlib.NewWriter(&b)
+	w.Write([]byte(s))
+	w.Close()
+	return b.String()
+}
+
+func streamText(t *testing.T, v Value) string {
+	t.Helper()
+	rc := v.Reader()
+	defer rc.Close()
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestCryptFilters(t *testing.T) {
+	const content = "BT /F1 10 Tf 72 700 Td (Secret text) Tj ET"
+	const xmp = `<?xpacket begin=""?><x:xmpmeta xmlns:x="adobe:ns:meta/"/><?xpacket end="w"?>`
+	tests := []struct {
+		name          string
+		entries       string
+		clearMetadata bool
+		objs          []cryptObject
+	}{
+		{
+			name:    "streams AES, strings RC4",
+			entries: "/StmF /StdCF /StrF /RC4CF",
+			objs: []cryptObject{
+				{dict: "<< >>", data: content, filter: "StdCF"},
+				{dict: "<< /Type /Metadata /Subtype /XML >>", data: xmp, filter: "StdCF"},
+				{dict: "<< /Title %s >>", str: "Confidential", filter: "RC4CF"},
+			},
+		},
+		{
+			name:    "Identity streams",
+			entries: "/StmF /Identity /StrF /StdCF",
+			objs: []cryptObject{
+				{dict: "<< >>", data: content, filter: "Identity"},
+				{dict: "<< /Type /Metadata /Subtype /XML >>", data: xmp, filter: "Identity"},
+				{dict: "<< /Title %s >>", str: "Confidential", filter: "StdCF"},
+			},
+		},
+		{
+			name:    "default Identity",
+			entries: "",
+			objs: []cryptObject{
+				{dict: "<< >>", data: content, filter: "Identity"},
+				{dict: "<< /Type /Metadata /Subtype /XML >>", data: xmp, filter: "Identity"},
+				{dict: "<< /Title %s >>", str: "Confidential", filter: "Identity"},
+			},
+		},
+		{
+			name:          "clear metadata",
+			entries:       "/StmF /StdCF /StrF /StdCF /EncryptMetadata false",
+			clearMetadata: true,
+			objs: []cryptObject{
+				{dict: "<< >>", data: content, filter: "StdCF"},
+				{dict: "<< /Type /Metadata /Subtype /XML >>", data: xmp, filter: "Identity"},
+				{dict: "<< /Title %s >>", str: "Confidential", filter: "StdCF"},
+			},
+		},
+		{
+			name:    "per-stream Crypt",
+			entries: "/StmF /StdCF /StrF /StdCF",
+			objs: []cryptObject{
+				{dict: "<< /Filter /Crypt /DecodeParms << /Type /CryptFilterDecodeParms /Name /RC4CF >> >>", data: content, filter: "RC4CF"},
+				{dict: "<< /Type /Metadata /Subtype /XML /Filter [/Crypt /FlateDecode] /DecodeParms [<< /Name /Identity >> null] >>",
+					data: flate(xmp), filter: "Identity"},
+				{dict: "<< /Title %s >>", str: "Confidential", filter: "StdCF"},
+			},
+		},
+		{
+			name:    "Crypt without a name",
+			entries: "/StmF /StdCF /StrF /StdCF",
+			objs: []cryptObject{
+				{dict: "<< /Filter [/Crypt] >>", data: content, filter: "Identity"},
+				{dict: "<< /Type /Metadata /Subtype /XML >>", data: xmp, filter: "StdCF"},
+				{dict: "<< /Title %s >>", str: "Confidential", filter: "StdCF"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := cryptPDF(tt.entries, tt.clearMetadata, tt.objs...)
+			r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := readerText(t, r); got != "Secret text" {
+				t.Errorf("page text = %q", got)
+			}
+			if got := streamText(t, r.Trailer().Key("Root").Key("Pages").Key("Kids").Index(0).Key("Contents")); got != content {
+				t.Errorf("content stream = %q", got)
+			}
+			if got := streamText(t, r.resolve(objptr{}, objptr{6, 0})); got != xmp {
+				t.Errorf("metadata = %q", got)
+			}
+			if got := r.Trailer().Key("Info").Key("Title").Text(); got != "Confidential" {
+				t.Errorf("title = %q", got)
+			}
+		})
+	}
+}
+
+func TestCryptFilterErrors(t *testing.T) {
+	data := cryptPDF("/StmF /StdCF /StrF /StdCF", false,
+		cryptObject{dict: "<< /Filter /Crypt /DecodeParms << /Name /Missing >> >>", data: "BT ET", filter: "StdCF"},
+		cryptObject{dict: "<< /Title %s >>", str: "x", filter: "StdCF"},
+	)
+	r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc := r.resolve(objptr{}, objptr{5, 0}).Reader()
+	if _, err := io.ReadAll(rc); err == nil || !strings.Contains(err.Error(), "Missing") {
+		t.Errorf("reading a stream with an undefined crypt filter: %v", err)
+	}
+
+	data = cryptPDF("/StmF /Undefined /StrF /StdCF", false,
+		cryptObject{dict: "<< >>", data: "BT ET", filter: "StdCF"},
+		cryptObject{dict: "<< /Title %s >>", str: "x", filter: "StdCF"},
+	)
+	if _, err := NewReader(bytes.NewReader(data), int64(len(data))); err == nil || !strings.Contains(err.Error(), "Undefined") {
+		t.Errorf("NewReader with an undefined StmF: %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"math"
+)
+
+// DedupOptions configures the suppression of text drawn several times on
+// top of itself. Generators fake bold type by repeating a string with
+// sub-point offsets, and shadow effects overprint it slightly displaced;
+// both otherwise extract as "TTiittllee" or repeated lines.
+type DedupOptions struct {
+	// Tolerance is the largest offset, in points, between two copies of
+	// a run. Zero means a fifth of the font size.
+	Tolerance float64
+
+	// MarkBold sets Bold on runs that were overprinted with sub-point
+	// offsets, the usual way of faking bold type.
+	MarkBold bool
+}
+
+const (
+	// dedupToleranceRatio is the default tolerance as a fraction of the
+	// font size.
+	dedupToleranceRatio = 0.2
+
+	// fakeBoldOffset is the largest offset, in points, between copies
+	// that counts as fake bold rather than a shadow.
+	fakeBoldOffset = 1.0
+)
+
+// dedupKey buckets kept runs by string and position.
+type dedupKey struct {
+	s    string
+	x, y int
+}
+
+// DeduplicateTexts returns texts with overprinted copies collapsed into
+// the first run drawn. A run is a copy if it has the same string and font
+// size as an earlier run and is displaced by no more than the tolerance
+// and by less than half its width, so adjacent identical glyphs such as
+// the two in "ll" are kept.
+func DeduplicateTexts(texts []Text, opts DedupOptions) []Text {
+	if len(texts) < 2 {
+		return texts
+	}
+
+	out := make([]Text, 0, len(texts))
+	cells := make(map[dedupKey][]int, len(texts))
+	cell := func(v, size float64) int {
+		return int(math.Floor(v / size))
+	}
+
+	for _, t := range texts {
+		tol := opts.Tolerance
+		if tol <= 0 {
+			tol = math.Abs(t.FontSize) * dedupToleranceRatio
+		}
+		if tol <= 0 {
+			out = append(out, t)
+			continue
+		}
+
+		cx, cy := cell(t.X, tol), cell(t.Y, tol)
+		if i := findCopy(out, cells, t, cx, cy, tol); i >= 0 {
+			if opts.MarkBold && math.Abs(t.X-out[i].X) < fakeBoldOffset && math.Abs(t.Y-out[i].Y) < fakeBoldOffset {
+				out[i].Bold = true
+			}
+			continue
+		}
+
+		key := dedupKey{t.S, cx, cy}
+		cells[key] = append(cells[key], len(out))
+		out = append(out, t)
+	}
+	return out
+}
+
+// findCopy returns the index in kept of a run that t overprints, or -1.
+func findCopy(kept []Text, cells map[dedupKey][]int, t Text, cx, cy int, tol float64) int {
+	for dx := -1; dx <= 1; dx++ {
+		for dy := -1; dy <= 1; dy++ {
+			for _, i := range cells[dedupKey{t.S, cx + dx, cy + dy}] {
+				k := kept[i]
+				if k.FontSize != t.FontSize || k.Vertical != t.Vertical {
+					continue
+				}
+				offX, offY := math.Abs(t.X-k.X), math.Abs(t.Y-k.Y)
+				if offX > tol || offY > tol {
+					continue
+				}
+				// Identical neighbours sit a full advance apart
+				along, w := offX, math.Max(k.W, t.W)
+				if t.Vertical {
+					along, w = offY, math.Abs(t.FontSize)
+				}
+				if w > 0 && along >= w/2 {
+					continue
+				}
+				return i
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,122 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+// glyphRun returns one Text per rune of s, 6 points apart.
+func glyphRun(s string, x, y float64) []Text {
+	var texts []Text
+	for _, r := range s {
+		texts = append(texts, Text{Font: "Helvetica", FontSize: 12, X: x, Y: y, W: 6, S: string(r)})
+		x += 6
+	}
+	return texts
+}
+
+func TestDeduplicateTextsFakeBold(t *testing.T) {
+	// Drawn three times with sub-point offsets
+	var texts []Text
+	texts = append(texts, glyphRun("Title", 72, 700)...)
+	texts = append(texts, glyphRun("Title", 72.3, 700)...)
+	texts = append(texts, glyphRun("Title", 72.3, 700.3)...)
+
+	got := DeduplicateTexts(texts, DedupOptions{MarkBold: true})
+	if s := textString(got); s != "Title" {
+		t.Fatalf("deduplicated text = %q, want %q", s, "Title")
+	}
+	for i, g := range got {
+		if !g.Bold || g.X != texts[i].X || g.Y != texts[i].Y {
+			t.Errorf("run %+v should be bold and keep the first position", g)
+		}
+	}
+
+	if got := DeduplicateTexts(texts, DedupOptions{}); got[0].Bold {
+		t.Error("Bold set without MarkBold")
+	}
+}
+
+func TestDeduplicateTextsShadow(t *testing.T) {
+	var texts []Text
+	texts = append(texts, glyphRun("Shadow", 101.5, 498.5)...)
+	texts = append(texts, glyphRun("Shadow", 100, 500)...)
+
+	got := DeduplicateTexts(texts, DedupOptions{MarkBold: true})
+	if s := textString(got); s != "Shadow" {
+		t.Fatalf("deduplicated text = %q", s)
+	}
+	if got[0].Bold {
+		t.Error("shadow copies should not be marked bold")
+	}
+
+	// A tighter tolerance keeps the shadow
+	if got := DeduplicateTexts(texts, DedupOptions{Tolerance: 1}); len(got) != len(texts) {
+		t.Errorf("got %d runs with 1pt tolerance, want %d", len(got), len(texts))
+	}
+}
+
+func TestDeduplicateTextsKeepsDistinctRuns(t *testing.T) {
+	// Adjacent identical glyphs, repeated words on another line and
+	// another size must survive
+	texts := []Text{
+		{FontSize: 10, X: 72, Y: 700, W: 2.2, S: "l"},
+		{FontSize: 10, X: 74.2, Y: 700, W: 2.2, S: "l"},
+		{FontSize: 10, X: 72, Y: 688, W: 2.2, S: "l"},
+		{FontSize: 14, X: 72.2, Y: 700, W: 3.1, S: "l"},
+		{FontSize: 10, X: 72.1, Y: 700, W: 2.2, S: "I"},
+	}
+	if got := DeduplicateTexts(texts, DedupOptions{}); len(got) != len(texts) {
+		t.Errorf("DeduplicateTexts dropped runs: %+v", got)
+	}
+	if got := DeduplicateTexts(nil, DedupOptions{}); len(got) != 0 {
+		t.Errorf("expected no runs, got %v", got)
+	}
+}
+
+func TestExtractorDedup(t *testing.T) {
+	r := openTextPDF(t, "BT /F1 12 Tf 72 700 Td (Heading) Tj ET "+
+		"BT /F1 12 Tf 72.25 700 Td (Heading) Tj ET "+
+		"BT /F1 10 Tf 72 600 Td (body) Tj ET")
+
+	text, err := NewExtractor(r).Workers(1).Dedup(DedupOptions{MarkBold: true}).ExtractText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(text, "Heading") != 1 || strings.Contains(text, "HHee") || !strings.Contains(text, "body") {
+		t.Errorf("ExtractText = %q", text)
+	}
+
+	texts, err := NewExtractor(r).Dedup(DedupOptions{MarkBold: true}).ExtractStyledTexts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := textString(texts); s != "Headingbody" {
+		t.Errorf("styled texts = %q", s)
+	}
+	if !texts[0].Bold {
+		t.Errorf("fake bold heading not marked bold: %+v", texts[0])
+	}
+	for _, tt := range texts {
+		if tt.S == "b" && tt.Bold {
+			t.Errorf("body text marked bold: %+v", tt)
+		}
+	}
+
+	reader, err := r.ExtractWithContext(t.Context(), ExtractOptions{Dedup: &DedupOptions{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(string(data), "Heading") != 1 {
+		t.Errorf("ExtractWithContext = %q", data)
+	}
+}
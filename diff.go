@@ -0,0 +1,500 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"math"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// DiffOp is the kind of a DiffChange.
+type DiffOp int
+
+const (
+	DiffEqual  DiffOp = iota // Words unchanged
+	DiffDelete               // Words only in the old document
+	DiffInsert               // Words only in the new document
+	DiffMove                 // Words moved to another place
+	DiffStyle                // Words unchanged but for their font, size, weight or slant
+)
+
+func (op DiffOp) String() string {
+	switch op {
+	case DiffEqual:
+		return "equal"
+	case DiffDelete:
+		return "delete"
+	case DiffInsert:
+		return "insert"
+	case DiffMove:
+		return "move"
+	case DiffStyle:
+		return "style"
+	}
+	return "unknown"
+}
+
+// DiffOptions configures DiffDocuments.
+type DiffOptions struct {
+	// MinMoveWords is the fewest words a deleted run and an inserted run
+	// must have in common to be reported as a move. Zero means 3.
+	MinMoveWords int
+
+	// IgnoreStyle reports words whose style changed as equal.
+	IgnoreStyle bool
+
+	// Dedup, if not nil, collapses overprinted text before diffing.
+	Dedup *DedupOptions
+}
+
+// DiffSide is one side of a DiffChange.
+type DiffSide struct {
+	Page  int    // 1-based page number; 0 if the change has no words on this side
+	Words []Word // the words, in reading order
+}
+
+// Text returns the words of the side separated by spaces.
+func (s DiffSide) Text() string {
+	texts := make([]string, len(s.Words))
+	for i, w := range s.Words {
+		texts[i] = w.Text
+	}
+	return strings.Join(texts, " ")
+}
+
+// DiffChange is a run of words that changed in the same way, or that did
+// not change. A change lies within one page on each side.
+type DiffChange struct {
+	Op  DiffOp
+	Old DiffSide // the words in the old document; empty for DiffInsert
+	New DiffSide // the words in the new document; empty for DiffDelete
+}
+
+// PageAlignment pairs a page of the old document with the page of the new
+// document it became. A page that was removed has New 0, and a page that
+// was inserted has Old 0.
+type PageAlignment struct {
+	Old, New       int
+	OldBox, NewBox Rect    // crop boxes of the pages
+	Similarity     float64 // share of the words of the two pages in common, from 0 to 1
+}
+
+// DocumentDiff is the difference between two documents.
+type DocumentDiff struct {
+	// Pages aligns the pages of the documents, in the order of both.
+	Pages []PageAlignment
+
+	// Changes is the edit script from the old document to the new one,
+	// in the order of Pages and within a page in reading order. Unchanged
+	// words are included as DiffEqual changes. A move is listed where
+	// its words are in the new document.
+	Changes []DiffChange
+}
+
+// Stats returns the number of words in each kind of change.
+func (d *DocumentDiff) Stats() map[DiffOp]int {
+	stats := make(map[DiffOp]int)
+	for _, c := range d.Changes {
+		n := len(c.New.Words)
+		if c.Op == DiffDelete {
+			n = len(c.Old.Words)
+		}
+		stats[c.Op] += n
+	}
+	return stats
+}
+
+// diffPageThreshold is the least similarity of two pages that are aligned.
+const diffPageThreshold = 0.25
+
+// DiffDocuments compares the text of two documents. It aligns their pages,
+// so that inserted and removed pages are found, diffs the words of each
+// pair of aligned pages in reading order, and then pairs runs of deleted
+// and inserted words with the same text as moves.
+func DiffDocuments(old, new *Reader, opts DiffOptions) (*DocumentDiff, error) {
+	a, err := diffPages(old, opts.Dedup)
+	if err != nil {
+		return nil, err
+	}
+	b, err := diffPages(new, opts.Dedup)
+	if err != nil {
+		return nil, err
+	}
+	return diffDocuments(a, b, opts), nil
+}
+
+// diffPage is the text of a page being diffed.
+type diffPage struct {
+	box   Rect
+	words []Word
+}
+
+func diffPages(r *Reader, dedup *DedupOptions) ([]diffPage, error) {
+	pages := make([]diffPage, r.NumPage())
+	for i := range pages {
+		p := r.Page(i + 1)
+		layout, _, err := p.exportLayout(dedup)
+		if err != nil {
+			return nil, err
+		}
+		box, ok := p.cropBox()
+		if !ok {
+			box = p.bounds()
+		}
+		pages[i] = diffPage{box: box, words: layout.Words()}
+	}
+	return pages, nil
+}
+
+// diffRun is a change while the edit script is built. Its position is the
+// index in the script of its first word.
+type diffRun struct {
+	DiffChange
+	pair, pos int
+}
+
+func diffDocuments(a, b []diffPage, opts DiffOptions) *DocumentDiff {
+	d := &DocumentDiff{Pages: alignPages(a, b)}
+
+	var runs []*diffRun
+	add := func(r *diffRun) {
+		if n := len(runs); n > 0 {
+			last := runs[n-1]
+			if last.Op == r.Op && last.pair == r.pair && r.Op != DiffStyle {
+				last.Old.Words = append(last.Old.Words, r.Old.Words...)
+				last.New.Words = append(last.New.Words, r.New.Words...)
+				return
+			}
+		}
+		runs = append(runs, r)
+	}
+	for pair, pa := range d.Pages {
+		var old, new []Word
+		if pa.Old > 0 {
+			old = a[pa.Old-1].words
+		}
+		if pa.New > 0 {
+			new = b[pa.New-1].words
+		}
+		for pos, s := range editScript(diffTexts(old), diffTexts(new)) {
+			r := &diffRun{DiffChange: DiffChange{Op: s.op}, pair: pair, pos: pos}
+			if s.op != DiffInsert {
+				r.Old = DiffSide{Page: pa.Old, Words: []Word{old[s.i]}}
+			}
+			if s.op != DiffDelete {
+				r.New = DiffSide{Page: pa.New, Words: []Word{new[s.j]}}
+			}
+			if s.op == DiffEqual && !opts.IgnoreStyle && !sameStyle(old[s.i], new[s.j]) {
+				r.Op = DiffStyle
+				// Neighbouring words with the same change of style are one change
+				if n := len(runs); n > 0 {
+					last := runs[n-1]
+					if last.Op == DiffStyle && last.pair == pair &&
+						sameStyle(last.Old.Words[0], old[s.i]) && sameStyle(last.New.Words[0], new[s.j]) {
+						last.Old.Words = append(last.Old.Words, old[s.i])
+						last.New.Words = append(last.New.Words, new[s.j])
+						continue
+					}
+				}
+			}
+			add(r)
+		}
+	}
+
+	minMove := opts.MinMoveWords
+	if minMove <= 0 {
+		minMove = 3
+	}
+	runs = findMoves(runs, minMove)
+
+	sort.SliceStable(runs, func(i, j int) bool {
+		if runs[i].pair != runs[j].pair {
+			return runs[i].pair < runs[j].pair
+		}
+		return runs[i].pos < runs[j].pos
+	})
+	d.Changes = make([]DiffChange, len(runs))
+	for i, r := range runs {
+		d.Changes[i] = r.DiffChange
+	}
+	return d
+}
+
+// diffTexts returns the texts of words.
+func diffTexts(words []Word) []string {
+	s := make([]string, len(words))
+	for i, w := range words {
+		s[i] = w.Text
+	}
+	return s
+}
+
+// sameStyle reports whether two words are set in the same font, size,
+// weight and slant.
+func sameStyle(a, b Word) bool {
+	return a.Font == b.Font && a.Bold == b.Bold && a.Italic == b.Italic &&
+		math.Abs(a.FontSize-b.FontSize) < 0.5
+}
+
+// alignPages pairs the pages of two documents in order so as to maximise
+// the total similarity of the pairs, leaving out pairs less similar than
+// diffPageThreshold.
+func alignPages(a, b []diffPage) []PageAlignment {
+	bags := func(pages []diffPage) []map[string]int {
+		m := make([]map[string]int, len(pages))
+		for i, p := range pages {
+			m[i] = make(map[string]int)
+			for _, w := range p.words {
+				m[i][w.Text]++
+			}
+		}
+		return m
+	}
+	ba, bb := bags(a), bags(b)
+	sim := func(i, j int) float64 {
+		na, nb := len(a[i].words), len(b[j].words)
+		if na+nb == 0 {
+			return 1
+		}
+		common := 0
+		for w, n := range ba[i] {
+			common += min(n, bb[j][w])
+		}
+		return 2 * float64(common) / float64(na+nb)
+	}
+
+	n, m := len(a), len(b)
+	score := make([][]float64, n+1)
+	sims := make([][]float64, n+1)
+	for i := range score {
+		score[i] = make([]float64, m+1)
+		sims[i] = make([]float64, m+1)
+	}
+	for i := 1; i <= n; i++ {
+		for j := 1; j <= m; j++ {
+			score[i][j] = max(score[i-1][j], score[i][j-1])
+			if s := sim(i-1, j-1); s >= diffPageThreshold {
+				sims[i][j] = s
+				score[i][j] = max(score[i][j], score[i-1][j-1]+s)
+			}
+		}
+	}
+
+	var pages []PageAlignment
+	for i, j := n, m; i > 0 || j > 0; {
+		switch {
+		case i > 0 && j > 0 && sims[i][j] > 0 && score[i][j] == score[i-1][j-1]+sims[i][j]:
+			pages = append(pages, PageAlignment{Old: i, New: j, OldBox: a[i-1].box, NewBox: b[j-1].box, Similarity: sims[i][j]})
+			i, j = i-1, j-1
+		case j > 0 && (i == 0 || score[i][j] == score[i][j-1]):
+			pages = append(pages, PageAlignment{New: j, NewBox: b[j-1].box})
+			j--
+		default:
+			pages = append(pages, PageAlignment{Old: i, OldBox: a[i-1].box})
+			i--
+		}
+	}
+	slices.Reverse(pages)
+	return pages
+}
+
+// editStep is a step of an edit script: a[i] is kept as b[j], deleted, or
+// b[j] is inserted.
+type editStep struct {
+	op   DiffOp
+	i, j int
+}
+
+// editScript returns a shortest edit script turning a into b, found with
+// Myers' algorithm after trimming the common prefix and suffix.
+func editScript(a, b []string) []editStep {
+	var steps []editStep
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		steps = append(steps, editStep{DiffEqual, pre, pre})
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	for _, s := range myersDiff(a[pre:len(a)-suf], b[pre:len(b)-suf]) {
+		steps = append(steps, editStep{s.op, s.i + pre, s.j + pre})
+	}
+	for k := suf; k > 0; k-- {
+		steps = append(steps, editStep{DiffEqual, len(a) - k, len(b) - k})
+	}
+	return steps
+}
+
+func myersDiff(a, b []string) []editStep {
+	n, m := len(a), len(b)
+	limit := n + m
+	if limit == 0 {
+		return nil
+	}
+	off := limit
+	v := make([]int, 2*limit+2)
+	// trace[d] holds the diagonals -d..d before step d, so the trace grows
+	// with the square of the edit distance rather than of the input.
+	var trace [][]int
+	for d := 0; d <= limit; d++ {
+		trace = append(trace, slices.Clone(v[off-d:off+d+1]))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1]
+			} else {
+				x = v[off+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				return myersPath(trace, n, m, d)
+			}
+		}
+	}
+	panic("unreachable")
+}
+
+// myersPath walks back through the furthest points reached at each
+// distance to recover the edit script.
+func myersPath(trace [][]int, x, y, d int) []editStep {
+	var steps []editStep
+	for ; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+		prev := k - 1
+		if k == -d || (k != d && v[d+k-1] < v[d+k+1]) {
+			prev = k + 1
+		}
+		px := v[d+prev]
+		py := px - prev
+		// A step from (px, py) to (sx, sy), then a snake of equal words
+		sx, sy := px+1, py
+		if prev == k+1 {
+			sx, sy = px, py+1
+		}
+		for x > sx && y > sy {
+			x, y = x-1, y-1
+			steps = append(steps, editStep{DiffEqual, x, y})
+		}
+		if prev == k+1 {
+			steps = append(steps, editStep{DiffInsert, px, py})
+		} else {
+			steps = append(steps, editStep{DiffDelete, px, py})
+		}
+		x, y = px, py
+	}
+	for x > 0 && y > 0 {
+		x, y = x-1, y-1
+		steps = append(steps, editStep{DiffEqual, x, y})
+	}
+	slices.Reverse(steps)
+	return steps
+}
+
+// findMoves turns the longest runs of words that were both deleted and
+// inserted into moves, for as long as one of at least minWords is left.
+// The deleted and inserted runs they come from are split around them.
+func findMoves(runs []*diffRun, minWords int) []*diffRun {
+	for {
+		// Index the minWords-word sequences of the inserted runs
+		grams := make(map[string][][2]int)
+		for ri, r := range runs {
+			if r.Op != DiffInsert {
+				continue
+			}
+			texts := diffTexts(r.New.Words)
+			for k := 0; k+minWords <= len(texts); k++ {
+				g := strings.Join(texts[k:k+minWords], "\x00")
+				grams[g] = append(grams[g], [2]int{ri, k})
+			}
+		}
+		if len(grams) == 0 {
+			return runs
+		}
+
+		var best struct{ del, dk, ins, ik, n int }
+		for ri, r := range runs {
+			if r.Op != DiffDelete {
+				continue
+			}
+			texts := diffTexts(r.Old.Words)
+			for k := 0; k+minWords <= len(texts); k++ {
+				for _, at := range grams[strings.Join(texts[k:k+minWords], "\x00")] {
+					ins := runs[at[0]].New.Words
+					n := minWords
+					for k+n < len(texts) && at[1]+n < len(ins) && texts[k+n] == ins[at[1]+n].Text {
+						n++
+					}
+					if n > best.n {
+						best = struct{ del, dk, ins, ik, n int }{ri, k, at[0], at[1], n}
+					}
+				}
+			}
+		}
+		if best.n == 0 {
+			return runs
+		}
+
+		del, ins := runs[best.del], runs[best.ins]
+		move := &diffRun{
+			DiffChange: DiffChange{
+				Op:  DiffMove,
+				Old: DiffSide{Page: del.Old.Page, Words: del.Old.Words[best.dk : best.dk+best.n]},
+				New: DiffSide{Page: ins.New.Page, Words: ins.New.Words[best.ik : best.ik+best.n]},
+			},
+			pair: ins.pair,
+			pos:  ins.pos + best.ik,
+		}
+		var out []*diffRun
+		for ri, r := range runs {
+			switch ri {
+			case best.del:
+				out = append(out, splitRun(r, best.dk, best.n, nil)...)
+			case best.ins:
+				out = append(out, splitRun(r, best.ik, best.n, move)...)
+			default:
+				out = append(out, r)
+			}
+		}
+		runs = out
+	}
+}
+
+// splitRun returns the parts of a deleted or inserted run before and after
+// the n words from k, with mid, if not nil, in between.
+func splitRun(r *diffRun, k, n int, mid *diffRun) []*diffRun {
+	words := r.Old.Words
+	if r.Op == DiffInsert {
+		words = r.New.Words
+	}
+	part := func(from, to int) *diffRun {
+		p := &diffRun{DiffChange: r.DiffChange, pair: r.pair, pos: r.pos + from}
+		if r.Op == DiffInsert {
+			p.New.Words = words[from:to:to]
+		} else {
+			p.Old.Words = words[from:to:to]
+		}
+		return p
+	}
+	var parts []*diffRun
+	if k > 0 {
+		parts = append(parts, part(0, k))
+	}
+	if mid != nil {
+		parts = append(parts, mid)
+	}
+	if k+n < len(words) {
+		parts = append(parts, part(k+n, len(words)))
+	}
+	return parts
+}
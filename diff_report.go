@@ -0,0 +1,190 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strings"
+)
+
+const diffStyle = `text{font-family:sans-serif;fill:#424242}
+.page{fill:#fff;stroke:#000;stroke-width:0.5}
+.delete{fill:#e53935;fill-opacity:0.3}
+.insert{fill:#43a047;fill-opacity:0.3}
+.move{fill:#1e88e5;fill-opacity:0.3}
+.style{fill:#fb8c00;fill-opacity:0.3}
+.label{fill:#1e88e5;font-size:6px;font-weight:bold}`
+
+// diffGap is the space between the old and the new page in SVG reports.
+const diffGap = 24
+
+// WriteSVG draws the pair of pages Pages[pair] side by side, old on the
+// left and new on the right, with the words of each change highlighted:
+// red for deleted, green for inserted, orange for restyled, and blue,
+// numbered on both sides, for moved.
+func (d *DocumentDiff) WriteSVG(w io.Writer, pair int) error {
+	if pair < 0 || pair >= len(d.Pages) {
+		return fmt.Errorf("pdf: no page pair %d in diff of %d", pair, len(d.Pages))
+	}
+	var sb strings.Builder
+	d.svg(&sb, pair, d.moveNumbers())
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// moveNumbers numbers the moves of the diff from 1, by index in Changes.
+func (d *DocumentDiff) moveNumbers() map[int]int {
+	nums := make(map[int]int)
+	for i, c := range d.Changes {
+		if c.Op == DiffMove {
+			nums[i] = len(nums) + 1
+		}
+	}
+	return nums
+}
+
+func (d *DocumentDiff) svg(sb *strings.Builder, pair int, moves map[int]int) {
+	pa := d.Pages[pair]
+	size := func(r Rect) (float64, float64) { return r.Max.X - r.Min.X, r.Max.Y - r.Min.Y }
+	ow, oh := size(pa.OldBox)
+	nw, nh := size(pa.NewBox)
+	width, height := ow+diffGap+nw, math.Max(oh, nh)
+	fmt.Fprintf(sb, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%s\" height=\"%s\" viewBox=\"0 0 %s %s\">\n",
+		svgNum(width), svgNum(height), svgNum(width), svgNum(height))
+	fmt.Fprintf(sb, "<style>\n%s\n</style>\n", diffStyle)
+
+	side := func(class string, page int, box Rect, ox float64, words func(DiffChange) DiffSide) {
+		fmt.Fprintf(sb, "<g class=\"%s\">\n", class)
+		if page == 0 {
+			sb.WriteString("</g>\n")
+			return
+		}
+		point := func(p Point) Point { return Point{ox + p.X - box.Min.X, box.Max.Y - p.Y} }
+		bw, bh := size(box)
+		fmt.Fprintf(sb, "<rect class=\"page\" x=\"%s\" y=\"0\" width=\"%s\" height=\"%s\"/>\n", svgNum(ox), svgNum(bw), svgNum(bh))
+		for i, c := range d.Changes {
+			s := words(c)
+			if s.Page != page {
+				continue
+			}
+			for _, word := range s.Words {
+				b := word.Bounds
+				tl := point(Point{b.Min.X, b.Max.Y})
+				h := b.Max.Y - b.Min.Y
+				if c.Op != DiffEqual {
+					fmt.Fprintf(sb, "<rect class=\"%s\" x=\"%s\" y=\"%s\" width=\"%s\" height=\"%s\"/>",
+						c.Op, svgNum(tl.X), svgNum(tl.Y), svgNum(b.Max.X-b.Min.X), svgNum(h))
+				}
+				fmt.Fprintf(sb, "<text x=\"%s\" y=\"%s\" font-size=\"%s\">%s</text>\n",
+					svgNum(tl.X), svgNum(tl.Y+h*0.8), svgNum(h*0.8), xmlEscape(word.Text))
+			}
+			if n, ok := moves[i]; ok && len(s.Words) > 0 {
+				tl := point(Point{s.Words[0].Bounds.Min.X, s.Words[0].Bounds.Max.Y})
+				fmt.Fprintf(sb, "<text class=\"label\" x=\"%s\" y=\"%s\">M%d</text>\n", svgNum(tl.X), svgNum(tl.Y-1), n)
+			}
+		}
+		sb.WriteString("</g>\n")
+	}
+	side("old", pa.Old, pa.OldBox, 0, func(c DiffChange) DiffSide {
+		if c.Op == DiffInsert {
+			return DiffSide{}
+		}
+		return c.Old
+	})
+	side("new", pa.New, pa.NewBox, ow+diffGap, func(c DiffChange) DiffSide {
+		if c.Op == DiffDelete {
+			return DiffSide{}
+		}
+		return c.New
+	})
+	sb.WriteString("</svg>\n")
+}
+
+const diffHTMLStyle = `body{font-family:sans-serif;margin:2em}
+section{margin-bottom:3em}
+svg{max-width:100%;height:auto;border:1px solid #ccc}
+del{background:#ffcdd2}
+ins{background:#c8e6c9;text-decoration:none}
+.move{background:#bbdefb}
+.style{background:#ffe0b2}
+.flow{line-height:1.6;max-width:60em}`
+
+// WriteHTML writes a report of the diff as an HTML document: a summary,
+// then for each pair of pages the drawing of WriteSVG and the text of the
+// new page with deleted words struck out and the other changes marked.
+func (d *DocumentDiff) WriteHTML(w io.Writer) error {
+	var sb strings.Builder
+	sb.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>Document diff</title>\n")
+	fmt.Fprintf(&sb, "<style>\n%s\n</style>\n</head>\n<body>\n<h1>Document diff</h1>\n", diffHTMLStyle)
+	stats := d.Stats()
+	fmt.Fprintf(&sb, "<p class=\"summary\">%d words inserted, %d deleted, %d moved and %d restyled.</p>\n",
+		stats[DiffInsert], stats[DiffDelete], stats[DiffMove], stats[DiffStyle])
+
+	// Each change is shown with the pair of pages its new words are on,
+	// or its old words if it has none.
+	oldPair := make(map[int]int)
+	newPair := make(map[int]int)
+	for i, pa := range d.Pages {
+		oldPair[pa.Old] = i
+		newPair[pa.New] = i
+	}
+	flows := make([][]string, len(d.Pages))
+	moves := d.moveNumbers()
+	for i, c := range d.Changes {
+		pair := newPair[c.New.Page]
+		if c.New.Page == 0 {
+			pair = oldPair[c.Old.Page]
+		}
+		var s string
+		switch c.Op {
+		case DiffEqual:
+			s = html.EscapeString(c.New.Text())
+		case DiffDelete:
+			s = "<del>" + html.EscapeString(c.Old.Text()) + "</del>"
+		case DiffInsert:
+			s = "<ins>" + html.EscapeString(c.New.Text()) + "</ins>"
+		case DiffMove:
+			s = fmt.Sprintf("<ins class=\"move\" title=\"M%d: moved from page %d\">%s</ins>",
+				moves[i], c.Old.Page, html.EscapeString(c.New.Text()))
+		case DiffStyle:
+			s = fmt.Sprintf("<span class=\"style\" title=\"%s\">%s</span>",
+				html.EscapeString(wordStyleName(c.Old.Words[0])+" → "+wordStyleName(c.New.Words[0])), html.EscapeString(c.New.Text()))
+		}
+		flows[pair] = append(flows[pair], s)
+	}
+
+	for i, pa := range d.Pages {
+		var title string
+		switch {
+		case pa.Old == 0:
+			title = fmt.Sprintf("Page %d inserted", pa.New)
+		case pa.New == 0:
+			title = fmt.Sprintf("Page %d removed", pa.Old)
+		default:
+			title = fmt.Sprintf("Page %d → page %d (%.0f%% similar)", pa.Old, pa.New, pa.Similarity*100)
+		}
+		fmt.Fprintf(&sb, "<section id=\"pair-%d\">\n<h2>%s</h2>\n", i+1, html.EscapeString(title))
+		d.svg(&sb, i, moves)
+		fmt.Fprintf(&sb, "<p class=\"flow\">%s</p>\n</section>\n", strings.Join(flows[i], " "))
+	}
+	sb.WriteString("</body>\n</html>\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// wordStyleName describes the style of a word, such as "Helvetica 10pt bold".
+func wordStyleName(w Word) string {
+	s := w.Font + " " + svgNum(w.FontSize) + "pt"
+	if w.Bold {
+		s += " bold"
+	}
+	if w.Italic {
+		s += " italic"
+	}
+	return s
+}
@@ -0,0 +1,78 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffReport(t *testing.T) {
+	d := diffTestDocuments(t,
+		[]string{"BT /F1 10 Tf 72 700 Td (one two three four <five>) Tj ET"},
+		[]string{
+			"BT /F1 10 Tf 72 700 Td (four <five> one two three) Tj ET",
+			"BT /F2 10 Tf 72 700 Td (new page) Tj ET",
+		}, DiffOptions{MinMoveWords: 2})
+
+	var sb strings.Builder
+	if err := d.WriteSVG(&sb, 0); err != nil {
+		t.Fatal(err)
+	}
+	root, err := parseXML([]byte(sb.String()))
+	if err != nil {
+		t.Fatalf("SVG not well-formed: %v\n%s", err, sb.String())
+	}
+	if root.attrs["width"] != "1248" || root.attrs["height"] != "792" {
+		t.Errorf("SVG size %sx%s, want two pages side by side", root.attrs["width"], root.attrs["height"])
+	}
+	sides := make(map[string]*xmlNode)
+	for _, c := range root.children {
+		if c.name.Local == "g" {
+			sides[c.attrs["class"]] = c
+		}
+	}
+	for _, side := range []string{"old", "new"} {
+		var moved, labels int
+		for _, c := range sides[side].children {
+			if c.attrs["class"] == "move" {
+				moved++
+			}
+			if c.attrs["class"] == "label" {
+				labels++
+			}
+		}
+		if moved != 2 || labels != 1 {
+			t.Errorf("%s side has %d moved words and %d labels, want 2 and 1", side, moved, labels)
+		}
+	}
+	// The new page of the pair starts after the old one and the gap
+	if !strings.Contains(sb.String(), `<rect class="page" x="636" y="0" width="612" height="792"/>`) {
+		t.Error("new page misplaced")
+	}
+	if err := d.WriteSVG(&sb, 5); err == nil {
+		t.Error("WriteSVG of a missing pair succeeded")
+	}
+
+	sb.Reset()
+	if err := d.WriteHTML(&sb); err != nil {
+		t.Fatal(err)
+	}
+	out := sb.String()
+	for _, want := range []string{
+		"<p class=\"summary\">2 words inserted, 0 deleted, 2 moved and 0 restyled.</p>",
+		"<h2>Page 1 → page 1 (100% similar)</h2>",
+		"<h2>Page 2 inserted</h2>",
+		`<ins class="move" title="M1: moved from page 1">four &lt;five&gt;</ins> one two three`,
+		"<ins>new page</ins>",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("HTML report lacks %q", want)
+		}
+	}
+	if n := strings.Count(out, "<svg "); n != 2 {
+		t.Errorf("HTML report has %d drawings, want 2", n)
+	}
+}
@@ -0,0 +1,230 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// diffString summarises the changes of a diff, one per line, as the
+// operation, pages and text.
+func diffString(d *DocumentDiff) string {
+	var lines []string
+	for _, c := range d.Changes {
+		switch c.Op {
+		case DiffDelete:
+			lines = append(lines, fmt.Sprintf("%v %d: %s", c.Op, c.Old.Page, c.Old.Text()))
+		case DiffInsert, DiffEqual:
+			lines = append(lines, fmt.Sprintf("%v %d: %s", c.Op, c.New.Page, c.New.Text()))
+		default:
+			lines = append(lines, fmt.Sprintf("%v %d-%d: %s", c.Op, c.Old.Page, c.New.Page, c.New.Text()))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func diffTestDocuments(t *testing.T, old, new []string, opts DiffOptions) *DocumentDiff {
+	t.Helper()
+	d, err := DiffDocuments(openTextPDF(t, old...), openTextPDF(t, new...), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestDiffDocuments(t *testing.T) {
+	old := []string{
+		"BT /F1 10 Tf 72 700 Td (The parties agree to pay ten dollars) Tj ET",
+		"BT /F1 10 Tf 72 700 Td (Termination clause applies here) Tj ET",
+	}
+	new := []string{
+		"BT /F1 10 Tf 72 700 Td (The parties agree to pay twenty dollars) Tj ET",
+		"BT /F1 10 Tf 72 700 Td (Schedule A annex) Tj ET",
+		"BT /F1 10 Tf 72 700 Td (Termination ) Tj /F2 10 Tf (clause) Tj /F1 10 Tf ( applies here) Tj ET",
+	}
+	d := diffTestDocuments(t, old, new, DiffOptions{})
+
+	var pages []string
+	for _, p := range d.Pages {
+		pages = append(pages, fmt.Sprintf("%d-%d", p.Old, p.New))
+	}
+	if got := strings.Join(pages, " "); got != "1-1 0-2 2-3" {
+		t.Errorf("page alignment = %s, want 1-1 0-2 2-3", got)
+	}
+
+	want := strings.Join([]string{
+		"equal 1: The parties agree to pay",
+		"delete 1: ten",
+		"insert 1: twenty",
+		"equal 1: dollars",
+		"insert 2: Schedule A annex",
+		"equal 3: Termination",
+		"style 2-3: clause",
+		"equal 3: applies here",
+	}, "\n")
+	if got := diffString(d); got != want {
+		t.Errorf("changes:\n%s\nwant\n%s", got, want)
+	}
+
+	// The changed words have their boxes on both sides
+	for _, c := range d.Changes {
+		if c.Op == DiffStyle {
+			o, n := c.Old.Words[0], c.New.Words[0]
+			if o.Font != "Helvetica" || n.Font != "Helvetica-Bold" || !n.Bold {
+				t.Errorf("style change from %s to %s (bold %v)", o.Font, n.Font, n.Bold)
+			}
+			if o.Bounds != n.Bounds {
+				t.Errorf("restyled word moved from %v to %v", o.Bounds, n.Bounds)
+			}
+		}
+		if c.Op == DiffDelete && c.Old.Words[0].Bounds.Min.X != 72+5*float64(len("The parties agree to pay ")) {
+			t.Errorf("deleted word at %v", c.Old.Words[0].Bounds)
+		}
+	}
+
+	st := d.Stats()
+	if st[DiffInsert] != 4 || st[DiffDelete] != 1 || st[DiffStyle] != 1 || st[DiffEqual] != 9 {
+		t.Errorf("Stats = %v", st)
+	}
+
+	if got := diffString(diffTestDocuments(t, old, new, DiffOptions{IgnoreStyle: true})); strings.Contains(got, "style") {
+		t.Errorf("IgnoreStyle reported a style change:\n%s", got)
+	}
+}
+
+func TestDiffRemovedPageAndMoves(t *testing.T) {
+	old := []string{
+		"BT /F1 10 Tf 72 700 Td (alpha beta gamma delta epsilon zeta eta) Tj ET",
+		"BT /F1 10 Tf 72 700 Td (a page that goes away) Tj ET",
+		"BT /F1 10 Tf 72 700 Td (the last page) Tj ET",
+	}
+	new := []string{
+		"BT /F1 10 Tf 72 700 Td (delta epsilon zeta eta) Tj 0 -14 Td (alpha beta gamma) Tj ET",
+		"BT /F1 10 Tf 72 700 Td (the last page) Tj ET",
+	}
+	d := diffTestDocuments(t, old, new, DiffOptions{})
+	want := strings.Join([]string{
+		"equal 1: delta epsilon zeta eta",
+		"move 1-1: alpha beta gamma",
+		"delete 2: a page that goes away",
+		"equal 2: the last page",
+	}, "\n")
+	if got := diffString(d); got != want {
+		t.Errorf("changes:\n%s\nwant\n%s", got, want)
+	}
+	for _, c := range d.Changes {
+		if c.Op == DiffMove && (c.Old.Words[0].Bounds.Min.Y != 698 || c.New.Words[0].Bounds.Min.Y != 684) {
+			t.Errorf("move from %v to %v", c.Old.Words[0].Bounds, c.New.Words[0].Bounds)
+		}
+	}
+
+	// Runs shorter than MinMoveWords are left as deletions and insertions
+	d = diffTestDocuments(t, old, new, DiffOptions{MinMoveWords: 4})
+	if got := diffString(d); strings.Contains(got, "move") {
+		t.Errorf("move shorter than MinMoveWords:\n%s", got)
+	}
+}
+
+func TestFindMovesSplitsRuns(t *testing.T) {
+	words := func(s string) []Word {
+		var ws []Word
+		for _, f := range strings.Fields(s) {
+			ws = append(ws, Word{Text: f})
+		}
+		return ws
+	}
+	runs := []*diffRun{
+		{DiffChange: DiffChange{Op: DiffDelete, Old: DiffSide{Page: 1, Words: words("x one two three y")}}, pos: 0},
+		{DiffChange: DiffChange{Op: DiffEqual, New: DiffSide{Page: 1, Words: words("same")}}, pos: 5},
+		{DiffChange: DiffChange{Op: DiffInsert, New: DiffSide{Page: 1, Words: words("p one two three q")}}, pos: 6},
+	}
+	d := &DocumentDiff{}
+	for _, r := range findMoves(runs, 2) {
+		d.Changes = append(d.Changes, r.DiffChange)
+	}
+	want := "delete 1: x\ndelete 1: y\nequal 1: same\ninsert 1: p\nmove 1-1: one two three\ninsert 1: q"
+	if got := diffString(d); got != want {
+		t.Errorf("changes:\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestEditScript(t *testing.T) {
+	lcs := func(a, b []string) int {
+		m := make([][]int, len(a)+1)
+		for i := range m {
+			m[i] = make([]int, len(b)+1)
+		}
+		for i := len(a) - 1; i >= 0; i-- {
+			for j := len(b) - 1; j >= 0; j-- {
+				if a[i] == b[j] {
+					m[i][j] = m[i+1][j+1] + 1
+				} else {
+					m[i][j] = max(m[i+1][j], m[i][j+1])
+				}
+			}
+		}
+		return m[0][0]
+	}
+	rnd := rand.New(rand.NewSource(1))
+	seq := func() []string {
+		s := make([]string, rnd.Intn(12))
+		for i := range s {
+			s[i] = string(rune('a' + rnd.Intn(4)))
+		}
+		return s
+	}
+	for range 500 {
+		a, b := seq(), seq()
+		var gotA, gotB []string
+		equal := 0
+		for _, s := range editScript(a, b) {
+			switch s.op {
+			case DiffEqual:
+				if a[s.i] != b[s.j] {
+					t.Fatalf("editScript(%v, %v) keeps %q as %q", a, b, a[s.i], b[s.j])
+				}
+				gotA, gotB = append(gotA, a[s.i]), append(gotB, b[s.j])
+				equal++
+			case DiffDelete:
+				gotA = append(gotA, a[s.i])
+			case DiffInsert:
+				gotB = append(gotB, b[s.j])
+			}
+		}
+		if fmt.Sprint(gotA) != fmt.Sprint(a) || fmt.Sprint(gotB) != fmt.Sprint(b) {
+			t.Fatalf("editScript(%v, %v) gives %v, %v", a, b, gotA, gotB)
+		}
+		if want := lcs(a, b); equal != want {
+			t.Fatalf("editScript(%v, %v) keeps %d, want %d", a, b, equal, want)
+		}
+	}
+}
+
+func TestEditScriptMemory(t *testing.T) {
+	// Long documents with a few scattered edits must not need memory in
+	// proportion to their length times the edit distance.
+	a := make([]string, 20000)
+	for i := range a {
+		a[i] = fmt.Sprint(i)
+	}
+	b := append([]string(nil), a...)
+	for i := 0; i < len(b); i += 400 {
+		b[i] = "changed"
+	}
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	steps := editScript(a, b)
+	runtime.ReadMemStats(&after)
+	if len(steps) != len(a)+len(a)/400 {
+		t.Errorf("editScript gives %d steps, want %d", len(steps), len(a)+len(a)/400)
+	}
+	if n := after.TotalAlloc - before.TotalAlloc; n > 16<<20 {
+		t.Errorf("editScript allocated %d bytes", n)
+	}
+}
@@ -377,6 +377,11 @@ func (pe *ParallelExtractor) ExtractAllPages(
 	ctx context.Context,
 	pages []Page,
 ) ([][]Text, error) {
+	for _, page := range pages {
+		if err := page.V.r.checkExtract(); err != nil {
+			return nil, err
+		}
+	}
 	return pe.processor.ProcessPagesEnhanced(ctx, pages, func(page Page) ([]Text, error) {
 		// Use cache to accelerate content extraction
 		content := page.Content()
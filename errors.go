@@ -57,6 +57,28 @@ var (
 
 	// ErrNoContent indicates the page has no content
 	ErrNoContent = errors.New("page has no content")
+
+	// ErrWriterClosed indicates a page was written to an exporter after Close
+	ErrWriterClosed = errors.New("writer is closed")
+
+	// ErrJSONVersion indicates a JSON export has a missing or unsupported schema version
+	ErrJSONVersion = errors.New("unsupported JSON schema version")
+
+	// ErrNoRevisions indicates the cross-reference sections of a file could not be
+	// read as a chain, as when a damaged file's cross-reference table was rebuilt
+	ErrNoRevisions = errors.New("revision history not available")
+
+	// ErrNotRecipient indicates a certificate is not among the recipients a
+	// PDF encrypted with the public-key security handler was encrypted to
+	ErrNotRecipient = errors.New("certificate is not a recipient of the encrypted PDF")
+
+	// ErrNotPermitted indicates the permissions of an encrypted PDF do not allow
+	// an operation; see PermissionError
+	ErrNotPermitted = errors.New("operation not permitted by document")
+
+	// ErrStrict indicates a malformed PDF was rejected because
+	// ReaderOptions.Strict lists one of its warnings; see StrictError
+	ErrStrict = errors.New("malformed file rejected in strict mode")
 )
 
 // wrapError wraps an error with operation context
@@ -13,8 +13,9 @@ import (
 
 // ExtractOptions configures text extraction behavior
 type ExtractOptions struct {
-	Workers   int   // Number of concurrent workers (0 = use NumCPU)
-	PageRange []int // Specific pages to extract (nil = all pages)
+	Workers   int           // Number of concurrent workers (0 = use NumCPU)
+	PageRange []int         // Specific pages to extract (nil = all pages)
+	Dedup     *DedupOptions // Collapse fake-bold and shadow copies of text (nil = keep all)
 }
 
 // ExtractWithContext extracts plain text from all pages with cancellation support
@@ -74,7 +75,7 @@ func (r *Reader) ExtractWithContext(ctx context.Context, opts ExtractOptions) (i
 
 			pageNum := pageList[idx]
 			page := r.Page(pageNum)
-			text, err := page.GetPlainText(context.Background(), nil)
+			text, err := page.plainText(context.Background(), nil, textRunsToPlain, opts.Dedup)
 			if err != nil {
 				select {
 				case errCh <- wrapPageError("extract text", pageNum, err):
@@ -8,6 +8,7 @@ import (
 	"context"
 	"io"
 	"runtime"
+	"strings"
 )
 
 // ExtractMode specifies the type of extraction to perform
@@ -17,11 +18,12 @@ const (
 	ModePlain      ExtractMode = iota // Plain text extraction
 	ModeStyled                        // Text with style information
 	ModeStructured                    // Structured text with classification
+	ModeLayout                        // Plain text with the page layout preserved
 )
 
 // ExtractResult contains the results of text extraction
 type ExtractResult struct {
-	Text             string            // Plain text (for ModePlain)
+	Text             string            // Plain text (for ModePlain and ModeLayout)
 	StyledTexts      []Text            // Styled texts (for ModeStyled)
 	ClassifiedBlocks []ClassifiedBlock // Classified blocks (for ModeStructured)
 	Metadata         Metadata          // Document metadata
@@ -35,6 +37,8 @@ type Extractor struct {
 	workers       int
 	pageRange     []int
 	smartOrdering bool
+	dedup         *DedupOptions
+	layout        LayoutTextOptions
 	ctx           context.Context
 }
 
@@ -76,6 +80,19 @@ func (e *Extractor) SmartOrdering(enabled bool) *Extractor {
 	return e
 }
 
+// Dedup collapses text drawn several times on top of itself, such as fake
+// bold type and shadows, into a single run
+func (e *Extractor) Dedup(opts DedupOptions) *Extractor {
+	e.dedup = &opts
+	return e
+}
+
+// LayoutOptions configures the text of ModeLayout
+func (e *Extractor) LayoutOptions(opts LayoutTextOptions) *Extractor {
+	e.layout = opts
+	return e
+}
+
 // Context sets the context for cancellation
 func (e *Extractor) Context(ctx context.Context) *Extractor {
 	e.ctx = ctx
@@ -122,6 +139,13 @@ func (e *Extractor) Extract() (*ExtractResult, error) {
 			return nil, err
 		}
 		result.ClassifiedBlocks = blocks
+
+	case ModeLayout:
+		text, err := e.extractLayoutText(pages)
+		if err != nil {
+			return nil, err
+		}
+		result.Text = text
 	}
 
 	return result, nil
@@ -137,6 +161,17 @@ func (e *Extractor) ExtractText() (string, error) {
 	return result.Text, nil
 }
 
+// ExtractLayoutText is a convenience method for extracting text with the
+// page layout preserved
+func (e *Extractor) ExtractLayoutText() (string, error) {
+	e.mode = ModeLayout
+	result, err := e.Extract()
+	if err != nil {
+		return "", err
+	}
+	return result.Text, nil
+}
+
 // ExtractStyledTexts is a convenience method for extracting styled texts
 func (e *Extractor) ExtractStyledTexts() ([]Text, error) {
 	e.mode = ModeStyled
@@ -182,6 +217,7 @@ func (e *Extractor) extractPlainText(pages []int) (string, error) {
 	opts := ExtractOptions{
 		Workers:   e.workers,
 		PageRange: pages,
+		Dedup:     e.dedup,
 	}
 
 	reader, err := e.reader.ExtractWithContext(e.ctx, opts)
@@ -216,9 +252,9 @@ func (e *Extractor) extractPlainTextSequential(pages []int) (string, error) {
 		var err error
 
 		if e.smartOrdering {
-			text, err = page.GetPlainTextWithSmartOrdering(context.Background(), nil)
+			text, err = page.plainText(context.Background(), nil, SmartTextRunsToPlain, e.dedup)
 		} else {
-			text, err = page.GetPlainText(context.Background(), nil)
+			text, err = page.plainText(context.Background(), nil, textRunsToPlain, e.dedup)
 		}
 
 		if err != nil {
@@ -242,8 +278,41 @@ func (e *Extractor) extractPlainTextSequential(pages []int) (string, error) {
 	return builder.String(), nil
 }
 
+// extractLayoutText renders the specified pages with their layout
+// preserved, separated by the page separator
+func (e *Extractor) extractLayoutText(pages []int) (string, error) {
+	var sb strings.Builder
+	for i, pageNum := range pages {
+		select {
+		case <-e.ctx.Done():
+			return sb.String(), e.ctx.Err()
+		default:
+		}
+
+		page := e.reader.Page(pageNum)
+		text, err := page.layoutText(e.layout, e.dedup)
+		page.Cleanup()
+		if err != nil {
+			return "", &PDFError{
+				Op:   "extract layout text",
+				Page: pageNum,
+				Err:  err,
+			}
+		}
+
+		if i > 0 {
+			sb.WriteString(e.layout.separator())
+		}
+		sb.WriteString(text)
+	}
+	return sb.String(), nil
+}
+
 // extractStyledTexts extracts styled texts from specified pages
 func (e *Extractor) extractStyledTexts(pages []int) ([]Text, error) {
+	if err := e.reader.checkExtract(); err != nil {
+		return nil, err
+	}
 	var allTexts []Text
 
 	for _, pageNum := range pages {
@@ -254,9 +323,12 @@ func (e *Extractor) extractStyledTexts(pages []int) ([]Text, error) {
 		}
 
 		page := e.reader.Page(pageNum)
-		content := page.Content()
+		texts := page.Content().Text
+		if e.dedup != nil {
+			texts = DeduplicateTexts(texts, *e.dedup)
+		}
 
-		allTexts = append(allTexts, content.Text...)
+		allTexts = append(allTexts, texts...)
 
 		// CRITICAL FIX: Cleanup page resources
 		page.Cleanup()
@@ -277,7 +349,7 @@ func (e *Extractor) extractStructuredText(pages []int) ([]ClassifiedBlock, error
 		}
 
 		page := e.reader.Page(pageNum)
-		blocks, err := page.ClassifyTextBlocks()
+		blocks, err := page.classifyTextBlocks(e.dedup)
 		if err != nil {
 			return nil, &PDFError{
 				Op:   "classify text blocks",
@@ -286,6 +358,9 @@ func (e *Extractor) extractStructuredText(pages []int) ([]ClassifiedBlock, error
 			}
 		}
 
+		for i := range blocks {
+			blocks[i].Page = pageNum
+		}
 		allBlocks = append(allBlocks, blocks...)
 
 		// CRITICAL FIX: Cleanup page resources
@@ -294,3 +369,29 @@ func (e *Extractor) extractStructuredText(pages []int) ([]ClassifiedBlock, error
 
 	return allBlocks, nil
 }
+
+// pageWriter is implemented by the exporters that write a document one
+// page at a time.
+type pageWriter interface {
+	WritePage(num int, p Page) error
+	Close() error
+}
+
+// writePages writes the selected pages to pw and ends the document.
+func (e *Extractor) writePages(pw pageWriter) error {
+	for _, pageNum := range e.getPageNumbers() {
+		select {
+		case <-e.ctx.Done():
+			return e.ctx.Err()
+		default:
+		}
+
+		page := e.reader.Page(pageNum)
+		err := pw.WritePage(pageNum, page)
+		page.Cleanup()
+		if err != nil {
+			return err
+		}
+	}
+	return pw.Close()
+}
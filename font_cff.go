@@ -7,6 +7,7 @@ package pdf
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 )
@@ -43,8 +44,12 @@ type CFFFont struct {
 	PrivateDict *CFFDict
 	LocalSubrs  *CFFIndex
 	FDArray     []*CFFDict // For CID-keyed fonts
-	FDSelect    []byte     // For CID-keyed fonts
+	FDSelect    []byte     // For CID-keyed fonts, the Font DICT of each glyph
 	isCID       bool
+
+	charset  []int        // SID, or CID for CID-keyed fonts, of each glyph
+	glyphs   map[int]int  // glyph of each SID or CID in the charset
+	encoding map[byte]int // glyph of each code of the built-in encoding
 }
 
 // NewCFFFont parses CFF font data with caching
@@ -115,17 +120,40 @@ func (f *CFFFont) parse(data []byte) error {
 	}
 	f.GlobalSubrs = globalSubrs
 
+	if f.TopDict == nil {
+		f.TopDict = &CFFDict{Data: make(map[int]interface{})}
+	}
+
 	// Check if this is a CID-keyed font
-	if ros, ok := f.TopDict.Data[12]; ok { // ROS operator
+	if ros, ok := f.TopDict.Data[0x0C1E]; ok { // ROS operator
 		if arr, ok := ros.([]interface{}); ok && len(arr) >= 3 {
 			f.isCID = true
 		}
 	}
 
+	// Parse CharStrings INDEX, at the offset the Top DICT gives
+	if offset, ok := f.TopDict.Data[17].(int); ok {
+		if _, err := r.Seek(int64(offset), io.SeekStart); err != nil {
+			return err
+		}
+	}
+	charStrings, err := f.parseIndex(r)
+	if err != nil {
+		return fmt.Errorf("failed to parse CharStrings INDEX: %w", err)
+	}
+	f.CharStrings = charStrings
+
+	if err := f.parseCharset(data); err != nil {
+		return fmt.Errorf("failed to parse charset: %w", err)
+	}
+
 	if f.isCID {
 		return f.parseCIDFont(r)
 	}
 
+	if err := f.parseEncoding(data); err != nil {
+		return fmt.Errorf("failed to parse Encoding: %w", err)
+	}
 	return f.parseSimpleFont(r)
 }
 
@@ -163,10 +191,19 @@ func (f *CFFFont) parseIndex(r io.Reader) (*CFFIndex, error) {
 		offsets[i] = offset
 	}
 
+	for i := 0; i < int(count); i++ {
+		if offsets[i+1] < offsets[i] {
+			return nil, fmt.Errorf("INDEX offset %d decreases", i+1)
+		}
+	}
+
 	// Read data
 	dataSize := offsets[count] - offsets[0]
+	if br, ok := r.(interface{ Len() int }); ok && int64(dataSize) > int64(br.Len()) {
+		return nil, io.ErrUnexpectedEOF
+	}
 	data := make([]byte, dataSize)
-	if _, err := r.Read(data); err != nil {
+	if _, err := io.ReadFull(r, data); err != nil {
 		return nil, err
 	}
 
@@ -397,35 +434,31 @@ func (f *CFFFont) executeOperator(dict *CFFDict, op int, operands []interface{})
 }
 
 func (f *CFFFont) parseSimpleFont(r *bytes.Reader) error {
-	// Parse CharStrings INDEX
-	charStrings, err := f.parseIndex(r)
-	if err != nil {
-		return fmt.Errorf("failed to parse CharStrings INDEX: %w", err)
-	}
-	f.CharStrings = charStrings
-
-	// Parse Private DICT if present
+	// Parse Private DICT if present; its offset is from the start of the
+	// font and that of its Subrs from the start of the DICT
 	if private, ok := f.TopDict.Data[18]; ok {
 		if arr, ok := private.([]interface{}); ok && len(arr) >= 2 {
-			size := int(arr[0].(int))
-			offset := int(arr[1].(int))
-			// Seek to private dict
-			currentPos, _ := r.Seek(0, io.SeekCurrent)
-			r.Seek(currentPos-int64(offset), io.SeekStart)
-
+			size, _ := arr[0].(int)
+			offset, _ := arr[1].(int)
+			if size < 0 || offset < 0 || int64(offset)+int64(size) > r.Size() {
+				return fmt.Errorf("Private DICT [%d %d] outside the font", size, offset)
+			}
 			privateData := make([]byte, size)
-			if _, err := r.Read(privateData); err != nil {
+			if _, err := r.ReadAt(privateData, int64(offset)); err != nil && size > 0 {
 				return err
 			}
 
+			var err error
 			f.PrivateDict, err = f.parseDict(privateData)
 			if err != nil {
 				return fmt.Errorf("failed to parse Private DICT: %w", err)
 			}
 
 			// Parse Local Subrs if present
-			if _, ok := f.PrivateDict.Data[19]; ok {
-				r.Seek(currentPos-int64(offset), io.SeekStart)
+			if subrs, ok := f.PrivateDict.Data[19].(int); ok {
+				if _, err := r.Seek(int64(offset)+int64(subrs), io.SeekStart); err != nil {
+					return err
+				}
 				localSubrs, err := f.parseIndex(r)
 				if err != nil {
 					return fmt.Errorf("failed to parse Local Subrs: %w", err)
@@ -439,17 +472,11 @@ func (f *CFFFont) parseSimpleFont(r *bytes.Reader) error {
 }
 
 func (f *CFFFont) parseCIDFont(r *bytes.Reader) error {
-	// Parse CharStrings INDEX
-	charStrings, err := f.parseIndex(r)
-	if err != nil {
-		return fmt.Errorf("failed to parse CharStrings INDEX: %w", err)
-	}
-	f.CharStrings = charStrings
-
 	// Parse FDArray
-	if fdArrayOffset, ok := f.TopDict.Data[12|36]; ok {
-		offset := int(fdArrayOffset.(int))
-		r.Seek(int64(offset), io.SeekStart)
+	if offset, ok := f.TopDict.Data[0x0C24].(int); ok {
+		if _, err := r.Seek(int64(offset), io.SeekStart); err != nil {
+			return err
+		}
 
 		fdArrayIndex, err := f.parseIndex(r)
 		if err != nil {
@@ -466,25 +493,176 @@ func (f *CFFFont) parseCIDFont(r *bytes.Reader) error {
 	}
 
 	// Parse FDSelect
-	if fdSelectOffset, ok := f.TopDict.Data[12|37]; ok {
-		offset := int(fdSelectOffset.(int))
-		r.Seek(int64(offset), io.SeekStart)
-
-		// Read FDSelect format and data
-		var format uint8
-		if err := binary.Read(r, binary.BigEndian, &format); err != nil {
+	if offset, ok := f.TopDict.Data[0x0C25].(int); ok {
+		if _, err := r.Seek(int64(offset), io.SeekStart); err != nil {
 			return err
 		}
+		var err error
+		f.FDSelect, err = parseFDSelect(r, f.NumGlyphs())
+		if err != nil {
+			return fmt.Errorf("failed to parse FDSelect: %w", err)
+		}
+	}
 
-		// For simplicity, read the entire FDSelect data
-		// In practice, this needs proper parsing based on format
-		remaining := f.CharStrings.Count
-		f.FDSelect = make([]byte, remaining)
-		if _, err := r.Read(f.FDSelect); err != nil {
-			return err
+	return nil
+}
+
+// parseFDSelect reads an FDSelect in format 0 or 3 and returns the Font
+// DICT of each of the n glyphs.
+func parseFDSelect(r *bytes.Reader, n int) ([]byte, error) {
+	format, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	fds := make([]byte, n)
+	switch format {
+	case 0:
+		if _, err := io.ReadFull(r, fds); err != nil {
+			return nil, err
+		}
+	case 3:
+		var nRanges, first uint16
+		if err := binary.Read(r, binary.BigEndian, &nRanges); err != nil {
+			return nil, err
+		}
+		if err := binary.Read(r, binary.BigEndian, &first); err != nil {
+			return nil, err
+		}
+		for range nRanges {
+			fd, err := r.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+			var next uint16
+			if err := binary.Read(r, binary.BigEndian, &next); err != nil {
+				return nil, err
+			}
+			for gid := int(first); gid < int(next) && gid < n; gid++ {
+				fds[gid] = fd
+			}
+			first = next
+		}
+	default:
+		return nil, fmt.Errorf("unknown format %d", format)
+	}
+	return fds, nil
+}
+
+// parseCharset reads the charset, which names the glyphs of a simple font
+// by SID and gives the CID of each glyph of a CID-keyed font. The expert
+// charsets are left unread.
+func (f *CFFFont) parseCharset(data []byte) error {
+	n := f.NumGlyphs()
+	offset, _ := f.TopDict.Data[15].(int)
+	switch {
+	case offset == 0 && !f.isCID: // ISOAdobe
+		f.charset = make([]int, n)
+		for gid := range f.charset {
+			f.charset[gid] = gid
+		}
+	case offset <= 2 && !f.isCID:
+		return nil
+	case offset <= 2:
+		return errors.New("CID-keyed font without a charset")
+	default:
+		if offset >= len(data) {
+			return fmt.Errorf("offset %d outside the font", offset)
+		}
+		p := data[offset:]
+		format := p[0]
+		p = p[1:]
+		f.charset = make([]int, 1, max(n, 1)) // glyph 0 is .notdef, or CID 0
+		for len(f.charset) < n {
+			switch format {
+			case 0:
+				if len(p) < 2 {
+					return io.ErrUnexpectedEOF
+				}
+				f.charset = append(f.charset, int(binary.BigEndian.Uint16(p)))
+				p = p[2:]
+			case 1, 2:
+				size := 3 + int(format-1)
+				if len(p) < size {
+					return io.ErrUnexpectedEOF
+				}
+				first := int(binary.BigEndian.Uint16(p))
+				left := int(p[2])
+				if format == 2 {
+					left = int(binary.BigEndian.Uint16(p[2:]))
+				}
+				p = p[size:]
+				for id := first; id <= first+left && len(f.charset) < n; id++ {
+					f.charset = append(f.charset, id)
+				}
+			default:
+				return fmt.Errorf("unknown format %d", format)
+			}
+		}
+		f.charset = f.charset[:min(n, len(f.charset))]
+	}
+	f.glyphs = make(map[int]int, len(f.charset))
+	for gid, id := range f.charset {
+		if _, ok := f.glyphs[id]; !ok {
+			f.glyphs[id] = gid
 		}
 	}
+	return nil
+}
 
+// parseEncoding reads the built-in encoding of a simple font. The standard
+// and expert encodings are predefined and left unread.
+func (f *CFFFont) parseEncoding(data []byte) error {
+	offset, _ := f.TopDict.Data[16].(int)
+	if offset <= 1 {
+		return nil
+	}
+	if offset >= len(data) {
+		return fmt.Errorf("offset %d outside the font", offset)
+	}
+	p := data[offset:]
+	format := p[0]
+	p = p[1:]
+	if len(p) < 1 {
+		return io.ErrUnexpectedEOF
+	}
+	f.encoding = make(map[byte]int)
+	n := int(p[0])
+	p = p[1:]
+	switch format & 0x7F {
+	case 0:
+		if len(p) < n {
+			return io.ErrUnexpectedEOF
+		}
+		for i, code := range p[:n] {
+			f.encoding[code] = i + 1
+		}
+		p = p[n:]
+	case 1:
+		if len(p) < 2*n {
+			return io.ErrUnexpectedEOF
+		}
+		gid := 1
+		for i := range n {
+			first, left := int(p[2*i]), int(p[2*i+1])
+			for code := first; code <= first+left && code < 256; code++ {
+				f.encoding[byte(code)] = gid
+				gid++
+			}
+		}
+		p = p[2*n:]
+	default:
+		return fmt.Errorf("unknown format %d", format)
+	}
+	if format&0x80 != 0 && len(p) > 0 {
+		// Supplements encode further codes by SID
+		n, p := int(p[0]), p[1:]
+		for i := 0; i < n && len(p) >= 3*(i+1); i++ {
+			code := p[3*i]
+			if gid, ok := f.glyphs[int(binary.BigEndian.Uint16(p[3*i+1:]))]; ok {
+				f.encoding[code] = gid
+			}
+		}
+	}
 	return nil
 }
 
@@ -521,6 +699,84 @@ func (f *CFFFont) IsCID() bool {
 	return f.isCID
 }
 
+// NumGlyphs returns the number of glyphs in the font
+func (f *CFFFont) NumGlyphs() int {
+	if f.CharStrings == nil {
+		return 0
+	}
+	return len(f.CharStrings.Data)
+}
+
+// GlyphName returns the name of glyph gid of a simple font, or "" if the
+// font is CID-keyed or its charset is unknown.
+func (f *CFFFont) GlyphName(gid int) string {
+	if f.isCID || gid < 0 || gid >= len(f.charset) {
+		return ""
+	}
+	return f.str(f.charset[gid])
+}
+
+// GlyphIndex returns the glyph of a simple font with the given name.
+func (f *CFFFont) GlyphIndex(name string) (int, bool) {
+	if f.isCID || f.glyphs == nil {
+		return 0, false
+	}
+	sid, ok := cffStandardSIDs[name]
+	if !ok && f.StringIndex != nil {
+		for i, s := range f.StringIndex.Data {
+			if string(s) == name {
+				sid, ok = len(cffStandardStrings)+i, true
+				break
+			}
+		}
+	}
+	if !ok {
+		return 0, false
+	}
+	gid, ok := f.glyphs[sid]
+	return gid, ok
+}
+
+// CIDGlyph returns the glyph of a CID in a CID-keyed font.
+func (f *CFFFont) CIDGlyph(cid int) (int, bool) {
+	if !f.isCID {
+		return 0, false
+	}
+	gid, ok := f.glyphs[cid]
+	return gid, ok
+}
+
+// CodeGlyph returns the glyph the built-in encoding of a simple font gives
+// code.
+func (f *CFFFont) CodeGlyph(code byte) (int, bool) {
+	if f.isCID {
+		return 0, false
+	}
+	if f.encoding != nil {
+		gid, ok := f.encoding[code]
+		return gid, ok
+	}
+	if offset, _ := f.TopDict.Data[16].(int); offset != 0 {
+		return 0, false // expert encoding
+	}
+	name, ok := standardEncoding[int(code)]
+	if !ok {
+		return 0, false
+	}
+	return f.GlyphIndex(name)
+}
+
+// str returns the string with the given SID.
+func (f *CFFFont) str(sid int) string {
+	if sid < len(cffStandardStrings) {
+		return cffStandardStrings[sid]
+	}
+	if i := sid - len(cffStandardStrings); f.StringIndex != nil && i < len(f.StringIndex.Data) {
+		return string(f.StringIndex.Data[i])
+	}
+	return ""
+}
+
 // CFFCharStringDecoder decodes CFF CharString data
 type CFFCharStringDecoder struct {
 	data     []byte
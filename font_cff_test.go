@@ -5,6 +5,7 @@
 package pdf
 
 import (
+	"encoding/binary"
 	"io"
 	"testing"
 )
@@ -171,3 +172,151 @@ func (r *bytesReader) Seek(offset int64, whence int) (int64, error) {
 	}
 	return int64(r.pos), nil
 }
+
+// cffIndex encodes items as a CFF INDEX with 4-byte offsets.
+func cffIndex(items ...[]byte) []byte {
+	if len(items) == 0 {
+		return []byte{0, 0}
+	}
+	out := binary.BigEndian.AppendUint16(nil, uint16(len(items)))
+	out = append(out, 4)
+	off := uint32(1)
+	out = binary.BigEndian.AppendUint32(out, off)
+	for _, it := range items {
+		off += uint32(len(it))
+		out = binary.BigEndian.AppendUint32(out, off)
+	}
+	for _, it := range items {
+		out = append(out, it...)
+	}
+	return out
+}
+
+// cffInt encodes n as a 5-byte DICT operand, so that offsets can be
+// written before they are known.
+func cffInt(n int) []byte {
+	return binary.BigEndian.AppendUint32([]byte{29}, uint32(n))
+}
+
+// buildTestCFF returns a CFF font program with n glyphs, the given charset
+// and strings, and a built-in encoding if encoding is not nil. If cid is
+// set the font is CID-keyed, with one Font DICT.
+func buildTestCFF(n int, strs []string, charset, encoding []byte, cid bool) []byte {
+	var stringItems [][]byte
+	for _, s := range strs {
+		stringItems = append(stringItems, []byte(s))
+	}
+	glyphs := make([][]byte, n)
+	for i := range glyphs {
+		glyphs[i] = []byte{14} // endchar
+	}
+	topDict := func(charsetOff, encodingOff, charStringsOff, fdArrayOff, fdSelectOff int) []byte {
+		var d []byte
+		if cid {
+			d = append(d, 139, 140, 139, 12, 30) // ROS
+		}
+		d = append(append(d, cffInt(charsetOff)...), 15)
+		if encoding != nil {
+			d = append(append(d, cffInt(encodingOff)...), 16)
+		}
+		d = append(append(d, cffInt(charStringsOff)...), 17)
+		if cid {
+			d = append(append(d, cffInt(fdArrayOff)...), 12, 36)
+			d = append(append(d, cffInt(fdSelectOff)...), 12, 37)
+		}
+		return d
+	}
+	head := append([]byte{1, 0, 4, 4}, cffIndex([]byte("Test"))...)
+	start := len(head) + len(cffIndex(topDict(0, 0, 0, 0, 0))) + len(cffIndex(stringItems...)) + len(cffIndex())
+	charsetOff := start
+	encodingOff := charsetOff + len(charset)
+	charStringsOff := encodingOff + len(encoding)
+	fdArrayOff := charStringsOff + len(cffIndex(glyphs...))
+	fdSelectOff := fdArrayOff + len(cffIndex([]byte{}))
+
+	out := append(head, cffIndex(topDict(charsetOff, encodingOff, charStringsOff, fdArrayOff, fdSelectOff))...)
+	out = append(out, cffIndex(stringItems...)...)
+	out = append(out, cffIndex()...)
+	out = append(out, charset...)
+	out = append(out, encoding...)
+	out = append(out, cffIndex(glyphs...)...)
+	if cid {
+		out = append(out, cffIndex([]byte{})...)
+		out = append(out, 3, 0, 1, 0, 0, 0, byte(n>>8), byte(n)) // FDSelect: all glyphs use Font DICT 0
+	}
+	return out
+}
+
+func TestCFFCharset(t *testing.T) {
+	// Glyphs .notdef, A, B and a custom glyph; a code is given to B and
+	// another by a supplement to the custom glyph
+	data := buildTestCFF(4, []string{"custom"}, []byte{0, 0, 34, 0, 35, 1, 135},
+		[]byte{0x80, 2, 'X', 'Y', 1, 'Z', 1, 135}, false)
+	cff, err := NewCFFFont(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cff.IsCID() || cff.NumGlyphs() != 4 || cff.GetFontName() != "Test" {
+		t.Fatalf("IsCID, NumGlyphs, GetFontName = %v, %d, %q", cff.IsCID(), cff.NumGlyphs(), cff.GetFontName())
+	}
+	for gid, want := range []string{".notdef", "A", "B", "custom"} {
+		if got := cff.GlyphName(gid); got != want {
+			t.Errorf("GlyphName(%d) = %q, want %q", gid, got, want)
+		}
+		if got, ok := cff.GlyphIndex(want); !ok || got != gid {
+			t.Errorf("GlyphIndex(%q) = %d, %v, want %d", want, got, ok, gid)
+		}
+	}
+	if _, ok := cff.GlyphIndex("C"); ok {
+		t.Error("GlyphIndex(C) found a glyph")
+	}
+	for code, want := range map[byte]int{'X': 1, 'Y': 2, 'Z': 3} {
+		if got, ok := cff.CodeGlyph(code); !ok || got != want {
+			t.Errorf("CodeGlyph(%q) = %d, %v, want %d", code, got, ok, want)
+		}
+	}
+	if _, ok := cff.CodeGlyph('A'); ok {
+		t.Error("CodeGlyph(A) found a glyph outside the built-in encoding")
+	}
+
+	// The standard encoding and a range charset
+	cff, err = NewCFFFont(buildTestCFF(3, nil, []byte{1, 0, 34, 1}, nil, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := cff.CodeGlyph('B'); !ok || got != 2 {
+		t.Errorf("CodeGlyph(B) with the standard encoding = %d, %v, want 2", got, ok)
+	}
+	if _, ok := cff.CodeGlyph('C'); ok {
+		t.Error("CodeGlyph(C) found a glyph")
+	}
+}
+
+func TestCFFCIDCharset(t *testing.T) {
+	// CIDs 0, 100-102 and 843
+	data := buildTestCFF(5, nil, []byte{2, 0, 100, 0, 2, 3, 75, 0, 0}, nil, true)
+	cff, err := NewCFFFont(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cff.IsCID() || len(cff.FDArray) != 1 || len(cff.FDSelect) != 5 {
+		t.Fatalf("IsCID, FDArray, FDSelect = %v, %d, %d", cff.IsCID(), len(cff.FDArray), len(cff.FDSelect))
+	}
+	for cid, want := range map[int]int{0: 0, 100: 1, 102: 3, 843: 4} {
+		if got, ok := cff.CIDGlyph(cid); !ok || got != want {
+			t.Errorf("CIDGlyph(%d) = %d, %v, want %d", cid, got, ok, want)
+		}
+	}
+	for _, cid := range []int{1, 103, 844} {
+		if gid, ok := cff.CIDGlyph(cid); ok {
+			t.Errorf("CIDGlyph(%d) = %d", cid, gid)
+		}
+	}
+	if _, ok := cff.GlyphIndex("A"); ok {
+		t.Error("GlyphIndex found a name in a CID-keyed font")
+	}
+
+	if _, err := NewCFFFont(buildTestCFF(3, nil, []byte{5}, nil, true)); err == nil {
+		t.Error("NewCFFFont accepted a charset of unknown format")
+	}
+}
@@ -0,0 +1,670 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// TrueTypeMetrics contains font-wide metrics read from the head, hhea and OS/2 tables.
+// All values are expressed in font design units (see UnitsPerEm).
+type TrueTypeMetrics struct {
+	UnitsPerEm  uint16
+	BBox        [4]int16 // xMin, yMin, xMax, yMax
+	Ascender    int16
+	Descender   int16
+	LineGap     int16
+	CapHeight   int16
+	XHeight     int16
+	WeightClass uint16
+	ItalicAngle float64
+	Bold        bool
+	Italic      bool
+}
+
+// TrueTypeFont represents a parsed TrueType or OpenType font program
+// (FontFile2, or FontFile3 with Subtype OpenType).
+type TrueTypeFont struct {
+	Metrics   TrueTypeMetrics
+	NumGlyphs int
+	IsCFF     bool // OpenType font with CFF outlines ('OTTO')
+
+	tables map[string][]byte
+
+	unicodeCmap map[rune]uint16   // (3,1), (3,10) or (0,x) subtables
+	symbolCmap  map[uint16]uint16 // (3,0) subtable
+	macCmap     map[uint16]uint16 // (1,0) subtable
+
+	advances   []uint16
+	glyphNames []string
+
+	reverseOnce sync.Once
+	reverse     map[uint16]rune
+}
+
+// ErrNotTrueType indicates the data is not a TrueType or OpenType font program
+var ErrNotTrueType = errors.New("not a TrueType/OpenType font")
+
+// NewTrueTypeFont parses TrueType/OpenType font data with caching
+func NewTrueTypeFont(data []byte) (*TrueTypeFont, error) {
+	// Try to get from cache first
+	cache := GetGlobalTrueTypeCache()
+	if cachedFont, found := cache.GetFont(data); found {
+		return cachedFont, nil
+	}
+
+	// Parse the font
+	font := &TrueTypeFont{
+		tables: make(map[string][]byte),
+	}
+	if err := font.parse(data); err != nil {
+		return nil, err
+	}
+
+	// Cache the parsed font
+	cache.PutFont(data, font)
+
+	return font, nil
+}
+
+func (f *TrueTypeFont) parse(data []byte) error {
+	if len(data) < 12 {
+		return ErrNotTrueType
+	}
+
+	switch tag := string(data[0:4]); tag {
+	case "\x00\x01\x00\x00", "true":
+	case "OTTO":
+		f.IsCFF = true
+	case "ttcf":
+		// TrueType collection: use the first font in the collection
+		if len(data) < 16 {
+			return ErrNotTrueType
+		}
+		off := int(binary.BigEndian.Uint32(data[12:16]))
+		if off <= 0 || off+12 > len(data) {
+			return fmt.Errorf("invalid TrueType collection offset %d", off)
+		}
+		return f.parseDirectory(data, off)
+	default:
+		return ErrNotTrueType
+	}
+	return f.parseDirectory(data, 0)
+}
+
+func (f *TrueTypeFont) parseDirectory(data []byte, start int) error {
+	if start+12 > len(data) {
+		return ErrNotTrueType
+	}
+	if string(data[start:start+4]) == "OTTO" {
+		f.IsCFF = true
+	}
+	numTables := int(binary.BigEndian.Uint16(data[start+4:]))
+	if start+12+numTables*16 > len(data) {
+		return fmt.Errorf("truncated table directory (%d tables)", numTables)
+	}
+	for i := 0; i < numTables; i++ {
+		rec := data[start+12+i*16:]
+		tag := string(rec[0:4])
+		off := binary.BigEndian.Uint32(rec[8:12])
+		length := binary.BigEndian.Uint32(rec[12:16])
+		end := uint64(off) + uint64(length)
+		if end > uint64(len(data)) {
+			// Subset fonts sometimes carry a truncated last table; keep what is there
+			if uint64(off) >= uint64(len(data)) {
+				continue
+			}
+			end = uint64(len(data))
+		}
+		f.tables[tag] = data[off:end]
+	}
+
+	if err := f.parseHead(); err != nil {
+		return err
+	}
+	f.parseMaxp()
+	f.parseHmtx()
+	f.parseOS2()
+	f.parsePost()
+	f.parseCmap()
+	return nil
+}
+
+func (f *TrueTypeFont) parseHead() error {
+	head := f.tables["head"]
+	if len(head) < 54 {
+		return errors.New("missing or truncated head table")
+	}
+	f.Metrics.UnitsPerEm = binary.BigEndian.Uint16(head[18:])
+	if f.Metrics.UnitsPerEm == 0 {
+		f.Metrics.UnitsPerEm = 1000
+	}
+	for i := 0; i < 4; i++ {
+		f.Metrics.BBox[i] = int16(binary.BigEndian.Uint16(head[36+2*i:]))
+	}
+	macStyle := binary.BigEndian.Uint16(head[44:])
+	f.Metrics.Bold = macStyle&1 != 0
+	f.Metrics.Italic = macStyle&2 != 0
+	return nil
+}
+
+func (f *TrueTypeFont) parseMaxp() {
+	if maxp := f.tables["maxp"]; len(maxp) >= 6 {
+		f.NumGlyphs = int(binary.BigEndian.Uint16(maxp[4:]))
+	}
+}
+
+func (f *TrueTypeFont) parseHmtx() {
+	hhea := f.tables["hhea"]
+	if len(hhea) < 36 {
+		return
+	}
+	f.Metrics.Ascender = int16(binary.BigEndian.Uint16(hhea[4:]))
+	f.Metrics.Descender = int16(binary.BigEndian.Uint16(hhea[6:]))
+	f.Metrics.LineGap = int16(binary.BigEndian.Uint16(hhea[8:]))
+	numMetrics := int(binary.BigEndian.Uint16(hhea[34:]))
+
+	hmtx := f.tables["hmtx"]
+	if numMetrics*4 > len(hmtx) {
+		numMetrics = len(hmtx) / 4
+	}
+	f.advances = make([]uint16, numMetrics)
+	for i := 0; i < numMetrics; i++ {
+		f.advances[i] = binary.BigEndian.Uint16(hmtx[i*4:])
+	}
+}
+
+func (f *TrueTypeFont) parseOS2() {
+	os2 := f.tables["OS/2"]
+	if len(os2) < 78 {
+		return
+	}
+	f.Metrics.WeightClass = binary.BigEndian.Uint16(os2[4:])
+	fsSelection := binary.BigEndian.Uint16(os2[62:])
+	if fsSelection&0x01 != 0 {
+		f.Metrics.Italic = true
+	}
+	if fsSelection&0x20 != 0 || f.Metrics.WeightClass >= 600 {
+		f.Metrics.Bold = true
+	}
+	// Typographic ascender/descender are more reliable than hhea for layout
+	f.Metrics.Ascender = int16(binary.BigEndian.Uint16(os2[68:]))
+	f.Metrics.Descender = int16(binary.BigEndian.Uint16(os2[70:]))
+	version := binary.BigEndian.Uint16(os2[0:])
+	if version >= 2 && len(os2) >= 90 {
+		f.Metrics.XHeight = int16(binary.BigEndian.Uint16(os2[86:]))
+		f.Metrics.CapHeight = int16(binary.BigEndian.Uint16(os2[88:]))
+	}
+}
+
+func (f *TrueTypeFont) parsePost() {
+	post := f.tables["post"]
+	if len(post) < 32 {
+		return
+	}
+	format := binary.BigEndian.Uint32(post[0:])
+	f.Metrics.ItalicAngle = float64(int32(binary.BigEndian.Uint32(post[4:]))) / 65536
+
+	switch format {
+	case 0x00010000:
+		f.glyphNames = append([]string(nil), macGlyphNames[:]...)
+	case 0x00020000:
+		if len(post) < 34 {
+			return
+		}
+		n := int(binary.BigEndian.Uint16(post[32:]))
+		if 34+2*n > len(post) {
+			return
+		}
+		indices := make([]uint16, n)
+		maxIndex := 0
+		for i := range indices {
+			indices[i] = binary.BigEndian.Uint16(post[34+2*i:])
+			if int(indices[i]) > maxIndex {
+				maxIndex = int(indices[i])
+			}
+		}
+		// Pascal strings follow the index array
+		var custom []string
+		p := 34 + 2*n
+		for p < len(post) && len(custom) < maxIndex-257 {
+			l := int(post[p])
+			p++
+			if p+l > len(post) {
+				break
+			}
+			custom = append(custom, string(post[p:p+l]))
+			p += l
+		}
+		f.glyphNames = make([]string, n)
+		for i, idx := range indices {
+			switch {
+			case idx < 258:
+				f.glyphNames[i] = macGlyphNames[idx]
+			case int(idx)-258 < len(custom):
+				f.glyphNames[i] = custom[idx-258]
+			}
+		}
+	}
+}
+
+func (f *TrueTypeFont) parseCmap() {
+	cmap := f.tables["cmap"]
+	if len(cmap) < 4 {
+		return
+	}
+	numSubtables := int(binary.BigEndian.Uint16(cmap[2:]))
+	if 4+numSubtables*8 > len(cmap) {
+		numSubtables = (len(cmap) - 4) / 8
+	}
+
+	// Remember the best Unicode subtable: full repertoire (format 12) beats BMP
+	unicodeRank := -1
+	for i := 0; i < numSubtables; i++ {
+		rec := cmap[4+i*8:]
+		platform := binary.BigEndian.Uint16(rec[0:])
+		encoding := binary.BigEndian.Uint16(rec[2:])
+		off := int(binary.BigEndian.Uint32(rec[4:]))
+		if off >= len(cmap) {
+			continue
+		}
+		m, err := parseCmapSubtable(cmap[off:])
+		if err != nil || len(m) == 0 {
+			if DebugOn {
+				fmt.Printf("truetype: skipping cmap (%d,%d): %v\n", platform, encoding, err)
+			}
+			continue
+		}
+
+		switch {
+		case platform == 3 && encoding == 0:
+			f.symbolCmap = make(map[uint16]uint16, len(m))
+			for c, g := range m {
+				f.symbolCmap[uint16(c)] = g
+			}
+		case platform == 1 && encoding == 0:
+			f.macCmap = make(map[uint16]uint16, len(m))
+			for c, g := range m {
+				f.macCmap[uint16(c)] = g
+			}
+		case platform == 3 && (encoding == 1 || encoding == 10), platform == 0:
+			rank := 1
+			if encoding == 10 || (platform == 0 && encoding >= 4) {
+				rank = 2
+			}
+			if rank > unicodeRank {
+				unicodeRank = rank
+				f.unicodeCmap = m
+			}
+		}
+	}
+}
+
+// maxCmapCodes bounds the codes a cmap subtable maps, so that the ranges of
+// a hostile font cannot force millions of map inserts. Real fonts map far
+// fewer, as they have at most 65535 glyphs.
+const maxCmapCodes = 1 << 18
+
+// parseCmapSubtable decodes a single cmap subtable in format 0, 4, 6 or 12.
+// Ranges past the first maxCmapCodes codes are ignored.
+func parseCmapSubtable(b []byte) (map[rune]uint16, error) {
+	if len(b) < 2 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	format := binary.BigEndian.Uint16(b)
+	m := make(map[rune]uint16)
+	n := 0 // codes mapped by the ranges so far
+	switch format {
+	case 0:
+		if len(b) < 6+256 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		for c := 0; c < 256; c++ {
+			if g := b[6+c]; g != 0 {
+				m[rune(c)] = uint16(g)
+			}
+		}
+	case 4:
+		if len(b) < 14 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		segX2 := int(binary.BigEndian.Uint16(b[6:]))
+		segCount := segX2 / 2
+		endOff := 14
+		startOff := endOff + segX2 + 2
+		deltaOff := startOff + segX2
+		rangeOff := deltaOff + segX2
+		if rangeOff+segX2 > len(b) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		for s := 0; s < segCount; s++ {
+			end := int(binary.BigEndian.Uint16(b[endOff+2*s:]))
+			start := int(binary.BigEndian.Uint16(b[startOff+2*s:]))
+			delta := binary.BigEndian.Uint16(b[deltaOff+2*s:])
+			ro := int(binary.BigEndian.Uint16(b[rangeOff+2*s:]))
+			if start > end || start == 0xFFFF {
+				continue
+			}
+			if n += end - start + 1; n > maxCmapCodes {
+				break
+			}
+			for c := start; c <= end; c++ {
+				var g uint16
+				if ro == 0 {
+					g = uint16(c) + delta
+				} else {
+					p := rangeOff + 2*s + ro + 2*(c-start)
+					if p+2 > len(b) {
+						break
+					}
+					g = binary.BigEndian.Uint16(b[p:])
+					if g != 0 {
+						g += delta
+					}
+				}
+				if g != 0 {
+					m[rune(c)] = g
+				}
+			}
+		}
+	case 6:
+		if len(b) < 10 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		first := int(binary.BigEndian.Uint16(b[6:]))
+		count := int(binary.BigEndian.Uint16(b[8:]))
+		if 10+2*count > len(b) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		for i := 0; i < count; i++ {
+			if g := binary.BigEndian.Uint16(b[10+2*i:]); g != 0 {
+				m[rune(first+i)] = g
+			}
+		}
+	case 12:
+		if len(b) < 16 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		nGroups := int(binary.BigEndian.Uint32(b[12:]))
+		if 16+12*nGroups > len(b) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		for i := 0; i < nGroups; i++ {
+			grp := b[16+12*i:]
+			start := binary.BigEndian.Uint32(grp[0:])
+			end := binary.BigEndian.Uint32(grp[4:])
+			gid := binary.BigEndian.Uint32(grp[8:])
+			if end < start || end > 0x10FFFF || end-start > 0xFFFF {
+				continue
+			}
+			if n += int(end-start) + 1; n > maxCmapCodes {
+				break
+			}
+			for c := start; c <= end; c++ {
+				m[rune(c)] = uint16(gid + c - start)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported cmap format %d", format)
+	}
+	return m, nil
+}
+
+// GlyphIndex returns the glyph index for a Unicode code point using the font's Unicode cmap
+func (f *TrueTypeFont) GlyphIndex(r rune) (uint16, bool) {
+	if f.unicodeCmap == nil {
+		return 0, false
+	}
+	g, ok := f.unicodeCmap[r]
+	return g, ok
+}
+
+// CodeToGlyph maps a single-byte character code of a simple TrueType font to a glyph index,
+// following PDF 32000-1:2008 §9.6.6.4: the (3,0) subtable is consulted with the code
+// in the 0xF000 range first, then the (1,0) subtable, then the Unicode subtable.
+func (f *TrueTypeFont) CodeToGlyph(code byte) (uint16, bool) {
+	if f.symbolCmap != nil {
+		for _, c := range [...]uint16{uint16(code), 0xF000 | uint16(code), 0xF100 | uint16(code), 0xF200 | uint16(code)} {
+			if g, ok := f.symbolCmap[c]; ok {
+				return g, true
+			}
+		}
+	}
+	if f.macCmap != nil {
+		if g, ok := f.macCmap[uint16(code)]; ok {
+			return g, true
+		}
+	}
+	return f.GlyphIndex(rune(code))
+}
+
+// GlyphName returns the PostScript name of a glyph from the post table, or "" if unknown
+func (f *TrueTypeFont) GlyphName(gid uint16) string {
+	if int(gid) < len(f.glyphNames) {
+		return f.glyphNames[gid]
+	}
+	return ""
+}
+
+// GlyphToUnicode returns the Unicode value of a glyph, derived from the reverse
+// Unicode cmap or, failing that, from the glyph's post table name.
+func (f *TrueTypeFont) GlyphToUnicode(gid uint16) (rune, bool) {
+	f.reverseOnce.Do(f.buildReverseCmap)
+	if r, ok := f.reverse[gid]; ok {
+		return r, true
+	}
+	if name := f.GlyphName(gid); name != "" && name != ".notdef" {
+		if r, ok := nameToRune[name]; ok {
+			return r, true
+		}
+		if r := GlyphNameToRune(name); r != 0 {
+			return r, true
+		}
+	}
+	return 0, false
+}
+
+// buildReverseCmap builds the glyph -> Unicode map, preferring the lowest code point
+// when several code points share a glyph so results are deterministic.
+func (f *TrueTypeFont) buildReverseCmap() {
+	f.reverse = make(map[uint16]rune, len(f.unicodeCmap))
+	codes := make([]rune, 0, len(f.unicodeCmap))
+	for r := range f.unicodeCmap {
+		codes = append(codes, r)
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i] < codes[j] })
+	for _, r := range codes {
+		g := f.unicodeCmap[r]
+		if _, ok := f.reverse[g]; !ok {
+			f.reverse[g] = r
+		}
+	}
+}
+
+// AdvanceWidth returns the advance width of a glyph in font design units
+func (f *TrueTypeFont) AdvanceWidth(gid uint16) uint16 {
+	if len(f.advances) == 0 {
+		return 0
+	}
+	if int(gid) < len(f.advances) {
+		return f.advances[gid]
+	}
+	// Glyphs past numberOfHMetrics share the last advance width
+	return f.advances[len(f.advances)-1]
+}
+
+// GlyphWidth returns the advance width of a glyph in PDF glyph space (1/1000 em)
+func (f *TrueTypeFont) GlyphWidth(gid uint16) float64 {
+	return float64(f.AdvanceWidth(gid)) * 1000 / float64(f.Metrics.UnitsPerEm)
+}
+
+// HasTable reports whether the font contains the given table
+func (f *TrueTypeFont) HasTable(tag string) bool {
+	_, ok := f.tables[tag]
+	return ok
+}
+
+// ParseTrueTypeFromStream parses a TrueType/OpenType font from a PDF stream
+func ParseTrueTypeFromStream(v Value) (*TrueTypeFont, error) {
+	if v.Kind() != Stream {
+		return nil, nil
+	}
+
+	reader := v.Reader()
+	defer reader.Close() // Important: close reader to prevent resource leak
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTrueTypeFont(data)
+}
+
+// trueTypeFontFile returns the embedded TrueType/OpenType program referenced by a FontDescriptor
+func trueTypeFontFile(desc Value) Value {
+	if desc.Kind() != Dict {
+		return Value{}
+	}
+	if ff := desc.Key("FontFile2"); ff.Kind() == Stream {
+		return ff
+	}
+	if ff := desc.Key("FontFile3"); ff.Kind() == Stream && ff.Key("Subtype").Name() == "OpenType" {
+		return ff
+	}
+	return Value{}
+}
+
+// trueTypeGlyphEncoder decodes single-byte codes of a simple TrueType font
+// through the embedded font's cmap and post tables.
+type trueTypeGlyphEncoder struct {
+	font *TrueTypeFont
+	base TextEncoding // used for codes the font cannot map
+}
+
+func (e *trueTypeGlyphEncoder) Decode(raw string) (text string) {
+	r := make([]rune, 0, len(raw))
+	for i := 0; i < len(raw); i++ {
+		if gid, ok := e.font.CodeToGlyph(raw[i]); ok {
+			if u, ok := e.font.GlyphToUnicode(gid); ok {
+				r = append(r, u)
+				continue
+			}
+		}
+		if e.base != nil {
+			r = append(r, []rune(e.base.Decode(raw[i:i+1]))...)
+			continue
+		}
+		r = append(r, noRune)
+	}
+	return string(r)
+}
+
+// trueTypeCIDEncoder decodes two-byte CIDs of a CIDFontType2 font through the
+// CIDToGIDMap and the embedded font's cmap and post tables.
+type trueTypeCIDEncoder struct {
+	font     *TrueTypeFont
+	cidToGID []uint16 // nil means Identity
+}
+
+func (e *trueTypeCIDEncoder) Decode(raw string) (text string) {
+	r := make([]rune, 0, len(raw)/2)
+	for i := 0; i+1 < len(raw); i += 2 {
+		cid := uint16(raw[i])<<8 | uint16(raw[i+1])
+		gid := cid
+		if e.cidToGID != nil {
+			if int(cid) >= len(e.cidToGID) {
+				r = append(r, noRune)
+				continue
+			}
+			gid = e.cidToGID[cid]
+		}
+		if u, ok := e.font.GlyphToUnicode(gid); ok {
+			r = append(r, u)
+		} else {
+			r = append(r, noRune)
+		}
+	}
+	return string(r)
+}
+
+// readCIDToGIDMap reads a CIDToGIDMap stream; it returns nil for Identity or missing maps.
+func readCIDToGIDMap(v Value) []uint16 {
+	if v.Kind() != Stream {
+		return nil
+	}
+	rd := v.Reader()
+	defer rd.Close()
+	data, err := io.ReadAll(rd)
+	if err != nil || len(data) < 2 {
+		return nil
+	}
+	m := make([]uint16, len(data)/2)
+	for i := range m {
+		m[i] = binary.BigEndian.Uint16(data[2*i:])
+	}
+	return m
+}
+
+// macGlyphNames is the standard Macintosh glyph ordering used by post table formats 1 and 2.
+var macGlyphNames = [258]string{
+	".notdef", ".null", "nonmarkingreturn", "space", "exclam", "quotedbl", "numbersign", "dollar",
+	"percent", "ampersand", "quotesingle", "parenleft", "parenright", "asterisk", "plus", "comma",
+	"hyphen", "period", "slash", "zero", "one", "two", "three", "four",
+	"five", "six", "seven", "eight", "nine", "colon", "semicolon", "less",
+	"equal", "greater", "question", "at", "A", "B", "C", "D",
+	"E", "F", "G", "H", "I", "J", "K", "L",
+	"M", "N", "O", "P", "Q", "R", "S", "T",
+	"U", "V", "W", "X", "Y", "Z", "bracketleft", "backslash",
+	"bracketright", "asciicircum", "underscore", "grave", "a", "b", "c", "d",
+	"e", "f", "g", "h", "i", "j", "k", "l",
+	"m", "n", "o", "p", "q", "r", "s", "t",
+	"u", "v", "w", "x", "y", "z", "braceleft", "bar",
+	"braceright", "asciitilde", "Adieresis", "Aring", "Ccedilla", "Eacute", "Ntilde", "Odieresis",
+	"Udieresis", "aacute", "agrave", "acircumflex", "adieresis", "atilde", "aring", "ccedilla",
+	"eacute", "egrave", "ecircumflex", "edieresis", "iacute", "igrave", "icircumflex", "idieresis",
+	"ntilde", "oacute", "ograve", "ocircumflex", "odieresis", "otilde", "uacute", "ugrave",
+	"ucircumflex", "udieresis", "dagger", "degree", "cent", "sterling", "section", "bullet",
+	"paragraph", "germandbls", "registered", "copyright", "trademark", "acute", "dieresis", "notequal",
+	"AE", "Oslash", "infinity", "plusminus", "lessequal", "greaterequal", "yen", "mu",
+	"partialdiff", "summation", "product", "pi", "integral", "ordfeminine", "ordmasculine", "Omega",
+	"ae", "oslash", "questiondown", "exclamdown", "logicalnot", "radical", "florin", "approxequal",
+	"Delta", "guillemotleft", "guillemotright", "ellipsis", "nonbreakingspace", "Agrave", "Atilde", "Otilde",
+	"OE", "oe", "endash", "emdash", "quotedblleft", "quotedblright", "quoteleft", "quoteright",
+	"divide", "lozenge", "ydieresis", "Ydieresis", "fraction", "currency", "guilsinglleft", "guilsinglright",
+	"fi", "fl", "daggerdbl", "periodcentered", "quotesinglbase", "quotedblbase", "perthousand", "Acircumflex",
+	"Ecircumflex", "Aacute", "Edieresis", "Egrave", "Iacute", "Icircumflex", "Idieresis", "Igrave",
+	"Oacute", "Ocircumflex", "apple", "Ograve", "Uacute", "Ucircumflex", "Ugrave", "dotlessi",
+	"circumflex", "tilde", "macron", "breve", "dotaccent", "ring", "cedilla", "hungarumlaut",
+	"ogonek", "caron", "Lslash", "lslash", "Scaron", "scaron", "Zcaron", "zcaron",
+	"brokenbar", "Eth", "eth", "Yacute", "yacute", "Thorn", "thorn", "minus",
+	"multiply", "onesuperior", "twosuperior", "threesuperior", "onehalf", "onequarter", "threequarters", "franc",
+	"Gbreve", "gbreve", "Idotaccent", "Scedilla", "scedilla", "Cacute", "cacute", "Ccaron",
+	"ccaron", "dcroat",
+}
+
+// embeddedTrueType returns the parsed font program in stream v, caching the
+// result (including parse failures) per stream so repeated lookups are cheap.
+func (r *Reader) embeddedTrueType(v Value) *TrueTypeFont {
+	strm, ok := v.data.(stream)
+	if r == nil || !ok || strm.ptr.id == 0 {
+		font, _ := ParseTrueTypeFromStream(v)
+		return font
+	}
+	if cached, ok := r.embeddedFonts.Load(strm.ptr); ok {
+		return cached.(*TrueTypeFont)
+	}
+	font, err := ParseTrueTypeFromStream(v)
+	if err != nil {
+		r.warn(WarnFont, strm.ptr, strm.offset, "embedded TrueType font cannot be parsed: %v", err)
+	}
+	r.embeddedFonts.Store(strm.ptr, font)
+	return font
+}
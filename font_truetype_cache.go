@@ -0,0 +1,147 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"crypto/md5"
+	"sync"
+	"time"
+)
+
+// TrueTypeCacheEntry represents a cached TrueType font
+type TrueTypeCacheEntry struct {
+	Data        *TrueTypeFont
+	Expiration  time.Time
+	LastAccess  time.Time
+	AccessCount int64
+}
+
+// IsExpired checks if the cache entry has expired
+func (ce *TrueTypeCacheEntry) IsExpired() bool {
+	return !ce.Expiration.IsZero() && time.Now().After(ce.Expiration)
+}
+
+// TrueTypeCache provides caching for TrueType font parsing operations
+type TrueTypeCache struct {
+	fonts   map[string]*TrueTypeCacheEntry
+	mutex   sync.RWMutex
+	maxSize int
+	ttl     time.Duration
+}
+
+// NewTrueTypeCache creates a new TrueType cache
+func NewTrueTypeCache(maxSize int, ttl time.Duration) *TrueTypeCache {
+	cache := &TrueTypeCache{
+		fonts:   make(map[string]*TrueTypeCacheEntry),
+		maxSize: maxSize,
+		ttl:     ttl,
+	}
+
+	// Start cleanup goroutine
+	go cache.cleanup()
+
+	return cache
+}
+
+// GetFont retrieves a cached TrueType font
+func (tc *TrueTypeCache) GetFont(data []byte) (*TrueTypeFont, bool) {
+	key := tc.hashData(data)
+
+	tc.mutex.RLock()
+	entry, exists := tc.fonts[key]
+	tc.mutex.RUnlock()
+
+	if !exists || entry.IsExpired() {
+		return nil, false
+	}
+
+	// Update access statistics
+	entry.LastAccess = time.Now()
+	entry.AccessCount++
+
+	return entry.Data, true
+}
+
+// PutFont caches a TrueType font
+func (tc *TrueTypeCache) PutFont(data []byte, font *TrueTypeFont) {
+	key := tc.hashData(data)
+
+	tc.mutex.Lock()
+	defer tc.mutex.Unlock()
+
+	// Evict if at capacity
+	if len(tc.fonts) >= tc.maxSize {
+		tc.evictOldest()
+	}
+
+	expiration := time.Time{}
+	if tc.ttl > 0 {
+		expiration = time.Now().Add(tc.ttl)
+	}
+
+	tc.fonts[key] = &TrueTypeCacheEntry{
+		Data:        font,
+		Expiration:  expiration,
+		LastAccess:  time.Now(),
+		AccessCount: 1,
+	}
+}
+
+// hashData creates a hash key for the given data
+func (tc *TrueTypeCache) hashData(data []byte) string {
+	hash := md5.Sum(data)
+	return string(hash[:])
+}
+
+// evictOldest removes the oldest entry from the cache
+func (tc *TrueTypeCache) evictOldest() {
+	var oldestKey string
+	var oldestTime time.Time
+
+	for key, entry := range tc.fonts {
+		if oldestKey == "" || entry.LastAccess.Before(oldestTime) {
+			oldestKey = key
+			oldestTime = entry.LastAccess
+		}
+	}
+
+	if oldestKey != "" {
+		delete(tc.fonts, oldestKey)
+	}
+}
+
+// cleanup periodically removes expired entries
+func (tc *TrueTypeCache) cleanup() {
+	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		tc.mutex.Lock()
+		tc.cleanupExpired()
+		tc.mutex.Unlock()
+	}
+}
+
+// cleanupExpired removes expired entries
+func (tc *TrueTypeCache) cleanupExpired() {
+	for key, entry := range tc.fonts {
+		if entry.IsExpired() {
+			delete(tc.fonts, key)
+		}
+	}
+}
+
+// Global TrueType cache instance
+var globalTrueTypeCache *TrueTypeCache
+
+// init initializes the global TrueType cache
+func init() {
+	globalTrueTypeCache = NewTrueTypeCache(100, 30*time.Minute) // Cache up to 100 fonts for 30 minutes
+}
+
+// GetGlobalTrueTypeCache returns the global TrueType cache instance
+func GetGlobalTrueTypeCache() *TrueTypeCache {
+	return globalTrueTypeCache
+}
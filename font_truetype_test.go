@@ -0,0 +1,253 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+	"testing"
+)
+
+// buildTestTrueType assembles a minimal sfnt with the given tables.
+func buildTestTrueType(tables map[string][]byte) []byte {
+	tags := make([]string, 0, len(tables))
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.BigEndian, uint32(0x00010000))
+	binary.Write(&buf, binary.BigEndian, uint16(len(tags)))
+	buf.Write(make([]byte, 6)) // searchRange, entrySelector, rangeShift
+
+	off := 12 + 16*len(tags)
+	for _, tag := range tags {
+		buf.WriteString(tag)
+		binary.Write(&buf, binary.BigEndian, uint32(0)) // checksum
+		binary.Write(&buf, binary.BigEndian, uint32(off))
+		binary.Write(&buf, binary.BigEndian, uint32(len(tables[tag])))
+		off += len(tables[tag])
+	}
+	for _, tag := range tags {
+		buf.Write(tables[tag])
+	}
+	return buf.Bytes()
+}
+
+func u16(v ...uint16) []byte {
+	b := make([]byte, 2*len(v))
+	for i, x := range v {
+		binary.BigEndian.PutUint16(b[2*i:], x)
+	}
+	return b
+}
+
+// testTrueTypeTables returns a font with glyphs: 0 .notdef, 1 "A", 2 "B", 3 "uni4E2D", 4 "customglyph".
+func testTrueTypeTables() map[string][]byte {
+	head := make([]byte, 54)
+	binary.BigEndian.PutUint16(head[18:], 2048) // unitsPerEm
+	binary.BigEndian.PutUint16(head[44:], 1)    // macStyle bold
+
+	hhea := make([]byte, 36)
+	binary.BigEndian.PutUint16(hhea[4:], 1800)
+	binary.BigEndian.PutUint16(hhea[34:], 3) // numberOfHMetrics
+
+	hmtx := u16(1024, 0, 1229, 10, 2048, 20)
+
+	maxp := u16(0, 0x5000, 5)
+
+	// cmap: (3,1) format 4 for 'A','B' and U+4E2D; (3,0) format 6 for symbol codes
+	fmt4 := buildFormat4([][3]uint16{{0x41, 0x42, 1}, {0x4E2D, 0x4E2D, 3}})
+	fmt6 := append(u16(6, 0, 0, 0xF041, 2), u16(2, 1)...)
+	cmapHdr := u16(0, 2, 3, 0)
+	cmapHdr = append(cmapHdr, 0, 0, 0, 20)
+	cmapHdr = append(cmapHdr, u16(3, 1)...)
+	cmapHdr = append(cmapHdr, 0, 0, 0, byte(20+len(fmt6)))
+	cmap := append(append(cmapHdr, fmt6...), fmt4...)
+
+	// post format 2.0 with one custom name
+	post := make([]byte, 32)
+	binary.BigEndian.PutUint32(post[0:], 0x00020000)
+	post = append(post, u16(5, 0, 36, 37, 258, 259)...)
+	post = append(post, 7)
+	post = append(post, "uni4E2D"...)
+	post = append(post, 11)
+	post = append(post, "customglyph"...)
+
+	return map[string][]byte{
+		"head": head,
+		"hhea": hhea,
+		"hmtx": hmtx,
+		"maxp": maxp,
+		"cmap": cmap,
+		"post": post,
+	}
+}
+
+// buildFormat4 builds a cmap format 4 subtable with delta-mapped segments {start, end, firstGID}.
+func buildFormat4(segs [][3]uint16) []byte {
+	segs = append(segs, [3]uint16{0xFFFF, 0xFFFF, 0})
+	n := len(segs)
+	var ends, starts, deltas, ranges []uint16
+	for _, s := range segs {
+		starts = append(starts, s[0])
+		ends = append(ends, s[1])
+		if s[0] == 0xFFFF {
+			deltas = append(deltas, 1)
+		} else {
+			deltas = append(deltas, s[2]-s[0])
+		}
+		ranges = append(ranges, 0)
+	}
+	body := u16(uint16(2*n), 0, 0, 0)
+	body = append(body, u16(ends...)...)
+	body = append(body, u16(0)...)
+	body = append(body, u16(starts...)...)
+	body = append(body, u16(deltas...)...)
+	body = append(body, u16(ranges...)...)
+	return append(u16(4, uint16(6+len(body)), 0), body...)
+}
+
+func TestNewTrueTypeFont(t *testing.T) {
+	data := buildTestTrueType(testTrueTypeTables())
+	font, err := NewTrueTypeFont(data)
+	if err != nil {
+		t.Fatalf("NewTrueTypeFont failed: %v", err)
+	}
+
+	if font.Metrics.UnitsPerEm != 2048 {
+		t.Errorf("UnitsPerEm = %d, want 2048", font.Metrics.UnitsPerEm)
+	}
+	if !font.Metrics.Bold {
+		t.Error("expected Bold from head.macStyle")
+	}
+	if font.NumGlyphs != 5 {
+		t.Errorf("NumGlyphs = %d, want 5", font.NumGlyphs)
+	}
+
+	if g, ok := font.GlyphIndex('B'); !ok || g != 2 {
+		t.Errorf("GlyphIndex('B') = %d, %v; want 2, true", g, ok)
+	}
+	if g, ok := font.GlyphIndex(0x4E2D); !ok || g != 3 {
+		t.Errorf("GlyphIndex(U+4E2D) = %d, %v; want 3, true", g, ok)
+	}
+
+	// Symbol cmap maps code 0x41 through the 0xF000 range
+	if g, ok := font.CodeToGlyph(0x41); !ok || g != 2 {
+		t.Errorf("CodeToGlyph(0x41) = %d, %v; want 2, true", g, ok)
+	}
+
+	if name := font.GlyphName(4); name != "customglyph" {
+		t.Errorf("GlyphName(4) = %q, want customglyph", name)
+	}
+	if r, ok := font.GlyphToUnicode(1); !ok || r != 'A' {
+		t.Errorf("GlyphToUnicode(1) = %q, %v; want 'A'", r, ok)
+	}
+	if _, ok := font.GlyphToUnicode(4); ok {
+		t.Error("GlyphToUnicode(4) should fail for an unmapped custom name")
+	}
+
+	if w := font.GlyphWidth(0); w != 500 {
+		t.Errorf("GlyphWidth(0) = %v, want 500", w)
+	}
+	// Glyph 4 is past numberOfHMetrics and reuses the last advance
+	if w := font.GlyphWidth(4); w != 1000 {
+		t.Errorf("GlyphWidth(4) = %v, want 1000", w)
+	}
+
+	// Second parse is served from the cache
+	again, err := NewTrueTypeFont(data)
+	if err != nil || again != font {
+		t.Error("expected cached font instance")
+	}
+}
+
+func TestNewTrueTypeFontInvalid(t *testing.T) {
+	if _, err := NewTrueTypeFont([]byte("%!PS-AdobeFont-1.0")); err == nil {
+		t.Error("expected error for non-TrueType data")
+	}
+	tables := testTrueTypeTables()
+	delete(tables, "head")
+	if _, err := NewTrueTypeFont(buildTestTrueType(tables)); err == nil {
+		t.Error("expected error for font without head table")
+	}
+}
+
+func TestParseCmapSubtableFormats(t *testing.T) {
+	fmt0 := make([]byte, 6+256)
+	binary.BigEndian.PutUint16(fmt0, 0)
+	fmt0[6+'x'] = 9
+	m, err := parseCmapSubtable(fmt0)
+	if err != nil || m['x'] != 9 || len(m) != 1 {
+		t.Errorf("format 0: got %v, %v", m, err)
+	}
+
+	fmt12 := u16(12, 0)
+	fmt12 = binary.BigEndian.AppendUint32(fmt12, 28)
+	fmt12 = binary.BigEndian.AppendUint32(fmt12, 0)
+	fmt12 = binary.BigEndian.AppendUint32(fmt12, 1)
+	fmt12 = binary.BigEndian.AppendUint32(fmt12, 0x1F600)
+	fmt12 = binary.BigEndian.AppendUint32(fmt12, 0x1F602)
+	fmt12 = binary.BigEndian.AppendUint32(fmt12, 40)
+	m, err = parseCmapSubtable(fmt12)
+	if err != nil || m[0x1F601] != 41 || len(m) != 3 {
+		t.Errorf("format 12: got %v, %v", m, err)
+	}
+
+	// Overlapping groups of the whole range stop at maxCmapCodes
+	const groups = 1000
+	hostile := u16(12, 0)
+	hostile = binary.BigEndian.AppendUint32(hostile, 16+12*groups)
+	hostile = binary.BigEndian.AppendUint32(hostile, 0)
+	hostile = binary.BigEndian.AppendUint32(hostile, groups)
+	for range groups {
+		hostile = binary.BigEndian.AppendUint32(hostile, 0x10000)
+		hostile = binary.BigEndian.AppendUint32(hostile, 0x1FFFF)
+		hostile = binary.BigEndian.AppendUint32(hostile, 1)
+	}
+	m, err = parseCmapSubtable(hostile)
+	if err != nil || len(m) != 0x10000 || m[0x10005] != 6 {
+		t.Errorf("hostile format 12: got %d codes, %v", len(m), err)
+	}
+
+	if _, err := parseCmapSubtable(u16(2, 0)); err == nil {
+		t.Error("expected error for unsupported format 2")
+	}
+}
+
+func TestTrueTypeEncoders(t *testing.T) {
+	font, err := NewTrueTypeFont(buildTestTrueType(testTrueTypeTables()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	simple := &trueTypeGlyphEncoder{font: font, base: &byteEncoder{&winAnsiEncoding}}
+	if got := simple.Decode("A!"); got != "B!" {
+		t.Errorf("simple decode = %q, want %q", got, "B!")
+	}
+
+	cid := &trueTypeCIDEncoder{font: font}
+	if got := cid.Decode("\x00\x01\x00\x03"); got != "A中" {
+		t.Errorf("identity CID decode = %q, want %q", got, "A中")
+	}
+
+	mapped := &trueTypeCIDEncoder{font: font, cidToGID: []uint16{0, 2}}
+	if got := mapped.Decode("\x00\x01\x00\x05"); got != "B�" {
+		t.Errorf("mapped CID decode = %q", got)
+	}
+}
+
+func TestMacGlyphNamesComplete(t *testing.T) {
+	for i, name := range macGlyphNames {
+		if name == "" {
+			t.Fatalf("macGlyphNames[%d] is empty", i)
+		}
+	}
+	if macGlyphNames[257] != "dcroat" {
+		t.Errorf("last standard glyph = %q, want dcroat", macGlyphNames[257])
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// hocrCapabilities lists the hOCR classes and properties the writer uses.
+const hocrCapabilities = "ocr_page ocr_carea ocr_par ocr_line ocrx_word ocrp_font"
+
+// An HOCRWriter writes a document as hOCR, the XHTML format of OCR
+// engines, one page at a time. Each page becomes an ocr_page, each block
+// an ocr_carea holding one ocr_par, and each line and word an ocr_line and
+// ocrx_word. Call Close after the last page.
+type HOCRWriter struct {
+	w       io.Writer
+	opts    OCROptions
+	started bool
+	closed  bool
+}
+
+// NewHOCRWriter returns a writer of hOCR documents to w.
+func NewHOCRWriter(w io.Writer, opts OCROptions) *HOCRWriter {
+	return &HOCRWriter{w: w, opts: opts}
+}
+
+// WritePage writes page p, numbered num, starting the document first if
+// it is the first page written.
+func (hw *HOCRWriter) WritePage(num int, p Page) error {
+	if hw.closed {
+		return ErrWriterClosed
+	}
+	layout, _, err := p.exportLayout(hw.opts.Dedup)
+	if err != nil {
+		return &PDFError{Op: "write hOCR", Page: num, Err: err}
+	}
+
+	var sb strings.Builder
+	hw.begin(&sb)
+	hw.writePage(&sb, num, layout)
+	_, err = io.WriteString(hw.w, sb.String())
+	return err
+}
+
+// Close ends the document. It does not close the underlying writer.
+func (hw *HOCRWriter) Close() error {
+	if hw.closed {
+		return nil
+	}
+	hw.closed = true
+	var sb strings.Builder
+	hw.begin(&sb)
+	sb.WriteString("</body>\n</html>\n")
+	_, err := io.WriteString(hw.w, sb.String())
+	return err
+}
+
+func (hw *HOCRWriter) begin(sb *strings.Builder) {
+	if hw.started {
+		return
+	}
+	hw.started = true
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd">
+<html xmlns="http://www.w3.org/1999/xhtml" xml:lang="en" lang="en">
+<head>
+`)
+	fmt.Fprintf(sb, "<title>%s</title>\n", xmlEscape(hw.opts.Title))
+	sb.WriteString(`<meta http-equiv="Content-Type" content="text/html; charset=utf-8"/>
+<meta name="ocr-system" content="github.com/Geek0x0/pdf"/>
+<meta name="ocr-capabilities" content="` + hocrCapabilities + `"/>
+</head>
+<body>
+`)
+}
+
+func (hw *HOCRWriter) writePage(sb *strings.Builder, num int, layout *PageLayout) {
+	page := layout.Page
+	w, h := hw.opts.pixelSize(page)
+	dpi := int(math.Round(hw.opts.scale() * 72))
+	fmt.Fprintf(sb, "<div class=\"ocr_page\" id=\"page_%d\" title=\"bbox 0 0 %d %d; ppageno %d; scan_res %d %d\">\n",
+		num, w, h, num-1, dpi, dpi)
+
+	for _, block := range layout.Nodes(LayoutBlock) {
+		id := fmt.Sprintf("%d_%d", num, block.Index+1)
+		bbox := hw.bbox(nodeBounds(block), page)
+		fmt.Fprintf(sb, "<div class=\"ocr_carea\" id=\"block_%s\" title=\"bbox %s\">\n", id, bbox)
+		fmt.Fprintf(sb, "<p class=\"ocr_par\" id=\"par_%s\" title=\"bbox %s\">\n", id, bbox)
+		for _, line := range block.Children {
+			fmt.Fprintf(sb, "<span class=\"ocr_line\" id=\"line_%d_%d\" title=\"bbox %s\">",
+				num, line.Index+1, hw.bbox(line.Bounds, page))
+			for i, word := range line.Children {
+				if i > 0 {
+					sb.WriteByte(' ')
+				}
+				hw.writeWord(sb, num, word, page)
+			}
+			sb.WriteString("</span>\n")
+		}
+		sb.WriteString("</p>\n</div>\n")
+	}
+	sb.WriteString("</div>\n")
+}
+
+func (hw *HOCRWriter) writeWord(sb *strings.Builder, num int, word *LayoutNode, page Rect) {
+	g := wordStyle(word)
+	fmt.Fprintf(sb, "<span class=\"ocrx_word\" id=\"word_%d_%d\" title=\"bbox %s", num, word.Index+1, hw.bbox(word.Bounds, page))
+	if family, _ := fontFamily(g.Font); family != "" {
+		fmt.Fprintf(sb, "; x_font &quot;%s&quot;", xmlEscape(family))
+	}
+	fmt.Fprintf(sb, "; x_fsize %g\">", math.Round(math.Abs(g.FontSize)*10)/10)
+
+	text := xmlEscape(word.Text)
+	switch {
+	case g.Bold && g.Italic:
+		text = "<strong><em>" + text + "</em></strong>"
+	case g.Bold:
+		text = "<strong>" + text + "</strong>"
+	case g.Italic:
+		text = "<em>" + text + "</em>"
+	}
+	sb.WriteString(text)
+	sb.WriteString("</span>")
+}
+
+func (hw *HOCRWriter) bbox(r, page Rect) string {
+	b := hw.opts.pixelBox(r, page)
+	return fmt.Sprintf("%d %d %d %d", b[0], b[1], b[2], b[3])
+}
+
+// ExtractHOCR writes the selected pages to w as hOCR. The extractor's
+// dedup options apply unless opts sets its own.
+func (e *Extractor) ExtractHOCR(w io.Writer, opts OCROptions) error {
+	if opts.Dedup == nil {
+		opts.Dedup = e.dedup
+	}
+	return e.writePages(NewHOCRWriter(w, opts))
+}
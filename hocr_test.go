@@ -0,0 +1,73 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHOCRWriter(t *testing.T) {
+	var sb strings.Builder
+	err := NewExtractor(ocrTestPDF(t)).ExtractHOCR(&sb, OCROptions{DPI: 144, Title: "Report & data"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := sb.String()
+	for _, err := range validateHOCR(t, []byte(out)) {
+		t.Error(err)
+	}
+
+	for _, want := range []string{
+		"<title>Report &amp; data</title>",
+		`<div class="ocr_page" id="page_1" title="bbox 0 0 1224 1584; ppageno 0; scan_res 144 144">`,
+		`<span class="ocrx_word" id="word_1_1" title="bbox 144 115 252 152; x_font &quot;Helvetica&quot;; x_fsize 18"><strong>Report</strong></span>`,
+		`title="bbox 144 168 194 188; x_font &quot;Helvetica&quot;; x_fsize 10">Hello</span>`,
+		`>&lt;world&gt;</span>`,
+		`<div class="ocr_page" id="page_2" title="bbox 0 0 1224 1584; ppageno 1; scan_res 144 144">`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %s", want)
+		}
+	}
+}
+
+func TestHOCRControlCharacters(t *testing.T) {
+	r := openTextPDF(t, `BT /F1 10 Tf 72 700 Td (ab\002c) Tj ET`)
+	var sb strings.Builder
+	if err := NewExtractor(r).ExtractHOCR(&sb, OCROptions{Title: "Report\x01"}); err != nil {
+		t.Fatal(err)
+	}
+	out := sb.String()
+	for _, err := range validateHOCR(t, []byte(out)) {
+		t.Error(err)
+	}
+	for _, want := range []string{"<title>Report</title>", ">abc</span>"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %s", want)
+		}
+	}
+}
+
+func TestHOCRValidatorRejects(t *testing.T) {
+	const doc = `<?xml version="1.0"?>
+<html xmlns="http://www.w3.org/1999/xhtml"><head><meta name="ocr-capabilities" content="ocr_page ocr_line"/></head><body>
+<div class="ocr_page" title="bbox 0 0 100 100">
+<span class="ocr_line" id="l1" title="bbox 0 0 200 10; x_fsize 10"><span class="ocrx_word" id="l1" title="bbox 5 5 1 1">x</span></span>
+</div></body></html>`
+	errs := strings.Join(validateHOCR(t, []byte(doc)), "\n")
+	for _, want := range []string{
+		"ocr_line inside ocr_page",
+		"outside its ocr_page",
+		"inverted bbox",
+		"duplicate id l1",
+		"ocr-capabilities lacks ocrp_font",
+		"ocr-capabilities lacks ocrx_word",
+	} {
+		if !strings.Contains(errs, want) {
+			t.Errorf("validator did not report %q; got:\n%s", want, errs)
+		}
+	}
+}
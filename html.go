@@ -0,0 +1,331 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strings"
+)
+
+// HTMLMode selects how an HTMLWriter lays out pages.
+type HTMLMode int
+
+const (
+	HTMLFlow  HTMLMode = iota // Semantic HTML built from classified blocks
+	HTMLFixed                 // Words and rectangles placed at their page coordinates
+)
+
+// HTMLOptions configures an HTMLWriter.
+type HTMLOptions struct {
+	Mode  HTMLMode
+	Title string        // Document title; empty for none
+	Dedup *DedupOptions // Collapse overprinted text before writing, if set
+}
+
+const htmlFlowStyle = `section.page{max-width:50em;margin:2em auto}
+.caption{font-style:italic}
+.footnotes{font-size:smaller;border-top:1px solid #ccc}
+table{border-collapse:collapse}
+th,td{border:1px solid #ccc;padding:0.2em 0.5em}
+li.nested{margin-left:2em}`
+
+const htmlFixedStyle = `.page{position:relative;overflow:hidden;margin:1em auto;border:1px solid #ccc;background:#fff}
+.page span{position:absolute;white-space:pre;line-height:1}
+.page .rect{position:absolute;box-sizing:border-box;border:0.5pt solid #000}`
+
+// An HTMLWriter writes a document as HTML one page at a time, so a large
+// document never has to be held in memory. Call Close after the last page.
+type HTMLWriter struct {
+	w       io.Writer
+	opts    HTMLOptions
+	notes   *mdRenderer // footnote labels, shared by all pages
+	started bool
+	closed  bool
+}
+
+// NewHTMLWriter returns a writer of HTML documents to w.
+func NewHTMLWriter(w io.Writer, opts HTMLOptions) *HTMLWriter {
+	return &HTMLWriter{
+		w:     w,
+		opts:  opts,
+		notes: &mdRenderer{notes: make(map[int]map[string]string), used: make(map[string]bool)},
+	}
+}
+
+// WritePage writes page p, numbered num, starting the document first if
+// it is the first page written.
+func (hw *HTMLWriter) WritePage(num int, p Page) error {
+	if hw.closed {
+		return ErrWriterClosed
+	}
+	layout, rects, err := p.exportLayout(hw.opts.Dedup)
+	if err != nil {
+		return &PDFError{Op: "write HTML", Page: num, Err: err}
+	}
+
+	var sb strings.Builder
+	hw.begin(&sb)
+	if hw.opts.Mode == HTMLFixed {
+		hw.fixedPage(&sb, num, layout, rects)
+	} else {
+		blocks := layout.ClassifiedBlocks()
+		for i := range blocks {
+			blocks[i].Page = num
+		}
+		hw.flowPage(&sb, num, blocks)
+	}
+	_, err = io.WriteString(hw.w, sb.String())
+	return err
+}
+
+// Close ends the document. It does not close the underlying writer.
+func (hw *HTMLWriter) Close() error {
+	if hw.closed {
+		return nil
+	}
+	hw.closed = true
+	var sb strings.Builder
+	hw.begin(&sb)
+	sb.WriteString("</body>\n</html>\n")
+	_, err := io.WriteString(hw.w, sb.String())
+	return err
+}
+
+// begin writes the document head if it has not been written yet.
+func (hw *HTMLWriter) begin(sb *strings.Builder) {
+	if hw.started {
+		return
+	}
+	hw.started = true
+	style := htmlFlowStyle
+	if hw.opts.Mode == HTMLFixed {
+		style = htmlFixedStyle
+	}
+	sb.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	if hw.opts.Title != "" {
+		fmt.Fprintf(sb, "<title>%s</title>\n", html.EscapeString(hw.opts.Title))
+	}
+	fmt.Fprintf(sb, "<style>\n%s\n</style>\n</head>\n<body>\n", style)
+}
+
+// fixedPage writes every word of the page, and its rectangles, at their
+// positions on the page.
+func (hw *HTMLWriter) fixedPage(sb *strings.Builder, num int, layout *PageLayout, rects []Rect) {
+	box := layout.Page
+	fmt.Fprintf(sb, "<div class=\"page\" id=\"page-%d\" style=\"width:%.2fpt;height:%.2fpt\">\n",
+		num, box.Max.X-box.Min.X, box.Max.Y-box.Min.Y)
+
+	for _, r := range rects {
+		x0, x1 := math.Min(r.Min.X, r.Max.X), math.Max(r.Min.X, r.Max.X)
+		y0, y1 := math.Min(r.Min.Y, r.Max.Y), math.Max(r.Min.Y, r.Max.Y)
+		fmt.Fprintf(sb, "<div class=\"rect\" style=\"left:%.2fpt;top:%.2fpt;width:%.2fpt;height:%.2fpt\"></div>\n",
+			x0-box.Min.X, box.Max.Y-y1, x1-x0, y1-y0)
+	}
+
+	for _, word := range layout.Nodes(LayoutWord) {
+		if len(word.Children) == 0 {
+			continue
+		}
+		g := word.Children[0].Glyph
+		fmt.Fprintf(sb, "<span style=\"left:%.2fpt;top:%.2fpt;%s\">%s</span>\n",
+			word.Bounds.Min.X-box.Min.X, box.Max.Y-word.Bounds.Max.Y, html.EscapeString(textCSS(g)), html.EscapeString(word.Text))
+	}
+	sb.WriteString("</div>\n")
+}
+
+// textCSS returns the CSS declarations for the font and colour of t.
+func textCSS(t Text) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "font-family:%s;font-size:%.2fpt", cssFontFamily(t.Font), math.Max(math.Abs(t.FontSize), 1))
+	if t.Bold {
+		sb.WriteString(";font-weight:bold")
+	}
+	if t.Italic {
+		sb.WriteString(";font-style:italic")
+	}
+	if t.Underline {
+		sb.WriteString(";text-decoration:underline")
+	}
+	if t.Color != (Color{}) {
+		sb.WriteString(";color:" + t.Color.String())
+	}
+	if t.Vertical {
+		sb.WriteString(";writing-mode:vertical-rl")
+	}
+	return sb.String()
+}
+
+// cssFontFamily maps a PDF font name such as "ABCDEF+Times-Bold" to a CSS
+// font family with a generic fallback.
+func cssFontFamily(name string) string {
+	family, generic := fontFamily(name)
+	if family == "" {
+		return generic
+	}
+	return "'" + family + "'," + generic
+}
+
+// fontFamily returns the family of a PDF font name, without subset tag
+// and style suffix, and its CSS generic family.
+func fontFamily(name string) (family, generic string) {
+	if i := strings.IndexByte(name, '+'); i >= 0 {
+		name = name[i+1:] // subset tag
+	}
+	if i := strings.IndexAny(name, "-,"); i > 0 {
+		name = name[:i]
+	}
+	name = strings.Map(func(r rune) rune {
+		if r == '\'' || r == '"' || r == '\\' || r < ' ' {
+			return -1
+		}
+		return r
+	}, name)
+
+	generic = "sans-serif"
+	lower := strings.ToLower(name)
+	switch {
+	case strings.Contains(lower, "sans"), strings.Contains(lower, "gothic"):
+	case strings.Contains(lower, "courier"), strings.Contains(lower, "mono"), strings.Contains(lower, "consol"):
+		generic = "monospace"
+	case strings.Contains(lower, "times"), strings.Contains(lower, "serif"), strings.Contains(lower, "roman"),
+		strings.Contains(lower, "georgia"), strings.Contains(lower, "garamond"), strings.Contains(lower, "minion"),
+		strings.Contains(lower, "mincho"), strings.Contains(lower, "song"), strings.Contains(lower, "ming"):
+		generic = "serif"
+	}
+	return name, generic
+}
+
+// flowPage writes the blocks of a page as semantic HTML.
+func (hw *HTMLWriter) flowPage(sb *strings.Builder, num int, blocks []ClassifiedBlock) {
+	m := hw.notes
+	fmt.Fprintf(sb, "<section class=\"page\" id=\"page-%d\">\n", num)
+	for _, b := range m.preparePage(blocks) {
+		tables, lines := b.takeLines()
+		for _, t := range tables {
+			hw.writeTable(sb, t, b.Page)
+		}
+		if len(lines) == 0 {
+			continue
+		}
+
+		switch b.Type {
+		case BlockHeader:
+			fmt.Fprintf(sb, "<header>%s</header>\n", hw.inline(lines, b.Page, true))
+		case BlockFooter:
+			fmt.Fprintf(sb, "<footer>%s</footer>\n", hw.inline(lines, b.Page, true))
+		case BlockTitle:
+			level := min(max(b.Level, 1), 6)
+			fmt.Fprintf(sb, "<h%d>%s</h%d>\n", level, hw.inline(lines, b.Page, false), level)
+		case BlockCaption:
+			fmt.Fprintf(sb, "<p class=\"caption\">%s</p>\n", hw.inline(lines, b.Page, true))
+		case BlockFootnote:
+			sb.WriteString("<aside class=\"footnotes\">\n")
+			for _, i := range b.notes {
+				n := m.defs[i]
+				fmt.Fprintf(sb, "<p id=\"fn-%s\"><sup>%s</sup> %s</p>\n",
+					html.EscapeString(n.label), html.EscapeString(n.label), hw.inline(n.lines, n.page, true))
+			}
+			sb.WriteString("</aside>\n")
+		case BlockList:
+			if items := listItems(lines); items != nil {
+				hw.writeList(sb, items, b.Page)
+				continue
+			}
+			fallthrough
+		default:
+			fmt.Fprintf(sb, "<p>%s</p>\n", hw.inline(lines, b.Page, true))
+		}
+	}
+	sb.WriteString("</section>\n")
+}
+
+// writeList writes list items as an ordered list if the first is numbered.
+func (hw *HTMLWriter) writeList(sb *strings.Builder, items []*mdListItem, page int) {
+	tag, start := "ul", ""
+	if p := items[0].prefix; p != "- " {
+		tag = "ol"
+		if n := strings.TrimSuffix(p, ". "); n != "1" {
+			start = " start=\"" + n + "\""
+		}
+	}
+	fmt.Fprintf(sb, "<%s%s>\n", tag, start)
+	for _, it := range items {
+		class := ""
+		if it.nested {
+			class = " class=\"nested\""
+		}
+		fmt.Fprintf(sb, "<li%s>%s</li>\n", class, hw.inline(it.lines, page, true))
+	}
+	fmt.Fprintf(sb, "</%s>\n", tag)
+}
+
+// writeTable writes a table with its first row as header.
+func (hw *HTMLWriter) writeTable(sb *strings.Builder, t *mdTable, page int) {
+	sb.WriteString("<table>\n")
+	for i, row := range t.rows {
+		cell := "td"
+		if i == 0 {
+			cell = "th"
+			sb.WriteString("<thead>\n")
+		}
+		sb.WriteString("<tr>")
+		for _, c := range row {
+			fmt.Fprintf(sb, "<%s>%s</%s>", cell, hw.inline([][]*mdWord{c}, page, true), cell)
+		}
+		sb.WriteString("</tr>\n")
+		if i == 0 {
+			sb.WriteString("</thead>\n<tbody>\n")
+		}
+	}
+	sb.WriteString("</tbody>\n</table>\n")
+}
+
+// inline renders lines of words as escaped HTML with links to footnotes,
+// and with strong and em elements if emphasis is set.
+func (hw *HTMLWriter) inline(lines [][]*mdWord, page int, emphasis bool) string {
+	return joinTokens(hw.notes.tokens(lines, page), func(t mdToken) string {
+		if t.ref != "" {
+			label := html.EscapeString(t.ref)
+			return "<sup><a href=\"#fn-" + label + "\">" + label + "</a></sup>"
+		}
+		return html.EscapeString(t.text)
+	}, func(bold, italic bool) (string, string) {
+		switch {
+		case !emphasis:
+			return "", ""
+		case bold && italic:
+			return "<strong><em>", "</em></strong>"
+		case bold:
+			return "<strong>", "</strong>"
+		case italic:
+			return "<em>", "</em>"
+		}
+		return "", ""
+	})
+}
+
+// ExtractHTML writes the selected pages to w as HTML. The extractor's
+// dedup options apply unless opts sets its own.
+func (e *Extractor) ExtractHTML(w io.Writer, opts HTMLOptions) error {
+	if opts.Dedup == nil {
+		opts.Dedup = e.dedup
+	}
+	return e.writePages(NewHTMLWriter(w, opts))
+}
+
+// ProcessHTMLStream writes the document to w as HTML, one page at a time.
+func (sp *StreamProcessor) ProcessHTMLStream(reader *Reader, w io.Writer, opts HTMLOptions) error {
+	hw := NewHTMLWriter(w, opts)
+	err := sp.ProcessPageStream(reader, func(ps PageStream) error {
+		return hw.WritePage(ps.PageNum, ps.Page)
+	})
+	if err != nil {
+		return err
+	}
+	return hw.Close()
+}
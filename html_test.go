@@ -0,0 +1,135 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestHTMLWriterFixed(t *testing.T) {
+	r := openTextPDF(t, "1 0 0 rg BT /F2 12 Tf 72 700 Td (Red title) Tj ET "+
+		"0 g 10 20 100 50 re S BT /F1 10 Tf 72 650 Td (a<b) Tj ET")
+
+	var sb strings.Builder
+	hw := NewHTMLWriter(&sb, HTMLOptions{Mode: HTMLFixed, Title: "Q&A"})
+	if err := hw.WritePage(1, r.Page(1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := hw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	out := sb.String()
+
+	for _, want := range []string{
+		"<title>Q&amp;A</title>",
+		`<div class="page" id="page-1" style="width:612.00pt;height:792.00pt">`,
+		`<div class="rect" style="left:10.00pt;top:722.00pt;width:100.00pt;height:50.00pt"></div>`,
+		`<span style="left:72.00pt;top:82.40pt;font-family:&#39;Helvetica&#39;,sans-serif;font-size:12.00pt;font-weight:bold;color:#ff0000">Red</span>`,
+		`>title</span>`,
+		`font-size:10.00pt">a&lt;b</span>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %s:\n%s", want, out)
+		}
+	}
+	if !strings.HasPrefix(out, "<!DOCTYPE html>") || !strings.HasSuffix(out, "</html>\n") {
+		t.Errorf("output is not a complete document:\n%s", out)
+	}
+
+	if err := hw.WritePage(2, r.Page(1)); !errors.Is(err, ErrWriterClosed) {
+		t.Errorf("WritePage after Close: %v", err)
+	}
+}
+
+func TestHTMLWriterFlow(t *testing.T) {
+	ref := glyphRun("1", 144, 703)
+	ref[0].FontSize = 7
+	blocks := []ClassifiedBlock{
+		block(BlockHeader, 0, glyphRun("Journal", 72, 760)),
+		block(BlockTitle, 2, glyphRun("Method & data", 72, 730)),
+		block(BlockParagraph, 0, append(styled(glyphRun("See the note", 72, 700), 8, 12, true, false), ref...)),
+		block(BlockList, 0, glyphRun("3. Three", 72, 650), glyphRun("4. Four", 72, 638)),
+		block(BlockParagraph, 0, glyphRun("Name", 72, 600), glyphRun("Alice", 72, 588)),
+		block(BlockParagraph, 0, glyphRun("Score", 200, 600), glyphRun("30", 200, 588)),
+		block(BlockFootnote, 0, glyphRun("1 A note", 72, 100)),
+	}
+	for i := range blocks {
+		blocks[i].Page = 1
+	}
+
+	var sb strings.Builder
+	hw := NewHTMLWriter(&sb, HTMLOptions{})
+	hw.flowPage(&sb, 1, blocks)
+	want := `<section class="page" id="page-1">
+<header>Journal</header>
+<h2>Method &amp; data</h2>
+<p>See the <strong>note<sup><a href="#fn-1">1</a></sup></strong></p>
+<ol start="3">
+<li>Three</li>
+<li>Four</li>
+</ol>
+<table>
+<thead>
+<tr><th>Name</th><th>Score</th></tr>
+</thead>
+<tbody>
+<tr><td>Alice</td><td>30</td></tr>
+</tbody>
+</table>
+<aside class="footnotes">
+<p id="fn-1"><sup>1</sup> A note</p>
+</aside>
+</section>
+`
+	if got := sb.String(); got != want {
+		t.Errorf("flowPage =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestHTMLStreaming(t *testing.T) {
+	r := openTextPDF(t,
+		"BT /F2 24 Tf 72 700 Td (Introduction) Tj ET BT /F1 10 Tf 72 660 Td (This is the body text of the paper.) Tj ET",
+		"BT /F1 10 Tf 72 660 Td (Second page text.) Tj ET")
+
+	var viaExtractor strings.Builder
+	if err := NewExtractor(r).ExtractHTML(&viaExtractor, HTMLOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	out := viaExtractor.String()
+	first, second := strings.Index(out, `id="page-1"`), strings.Index(out, `id="page-2"`)
+	if first < 0 || second < first || !strings.Contains(out, "<h1>Introduction</h1>") || !strings.Contains(out, "<p>Second page text.</p>") {
+		t.Errorf("ExtractHTML =\n%s", out)
+	}
+
+	var viaStream strings.Builder
+	sp := NewStreamProcessor(1024, 16, 1<<30)
+	defer sp.Close()
+	if err := sp.ProcessHTMLStream(r, &viaStream, HTMLOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if viaStream.String() != out {
+		t.Errorf("ProcessHTMLStream =\n%s\nwant\n%s", viaStream.String(), out)
+	}
+}
+
+func TestCSSFontFamily(t *testing.T) {
+	tests := map[string]string{
+		"ABCDEF+Times-Bold":    "'Times',serif",
+		"Courier":              "'Courier',monospace",
+		"DejaVuSans-Oblique":   "'DejaVuSans',sans-serif",
+		"Arial,Bold":           "'Arial',sans-serif",
+		"":                     "sans-serif",
+		"Odd'Name":             "'OddName',sans-serif",
+		"KozMinPro-Regular":    "'KozMinPro',sans-serif",
+		"LiberationSerif-Bold": "'LiberationSerif',serif",
+	}
+	for name, want := range tests {
+		if got := cssFontFamily(name); got != want {
+			t.Errorf("cssFontFamily(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
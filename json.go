@@ -0,0 +1,652 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JSONSchemaVersion is the version of the JSON export written by
+// JSONWriter. The minor version grows when properties are added; readers
+// of one 1.x version can read any other.
+const JSONSchemaVersion = "1.0"
+
+//go:embed schema/document-v1.schema.json
+var jsonSchema []byte
+
+// JSONSchema returns the JSON Schema of the documents JSONWriter writes.
+func JSONSchema() []byte {
+	return append([]byte(nil), jsonSchema...)
+}
+
+// JSONOptions configures a JSONWriter.
+type JSONOptions struct {
+	Dedup *DedupOptions // Collapse overprinted text before writing, if set
+}
+
+// A JSONDocument is a document as written by JSONWriter.
+type JSONDocument struct {
+	Version   string     // Schema version
+	Metadata  Metadata   // Document information
+	PageCount int        // Number of pages in the document
+	Outline   Outline    // Root of the outline
+	Pages     []JSONPage // Exported pages, in the order written
+}
+
+// A JSONPage is a page of a JSONDocument.
+type JSONPage struct {
+	Number      int
+	MediaBox    Rect
+	CropBox     Rect
+	Rotate      int               // Clockwise rotation in degrees
+	Texts       []Text            // Text runs in content stream order
+	Blocks      []ClassifiedBlock // Blocks in reading order; their runs are copies of Texts
+	Annotations []Annotation
+}
+
+// The JSON forms of the types, as described by the schema.
+type (
+	jsonRect [4]float64
+
+	jsonInfo struct {
+		PageCount    int               `json:"pageCount"`
+		Title        string            `json:"title,omitempty"`
+		Author       string            `json:"author,omitempty"`
+		Subject      string            `json:"subject,omitempty"`
+		Keywords     []string          `json:"keywords,omitempty"`
+		Creator      string            `json:"creator,omitempty"`
+		Producer     string            `json:"producer,omitempty"`
+		CreationDate string            `json:"creationDate,omitempty"`
+		ModDate      string            `json:"modDate,omitempty"`
+		Trapped      string            `json:"trapped,omitempty"`
+		Custom       map[string]string `json:"custom,omitempty"`
+	}
+
+	jsonOutline struct {
+		Title    string        `json:"title"`
+		Children []jsonOutline `json:"children,omitempty"`
+	}
+
+	jsonPage struct {
+		Number      int              `json:"number"`
+		MediaBox    jsonRect         `json:"mediaBox"`
+		CropBox     jsonRect         `json:"cropBox"`
+		Rotate      int              `json:"rotate,omitempty"`
+		Texts       []jsonText       `json:"texts"`
+		Blocks      []jsonBlock      `json:"blocks,omitempty"`
+		Annotations []jsonAnnotation `json:"annotations,omitempty"`
+	}
+
+	jsonText struct {
+		Text       string  `json:"text"`
+		Font       string  `json:"font,omitempty"`
+		Size       float64 `json:"size"`
+		X          float64 `json:"x"`
+		Y          float64 `json:"y"`
+		Width      float64 `json:"width"`
+		Vertical   bool    `json:"vertical,omitempty"`
+		Bold       bool    `json:"bold,omitempty"`
+		Italic     bool    `json:"italic,omitempty"`
+		Underline  bool    `json:"underline,omitempty"`
+		Color      string  `json:"color,omitempty"`
+		Visibility string  `json:"visibility,omitempty"`
+	}
+
+	jsonBlock struct {
+		Type   string   `json:"type"`
+		Level  int      `json:"level,omitempty"`
+		Bounds jsonRect `json:"bounds"`
+		Text   string   `json:"text"`
+		Runs   []int    `json:"runs"`
+	}
+
+	jsonAnnotation struct {
+		Subtype  string   `json:"subtype"`
+		Rect     jsonRect `json:"rect"`
+		Contents string   `json:"contents,omitempty"`
+		Author   string   `json:"author,omitempty"`
+		URI      string   `json:"uri,omitempty"`
+	}
+)
+
+// A JSONWriter writes a document as JSON one page at a time, so a large
+// document never has to be held in memory. The document information and
+// outline are written before the first page. Call Close after the last
+// page.
+type JSONWriter struct {
+	w       io.Writer
+	r       *Reader
+	opts    JSONOptions
+	started bool
+	pages   int
+	closed  bool
+}
+
+// NewJSONWriter returns a writer of the JSON form of the document read by
+// r to w.
+func NewJSONWriter(w io.Writer, r *Reader, opts JSONOptions) *JSONWriter {
+	return &JSONWriter{w: w, r: r, opts: opts}
+}
+
+// WritePage writes page p, numbered num, starting the document first if
+// it is the first page written.
+func (jw *JSONWriter) WritePage(num int, p Page) error {
+	if jw.closed {
+		return ErrWriterClosed
+	}
+	page, err := p.jsonPage(num, jw.opts.Dedup)
+	if err != nil {
+		return &PDFError{Op: "write JSON", Page: num, Err: err}
+	}
+	data, err := json.Marshal(page)
+	if err != nil {
+		return &PDFError{Op: "write JSON", Page: num, Err: err}
+	}
+
+	var sb strings.Builder
+	if err := jw.begin(&sb); err != nil {
+		return err
+	}
+	if jw.pages > 0 {
+		sb.WriteString(",\n")
+	}
+	sb.Write(data)
+	jw.pages++
+	_, err = io.WriteString(jw.w, sb.String())
+	return err
+}
+
+// Close ends the document. It does not close the underlying writer.
+func (jw *JSONWriter) Close() error {
+	if jw.closed {
+		return nil
+	}
+	jw.closed = true
+	var sb strings.Builder
+	if err := jw.begin(&sb); err != nil {
+		return err
+	}
+	sb.WriteString("\n]}\n")
+	_, err := io.WriteString(jw.w, sb.String())
+	return err
+}
+
+func (jw *JSONWriter) begin(sb *strings.Builder) error {
+	if jw.started {
+		return nil
+	}
+	jw.started = true
+
+	meta, err := jw.r.GetMetadata()
+	if err != nil {
+		return &PDFError{Op: "write JSON", Err: err}
+	}
+	info, err := json.Marshal(newJSONInfo(meta, jw.r.NumPage()))
+	if err != nil {
+		return &PDFError{Op: "write JSON", Err: err}
+	}
+	outline, err := json.Marshal(newJSONOutline(jw.r.Outline()).Children)
+	if err != nil {
+		return &PDFError{Op: "write JSON", Err: err}
+	}
+
+	fmt.Fprintf(sb, "{\"version\":%q,\n\"info\":%s,\n", JSONSchemaVersion, info)
+	if string(outline) != "null" {
+		fmt.Fprintf(sb, "\"outline\":%s,\n", outline)
+	}
+	sb.WriteString("\"pages\":[\n")
+	return nil
+}
+
+// jsonPage returns the JSON form of the page.
+func (p Page) jsonPage(num int, dedup *DedupOptions) (*jsonPage, error) {
+	content, err := p.contentWithFonts(nil)
+	if err != nil {
+		return nil, err
+	}
+	texts := content.Text
+	if dedup != nil {
+		texts = DeduplicateTexts(texts, *dedup)
+	}
+
+	media := p.bounds()
+	crop, ok := p.cropBox()
+	if !ok {
+		crop = media
+	}
+	page := &jsonPage{
+		Number:   num,
+		MediaBox: newJSONRect(media),
+		CropBox:  newJSONRect(crop),
+		Rotate:   pageRotation(p.findInherited("Rotate")),
+		Texts:    make([]jsonText, len(texts)),
+	}
+
+	// Blocks refer to their runs by index; identical runs are told apart
+	// by taking their indexes in turn.
+	index := make(map[Text][]int, len(texts))
+	for i, t := range texts {
+		page.Texts[i] = newJSONText(t)
+		index[t] = append(index[t], i)
+	}
+	for _, b := range NewPageLayout(texts, media).ClassifiedBlocks() {
+		block := jsonBlock{
+			Type:   b.Type.String(),
+			Level:  b.Level,
+			Bounds: newJSONRect(b.Bounds),
+			Text:   b.Text,
+			Runs:   make([]int, 0, len(b.Content)),
+		}
+		for _, t := range b.Content {
+			if ids := index[t]; len(ids) > 0 {
+				block.Runs = append(block.Runs, ids[0])
+				index[t] = ids[1:]
+			}
+		}
+		page.Blocks = append(page.Blocks, block)
+	}
+
+	for _, a := range p.Annotations() {
+		page.Annotations = append(page.Annotations, jsonAnnotation{
+			Subtype:  a.Subtype,
+			Rect:     newJSONRect(a.Rect),
+			Contents: a.Contents,
+			Author:   a.Author,
+			URI:      a.URI,
+		})
+	}
+	return page, nil
+}
+
+func newJSONRect(r Rect) jsonRect {
+	return jsonRect{r.Min.X, r.Min.Y, r.Max.X, r.Max.Y}
+}
+
+func (r jsonRect) rect() Rect {
+	return Rect{Point{r[0], r[1]}, Point{r[2], r[3]}}
+}
+
+func newJSONInfo(m Metadata, pages int) jsonInfo {
+	info := jsonInfo{
+		PageCount: pages,
+		Title:     m.Title,
+		Author:    m.Author,
+		Subject:   m.Subject,
+		Keywords:  m.Keywords,
+		Creator:   m.Creator,
+		Producer:  m.Producer,
+		Trapped:   m.Trapped,
+	}
+	if len(m.Custom) > 0 {
+		info.Custom = m.Custom
+	}
+	if !m.CreationDate.IsZero() {
+		info.CreationDate = m.CreationDate.Format(time.RFC3339)
+	}
+	if !m.ModDate.IsZero() {
+		info.ModDate = m.ModDate.Format(time.RFC3339)
+	}
+	return info
+}
+
+func (info jsonInfo) metadata() (Metadata, error) {
+	m := Metadata{
+		Title:    info.Title,
+		Author:   info.Author,
+		Subject:  info.Subject,
+		Keywords: info.Keywords,
+		Creator:  info.Creator,
+		Producer: info.Producer,
+		Trapped:  info.Trapped,
+		Custom:   make(map[string]string, len(info.Custom)),
+	}
+	for k, v := range info.Custom {
+		m.Custom[k] = v
+	}
+	for _, d := range []struct {
+		s string
+		t *time.Time
+	}{{info.CreationDate, &m.CreationDate}, {info.ModDate, &m.ModDate}} {
+		if d.s == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, d.s)
+		if err != nil {
+			return m, err
+		}
+		*d.t = t
+	}
+	return m, nil
+}
+
+func newJSONOutline(o Outline) jsonOutline {
+	x := jsonOutline{Title: o.Title}
+	for _, c := range o.Child {
+		x.Children = append(x.Children, newJSONOutline(c))
+	}
+	return x
+}
+
+func (o jsonOutline) outline() Outline {
+	x := Outline{Title: o.Title}
+	for _, c := range o.Children {
+		x.Child = append(x.Child, c.outline())
+	}
+	return x
+}
+
+func newJSONText(t Text) jsonText {
+	x := jsonText{
+		Text:      t.S,
+		Font:      t.Font,
+		Size:      t.FontSize,
+		X:         t.X,
+		Y:         t.Y,
+		Width:     t.W,
+		Vertical:  t.Vertical,
+		Bold:      t.Bold,
+		Italic:    t.Italic,
+		Underline: t.Underline,
+	}
+	if t.Color != (Color{}) {
+		x.Color = t.Color.String()
+	}
+	if t.Visibility != TextVisible {
+		x.Visibility = t.Visibility.String()
+	}
+	return x
+}
+
+func (x jsonText) text() (Text, error) {
+	t := Text{
+		Font:      x.Font,
+		FontSize:  x.Size,
+		X:         x.X,
+		Y:         x.Y,
+		W:         x.Width,
+		S:         x.Text,
+		Vertical:  x.Vertical,
+		Bold:      x.Bold,
+		Italic:    x.Italic,
+		Underline: x.Underline,
+	}
+	if x.Color != "" {
+		v, err := strconv.ParseUint(strings.TrimPrefix(x.Color, "#"), 16, 24)
+		if err != nil || len(x.Color) != 7 {
+			return t, fmt.Errorf("invalid color %q", x.Color)
+		}
+		t.Color = Color{uint8(v >> 16), uint8(v >> 8), uint8(v)}
+	}
+	switch x.Visibility {
+	case "", "Visible":
+	case "PartlyClipped":
+		t.Visibility = TextPartlyClipped
+	case "Clipped":
+		t.Visibility = TextClipped
+	default:
+		return t, fmt.Errorf("invalid visibility %q", x.Visibility)
+	}
+	return t, nil
+}
+
+func (x *jsonPage) page() (*JSONPage, error) {
+	p := &JSONPage{
+		Number:   x.Number,
+		MediaBox: x.MediaBox.rect(),
+		CropBox:  x.CropBox.rect(),
+		Rotate:   x.Rotate,
+		Texts:    make([]Text, len(x.Texts)),
+	}
+	for i, jt := range x.Texts {
+		t, err := jt.text()
+		if err != nil {
+			return nil, err
+		}
+		p.Texts[i] = t
+	}
+	for _, jb := range x.Blocks {
+		typ, ok := parseBlockType(jb.Type)
+		if !ok {
+			return nil, fmt.Errorf("invalid block type %q", jb.Type)
+		}
+		b := ClassifiedBlock{
+			Type:    typ,
+			Level:   jb.Level,
+			Bounds:  jb.Bounds.rect(),
+			Text:    jb.Text,
+			Page:    x.Number,
+			Content: make([]Text, len(jb.Runs)),
+		}
+		for i, run := range jb.Runs {
+			if run < 0 || run >= len(p.Texts) {
+				return nil, fmt.Errorf("block run %d out of range", run)
+			}
+			b.Content[i] = p.Texts[run]
+		}
+		p.Blocks = append(p.Blocks, b)
+	}
+	for _, ja := range x.Annotations {
+		p.Annotations = append(p.Annotations, Annotation{
+			Subtype:  ja.Subtype,
+			Rect:     ja.Rect.rect(),
+			Contents: ja.Contents,
+			Author:   ja.Author,
+			URI:      ja.URI,
+		})
+	}
+	return p, nil
+}
+
+// parseBlockType returns the BlockType whose String method returns s.
+func parseBlockType(s string) (BlockType, bool) {
+	for t := BlockUnknown; t <= BlockFooter; t++ {
+		if t.String() == s {
+			return t, true
+		}
+	}
+	return BlockUnknown, false
+}
+
+// A JSONDecoder reads a document written by JSONWriter one page at a
+// time.
+type JSONDecoder struct {
+	dec   *json.Decoder
+	doc   *JSONDocument
+	state int // jsonBeforePages, jsonInPages or jsonDone
+}
+
+const (
+	jsonBeforePages = iota
+	jsonInPages
+	jsonDone
+)
+
+// NewJSONDecoder returns a decoder reading from r.
+func NewJSONDecoder(r io.Reader) *JSONDecoder {
+	return &JSONDecoder{dec: json.NewDecoder(r)}
+}
+
+// Header reads the document up to its first page and returns it without
+// pages. The version must come before the pages, as JSONWriter writes it.
+func (d *JSONDecoder) Header() (*JSONDocument, error) {
+	if d.doc != nil {
+		return d.doc, nil
+	}
+	d.doc = &JSONDocument{Metadata: Metadata{Custom: make(map[string]string)}}
+	if err := d.expect(json.Delim('{')); err != nil {
+		return nil, err
+	}
+	for {
+		key, err := d.key()
+		if err != nil {
+			return nil, err
+		}
+		if key == "" {
+			d.state = jsonDone
+			break
+		}
+		if key == "pages" {
+			if err := d.expect(json.Delim('[')); err != nil {
+				return nil, err
+			}
+			d.state = jsonInPages
+			break
+		}
+		if err := d.field(key); err != nil {
+			return nil, err
+		}
+	}
+
+	major, _, _ := strings.Cut(d.doc.Version, ".")
+	if want, _, _ := strings.Cut(JSONSchemaVersion, "."); major != want {
+		return nil, fmt.Errorf("%w: %q", ErrJSONVersion, d.doc.Version)
+	}
+	return d.doc, nil
+}
+
+// NextPage returns the next page of the document, or io.EOF after the
+// last.
+func (d *JSONDecoder) NextPage() (*JSONPage, error) {
+	if _, err := d.Header(); err != nil {
+		return nil, err
+	}
+	if d.state == jsonDone {
+		return nil, io.EOF
+	}
+	if d.dec.More() {
+		var x jsonPage
+		if err := d.dec.Decode(&x); err != nil {
+			return nil, err
+		}
+		return x.page()
+	}
+
+	// Read the end of the pages and any properties that follow them
+	if err := d.expect(json.Delim(']')); err != nil {
+		return nil, err
+	}
+	for {
+		key, err := d.key()
+		if err != nil {
+			return nil, err
+		}
+		if key == "" {
+			break
+		}
+		if err := d.field(key); err != nil {
+			return nil, err
+		}
+	}
+	d.state = jsonDone
+	return nil, io.EOF
+}
+
+// key returns the next key of the document object, or "" at its end.
+func (d *JSONDecoder) key() (string, error) {
+	tok, err := d.dec.Token()
+	if err != nil {
+		return "", err
+	}
+	switch tok := tok.(type) {
+	case string:
+		return tok, nil
+	case json.Delim:
+		if tok == '}' {
+			return "", nil
+		}
+	}
+	return "", fmt.Errorf("unexpected %v in JSON document", tok)
+}
+
+// field reads the value of a document property other than the pages.
+func (d *JSONDecoder) field(key string) error {
+	switch key {
+	case "version":
+		return d.dec.Decode(&d.doc.Version)
+	case "info":
+		var info jsonInfo
+		if err := d.dec.Decode(&info); err != nil {
+			return err
+		}
+		meta, err := info.metadata()
+		if err != nil {
+			return err
+		}
+		d.doc.Metadata = meta
+		d.doc.PageCount = info.PageCount
+	case "outline":
+		var root jsonOutline
+		if err := d.dec.Decode(&root.Children); err != nil {
+			return err
+		}
+		d.doc.Outline = root.outline()
+	default:
+		// Properties of later minor versions
+		var skip json.RawMessage
+		return d.dec.Decode(&skip)
+	}
+	return nil
+}
+
+func (d *JSONDecoder) expect(want json.Delim) error {
+	tok, err := d.dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != want {
+		return fmt.Errorf("expected %v in JSON document, found %v", want, tok)
+	}
+	return nil
+}
+
+// DecodeJSON reads a whole document written by JSONWriter.
+func DecodeJSON(r io.Reader) (*JSONDocument, error) {
+	d := NewJSONDecoder(r)
+	for {
+		page, err := d.NextPage()
+		if err == io.EOF {
+			return d.doc, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		d.doc.Pages = append(d.doc.Pages, *page)
+	}
+}
+
+// ExtractJSON writes the selected pages to w as JSON. The extractor's
+// dedup options apply unless opts sets its own.
+func (e *Extractor) ExtractJSON(w io.Writer, opts JSONOptions) error {
+	if opts.Dedup == nil {
+		opts.Dedup = e.dedup
+	}
+	return e.writePages(NewJSONWriter(w, e.reader, opts))
+}
+
+// ProcessJSONStream writes the document to w as JSON, one page at a time.
+func (sp *StreamProcessor) ProcessJSONStream(reader *Reader, w io.Writer, opts JSONOptions) error {
+	jw := NewJSONWriter(w, reader, opts)
+	err := sp.ProcessPageStream(reader, func(ps PageStream) error {
+		return jw.WritePage(ps.PageNum, ps.Page)
+	})
+	if err != nil {
+		return err
+	}
+	return jw.Close()
+}
+
+// pageRotation normalizes a /Rotate value to 0, 90, 180 or 270, rounding
+// values that are not a multiple of 90 to the nearest one.
+func pageRotation(v Value) int {
+	n := int(math.Mod(math.Round(v.Float64()/90), 4))
+	return (n + 4) % 4 * 90
+}
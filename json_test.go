@@ -0,0 +1,310 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// validateJSONSchema checks value against the subset of JSON Schema the
+// shipped schema uses. Properties the schema does not declare are reported
+// too, since the writer must only write declared ones.
+func validateJSONSchema(root, schema map[string]any, value any, path string) []string {
+	if ref, ok := schema["$ref"].(string); ok {
+		def := strings.TrimPrefix(ref, "#/$defs/")
+		return validateJSONSchema(root, root["$defs"].(map[string]any)[def].(map[string]any), value, path)
+	}
+
+	var errs []string
+	fail := func(format string, args ...any) {
+		errs = append(errs, path+": "+fmt.Sprintf(format, args...))
+	}
+	if typ, ok := schema["type"].(string); ok {
+		okType := false
+		switch v := value.(type) {
+		case map[string]any:
+			okType = typ == "object"
+		case []any:
+			okType = typ == "array"
+		case string:
+			okType = typ == "string"
+		case bool:
+			okType = typ == "boolean"
+		case float64:
+			okType = typ == "number" || typ == "integer" && v == float64(int64(v))
+		}
+		if !okType {
+			fail("%v is not of type %s", value, typ)
+			return errs
+		}
+	}
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, e := range enum {
+			found = found || e == value
+		}
+		if !found {
+			fail("%v is not one of %v", value, enum)
+		}
+	}
+	if min, ok := schema["minimum"].(float64); ok && value.(float64) < min {
+		fail("%v is less than %v", value, min)
+	}
+
+	switch v := value.(type) {
+	case string:
+		if p, ok := schema["pattern"].(string); ok && !regexp.MustCompile(p).MatchString(v) {
+			fail("%q does not match %s", v, p)
+		}
+		if schema["format"] == "date-time" {
+			if _, err := time.Parse(time.RFC3339, v); err != nil {
+				fail("%q is not a date-time", v)
+			}
+		}
+	case []any:
+		if n, ok := schema["minItems"].(float64); ok && len(v) < int(n) {
+			fail("fewer than %v items", n)
+		}
+		if n, ok := schema["maxItems"].(float64); ok && len(v) > int(n) {
+			fail("more than %v items", n)
+		}
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range v {
+				errs = append(errs, validateJSONSchema(root, items, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	case map[string]any:
+		if req, ok := schema["required"].([]any); ok {
+			for _, name := range req {
+				if _, ok := v[name.(string)]; !ok {
+					fail("missing required property %s", name)
+				}
+			}
+		}
+		props, _ := schema["properties"].(map[string]any)
+		extra, _ := schema["additionalProperties"].(map[string]any)
+		for name, pv := range v {
+			switch ps, ok := props[name].(map[string]any); {
+			case ok:
+				errs = append(errs, validateJSONSchema(root, ps, pv, path+"."+name)...)
+			case extra != nil:
+				errs = append(errs, validateJSONSchema(root, extra, pv, path+"."+name)...)
+			default:
+				fail("undeclared property %s", name)
+			}
+		}
+	}
+	return errs
+}
+
+func validateJSONExport(t *testing.T, data []byte) []string {
+	t.Helper()
+	var schema, doc map[string]any
+	if err := json.Unmarshal(JSONSchema(), &schema); err != nil {
+		t.Fatalf("schema: %v", err)
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return []string{"invalid JSON: " + err.Error()}
+	}
+	errs := validateJSONSchema(schema, schema, doc, "$")
+	sort.Strings(errs)
+	return errs
+}
+
+func TestJSONExport(t *testing.T) {
+	r := annotatedPDF(t)
+	var sb strings.Builder
+	if err := NewExtractor(r).ExtractJSON(&sb, JSONOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	out := sb.String()
+	for _, err := range validateJSONExport(t, []byte(out)) {
+		t.Error(err)
+	}
+	for _, want := range []string{
+		`"version":"1.0"`,
+		`"creationDate":"2024-01-02T03:04:05Z"`,
+		`"custom":{"Department":"Audit"}`,
+		`"outline":[{"title":"Introduction","children":[{"title":"Background"}]},{"title":"Results"}]`,
+		`"cropBox":[10,10,600,780],"rotate":90`,
+		`"color":"#ff0000"`,
+		`"uri":"https://example.com/"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %s", want)
+		}
+	}
+
+	doc, err := DecodeJSON(strings.NewReader(out))
+	if err != nil {
+		t.Fatalf("DecodeJSON: %v", err)
+	}
+	meta, _ := r.GetMetadata()
+	if doc.Version != JSONSchemaVersion || doc.PageCount != 1 || !reflect.DeepEqual(doc.Metadata, meta) {
+		t.Errorf("decoded header = %q, %d, %+v; want metadata %+v", doc.Version, doc.PageCount, doc.Metadata, meta)
+	}
+	if !reflect.DeepEqual(doc.Outline, r.Outline()) {
+		t.Errorf("decoded outline = %+v", doc.Outline)
+	}
+	if len(doc.Pages) != 1 {
+		t.Fatalf("decoded %d pages", len(doc.Pages))
+	}
+
+	page := doc.Pages[0]
+	if page.Number != 1 || page.Rotate != 90 || page.MediaBox != (Rect{Max: Point{612, 792}}) ||
+		page.CropBox != (Rect{Point{10, 10}, Point{600, 780}}) {
+		t.Errorf("decoded geometry = %d %d %v %v", page.Number, page.Rotate, page.MediaBox, page.CropBox)
+	}
+	if want := r.Page(1).Content().Text; !reflect.DeepEqual(page.Texts, want) {
+		t.Errorf("decoded texts differ:\n got %+v\nwant %+v", page.Texts, want)
+	}
+	blocks, err := r.Page(1).classifyTextBlocks(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range blocks {
+		blocks[i].Page = 1
+	}
+	if !reflect.DeepEqual(page.Blocks, blocks) {
+		t.Errorf("decoded blocks differ:\n got %+v\nwant %+v", page.Blocks, blocks)
+	}
+	if !reflect.DeepEqual(page.Annotations, r.Page(1).Annotations()) {
+		t.Errorf("decoded annotations = %+v", page.Annotations)
+	}
+}
+
+func TestJSONPageRotation(t *testing.T) {
+	for _, tt := range []struct {
+		rotate string
+		want   int
+	}{
+		{"90", 90},
+		{"-90", 270},
+		{"450", 90},
+		{"100", 90},
+		{"44", 0},
+		{"-135.5", 180},
+		{"1e30", 0},
+		{"(x)", 0},
+	} {
+		data := buildPDF("/Root 1 0 R",
+			"<< /Type /Catalog /Pages 2 0 R >>",
+			"<< /Type /Pages /Count 1 /Kids [3 0 R] >>",
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Rotate "+tt.rotate+" >>",
+		)
+		r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var sb strings.Builder
+		if err := NewExtractor(r).ExtractJSON(&sb, JSONOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		doc, err := DecodeJSON(strings.NewReader(sb.String()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := doc.Pages[0].Rotate; got != tt.want {
+			t.Errorf("/Rotate %s = %d, want %d", tt.rotate, got, tt.want)
+		}
+	}
+}
+
+func TestJSONDecoderStreaming(t *testing.T) {
+	r := openTextPDF(t, "BT /F1 10 Tf 72 700 Td (one) Tj ET", "", "BT /F1 10 Tf 72 700 Td (three) Tj ET")
+	var sb strings.Builder
+	jw := NewJSONWriter(&sb, r, JSONOptions{})
+	for _, n := range []int{1, 2, 3} {
+		if err := jw.WritePage(n, r.Page(n)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := jw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := jw.WritePage(4, r.Page(1)); !errors.Is(err, ErrWriterClosed) {
+		t.Errorf("WritePage after Close = %v, want ErrWriterClosed", err)
+	}
+	for _, err := range validateJSONExport(t, []byte(sb.String())) {
+		t.Error(err)
+	}
+
+	d := NewJSONDecoder(strings.NewReader(sb.String()))
+	head, err := d.Header()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head.PageCount != 3 || len(head.Pages) != 0 {
+		t.Errorf("header = %+v", head)
+	}
+	var got []string
+	for {
+		p, err := d.NextPage()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		var words []string
+		for _, b := range p.Blocks {
+			words = append(words, b.Text)
+		}
+		got = append(got, fmt.Sprintf("%d:%s", p.Number, strings.Join(words, " ")))
+	}
+	if want := "1:one 2: 3:three"; strings.Join(got, " ") != want {
+		t.Errorf("pages = %q, want %q", strings.Join(got, " "), want)
+	}
+	if _, err := d.NextPage(); err != io.EOF {
+		t.Errorf("NextPage after the end = %v", err)
+	}
+}
+
+func TestJSONDecoderVersions(t *testing.T) {
+	// Later minor versions may add properties anywhere
+	later := `{"version":"1.7","info":{"pageCount":1,"language":"en"},"extra":[1,2],
+"pages":[{"number":1,"mediaBox":[0,0,10,10],"cropBox":[0,0,10,10],"texts":[{"text":"a","size":1,"x":0,"y":0,"width":1,"shadow":true}],
+"blocks":[{"type":"Title","level":1,"bounds":[0,0,1,1],"text":"a","runs":[0]}]}],"trailer":{}}`
+	doc, err := DecodeJSON(strings.NewReader(later))
+	if err != nil {
+		t.Fatalf("DecodeJSON of version 1.7: %v", err)
+	}
+	if len(doc.Pages) != 1 || doc.Pages[0].Blocks[0].Type != BlockTitle || doc.Pages[0].Blocks[0].Content[0].S != "a" {
+		t.Errorf("decoded %+v", doc.Pages)
+	}
+
+	for _, bad := range []string{
+		`{"version":"2.0","info":{"pageCount":0},"pages":[]}`,
+		`{"info":{"pageCount":0},"pages":[]}`,
+	} {
+		if _, err := DecodeJSON(strings.NewReader(bad)); !errors.Is(err, ErrJSONVersion) {
+			t.Errorf("DecodeJSON(%s) = %v, want ErrJSONVersion", bad, err)
+		}
+	}
+	for _, bad := range []string{
+		`{"version":"1.0","info":{"pageCount":1},"pages":[{"number":1,"mediaBox":[0,0,1,1],"cropBox":[0,0,1,1],"texts":[],"blocks":[{"type":"Title","bounds":[0,0,1,1],"text":"","runs":[3]}]}]}`,
+		`{"version":"1.0","info":{"pageCount":1},"pages":[{"number":1,"mediaBox":[0,0,1,1],"cropBox":[0,0,1,1],"texts":[{"text":"a","size":1,"x":0,"y":0,"width":1,"color":"red"}]}]}`,
+		`[]`,
+	} {
+		if _, err := DecodeJSON(strings.NewReader(bad)); err == nil {
+			t.Errorf("DecodeJSON(%s) succeeded", bad)
+		}
+	}
+
+	// The schema catches what the decoder would reject
+	if errs := validateJSONExport(t, []byte(`{"version":"2.0","info":{},"pages":[{"number":0}]}`)); len(errs) != 6 {
+		t.Errorf("schema violations = %q", errs)
+	}
+}
@@ -0,0 +1,415 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// LayoutKind identifies a level of the page layout tree.
+type LayoutKind int
+
+const (
+	LayoutRegion LayoutKind = iota // Column or other group of blocks
+	LayoutBlock                    // Paragraph-like cluster of lines
+	LayoutLine                     // Words sharing a baseline, or a vertical column of glyphs
+	LayoutWord                     // Glyphs with no space between them
+	LayoutGlyph                    // A single glyph
+
+	numLayoutKinds
+)
+
+// String returns the string representation of LayoutKind
+func (k LayoutKind) String() string {
+	switch k {
+	case LayoutRegion:
+		return "Region"
+	case LayoutBlock:
+		return "Block"
+	case LayoutLine:
+		return "Line"
+	case LayoutWord:
+		return "Word"
+	case LayoutGlyph:
+		return "Glyph"
+	default:
+		return "Unknown"
+	}
+}
+
+// layoutLineTolerance is the baseline difference, in points, below which
+// glyphs of a block share a line. It matches sortWithinBlockOptimized.
+const layoutLineTolerance = 3.0
+
+// A LayoutNode is one node of a PageLayout tree.
+type LayoutNode struct {
+	Kind     LayoutKind
+	Type     BlockType     // semantic type of the enclosing block
+	Level    int           // heading level of the enclosing block, if a title
+	Bounds   Rect          // bounding box, in points
+	Index    int           // position among the page's nodes of the same Kind, in reading order
+	Text     string        // text of the node and its descendants
+	Glyph    Text          // the glyph, for LayoutGlyph nodes
+	Parent   *LayoutNode   // enclosing node, nil for regions
+	Children []*LayoutNode // nodes of the next Kind, in reading order
+}
+
+// Ancestor returns the nearest enclosing node of the given kind,
+// or nil if there is none.
+func (n *LayoutNode) Ancestor(kind LayoutKind) *LayoutNode {
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p.Kind == kind {
+			return p
+		}
+	}
+	return nil
+}
+
+// Descendants returns the nodes of the given kind below n, in reading order.
+func (n *LayoutNode) Descendants(kind LayoutKind) []*LayoutNode {
+	if kind <= n.Kind {
+		return nil
+	}
+	var out []*LayoutNode
+	for _, c := range n.Children {
+		if c.Kind == kind {
+			out = append(out, c)
+		} else {
+			out = append(out, c.Descendants(kind)...)
+		}
+	}
+	return out
+}
+
+// A PageLayout is the text of a page organised as a tree of regions,
+// blocks, lines, words and glyphs, built on the block clustering and reading
+// order detection. Page.Words, Page.ClassifyTextBlocks and the exporters are
+// views over it. Moving the ClusterTextBlocks functions, GetTextByRow and
+// GetTextByColumn onto it is deferred: they still build their own
+// structures, and the row and column groupings keep grouping the strings of
+// the content stream by text matrix position rather than by glyph.
+type PageLayout struct {
+	Page    Rect          // the page box the layout was built for
+	Regions []*LayoutNode // top-level nodes, in reading order
+
+	nodes      [numLayoutKinds][]*LayoutNode
+	blocks     []*TextBlock // runs of each block in line order, including spaces
+	classified []ClassifiedBlock
+}
+
+// Layout builds the layout tree of the page.
+func (p Page) Layout() (*PageLayout, error) {
+	content, err := p.contentWithFonts(nil)
+	if err != nil {
+		return nil, err
+	}
+	return NewPageLayout(content.Text, p.bounds()), nil
+}
+
+// exportLayout builds the layout of the page within its crop box for the
+// exporters, after optionally collapsing overprinted text. It also returns
+// the rectangles drawn on the page.
+func (p Page) exportLayout(dedup *DedupOptions) (*PageLayout, []Rect, error) {
+	content, err := p.contentWithFonts(nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	texts := content.Text
+	if dedup != nil {
+		texts = DeduplicateTexts(texts, *dedup)
+	}
+	box, ok := p.cropBox()
+	if !ok {
+		box = p.bounds()
+	}
+	return NewPageLayout(texts, box), content.Rect, nil
+}
+
+// bounds returns the page's media box, or US Letter if it has none.
+func (p Page) bounds() Rect {
+	if r, ok := rectFromValue(p.findInherited("MediaBox")); ok {
+		return r
+	}
+	return Rect{Max: Point{612, 792}}
+}
+
+// NewPageLayout builds a layout tree from text runs in content stream order.
+// The page box is used to classify blocks; an empty box means US Letter.
+func NewPageLayout(texts []Text, page Rect) *PageLayout {
+	if page.Max.X <= page.Min.X || page.Max.Y <= page.Min.Y {
+		page = Rect{Max: Point{612, 792}}
+	}
+	l := &PageLayout{Page: page}
+
+	runs := make([]Text, 0, len(texts))
+	for _, t := range texts {
+		if t.S != "" {
+			runs = append(runs, t)
+		}
+	}
+	if len(runs) == 0 {
+		return l
+	}
+
+	clusters := clusterTextBlocks(runs)
+	order := make(map[*TextBlock]int, len(clusters))
+	for i, b := range detectReadingOrder(clusters) {
+		order[b] = i
+	}
+	regions := layoutRegions(clusters, order)
+
+	classifier := NewTextClassifier(runs, page.Max.X-page.Min.X, page.Max.Y-page.Min.Y)
+	for _, blocks := range regions {
+		region := &LayoutNode{Kind: LayoutRegion}
+		for _, cluster := range blocks {
+			l.addBlock(region, cluster, classifier)
+		}
+		if len(region.Children) == 0 {
+			continue
+		}
+		region.Bounds = region.Children[0].Bounds
+		texts := make([]string, len(region.Children))
+		for i, b := range region.Children {
+			region.Bounds = unionRect(region.Bounds, b.Bounds)
+			texts[i] = b.Text
+		}
+		region.Text = strings.Join(texts, "\n\n")
+		l.Regions = append(l.Regions, region)
+	}
+	PutTextBlocks(clusters)
+
+	l.index(l.Regions)
+	return l
+}
+
+// layoutRegions groups blocks into regions using the column detection, and
+// orders regions and the blocks inside them by reading order.
+func layoutRegions(blocks []*TextBlock, order map[*TextBlock]int) [][]*TextBlock {
+	seen := make(map[*TextBlock]bool, len(blocks))
+	var regions [][]*TextBlock
+	for _, col := range detectColumns(blocks) {
+		var region []*TextBlock
+		for _, b := range col {
+			if !seen[b] {
+				seen[b] = true
+				region = append(region, b)
+			}
+		}
+		if len(region) > 0 {
+			regions = append(regions, region)
+		}
+	}
+	// Blocks the column detection dropped become regions of their own
+	for _, b := range blocks {
+		if !seen[b] {
+			regions = append(regions, []*TextBlock{b})
+		}
+	}
+
+	for _, region := range regions {
+		sort.SliceStable(region, func(i, j int) bool {
+			return order[region[i]] < order[region[j]]
+		})
+	}
+	sort.SliceStable(regions, func(i, j int) bool {
+		return order[regions[i][0]] < order[regions[j][0]]
+	})
+	return regions
+}
+
+// addBlock appends the block node for cluster, with its lines, words and
+// glyphs, to region.
+func (l *PageLayout) addBlock(region *LayoutNode, cluster *TextBlock, classifier *TextClassifier) {
+	// The cluster belongs to the pool, so sort a copy of its runs
+	texts := sortWithinBlockOptimized(append([]Text(nil), cluster.Texts...))
+	tb := &TextBlock{
+		Texts:       texts,
+		MinX:        cluster.MinX,
+		MaxX:        cluster.MaxX,
+		MinY:        cluster.MinY,
+		MaxY:        cluster.MaxY,
+		AvgFontSize: cluster.AvgFontSize,
+	}
+	cb := classifier.classifyCluster(tb)
+
+	block := &LayoutNode{
+		Kind:   LayoutBlock,
+		Type:   cb.Type,
+		Level:  cb.Level,
+		Bounds: cb.Bounds,
+		Parent: region,
+	}
+
+	var lines []string
+	for _, runs := range layoutLines(texts) {
+		if line := newLayoutLine(block, runs); line != nil {
+			block.Children = append(block.Children, line)
+			lines = append(lines, line.Text)
+		}
+	}
+	if len(block.Children) == 0 {
+		return
+	}
+	block.Text = strings.Join(lines, "\n")
+
+	region.Children = append(region.Children, block)
+	l.blocks = append(l.blocks, tb)
+	l.classified = append(l.classified, cb)
+}
+
+// layoutLines splits the runs of a block, sorted in line order, into lines.
+func layoutLines(texts []Text) [][]Text {
+	// Vertical lines run down the page and share an X position instead
+	vertical := isVerticalTexts(texts)
+	lineOf := func(t Text) float64 {
+		if vertical {
+			return t.X
+		}
+		return t.Y
+	}
+
+	var lines [][]Text
+	start := 0
+	for i := 1; i <= len(texts); i++ {
+		if i < len(texts) && math.Abs(lineOf(texts[i])-lineOf(texts[start])) <= layoutLineTolerance {
+			continue
+		}
+		lines = append(lines, texts[start:i])
+		start = i
+	}
+	return lines
+}
+
+// newLayoutLine builds the line node for runs sharing a baseline, or
+// returns nil if they hold no words.
+func newLayoutLine(block *LayoutNode, texts []Text) *LayoutNode {
+	var wb wordBuilder
+	for _, t := range texts {
+		wb.addText(t)
+	}
+	wb.flush()
+	if len(wb.words) == 0 {
+		return nil
+	}
+
+	line := &LayoutNode{
+		Kind:   LayoutLine,
+		Type:   block.Type,
+		Level:  block.Level,
+		Bounds: wb.words[0].Bounds,
+		Parent: block,
+	}
+	words := make([]string, len(wb.words))
+	for i, w := range wb.words {
+		word := &LayoutNode{
+			Kind:   LayoutWord,
+			Type:   block.Type,
+			Level:  block.Level,
+			Bounds: w.Bounds,
+			Text:   w.Text,
+			Parent: line,
+		}
+		for _, g := range wb.glyphs[i] {
+			word.Children = append(word.Children, &LayoutNode{
+				Kind:   LayoutGlyph,
+				Type:   block.Type,
+				Level:  block.Level,
+				Bounds: glyphBounds(g, math.Max(math.Abs(g.FontSize), 1)),
+				Text:   g.S,
+				Glyph:  g,
+				Parent: word,
+			})
+		}
+		line.Children = append(line.Children, word)
+		line.Bounds = unionRect(line.Bounds, w.Bounds)
+		words[i] = w.Text
+	}
+	line.Text = strings.Join(words, " ")
+	return line
+}
+
+// index records nodes by kind in depth-first order and numbers them.
+func (l *PageLayout) index(nodes []*LayoutNode) {
+	for _, n := range nodes {
+		n.Index = len(l.nodes[n.Kind])
+		l.nodes[n.Kind] = append(l.nodes[n.Kind], n)
+		l.index(n.Children)
+	}
+}
+
+// Nodes returns all nodes of the given kind in reading order.
+func (l *PageLayout) Nodes(kind LayoutKind) []*LayoutNode {
+	if kind < 0 || kind >= numLayoutKinds {
+		return nil
+	}
+	return l.nodes[kind]
+}
+
+// NodesAt returns the nodes of the given kind whose bounds contain pt,
+// in reading order.
+func (l *PageLayout) NodesAt(pt Point, kind LayoutKind) []*LayoutNode {
+	var out []*LayoutNode
+	for _, n := range l.Nodes(kind) {
+		b := n.Bounds
+		if pt.X >= b.Min.X && pt.X <= b.Max.X && pt.Y >= b.Min.Y && pt.Y <= b.Max.Y {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// Text returns the text of the page, with blank lines between regions.
+func (l *PageLayout) Text() string {
+	texts := make([]string, len(l.Regions))
+	for i, r := range l.Regions {
+		texts[i] = r.Text
+	}
+	return strings.Join(texts, "\n\n")
+}
+
+// Texts returns the page's text runs in reading order, the same runs as
+// the page content with empty ones removed.
+func (l *PageLayout) Texts() []Text {
+	var out []Text
+	for _, b := range l.blocks {
+		out = append(out, b.Texts...)
+	}
+	return out
+}
+
+// Words returns the words of the page in reading order.
+func (l *PageLayout) Words() []Word {
+	nodes := l.nodes[LayoutWord]
+	words := make([]Word, len(nodes))
+	for i, n := range nodes {
+		g := n.Children[0].Glyph
+		words[i] = Word{
+			Text:      n.Text,
+			Bounds:    n.Bounds,
+			Font:      g.Font,
+			FontSize:  g.FontSize,
+			Vertical:  g.Vertical,
+			Bold:      g.Bold,
+			Italic:    g.Italic,
+			Underline: g.Underline,
+			Index:     n.Index,
+		}
+	}
+	return words
+}
+
+// Blocks returns the text blocks of the page in reading order, with the
+// runs of each block sorted into lines.
+func (l *PageLayout) Blocks() []*TextBlock {
+	return append([]*TextBlock(nil), l.blocks...)
+}
+
+// ClassifiedBlocks returns the page's blocks with their semantic types,
+// in reading order.
+func (l *PageLayout) ClassifiedBlocks() []ClassifiedBlock {
+	return append([]ClassifiedBlock(nil), l.classified...)
+}
@@ -0,0 +1,197 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"strings"
+	"testing"
+)
+
+const layoutTestContent = "BT /F2 24 Tf 72 680 Td (Annual Report) Tj ET " +
+	"BT /F1 10 Tf 72 640 Td (Left column one) Tj 0 -12 Td (left column two) Tj ET " +
+	"BT /F1 10 Tf 340 640 Td (Right column one) Tj 0 -12 Td (right column two) Tj ET"
+
+func TestPageLayoutTree(t *testing.T) {
+	r := openTextPDF(t, layoutTestContent)
+	layout, err := r.Page(1).Layout()
+	if err != nil {
+		t.Fatalf("Layout: %v", err)
+	}
+	if layout.Page.Max.X != 612 || layout.Page.Max.Y != 792 {
+		t.Errorf("Page = %+v", layout.Page)
+	}
+
+	var blocks []string
+	for _, b := range layout.Nodes(LayoutBlock) {
+		blocks = append(blocks, b.Text)
+	}
+	want := []string{"Annual Report", "Left column one\nleft column two", "Right column one\nright column two"}
+	if strings.Join(blocks, "|") != strings.Join(want, "|") {
+		t.Fatalf("blocks = %q, want %q", blocks, want)
+	}
+	if n := len(layout.Nodes(LayoutLine)); n != 5 {
+		t.Errorf("got %d lines, want 5", n)
+	}
+	if n := len(layout.Nodes(LayoutWord)); n != 14 {
+		t.Errorf("got %d words, want 14", n)
+	}
+
+	// Every node is reachable from a region and indexed in traversal order
+	for kind := LayoutRegion; kind <= LayoutGlyph; kind++ {
+		for i, n := range layout.Nodes(kind) {
+			if n.Kind != kind || n.Index != i {
+				t.Errorf("%v node %d has Kind %v Index %d", kind, i, n.Kind, n.Index)
+			}
+			if kind != LayoutRegion && n.Ancestor(LayoutRegion) == nil {
+				t.Errorf("%v node %q has no region", kind, n.Text)
+			}
+		}
+	}
+	var glyphs int
+	for _, region := range layout.Regions {
+		glyphs += len(region.Descendants(LayoutGlyph))
+	}
+	if glyphs != len(layout.Nodes(LayoutGlyph)) {
+		t.Errorf("regions hold %d glyphs, layout has %d", glyphs, len(layout.Nodes(LayoutGlyph)))
+	}
+
+	title := layout.Nodes(LayoutBlock)[0]
+	if title.Type != BlockTitle {
+		t.Errorf("first block type = %v, want Title", title.Type)
+	}
+	if w := title.Descendants(LayoutWord); len(w) != 2 || w[0].Type != BlockTitle {
+		t.Errorf("title words = %+v", w)
+	}
+}
+
+func TestPageLayoutQueries(t *testing.T) {
+	r := openTextPDF(t, layoutTestContent)
+	layout, err := r.Page(1).Layout()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// "two" on the second line of the right column
+	words := layout.NodesAt(Point{X: 340 + 14*5 + 2, Y: 630}, LayoutWord)
+	if len(words) != 1 || words[0].Text != "two" {
+		t.Fatalf("NodesAt = %+v", words)
+	}
+	line := words[0].Ancestor(LayoutLine)
+	if line == nil || line.Text != "right column two" {
+		t.Fatalf("line of word = %+v", line)
+	}
+	if words[0].Ancestor(LayoutBlock) != line.Parent {
+		t.Error("block ancestor differs from line parent")
+	}
+	if g := words[0].Children; len(g) != 3 || g[0].Glyph.S != "t" || g[0].Kind != LayoutGlyph {
+		t.Errorf("glyphs of word = %+v", g)
+	}
+	if got := words[0].Descendants(LayoutRegion); got != nil {
+		t.Errorf("Descendants above own kind = %v", got)
+	}
+
+	if got := layout.NodesAt(Point{X: 10, Y: 10}, LayoutBlock); len(got) != 0 {
+		t.Errorf("expected no block in the margin, got %d", len(got))
+	}
+	if got := layout.Nodes(LayoutKind(99)); got != nil {
+		t.Errorf("Nodes(99) = %v", got)
+	}
+}
+
+func TestPageLayoutViews(t *testing.T) {
+	r := openTextPDF(t, layoutTestContent)
+	page := r.Page(1)
+	layout, err := page.Layout()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	words, err := page.Words()
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodes := layout.Nodes(LayoutWord)
+	if len(words) != len(nodes) {
+		t.Fatalf("Words returned %d words, layout has %d", len(words), len(nodes))
+	}
+	for i, w := range words {
+		if w.Text != nodes[i].Text || w.Bounds != nodes[i].Bounds || w.Index != i {
+			t.Errorf("word %d = %+v, node %+v", i, w, nodes[i])
+		}
+	}
+
+	classified, err := page.ClassifyTextBlocks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	blocks := layout.Blocks()
+	if len(classified) != len(blocks) || len(blocks) != len(layout.Nodes(LayoutBlock)) {
+		t.Fatalf("%d classified blocks, %d blocks, %d block nodes",
+			len(classified), len(blocks), len(layout.Nodes(LayoutBlock)))
+	}
+	for i, cb := range classified {
+		if cb.Bounds != blocks[i].Bounds() || cb.Type != layout.Nodes(LayoutBlock)[i].Type {
+			t.Errorf("block %d: classified %+v, block %+v", i, cb.Bounds, blocks[i].Bounds())
+		}
+	}
+
+	var n int
+	for _, b := range blocks {
+		n += len(b.Texts)
+	}
+	if texts := layout.Texts(); len(texts) != n {
+		t.Errorf("Texts returned %d runs, blocks hold %d", len(texts), n)
+	}
+
+	text := layout.Text()
+	if !strings.HasPrefix(text, "Annual Report\n\n") || !strings.Contains(text, "Left column one\nleft column two") {
+		t.Errorf("Text = %q", text)
+	}
+}
+
+func TestNewPageLayoutEmpty(t *testing.T) {
+	layout := NewPageLayout([]Text{{S: ""}}, Rect{})
+	if len(layout.Regions) != 0 || len(layout.Words()) != 0 || layout.Text() != "" {
+		t.Errorf("unexpected layout for empty input: %+v", layout)
+	}
+	if layout.Page.Max.X != 612 {
+		t.Errorf("default page = %+v", layout.Page)
+	}
+	if LayoutLine.String() != "Line" || LayoutKind(-1).String() != "Unknown" {
+		t.Error("unexpected LayoutKind names")
+	}
+}
+
+func TestPageLayoutVertical(t *testing.T) {
+	// A horizontal headline over two vertical lines, 日本語 and 文字列,
+	// the left one drawn first. TJ kerning moves down the line.
+	r := openTextPDF(t, "BT /F1 18 Tf 300 700 Td (Headline) Tj ET "+
+		"BT /F3 12 Tf 380 640 Td <65875B575217> Tj ET "+
+		"BT /F3 12 Tf 400 640 Td [<65E5> 0 <672C8A9E>] TJ ET")
+	layout, err := r.Page(1).Layout()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var lines []string
+	for _, n := range layout.Nodes(LayoutLine) {
+		lines = append(lines, n.Text)
+	}
+	want := []string{"Headline", "日本語", "文字列"}
+	if strings.Join(lines, "|") != strings.Join(want, "|") {
+		t.Fatalf("lines = %q, want %q", lines, want)
+	}
+
+	// Glyphs advance one em down the line
+	glyphs := layout.Nodes(LayoutLine)[1].Descendants(LayoutGlyph)
+	for i, g := range glyphs {
+		if !g.Glyph.Vertical || g.Glyph.X != 400 || g.Glyph.Y != 640-12*float64(i) {
+			t.Errorf("glyph %d = %+v", i, g.Glyph)
+		}
+	}
+	if b := layout.Nodes(LayoutLine)[1].Bounds; b.Min.Y != 640-36 || b.Max.Y != 640 || b.Min.X != 394 {
+		t.Errorf("vertical line bounds = %+v", b)
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// LayoutTextOptions configures the layout-preserving text renderer.
+type LayoutTextOptions struct {
+	Pitch              float64 // Fixed width of a character cell, in points; zero uses the median glyph width
+	CompressBlankLines bool    // Collapse runs of blank lines into one
+	PageSeparator      string  // Written between pages; empty means a form feed
+	NoPageSeparator    bool    // Write nothing between pages, ignoring PageSeparator
+}
+
+func (o LayoutTextOptions) separator() string {
+	if o.NoPageSeparator {
+		return ""
+	}
+	if o.PageSeparator == "" {
+		return "\f"
+	}
+	return o.PageSeparator
+}
+
+// layoutCell is a glyph placed on the character grid.
+type layoutCell struct {
+	x, y, w, size float64
+	r             rune
+}
+
+// LayoutText renders text runs the way pdftotext -layout does: each glyph
+// is projected onto a character grid, so columns stay aligned and the
+// vertical gaps between lines become blank lines. The grid's columns are
+// as wide as the median glyph, or opts.Pitch, and its rows are 1.2 times
+// the median font size apart.
+func LayoutText(texts []Text, opts LayoutTextOptions) string {
+	var cells []layoutCell
+	for _, t := range texts {
+		n := utf8.RuneCountInString(t.S)
+		if n == 0 || t.Visibility == TextClipped {
+			continue
+		}
+		size := math.Abs(t.FontSize)
+		w := t.W / float64(n)
+		if w <= 0 {
+			w = size / 2
+		}
+		i := 0
+		for _, r := range t.S {
+			if !unicode.IsSpace(r) {
+				cells = append(cells, layoutCell{x: t.X + float64(i)*w, y: t.Y, w: w, size: size, r: r})
+			}
+			i++
+		}
+	}
+	if len(cells) == 0 {
+		return ""
+	}
+
+	widths := make([]float64, 0, len(cells))
+	sizes := make([]float64, 0, len(cells))
+	left := cells[0].x
+	for _, c := range cells {
+		if c.w > 0 {
+			widths = append(widths, c.w)
+		}
+		if c.size > 0 {
+			sizes = append(sizes, c.size)
+		}
+		left = math.Min(left, c.x)
+	}
+	pitch := opts.Pitch
+	if pitch <= 0 {
+		pitch = medianFloat(widths, 6)
+	}
+	size := medianFloat(sizes, 12)
+	lineHeight := 1.2 * size
+
+	// Rows are runs of glyphs whose baselines lie within half a font size
+	sort.SliceStable(cells, func(i, j int) bool { return cells[i].y > cells[j].y })
+	var rows [][]layoutCell
+	var baselines []float64
+	for i := 0; i < len(cells); {
+		j := i + 1
+		for j < len(cells) && cells[i].y-cells[j].y <= size/2 {
+			j++
+		}
+		rows = append(rows, cells[i:j])
+		baselines = append(baselines, cells[i].y)
+		i = j
+	}
+
+	var sb strings.Builder
+	blank := false
+	for i, row := range rows {
+		if i > 0 {
+			for n := int(math.Round((baselines[i-1]-baselines[i])/lineHeight)) - 1; n > 0; n-- {
+				if blank && opts.CompressBlankLines {
+					break
+				}
+				sb.WriteByte('\n')
+				blank = true
+			}
+		}
+		sb.WriteString(layoutRow(row, left, pitch))
+		sb.WriteByte('\n')
+		blank = false
+	}
+	return sb.String()
+}
+
+// layoutRow places the glyphs of a row in their grid columns. A glyph
+// never overwrites another, and glyphs separated by a word space keep at
+// least one blank column between them.
+func layoutRow(row []layoutCell, left, pitch float64) string {
+	sort.SliceStable(row, func(i, j int) bool { return row[i].x < row[j].x })
+	var line []rune
+	prevEnd := math.Inf(-1)
+	for _, c := range row {
+		n := len(line)
+		col := max(int(math.Round((c.x-left)/pitch)), n)
+		if col == n && n > 0 && c.x-prevEnd >= pitch/2 {
+			col++
+		}
+		for len(line) < col {
+			line = append(line, ' ')
+		}
+		line = append(line, c.r)
+		prevEnd = c.x + c.w
+	}
+	return string(line)
+}
+
+// medianFloat returns the median of values, or def if there are none.
+func medianFloat(values []float64, def float64) float64 {
+	if len(values) == 0 {
+		return def
+	}
+	s := append([]float64(nil), values...)
+	sort.Float64s(s)
+	return s[len(s)/2]
+}
+
+// LayoutText renders the text of the page with its layout preserved. See
+// the LayoutText function.
+func (p Page) LayoutText(opts LayoutTextOptions) (string, error) {
+	return p.layoutText(opts, nil)
+}
+
+func (p Page) layoutText(opts LayoutTextOptions, dedup *DedupOptions) (string, error) {
+	content, err := p.contentWithFonts(nil)
+	if err != nil {
+		return "", err
+	}
+	texts := content.Text
+	if dedup != nil {
+		texts = DeduplicateTexts(texts, *dedup)
+	}
+	return LayoutText(texts, opts), nil
+}
@@ -0,0 +1,100 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"strings"
+	"testing"
+)
+
+// formPage lays out labels and values in two columns, then a note after a
+// gap of three lines. Its glyphs are 5 points wide.
+const formPage = "BT /F1 10 Tf 72 700 Td (Name:) Tj 200 0 Td (Alice) Tj ET " +
+	"BT /F1 10 Tf 72 686 Td (Total:) Tj 200 0 Td (1234) Tj ET " +
+	"BT /F1 10 Tf 92 650 Td (Notes here) Tj ET"
+
+func TestPageLayoutText(t *testing.T) {
+	r := openTextPDF(t, formPage)
+	tests := []struct {
+		opts LayoutTextOptions
+		want []string
+	}{
+		{LayoutTextOptions{}, []string{
+			"Name:" + strings.Repeat(" ", 35) + "Alice",
+			"Total:" + strings.Repeat(" ", 34) + "1234",
+			"",
+			"",
+			"    Notes here",
+		}},
+		{LayoutTextOptions{CompressBlankLines: true}, []string{
+			"Name:" + strings.Repeat(" ", 35) + "Alice",
+			"Total:" + strings.Repeat(" ", 34) + "1234",
+			"",
+			"    Notes here",
+		}},
+		{LayoutTextOptions{Pitch: 10}, []string{
+			"Name:" + strings.Repeat(" ", 15) + "Alice",
+			"Total:" + strings.Repeat(" ", 14) + "1234",
+			"",
+			"",
+			"  Notes here",
+		}},
+	}
+	for _, tt := range tests {
+		got, err := r.Page(1).LayoutText(tt.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := strings.Join(tt.want, "\n") + "\n"; got != want {
+			t.Errorf("LayoutText(%+v) =\n%s\nwant\n%s", tt.opts, got, want)
+		}
+	}
+}
+
+func TestLayoutTextGrid(t *testing.T) {
+	// A run of several glyphs is spread over its width
+	texts := []Text{{FontSize: 12, X: 0, Y: 100, W: 18, S: "abc"}}
+	// A word space keeps words apart even when their columns would touch
+	texts = append(texts, Text{FontSize: 12, X: 21.5, Y: 100, W: 6, S: "d"})
+	// Glyphs sharing a cell do not overwrite one another
+	texts = append(texts, glyphRun("xy", 0, 80)...)
+	texts = append(texts, Text{FontSize: 12, X: 1, Y: 80, W: 6, S: "z"})
+	// Clipped text and empty runs take no cells
+	texts = append(texts, Text{FontSize: 12, X: 60, Y: 100, W: 6, S: "q", Visibility: TextClipped}, Text{Y: 90})
+
+	if got, want := LayoutText(texts, LayoutTextOptions{}), "abc d\nxzy\n"; got != want {
+		t.Errorf("LayoutText = %q, want %q", got, want)
+	}
+	if got := LayoutText(nil, LayoutTextOptions{}); got != "" {
+		t.Errorf("LayoutText(nil) = %q", got)
+	}
+}
+
+func TestExtractLayoutText(t *testing.T) {
+	r := openTextPDF(t, "BT /F1 10 Tf 72 700 Td (one) Tj ET", "BT /F1 10 Tf 72 700 Td (two) Tj ET")
+	got, err := NewExtractor(r).ExtractLayoutText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "one\n\ftwo\n"; got != want {
+		t.Errorf("ExtractLayoutText = %q, want %q", got, want)
+	}
+
+	res, err := NewExtractor(r).Mode(ModeLayout).Pages(2, 1).LayoutOptions(LayoutTextOptions{PageSeparator: "----\n"}).Extract()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "two\n----\none\n"; res.Text != want {
+		t.Errorf("Extract in ModeLayout = %q, want %q", res.Text, want)
+	}
+
+	res, err = NewExtractor(r).Mode(ModeLayout).LayoutOptions(LayoutTextOptions{NoPageSeparator: true}).Extract()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "one\ntwo\n"; res.Text != want {
+		t.Errorf("Extract with NoPageSeparator = %q, want %q", res.Text, want)
+	}
+}
@@ -49,6 +49,10 @@ type buffer struct {
 	// Context support for cancellation
 	ctxChecker *contextChecker
 	limits     *ParseLimits
+	// Recovered malformations are recorded in warnings, at warnAt if the
+	// data is not read from the file itself, such as an object stream
+	warnings *WarningCollector
+	warnAt   int64
 }
 
 // newBuffer returns a new buffer reading from r at the given offset.
@@ -185,6 +189,7 @@ func (b *buffer) readToken() token {
 		if isDelim(c) {
 			// Tolerate unexpected delimiter in corrupted PDFs
 			// Return nil to signal end of token stream
+			b.warn(WarnUnexpectedToken, "unexpected %q", c)
 			return nil
 		}
 		b.unreadByte()
@@ -481,6 +486,7 @@ func (b *buffer) readHexStringSIMDAdvanced() token {
 	// Collect hex data for batch processing
 	var hexData []byte
 	collecting := true
+	invalid := false
 
 	for collecting {
 		// Check size limit
@@ -506,9 +512,16 @@ func (b *buffer) readHexStringSIMDAdvanced() token {
 				break
 			}
 
-			// Collect hex character
+			// Collect hex character; invalid digits are skipped when decoding
+			if unhex(c) < 0 {
+				invalid = true
+			}
 			hexData = append(hexData, c)
 		}
+		if !collecting {
+			// Reloading now would discard the bytes after '>'
+			break
+		}
 
 		// Need more data - also check cancellation before blocking on reload
 		if b.ctxChecker != nil && b.ctxChecker.CheckNow() {
@@ -516,13 +529,21 @@ func (b *buffer) readHexStringSIMDAdvanced() token {
 			return nil
 		}
 		if !b.reload() {
+			b.warn(WarnUnterminated, "hex string lacks >")
 			collecting = false
 		}
 	}
 
+	if invalid {
+		b.warn(WarnBadHexString, "hex string has invalid digits, which are skipped")
+	}
+
 	// Process collected hex data using SIMD operations
 	if len(hexData) > 0 {
 		result, err := HexDecodeSIMD("<" + string(hexData) + ">")
+		if err != nil {
+			b.warn(WarnBadHexString, "%v", err)
+		}
 		if err == nil && len(result) > 0 {
 			// Apply size limit before appending
 			if maxBytes > 0 && len(tmp)+len(result) > maxBytes {
@@ -632,6 +653,9 @@ Loop:
 			}
 		}
 	}
+	if depth > 0 {
+		b.warn(WarnUnterminated, "string lacks )")
+	}
 	b.tmp = tmp
 	return string(tmp)
 }
@@ -899,10 +923,12 @@ func (b *buffer) readObject() object {
 			return nil
 		case "endobj", "endstream", "stream":
 			// Tolerate these keywords appearing unexpectedly in corrupted PDFs
+			b.warn(WarnUnexpectedToken, "unexpected %s where an object belongs", kw)
 			return nil
 		}
 		// Return the keyword itself for other unexpected keywords
 		// This allows the caller to handle it appropriately
+		b.warn(WarnUnexpectedToken, "unexpected %s where an object belongs", kw)
 		return nil
 	}
 
@@ -932,6 +958,7 @@ func (b *buffer) readObject() object {
 					if tok4 != keyword("endobj") {
 						// Tolerate missing endobj - common in corrupted PDFs
 						// Just unread the token and continue
+						b.warn(WarnMissingEndobj, "object definition lacks endobj")
 						if tok4 != nil && tok4 != io.EOF {
 							b.unreadToken(tok4)
 						}
@@ -956,6 +983,7 @@ func (b *buffer) readArray() object {
 		}
 		if tok == io.EOF {
 			// Tolerate unterminated array, return what we have
+			b.warn(WarnUnterminated, "array lacks ]")
 			break
 		}
 		if len(x) >= maxArrayElements {
@@ -976,11 +1004,13 @@ func (b *buffer) readDict() object {
 			break
 		}
 		if tok == io.EOF {
+			b.warn(WarnUnterminated, "dictionary lacks >>")
 			break
 		}
 		n, ok := tok.(name)
 		if !ok {
 			// When encountering non-name key, possibly corrupted or missing ">>"/"stream", fall back and end current dictionary to avoid panic
+			b.warn(WarnUnexpectedToken, "dictionary key is not a name")
 			b.unreadToken(tok)
 			break
 		}
@@ -1007,6 +1037,7 @@ func (b *buffer) readDict() object {
 	default:
 		// Some corrupted PDFs lack newline after stream, tolerate and fall back one byte to treat it as data start
 		b.unreadByte()
+		b.warn(WarnStreamEOL, "no end-of-line marker after stream")
 	}
 
 	return stream{x, b.objptr, b.readOffset()}
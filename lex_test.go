@@ -443,3 +443,17 @@ func TestReadObjectMissingEndobj(t *testing.T) {
 		})
 	}
 }
+
+func TestReadHexStringKeepsFollowingTokens(t *testing.T) {
+	b := newBuffer(strings.NewReader("<65E5 672C> Tj ET"), 0)
+	b.allowEOF = true
+
+	if tok := b.readToken(); tok != "\x65\xe5\x67\x2c" {
+		t.Fatalf("hex string = %q", tok)
+	}
+	for _, want := range []keyword{"Tj", "ET"} {
+		if tok := b.readToken(); tok != want {
+			t.Fatalf("token after hex string = %v, want %v", tok, want)
+		}
+	}
+}
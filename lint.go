@@ -0,0 +1,533 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Severity is how serious a Diagnostic is.
+type Severity int
+
+const (
+	SeverityError   Severity = iota // the file does not conform to the specification
+	SeverityWarning                 // readers usually cope, but the file is suspect
+	SeverityInfo                    // harmless, such as an object nothing refers to
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	case SeverityInfo:
+		return "info"
+	}
+	return "unknown"
+}
+
+// MarshalText encodes s as its name, so that diagnostics marshal to JSON
+// readably.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Diagnostic codes reported by Lint.
+const (
+	LintXrefRecovered     = "xref-recovered"     // the cross-reference table was rebuilt by scanning the file
+	LintXrefOffset        = "xref-offset"        // an xref entry does not point at an object definition
+	LintObjectID          = "object-id"          // the object found differs in number or generation from its xref entry
+	LintObjectStream      = "object-stream"      // an object stream is missing or does not hold the object
+	LintStreamLength      = "stream-length"      // the Length of a stream does not match its data
+	LintMissingKey        = "missing-key"        // a dictionary lacks a key its type requires
+	LintWrongType         = "wrong-type"         // an entry has the wrong type of value
+	LintPageTree          = "page-tree"          // the page tree is inconsistent
+	LintDanglingReference = "dangling-reference" // a reference to an object that does not exist
+	LintUnusedObject      = "unused-object"      // an object that nothing refers to
+)
+
+// A Diagnostic is a problem Lint found in the structure of a file.
+type Diagnostic struct {
+	Code       string   `json:"code"` // one of the Lint constants
+	Severity   Severity `json:"severity"`
+	Object     int      `json:"object"` // object number, or 0 for the file or trailer
+	Generation int      `json:"generation"`
+	Offset     int64    `json:"offset"` // byte offset in the file, or -1 if unknown
+	Message    string   `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	var where string
+	if d.Object != 0 {
+		where = fmt.Sprintf(" %d %d R", d.Object, d.Generation)
+	}
+	if d.Offset >= 0 {
+		where += fmt.Sprintf(" @%d", d.Offset)
+	}
+	return fmt.Sprintf("%s [%s]%s: %s", d.Severity, d.Code, where, d.Message)
+}
+
+// Lint checks the structure of the file against the object graph as parsed:
+// that the cross-reference entries point at the objects they list, that
+// stream lengths are right, that dictionaries of the common types have the
+// keys they require, that the page tree is consistent, and that every
+// reference resolves and every object is used. It returns the problems
+// found in object number order; nil means none.
+//
+// The offset of an object held in an object stream is that of the stream.
+func (r *Reader) Lint() []Diagnostic {
+	l := &linter{r: r, seen: make(map[objptr]bool)}
+	l.checkXref()
+	l.checkTrailer()
+	l.walk()
+	l.checkPageTree()
+	l.checkUnused()
+	sort.SliceStable(l.diags, func(i, j int) bool {
+		return l.diags[i].Object < l.diags[j].Object
+	})
+	return l.diags
+}
+
+type linter struct {
+	r     *Reader
+	diags []Diagnostic
+	seen  map[objptr]bool // objects reached from the trailer
+}
+
+func (l *linter) report(code string, sev Severity, ptr objptr, format string, args ...interface{}) {
+	l.diags = append(l.diags, Diagnostic{
+		Code:       code,
+		Severity:   sev,
+		Object:     int(ptr.id),
+		Generation: int(ptr.gen),
+		Offset:     l.offset(ptr),
+		Message:    fmt.Sprintf(format, args...),
+	})
+}
+
+// offset returns the byte offset of the object ptr, or of the object
+// stream holding it, or -1.
+func (l *linter) offset(ptr objptr) int64 {
+	if ptr.id == 0 || !l.exists(ptr) {
+		return -1
+	}
+	x := l.r.xref[ptr.id]
+	if x.inStream {
+		if x.stream.id < uint32(len(l.r.xref)) && !l.r.xref[x.stream.id].inStream {
+			return l.r.xref[x.stream.id].offset
+		}
+		return -1
+	}
+	return x.offset
+}
+
+// exists reports whether the cross-reference table lists ptr as in use.
+func (l *linter) exists(ptr objptr) bool {
+	if ptr.id == 0 || ptr.id >= uint32(len(l.r.xref)) {
+		return false
+	}
+	x := l.r.xref[ptr.id]
+	return x.ptr == ptr && (x.inStream || x.offset != 0)
+}
+
+// inUse returns the in-use entries of the cross-reference table.
+func (l *linter) inUse() []objptr {
+	var ptrs []objptr
+	for i, x := range l.r.xref {
+		if ptr := (objptr{uint32(i), x.ptr.gen}); x.ptr.id == uint32(i) && l.exists(ptr) {
+			ptrs = append(ptrs, ptr)
+		}
+	}
+	return ptrs
+}
+
+// safely runs f, reporting whether it completed: the lexer panics on some
+// malformed input.
+func safely(f func()) (ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+	f()
+	return true
+}
+
+// checkXref checks that each entry of the cross-reference table leads to
+// the object it lists, and the Length of each stream.
+func (l *linter) checkXref() {
+	r := l.r
+	if r.startxref == 0 {
+		l.report(LintXrefRecovered, SeverityWarning, objptr{}, "cross-reference table is missing or damaged and was rebuilt by scanning the file")
+	}
+	objStreams := make(map[uint32]map[uint32]bool)
+	for _, ptr := range l.inUse() {
+		x := r.xref[ptr.id]
+		if x.inStream {
+			ids, ok := objStreams[x.stream.id]
+			if !ok {
+				ids = l.objectStream(x.stream)
+				objStreams[x.stream.id] = ids
+			}
+			if ids != nil && !ids[ptr.id] {
+				l.report(LintObjectStream, SeverityError, ptr, "object stream %d 0 R does not hold object %d", x.stream.id, ptr.id)
+			}
+			continue
+		}
+		if x.offset <= 0 || x.offset >= r.end {
+			l.report(LintXrefOffset, SeverityError, ptr, "offset %d is outside the file", x.offset)
+			continue
+		}
+		var def objdef
+		var isDef bool
+		safely(func() {
+			b := newBuffer(io.NewSectionReader(r.f, x.offset, r.end-x.offset), x.offset)
+			defer PutPDFBuffer(b)
+			def, isDef = b.readObject().(objdef)
+		})
+		if !isDef {
+			l.report(LintXrefOffset, SeverityError, ptr, "no object definition at offset %d", x.offset)
+			continue
+		}
+		if def.ptr != ptr {
+			l.report(LintObjectID, SeverityError, ptr, "xref entry for %d %d R points at object %d %d", ptr.id, ptr.gen, def.ptr.id, def.ptr.gen)
+		}
+		if s, ok := def.obj.(stream); ok {
+			l.checkStreamLength(ptr, s)
+		}
+	}
+}
+
+// objectStream returns the object numbers the object stream ptr holds, or
+// nil, having reported why, if it is not an object stream.
+func (l *linter) objectStream(ptr objptr) map[uint32]bool {
+	var ids map[uint32]bool
+	var strm Value
+	safely(func() {
+		strm = l.r.resolve(objptr{}, ptr)
+	})
+	if strm.Kind() != Stream || strm.Key("Type").Name() != "ObjStm" {
+		l.report(LintObjectStream, SeverityError, ptr, "object %d is not an object stream", ptr.id)
+		return nil
+	}
+	safely(func() {
+		rc := strm.Reader()
+		defer rc.Close()
+		b := newBuffer(rc, 0)
+		defer PutPDFBuffer(b)
+		b.allowEOF = true
+		ids = make(map[uint32]bool)
+		for range strm.Key("N").Int64() {
+			id, ok1 := b.readToken().(int64)
+			_, ok2 := b.readToken().(int64)
+			if !ok1 || !ok2 {
+				break
+			}
+			ids[uint32(id)] = true
+		}
+	})
+	if ids == nil {
+		l.report(LintObjectStream, SeverityError, ptr, "cannot read the index of object stream %d", ptr.id)
+	}
+	return ids
+}
+
+// checkStreamLength checks that the data of s, Length bytes from its start,
+// is followed by endstream.
+func (l *linter) checkStreamLength(ptr objptr, s stream) {
+	var length Value
+	safely(func() {
+		length = l.r.resolve(ptr, s.hdr["Length"])
+	})
+	if length.Kind() != Integer {
+		if s.hdr["Length"] == nil {
+			l.report(LintMissingKey, SeverityError, ptr, "stream has no Length")
+		} else {
+			l.report(LintWrongType, SeverityError, ptr, "stream Length is not an integer")
+		}
+		return
+	}
+	n := length.Int64()
+	if n >= 0 && l.endstreamAt(s.offset+n) {
+		return
+	}
+	actual := l.findEndstream(s.offset)
+	if actual < 0 {
+		l.report(LintStreamLength, SeverityError, ptr, "stream Length is %d, and no endstream follows the data", n)
+		return
+	}
+	l.report(LintStreamLength, SeverityError, ptr, "stream Length is %d, but the data is %d bytes", n, actual)
+}
+
+// endstreamAt reports whether endstream follows offset, after at most an
+// end-of-line marker and some white space.
+func (l *linter) endstreamAt(offset int64) bool {
+	buf := make([]byte, 32)
+	n, _ := l.r.f.ReadAt(buf, offset)
+	buf = buf[:n]
+	i := 0
+	for i < len(buf) && isSpace(buf[i]) {
+		i++
+	}
+	return bytes.HasPrefix(buf[i:], []byte("endstream"))
+}
+
+// findEndstream returns the length of the stream data starting at offset,
+// up to the end-of-line marker before the next endstream, or -1 if there
+// is none.
+func (l *linter) findEndstream(offset int64) int64 {
+	const chunk = 64 << 10
+	kw := []byte("endstream")
+	buf := make([]byte, chunk+len(kw))
+	for pos := offset; pos < l.r.end; pos += chunk {
+		n, _ := l.r.f.ReadAt(buf, pos)
+		i := bytes.Index(buf[:n], kw)
+		if i < 0 {
+			if n < len(buf) {
+				break
+			}
+			continue
+		}
+		end := pos + int64(i)
+		if i > 0 && buf[i-1] == '\n' {
+			end--
+			i--
+		}
+		if i > 0 && buf[i-1] == '\r' {
+			end--
+		}
+		return end - offset
+	}
+	return -1
+}
+
+// checkTrailer checks the keys the trailer requires.
+func (l *linter) checkTrailer() {
+	r := l.r
+	trailer := r.Trailer()
+	if size := trailer.Key("Size"); size.Kind() != Integer {
+		l.report(LintMissingKey, SeverityError, objptr{}, "trailer has no Size")
+	} else if size.Int64() < int64(len(l.inUse())) {
+		l.report(LintWrongType, SeverityWarning, objptr{}, "trailer Size %d is less than the number of objects", size.Int64())
+	}
+	root, ok := r.trailer["Root"].(objptr)
+	if !ok {
+		l.report(LintMissingKey, SeverityError, objptr{}, "trailer has no indirect Root")
+		return
+	}
+	if t := trailer.Key("Root").Key("Type").Name(); l.exists(root) && t != "Catalog" {
+		l.report(LintWrongType, SeverityError, root, "document catalog has Type %q, not Catalog", t)
+	}
+}
+
+// walk visits every object reachable from the trailer, reporting dangling
+// references and checking the keys of each dictionary.
+func (l *linter) walk() {
+	var queue []objptr
+	var scan func(from objptr, x object)
+	scan = func(from objptr, x object) {
+		switch x := x.(type) {
+		case objptr:
+			if l.seen[x] {
+				return
+			}
+			if !l.exists(x) {
+				l.report(LintDanglingReference, SeverityWarning, from, "reference to missing object %d %d R", x.id, x.gen)
+				return
+			}
+			l.seen[x] = true
+			queue = append(queue, x)
+		case dict:
+			for _, k := range sortedKeys(x) {
+				scan(from, x[name(k)])
+			}
+		case array:
+			for _, v := range x {
+				scan(from, v)
+			}
+		case stream:
+			scan(from, x.hdr)
+		}
+	}
+
+	if l.exists(l.r.trailerptr) {
+		l.seen[l.r.trailerptr] = true
+	}
+	scan(objptr{}, l.r.trailer)
+	for len(queue) > 0 {
+		ptr := queue[0]
+		queue = queue[1:]
+		var v Value
+		safely(func() {
+			v = l.r.resolve(objptr{}, ptr)
+		})
+		if v.data == nil {
+			continue
+		}
+		l.checkKeys(ptr, v)
+		scan(ptr, v.data)
+	}
+}
+
+func sortedKeys(d dict) []string {
+	keys := make([]string, 0, len(d))
+	for k := range d {
+		keys = append(keys, string(k))
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// requiredKeys lists the keys each type of dictionary requires, besides
+// pages, which inherit some and are checked with the page tree.
+var requiredKeys = map[string][]string{
+	"Catalog":        {"Pages"},
+	"Pages":          {"Kids", "Count"},
+	"Font":           {"Subtype"},
+	"FontDescriptor": {"FontName", "Flags", "ItalicAngle"},
+	"Annot":          {"Subtype", "Rect"},
+	"ObjStm":         {"N", "First"},
+	"XRef":           {"Size", "W"},
+	"Image":          {"Width", "Height"},
+	"Form":           {"BBox"},
+}
+
+// requiredFontKeys lists the keys each subtype of font requires.
+var requiredFontKeys = map[string][]string{
+	"Type0":    {"BaseFont", "Encoding", "DescendantFonts"},
+	"Type1":    {"BaseFont"},
+	"MMType1":  {"BaseFont"},
+	"TrueType": {"BaseFont"},
+	"Type3":    {"FontBBox", "FontMatrix", "CharProcs", "Encoding", "FirstChar", "LastChar", "Widths"},
+}
+
+// checkKeys checks that the dictionary or stream v, object ptr, has the
+// keys its type requires.
+func (l *linter) checkKeys(ptr objptr, v Value) {
+	typ := v.Key("Type").Name()
+	if typ == "XObject" || typ == "" && v.Kind() == Stream {
+		typ = v.Key("Subtype").Name()
+	}
+	need := requiredKeys[typ]
+	if typ == "Font" {
+		need = append(need, requiredFontKeys[v.Key("Subtype").Name()]...)
+	}
+	if typ == "Image" && !v.Key("ImageMask").Bool() && !hasFilter(v, "JPXDecode") {
+		need = append(need, "BitsPerComponent")
+	}
+	for _, k := range need {
+		if v.Key(k).IsNull() {
+			l.report(LintMissingKey, SeverityError, ptr, "%s dictionary has no %s", typ, k)
+		}
+	}
+}
+
+// hasFilter reports whether the stream v is encoded with the named filter.
+func hasFilter(v Value, filter string) bool {
+	f := v.Key("Filter")
+	if f.Name() == filter {
+		return true
+	}
+	for i := 0; i < f.Len(); i++ {
+		if f.Index(i).Name() == filter {
+			return true
+		}
+	}
+	return false
+}
+
+// checkPageTree walks the page tree from the catalog, checking the type,
+// Parent and Count of each node and the inherited keys of each page.
+func (l *linter) checkPageTree() {
+	rootptr, _ := l.r.trailer["Root"].(objptr)
+	root, ok := l.r.resolve(objptr{}, rootptr).data.(dict)
+	if !ok {
+		return
+	}
+	pages, ok := root["Pages"].(objptr)
+	if !ok {
+		if root["Pages"] != nil {
+			l.report(LintWrongType, SeverityError, rootptr, "catalog Pages is not an indirect reference")
+		}
+		return
+	}
+	visited := make(map[objptr]bool)
+	var walk func(ptr, parent objptr, mediaBox, resources bool) int
+	walk = func(ptr, parent objptr, mediaBox, resources bool) int {
+		if visited[ptr] {
+			l.report(LintPageTree, SeverityError, parent, "page tree node %d %d R appears more than once", ptr.id, ptr.gen)
+			return 0
+		}
+		visited[ptr] = true
+		var v Value
+		safely(func() {
+			v = l.r.resolve(objptr{}, ptr)
+		})
+		if v.Kind() != Dict {
+			return 0 // dangling, reported by walk
+		}
+		if parent != (objptr{}) {
+			if p, ok := v.data.(dict)["Parent"].(objptr); !ok {
+				l.report(LintMissingKey, SeverityError, ptr, "page tree node has no Parent")
+			} else if p != parent {
+				l.report(LintPageTree, SeverityError, ptr, "Parent is %d %d R, but the node is a kid of %d %d R", p.id, p.gen, parent.id, parent.gen)
+			}
+		}
+		mediaBox = mediaBox || !v.Key("MediaBox").IsNull()
+		resources = resources || !v.Key("Resources").IsNull()
+		switch typ := v.Key("Type").Name(); typ {
+		case "Page":
+			if !mediaBox {
+				l.report(LintMissingKey, SeverityError, ptr, "page has no MediaBox, nor inherits one")
+			}
+			if !resources {
+				l.report(LintMissingKey, SeverityWarning, ptr, "page has no Resources, nor inherits them")
+			}
+			return 1
+		case "Pages":
+			kids, _ := v.Key("Kids").data.(array)
+			count := 0
+			for _, kid := range kids {
+				p, ok := kid.(objptr)
+				if !ok {
+					l.report(LintPageTree, SeverityError, ptr, "Kids holds a direct object")
+					continue
+				}
+				count += walk(p, ptr, mediaBox, resources)
+			}
+			if c := v.Key("Count"); c.Kind() == Integer && c.Int64() != int64(count) {
+				l.report(LintPageTree, SeverityError, ptr, "Count is %d, but the subtree has %d pages", c.Int64(), count)
+			}
+			return count
+		default:
+			l.report(LintPageTree, SeverityError, ptr, "page tree node has Type %q, not Page or Pages", typ)
+			return 0
+		}
+	}
+	walk(pages, objptr{}, false, false)
+}
+
+// checkUnused reports the objects not reached from the trailer, other than
+// object and cross-reference streams and the linearization dictionary,
+// which nothing refers to by design.
+func (l *linter) checkUnused() {
+	for _, ptr := range l.inUse() {
+		if l.seen[ptr] {
+			continue
+		}
+		var v Value
+		safely(func() {
+			v = l.r.resolve(objptr{}, ptr)
+		})
+		if t := v.Key("Type").Name(); t == "ObjStm" || t == "XRef" || !v.Key("Linearized").IsNull() {
+			continue
+		}
+		l.report(LintUnusedObject, SeverityInfo, ptr, "object is not referred to")
+	}
+}
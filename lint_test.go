@@ -0,0 +1,113 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func lintPDF(t *testing.T, data []byte) []Diagnostic {
+	t.Helper()
+	r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r.Lint()
+}
+
+// lintCodes summarizes diags as "object:code" strings, sorted.
+func lintCodes(diags []Diagnostic) []string {
+	var codes []string
+	for _, d := range diags {
+		codes = append(codes, fmt.Sprintf("%d:%s", d.Object, d.Code))
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+func TestLintClean(t *testing.T) {
+	const content = "BT /F1 10 Tf 72 700 Td (Clean) Tj ET"
+	data := buildPDF("/Root 1 0 R",
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Count 1 /Kids [3 0 R] /MediaBox [0 0 612 792] >>",
+		"<< /Type /Page /Parent 2 0 R /Resources << /Font << /F1 4 0 R >> >> /Contents 5 0 R >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+	)
+	if diags := lintPDF(t, data); diags != nil {
+		t.Errorf("Lint = %v, want none", diags)
+	}
+	if diags := lintPDF(t, createMinimalXrefStreamPDF()); diags != nil {
+		t.Errorf("Lint of xref stream document = %v, want none", diags)
+	}
+}
+
+func TestLint(t *testing.T) {
+	const content = "BT /F1 10 Tf 72 700 Td (Defects) Tj ET"
+	data := buildPDF("/Root 1 0 R",
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Count 3 /Kids [3 0 R 4 0 R] >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << >> /Contents 5 0 R >>",
+		"<< /Type /Page /Parent 1 0 R /Resources << /Font << /F1 6 0 R >> >> /Annots [9 0 R] >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content)+5, content),
+		"<< /Type /Font /BaseFont /Helvetica >>",
+		"<< /Unused true >>",
+		"<< /Type /Annot /Subtype /Text >>",
+	)
+	data = bytes.Replace(data, []byte("7 0 obj"), []byte("7 1 obj"), 1)
+	diags := lintPDF(t, data)
+
+	want := []string{
+		"2:page-tree",          // Count
+		"4:dangling-reference", // Annots
+		"4:missing-key",        // MediaBox
+		"4:page-tree",          // Parent
+		"5:stream-length",
+		"6:missing-key", // Subtype
+		"7:object-id",
+		"7:unused-object",
+		"8:unused-object",
+	}
+	if got := lintCodes(diags); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("Lint codes = %v, want %v", got, want)
+	}
+	for _, d := range diags {
+		switch {
+		case d.Code == LintStreamLength && !strings.Contains(d.Message, fmt.Sprintf("data is %d bytes", len(content))):
+			t.Errorf("stream length diagnostic: %v", d)
+		case d.Code == LintObjectID && d.Offset != int64(bytes.Index(data, []byte("7 1 obj"))):
+			t.Errorf("object-id diagnostic offset: %v", d)
+		case d.Code == LintUnusedObject && d.Severity != SeverityInfo:
+			t.Errorf("unused-object severity: %v", d)
+		}
+	}
+
+	b, err := json.Marshal(diags[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"code":"page-tree","severity":"error","object":2,"generation":0,"offset":`) {
+		t.Errorf("JSON = %s", b)
+	}
+}
+
+func TestLintXrefOffset(t *testing.T) {
+	data := buildPDF("/Root 1 0 R",
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Count 0 /Kids [] >>",
+		"<< /Producer (test) >>",
+	)
+	off := bytes.Index(data, []byte("3 0 obj"))
+	data = bytes.Replace(data, fmt.Appendf(nil, "%010d 00000 n", off), fmt.Appendf(nil, "%010d 00000 n", off+1), 1)
+	diags := lintPDF(t, data)
+	if len(diags) == 0 || diags[0].Code != LintXrefOffset || diags[0].Object != 3 || diags[0].Offset != int64(off+1) {
+		t.Errorf("Lint = %v, want xref-offset of object 3 first", diags)
+	}
+}
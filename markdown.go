@@ -0,0 +1,778 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// mdCellGapRatio is the gap between words, as a fraction of the font
+	// size, above which they belong to separate table cells.
+	mdCellGapRatio = 1.0
+
+	// mdMaxCellWords is the average number of words per cell above which
+	// aligned rows are taken for columns of prose rather than a table.
+	mdMaxCellWords = 6
+
+	// mdSuperscriptRatio is the size, relative to the surrounding text,
+	// below which raised digits are taken for a footnote reference.
+	mdSuperscriptRatio = 0.8
+)
+
+var (
+	mdBulletPattern  = regexp.MustCompile(`^[•◦▪‣·∙*\-–]`)
+	mdNumberPattern  = regexp.MustCompile(`^(\d{1,3})[.)]$`)
+	mdLetterPattern  = regexp.MustCompile(`^\([a-z0-9]{1,3}\)$`)
+	mdMarkerPattern  = regexp.MustCompile(`^(?:\[(\d{1,3})\]|(\d{1,3})[.):]?|([*†‡§]))$`)
+	mdBracketRef     = regexp.MustCompile(`\[(\d{1,3})\]`)
+	mdOrderedStart   = regexp.MustCompile(`^(\d+)([.)])(\s|$)`)
+	mdEscaper        = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "<", `\<`)
+	mdTableCellClean = strings.NewReplacer("|", `\|`)
+)
+
+// BlocksToMarkdown renders classified blocks as Markdown. Titles become
+// headings of their level, list blocks become bullet or numbered items,
+// rows of aligned cells become pipe tables and bold or italic runs become
+// emphasis. Footnotes are collected as [^n] definitions at the end and
+// superscript references to them are linked; headers and footers are
+// dropped. Blocks are expected in reading order, grouped by Page.
+func BlocksToMarkdown(blocks []ClassifiedBlock) string {
+	m := &mdRenderer{notes: make(map[int]map[string]string), used: make(map[string]bool)}
+	var parts []string
+	for start := 0; start < len(blocks); {
+		end := start + 1
+		for end < len(blocks) && blocks[end].Page == blocks[start].Page {
+			end++
+		}
+		parts = append(parts, m.renderPage(blocks[start:end])...)
+		start = end
+	}
+
+	if len(m.defs) > 0 {
+		defs := make([]string, len(m.defs))
+		for i, n := range m.defs {
+			defs[i] = fmt.Sprintf("[^%s]: %s", n.label, m.inline(n.lines, n.page, true))
+		}
+		parts = append(parts, strings.Join(defs, "\n"))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return strings.Join(parts, "\n\n") + "\n"
+}
+
+// Markdown renders the classified blocks of a ModeStructured extraction
+// as Markdown. See BlocksToMarkdown.
+func (r *ExtractResult) Markdown() string {
+	return BlocksToMarkdown(r.ClassifiedBlocks)
+}
+
+// ExtractMarkdown is a convenience method for extracting Markdown
+func (e *Extractor) ExtractMarkdown() (string, error) {
+	blocks, err := e.ExtractStructured()
+	if err != nil {
+		return "", err
+	}
+	return BlocksToMarkdown(blocks), nil
+}
+
+// mdWord is a word of a block together with its glyphs.
+type mdWord struct {
+	Word
+	glyphs []Text
+	table  *mdTable // table the word was moved into, if any
+}
+
+// baseline returns the baseline of the word's first glyph.
+func (w *mdWord) baseline() float64 {
+	if len(w.glyphs) > 0 {
+		return w.glyphs[0].Y
+	}
+	return w.Bounds.Min.Y
+}
+
+// mdBlock is a classified block split into lines of words.
+type mdBlock struct {
+	ClassifiedBlock
+	lines [][]*mdWord
+	notes []int // footnotes defined by the block, as indexes into mdRenderer.defs
+}
+
+// mdTable is a detected table; cells hold the words of each cell by row.
+type mdTable struct {
+	rows    [][][]*mdWord
+	emitted bool
+}
+
+// mdNote is a footnote definition.
+type mdNote struct {
+	label string
+	page  int
+	lines [][]*mdWord
+}
+
+// mdToken is a word, or a footnote reference, with its style.
+type mdToken struct {
+	text         string
+	ref          string // label of the footnote referenced, instead of text
+	bold, italic bool
+	glue         bool // joined to the previous token without a space
+}
+
+// mdRenderer holds the footnotes of the document being rendered.
+type mdRenderer struct {
+	notes map[int]map[string]string // footnote labels by page and marker
+	used  map[string]bool
+	defs  []mdNote
+}
+
+func newMDBlock(b ClassifiedBlock) *mdBlock {
+	mb := &mdBlock{ClassifiedBlock: b}
+	if len(b.Content) == 0 {
+		// Blocks built by hand may only carry their text
+		for _, line := range strings.Split(b.Text, "\n") {
+			var words []*mdWord
+			for _, f := range strings.Fields(line) {
+				words = append(words, &mdWord{Word: Word{Text: f}})
+			}
+			if len(words) > 0 {
+				mb.lines = append(mb.lines, words)
+			}
+		}
+		return mb
+	}
+
+	texts := sortWithinBlockOptimized(append([]Text(nil), b.Content...))
+	for _, runs := range layoutLines(texts) {
+		var wb wordBuilder
+		for _, t := range runs {
+			wb.addText(t)
+		}
+		wb.flush()
+		if len(wb.words) == 0 {
+			continue
+		}
+		words := make([]*mdWord, len(wb.words))
+		for i, w := range wb.words {
+			words[i] = &mdWord{Word: w, glyphs: wb.glyphs[i]}
+		}
+		mb.lines = append(mb.lines, words)
+	}
+	return mb
+}
+
+// preparePage splits the blocks of one page into words, finds its tables
+// and records its footnotes.
+func (m *mdRenderer) preparePage(blocks []ClassifiedBlock) []*mdBlock {
+	mbs := make([]*mdBlock, len(blocks))
+	for i, b := range blocks {
+		mbs[i] = newMDBlock(b)
+	}
+	detectMDTables(mbs)
+	for _, b := range mbs {
+		if b.Type == BlockFootnote {
+			m.addNotes(b)
+		}
+	}
+	return mbs
+}
+
+// takeLines returns the tables that start in the block and have not been
+// output yet, and the lines of the block without the words of any table.
+func (b *mdBlock) takeLines() ([]*mdTable, [][]*mdWord) {
+	var tables []*mdTable
+	var lines [][]*mdWord
+	for _, line := range b.lines {
+		var rest []*mdWord
+		for _, w := range line {
+			if w.table == nil {
+				rest = append(rest, w)
+			} else if !w.table.emitted {
+				w.table.emitted = true
+				tables = append(tables, w.table)
+			}
+		}
+		if len(rest) > 0 {
+			lines = append(lines, rest)
+		}
+	}
+	return tables, lines
+}
+
+// renderPage renders the blocks of one page.
+func (m *mdRenderer) renderPage(blocks []ClassifiedBlock) []string {
+	var parts []string
+	for _, b := range m.preparePage(blocks) {
+		switch b.Type {
+		case BlockHeader, BlockFooter, BlockFootnote:
+			continue
+		}
+
+		tables, lines := b.takeLines()
+		for _, t := range tables {
+			parts = append(parts, m.renderTable(t, b.Page))
+		}
+		if len(lines) == 0 {
+			continue
+		}
+
+		switch b.Type {
+		case BlockTitle:
+			level := min(max(b.Level, 1), 6)
+			parts = append(parts, strings.Repeat("#", level)+" "+m.inline(lines, b.Page, false))
+		case BlockList:
+			if items := m.renderList(lines, b.Page); items != "" {
+				parts = append(parts, items)
+				continue
+			}
+			fallthrough
+		default:
+			parts = append(parts, escapeLineStart(m.inline(lines, b.Page, true)))
+		}
+	}
+	return parts
+}
+
+// mdListItem is an item of a list block.
+type mdListItem struct {
+	prefix string // Markdown prefix, "- " or "n. "
+	nested bool   // set in further than the first items
+	lines  [][]*mdWord
+}
+
+// listItems splits lines starting with list markers into items, joining
+// the other lines to the item before. It returns nil if the first line
+// does not start with a marker.
+func listItems(lines [][]*mdWord) []*mdListItem {
+	var items []*mdListItem
+	var xs []float64
+	var cur *mdListItem
+	for _, line := range lines {
+		prefix, rest := listMarker(line)
+		if prefix == "" {
+			if cur == nil {
+				return nil
+			}
+			cur.lines = append(cur.lines, line)
+			continue
+		}
+		cur = &mdListItem{prefix: prefix}
+		if len(rest) > 0 {
+			cur.lines = append(cur.lines, rest)
+		}
+		items = append(items, cur)
+		xs = append(xs, line[0].Bounds.Min.X)
+	}
+
+	left := xs[0]
+	for _, x := range xs {
+		left = min(left, x)
+	}
+	if size := lines[0][0].FontSize; size > 0 {
+		for i, it := range items {
+			// Items set in further than their siblings are nested
+			it.nested = xs[i]-left > size
+		}
+	}
+	return items
+}
+
+// renderList renders a list block as items, or returns "" if its lines
+// do not start with list markers.
+func (m *mdRenderer) renderList(lines [][]*mdWord, page int) string {
+	items := listItems(lines)
+	if items == nil {
+		return ""
+	}
+	out := make([]string, len(items))
+	for i, it := range items {
+		indent := ""
+		if it.nested {
+			indent = "    "
+		}
+		out[i] = indent + it.prefix + escapeLineStart(m.inline(it.lines, page, true))
+	}
+	return strings.Join(out, "\n")
+}
+
+// listMarker returns the Markdown item prefix for a line starting with a
+// list marker, and the line without it.
+func listMarker(line []*mdWord) (string, []*mdWord) {
+	first := line[0]
+	switch {
+	case mdNumberPattern.MatchString(first.Text):
+		n := mdNumberPattern.FindStringSubmatch(first.Text)[1]
+		return n + ". ", line[1:]
+	case mdLetterPattern.MatchString(first.Text):
+		return "- ", line
+	case mdBulletPattern.MatchString(first.Text):
+		_, size := utf8.DecodeRuneInString(first.Text)
+		if size == len(first.Text) {
+			return "- ", line[1:]
+		}
+		if r, _ := utf8.DecodeRuneInString(first.Text[size:]); r == '-' || unicode.IsDigit(r) {
+			// A negative number or a dash, not a bullet
+			return "", line
+		}
+		w := *first
+		w.Text = first.Text[size:]
+		if len(w.glyphs) > 0 {
+			w.glyphs = w.glyphs[1:]
+		}
+		return "- ", append([]*mdWord{&w}, line[1:]...)
+	}
+	return "", line
+}
+
+// renderTable renders a table as a pipe table with its first row as header.
+func (m *mdRenderer) renderTable(t *mdTable, page int) string {
+	rows := make([]string, 0, len(t.rows)+1)
+	for i, row := range t.rows {
+		cells := make([]string, len(row))
+		for j, cell := range row {
+			cells[j] = mdTableCellClean.Replace(m.inline([][]*mdWord{cell}, page, true))
+		}
+		rows = append(rows, "| "+strings.Join(cells, " | ")+" |")
+		if i == 0 {
+			rows = append(rows, "|"+strings.Repeat(" --- |", len(row)))
+		}
+	}
+	return strings.Join(rows, "\n")
+}
+
+// detectMDTables finds runs of rows on a page whose words fall into the
+// same number of horizontally aligned cells, and marks their words as
+// belonging to a table.
+func detectMDTables(blocks []*mdBlock) {
+	var words []*mdWord
+	for _, b := range blocks {
+		switch b.Type {
+		case BlockParagraph, BlockList, BlockUnknown:
+		default:
+			continue
+		}
+		for _, line := range b.lines {
+			for _, w := range line {
+				if !w.Vertical && len(w.glyphs) > 0 {
+					words = append(words, w)
+				}
+			}
+		}
+	}
+	if len(words) < 4 {
+		return
+	}
+
+	sort.SliceStable(words, func(i, j int) bool {
+		return words[i].baseline() > words[j].baseline()
+	})
+	var rows [][]*mdWord
+	start := 0
+	for i := 1; i <= len(words); i++ {
+		if i < len(words) && words[start].baseline()-words[i].baseline() <= layoutLineTolerance {
+			continue
+		}
+		row := words[start:i]
+		sort.SliceStable(row, func(a, b int) bool { return row[a].Bounds.Min.X < row[b].Bounds.Min.X })
+		rows = append(rows, row)
+		start = i
+	}
+
+	cells := make([][][]*mdWord, len(rows))
+	for i, row := range rows {
+		cells[i] = splitCells(row)
+	}
+	for i := 0; i < len(cells); {
+		j := i + 1
+		if len(cells[i]) >= 2 {
+			for j < len(cells) && continuesTable(cells[j-1], cells[j]) {
+				j++
+			}
+		}
+		if j-i >= 2 && isTable(cells[i:j]) {
+			t := &mdTable{rows: cells[i:j]}
+			for _, row := range t.rows {
+				for _, cell := range row {
+					for _, w := range cell {
+						w.table = t
+					}
+				}
+			}
+		}
+		i = j
+	}
+}
+
+// splitCells splits a row of words, sorted left to right, at wide gaps.
+func splitCells(row []*mdWord) [][]*mdWord {
+	cells := [][]*mdWord{{row[0]}}
+	for i := 1; i < len(row); i++ {
+		prev, w := row[i-1], row[i]
+		size := max(prev.FontSize, w.FontSize, 1)
+		if w.Bounds.Min.X-prev.Bounds.Max.X > size*mdCellGapRatio {
+			cells = append(cells, nil)
+		}
+		cells[len(cells)-1] = append(cells[len(cells)-1], w)
+	}
+	return cells
+}
+
+// continuesTable reports whether row b, below row a, has the same columns.
+func continuesTable(a, b [][]*mdWord) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	aBox, bBox := cellBounds(a[0]), cellBounds(b[0])
+	if aBox.Min.Y-bBox.Max.Y > 2*max(a[0][0].FontSize, 1) {
+		return false
+	}
+	for k := range a {
+		aBox, bBox = cellBounds(a[k]), cellBounds(b[k])
+		if aBox.Max.X < bBox.Min.X || bBox.Max.X < aBox.Min.X {
+			return false
+		}
+	}
+	return true
+}
+
+// isTable reports whether aligned rows hold short cells rather than prose.
+func isTable(rows [][][]*mdWord) bool {
+	words, cells := 0, 0
+	for _, row := range rows {
+		for _, cell := range row {
+			words += len(cell)
+			cells++
+		}
+	}
+	return float64(words)/float64(cells) <= mdMaxCellWords
+}
+
+func cellBounds(cell []*mdWord) Rect {
+	r := cell[0].Bounds
+	for _, w := range cell[1:] {
+		r = unionRect(r, w.Bounds)
+	}
+	return r
+}
+
+// addNotes splits a footnote block into notes at their markers and gives
+// each a label.
+func (m *mdRenderer) addNotes(b *mdBlock) {
+	cur := -1
+	for _, line := range b.lines {
+		marker, first := footnoteMarker(line[0])
+		if marker != "" || cur < 0 {
+			cur = len(m.defs)
+			m.defs = append(m.defs, mdNote{label: m.label(b.Page, marker), page: b.Page})
+			b.notes = append(b.notes, cur)
+			if first == nil {
+				line = line[1:]
+			} else {
+				line = append([]*mdWord{first}, line[1:]...)
+			}
+		}
+		if len(line) > 0 {
+			m.defs[cur].lines = append(m.defs[cur].lines, line)
+		}
+	}
+}
+
+// label allocates the label of a footnote. Numbered notes keep their
+// number unless another page used it already.
+func (m *mdRenderer) label(page int, marker string) string {
+	numbered := marker != "" && strings.Trim(marker, "0123456789") == ""
+	label := marker
+	if !numbered || m.used[label] {
+		label = ""
+		if numbered && page > 0 {
+			label = fmt.Sprintf("p%d-%s", page, marker)
+		}
+		for n := len(m.used) + 1; label == "" || m.used[label]; n++ {
+			label = strconv.Itoa(n)
+		}
+	}
+	m.used[label] = true
+	if numbered {
+		if m.notes[page] == nil {
+			m.notes[page] = make(map[string]string)
+		}
+		m.notes[page][marker] = label
+	}
+	return label
+}
+
+// ref returns the label of the footnote with the given marker on a page.
+func (m *mdRenderer) ref(page int, marker string) (string, bool) {
+	label, ok := m.notes[page][marker]
+	return label, ok
+}
+
+// footnoteMarker returns the marker that starts a footnote line, and the
+// rest of the word if the marker was attached to it.
+func footnoteMarker(w *mdWord) (string, *mdWord) {
+	if sub := mdMarkerPattern.FindStringSubmatch(w.Text); sub != nil {
+		return sub[1] + sub[2] + sub[3], nil
+	}
+	if digits, n := leadingSuperscript(w); n > 0 {
+		rest := *w
+		rest.Text = string([]rune(w.Text)[n:])
+		if len(rest.glyphs) >= n {
+			rest.glyphs = rest.glyphs[n:]
+		}
+		return digits, &rest
+	}
+	return "", w
+}
+
+// leadingSuperscript returns the superscript digits that start a word and
+// their number of runes.
+func leadingSuperscript(w *mdWord) (string, int) {
+	var digits []rune
+	for _, r := range w.Text {
+		d, ok := superscriptDigit(r)
+		if !ok {
+			break
+		}
+		digits = append(digits, d)
+	}
+	if len(digits) > 0 && len(digits) < utf8.RuneCountInString(w.Text) {
+		return string(digits), len(digits)
+	}
+
+	// Digits set smaller than the text that follows them
+	n := 0
+	for n < len(w.glyphs) && isASCIIDigit(w.glyphs[n].S) {
+		n++
+	}
+	if n > 0 && n < len(w.glyphs) && w.glyphs[0].FontSize < w.glyphs[n].FontSize*mdSuperscriptRatio {
+		var sb strings.Builder
+		for _, g := range w.glyphs[:n] {
+			sb.WriteString(g.S)
+		}
+		return sb.String(), n
+	}
+	return "", 0
+}
+
+// trailingReference splits the digits of a footnote reference off the end
+// of a word: Unicode superscript digits, or digits set smaller and raised.
+func trailingReference(w *mdWord) (body, ref string) {
+	runes := []rune(w.Text)
+	n := len(runes)
+	for n > 0 {
+		if _, ok := superscriptDigit(runes[n-1]); !ok {
+			break
+		}
+		n--
+	}
+	if n > 0 && n < len(runes) {
+		digits := make([]rune, 0, len(runes)-n)
+		for _, r := range runes[n:] {
+			d, _ := superscriptDigit(r)
+			digits = append(digits, d)
+		}
+		return string(runes[:n]), string(digits)
+	}
+
+	g := w.glyphs
+	if len(g) != len(runes) || len(g) < 2 {
+		return w.Text, ""
+	}
+	n = len(g)
+	for n > 1 && isASCIIDigit(g[n-1].S) && g[n-1].FontSize < g[0].FontSize*mdSuperscriptRatio && g[n-1].Y > g[0].Y {
+		n--
+	}
+	if n == len(g) {
+		return w.Text, ""
+	}
+	return string(runes[:n]), string(runes[n:])
+}
+
+// isReference reports whether w is a footnote reference set apart from
+// the word before it.
+func isReference(w, prev *mdWord) (string, bool) {
+	var digits []rune
+	for _, r := range w.Text {
+		d, ok := superscriptDigit(r)
+		if !ok {
+			digits = nil
+			break
+		}
+		digits = append(digits, d)
+	}
+	if len(digits) > 0 {
+		return string(digits), true
+	}
+	if isASCIIDigit(w.Text) && len(w.glyphs) > 0 && len(prev.glyphs) > 0 &&
+		w.FontSize < prev.FontSize*mdSuperscriptRatio && w.baseline() > prev.baseline() {
+		return w.Text, true
+	}
+	return "", false
+}
+
+// inline renders lines of words as Markdown, with or without emphasis.
+func (m *mdRenderer) inline(lines [][]*mdWord, page int, emphasis bool) string {
+	return joinTokens(m.tokens(lines, page), func(t mdToken) string {
+		if t.ref != "" {
+			return "[^" + t.ref + "]"
+		}
+		return mdEscaper.Replace(t.text)
+	}, func(bold, italic bool) (string, string) {
+		switch {
+		case !emphasis:
+			return "", ""
+		case bold && italic:
+			return "***", "***"
+		case bold:
+			return "**", "**"
+		case italic:
+			return "*", "*"
+		}
+		return "", ""
+	})
+}
+
+// tokens turns lines of words into one run of tokens, joining words
+// hyphenated across lines and linking footnote references.
+func (m *mdRenderer) tokens(lines [][]*mdWord, page int) []mdToken {
+	var toks []mdToken
+	var prev *mdWord
+	for li, line := range lines {
+		for wi, w := range line {
+			if prev != nil {
+				if marker, ok := isReference(w, prev); ok {
+					if label, ok := m.ref(page, marker); ok {
+						last := toks[len(toks)-1]
+						toks = append(toks, mdToken{ref: label, bold: last.bold, italic: last.italic, glue: true})
+						continue
+					}
+				}
+			}
+
+			body, marker := trailingReference(w)
+			label, linked := "", false
+			if marker != "" {
+				if label, linked = m.ref(page, marker); !linked {
+					body = w.Text
+				}
+			}
+
+			start := len(toks)
+			toks = m.appendText(toks, body, page, w.Bold, w.Italic)
+			if wi == 0 && li > 0 && start > 0 && len(toks) > start {
+				// Rejoin words hyphenated at the end of the line
+				last := &toks[start-1]
+				if r, _ := utf8.DecodeRuneInString(toks[start].text); strings.HasSuffix(last.text, "-") && len(last.text) > 1 && unicode.IsLower(r) {
+					last.text = strings.TrimSuffix(last.text, "-")
+					toks[start].glue = true
+				}
+			}
+			if linked {
+				toks = append(toks, mdToken{ref: label, bold: w.Bold, italic: w.Italic, glue: true})
+			}
+			prev = w
+		}
+	}
+	return toks
+}
+
+// appendText appends the tokens of a word, splitting off bracketed
+// references such as [1] to the footnotes of the page.
+func (m *mdRenderer) appendText(toks []mdToken, s string, page int, bold, italic bool) []mdToken {
+	last, glue := 0, false
+	for _, loc := range mdBracketRef.FindAllStringSubmatchIndex(s, -1) {
+		label, ok := m.ref(page, s[loc[2]:loc[3]])
+		if !ok {
+			continue
+		}
+		if loc[0] > last {
+			toks = append(toks, mdToken{text: s[last:loc[0]], bold: bold, italic: italic, glue: glue})
+			glue = true
+		}
+		toks = append(toks, mdToken{ref: label, bold: bold, italic: italic, glue: glue})
+		glue = true
+		last = loc[1]
+	}
+	if last < len(s) {
+		toks = append(toks, mdToken{text: s[last:], bold: bold, italic: italic, glue: glue})
+	}
+	return toks
+}
+
+// joinTokens joins tokens with spaces, wrapping runs of tokens of the same
+// style in the markers returned by emphasis.
+func joinTokens(toks []mdToken, text func(mdToken) string, emphasis func(bold, italic bool) (string, string)) string {
+	var sb strings.Builder
+	for i := 0; i < len(toks); {
+		j := i + 1
+		for j < len(toks) && toks[j].bold == toks[i].bold && toks[j].italic == toks[i].italic {
+			j++
+		}
+		if i > 0 && !toks[i].glue {
+			sb.WriteByte(' ')
+		}
+
+		open, end := emphasis(toks[i].bold, toks[i].italic)
+		sb.WriteString(open)
+		for k := i; k < j; k++ {
+			if k > i && !toks[k].glue {
+				sb.WriteByte(' ')
+			}
+			sb.WriteString(text(toks[k]))
+		}
+		sb.WriteString(end)
+		i = j
+	}
+	return sb.String()
+}
+
+// escapeLineStart escapes text that Markdown would otherwise read as the
+// start of a heading, quote, list or rule.
+func escapeLineStart(s string) string {
+	if s == "" {
+		return s
+	}
+	switch s[0] {
+	case '#', '>', '+', '-', '=':
+		return `\` + s
+	}
+	return mdOrderedStart.ReplaceAllString(s, `$1\$2$3`)
+}
+
+// superscriptDigit maps a Unicode superscript digit to its ASCII digit.
+func superscriptDigit(r rune) (rune, bool) {
+	switch {
+	case r == '¹':
+		return '1', true
+	case r == '²':
+		return '2', true
+	case r == '³':
+		return '3', true
+	case r == '⁰':
+		return '0', true
+	case r >= '⁴' && r <= '⁹':
+		return '4' + (r - '⁴'), true
+	}
+	return 0, false
+}
+
+func isASCIIDigit(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
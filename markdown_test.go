@@ -0,0 +1,144 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"strings"
+	"testing"
+)
+
+// block returns a classified block holding the given lines of glyphs.
+func block(typ BlockType, level int, lines ...[]Text) ClassifiedBlock {
+	b := ClassifiedBlock{Type: typ, Level: level}
+	for _, line := range lines {
+		b.Content = append(b.Content, line...)
+	}
+	b.Text = textString(b.Content)
+	return b
+}
+
+// styled marks the glyphs of run from i to j bold or italic.
+func styled(run []Text, i, j int, bold, italic bool) []Text {
+	for k := i; k < j; k++ {
+		run[k].Bold, run[k].Italic = bold, italic
+	}
+	return run
+}
+
+func TestBlocksToMarkdown(t *testing.T) {
+	blocks := []ClassifiedBlock{
+		block(BlockHeader, 0, glyphRun("Running head", 72, 760)),
+		block(BlockTitle, 2, styled(glyphRun("Results", 72, 700), 0, 7, true, false)),
+		block(BlockParagraph, 0,
+			styled(styled(glyphRun("Plain strong and slanted exam-", 72, 680), 6, 12, true, false), 17, 24, false, true),
+			glyphRun("ple text.", 72, 668)),
+		block(BlockParagraph, 0, glyphRun("# not a *heading*", 72, 640)),
+		block(BlockFooter, 0, glyphRun("Page 1", 300, 30)),
+	}
+
+	want := "## Results\n\n" +
+		"Plain **strong** and *slanted* example text.\n\n" +
+		`\# not a \*heading\*` + "\n"
+	if got := BlocksToMarkdown(blocks); got != want {
+		t.Errorf("BlocksToMarkdown =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestBlocksToMarkdownLists(t *testing.T) {
+	blocks := []ClassifiedBlock{
+		block(BlockList, 0,
+			glyphRun("• First item", 72, 700),
+			glyphRun("continued", 84, 688),
+			glyphRun("• Second", 72, 676),
+			glyphRun("• Nested", 96, 664)),
+		block(BlockList, 0, glyphRun("1. One", 72, 600), glyphRun("2. Two", 72, 588)),
+		block(BlockList, 0, glyphRun("No markers here", 72, 500)),
+	}
+
+	want := "- First item continued\n- Second\n    - Nested\n\n" +
+		"1. One\n2. Two\n\n" +
+		"No markers here\n"
+	if got := BlocksToMarkdown(blocks); got != want {
+		t.Errorf("BlocksToMarkdown =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestBlocksToMarkdownTable(t *testing.T) {
+	// The columns of the table are clustered as separate blocks
+	blocks := []ClassifiedBlock{
+		block(BlockParagraph, 0, glyphRun("Scores of the first round", 72, 730)),
+		block(BlockParagraph, 0, glyphRun("Name", 72, 700), glyphRun("Alice", 72, 688), glyphRun("Bob | Jr", 72, 676)),
+		block(BlockParagraph, 0, glyphRun("Score", 200, 700), glyphRun("30", 200, 688), glyphRun("41", 200, 676)),
+	}
+
+	want := "Scores of the first round\n\n" +
+		"| Name | Score |\n| --- | --- |\n| Alice | 30 |\n| Bob \\| Jr | 41 |\n"
+	if got := BlocksToMarkdown(blocks); got != want {
+		t.Errorf("BlocksToMarkdown =\n%s\nwant\n%s", got, want)
+	}
+
+	// Two columns of prose line up too, but are not a table
+	prose := []ClassifiedBlock{
+		block(BlockParagraph, 0, glyphRun("a b c d e f g h", 72, 700), glyphRun("i j k l m n o p", 72, 688)),
+		block(BlockParagraph, 0, glyphRun("q r s t u v w x", 300, 700), glyphRun("y z a b c d e f", 300, 688)),
+	}
+	if got := BlocksToMarkdown(prose); strings.Contains(got, "|") {
+		t.Errorf("prose rendered as a table:\n%s", got)
+	}
+}
+
+func TestBlocksToMarkdownFootnotes(t *testing.T) {
+	ref := func(s string, x, y float64) []Text {
+		run := glyphRun(s, x, y)
+		for i := range run {
+			run[i].FontSize, run[i].Y = 7, y+3
+		}
+		return run
+	}
+	page := func(n int, note string) []ClassifiedBlock {
+		body := append(glyphRun("See the note", 72, 700), ref("1", 144, 700)...)
+		blocks := []ClassifiedBlock{
+			block(BlockParagraph, 0, body, glyphRun("and [1] and x² again.", 72, 688)),
+			block(BlockFootnote, 0, glyphRun("1 "+note, 72, 100), glyphRun("continues here", 72, 90)),
+		}
+		for i := range blocks {
+			blocks[i].Page = n
+		}
+		return blocks
+	}
+	blocks := append(page(1, "First note"), page(2, "Second note")...)
+
+	want := "See the note[^1] and [^1] and x² again.\n\n" +
+		"See the note[^p2-1] and [^p2-1] and x² again.\n\n" +
+		"[^1]: First note continues here\n" +
+		"[^p2-1]: Second note continues here\n"
+	if got := BlocksToMarkdown(blocks); got != want {
+		t.Errorf("BlocksToMarkdown =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestExtractorMarkdown(t *testing.T) {
+	r := openTextPDF(t, "BT /F2 24 Tf 72 700 Td (Introduction) Tj ET "+
+		"BT /F1 10 Tf 72 660 Td (This is the body text of the paper.) Tj ET")
+
+	md, err := NewExtractor(r).ExtractMarkdown()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(md, "# Introduction\n\n") || !strings.Contains(md, "This is the body text of the paper.") {
+		t.Errorf("ExtractMarkdown =\n%s", md)
+	}
+
+	result, err := NewExtractor(r).Mode(ModeStructured).Extract()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Markdown() != md {
+		t.Errorf("ExtractResult.Markdown differs:\n%s", result.Markdown())
+	}
+	if result.ClassifiedBlocks[0].Page != 1 {
+		t.Errorf("block page = %d, want 1", result.ClassifiedBlocks[0].Page)
+	}
+}
@@ -228,6 +228,9 @@ func NewLanguageTextExtractor() *LanguageTextExtractor {
 
 // ExtractTextByLanguage extracts text grouped by detected language
 func (lte *LanguageTextExtractor) ExtractTextByLanguage(reader *Reader) (map[Language][]Text, error) {
+	if err := reader.checkExtract(); err != nil {
+		return nil, err
+	}
 	totalPages := reader.NumPage()
 	result := make(map[Language][]Text)
 
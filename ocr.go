@@ -0,0 +1,70 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"math"
+)
+
+// defaultOCRDPI is the resolution of the pixel coordinates written by the
+// OCR format exporters unless OCROptions sets one.
+const defaultOCRDPI = 300
+
+// OCROptions configures the hOCR and ALTO exporters, which describe text
+// the way OCR engines do: in pixel coordinates of a page image with the
+// origin at the top left.
+type OCROptions struct {
+	DPI   float64       // Resolution of the pixel coordinates; zero means 300
+	Title string        // Title of hOCR documents
+	Dedup *DedupOptions // Collapse overprinted text before writing, if set
+}
+
+func (o OCROptions) scale() float64 {
+	if o.DPI > 0 {
+		return o.DPI / 72
+	}
+	return defaultOCRDPI / 72.0
+}
+
+// pixelSize returns the size of the page in pixels.
+func (o OCROptions) pixelSize(page Rect) (int, int) {
+	s := o.scale()
+	return int(math.Ceil((page.Max.X - page.Min.X) * s)), int(math.Ceil((page.Max.Y - page.Min.Y) * s))
+}
+
+// pixelBox converts r to pixel coordinates x0, y0, x1, y1 with the origin
+// at the top left of page, rounding outwards and clamping to the page.
+func (o OCROptions) pixelBox(r, page Rect) [4]int {
+	s := o.scale()
+	w, h := o.pixelSize(page)
+	clamp := func(v float64, limit int) int {
+		return min(max(int(v), 0), limit)
+	}
+	return [4]int{
+		clamp(math.Floor((r.Min.X-page.Min.X)*s), w),
+		clamp(math.Floor((page.Max.Y-r.Max.Y)*s), h),
+		clamp(math.Ceil((r.Max.X-page.Min.X)*s), w),
+		clamp(math.Ceil((page.Max.Y-r.Min.Y)*s), h),
+	}
+}
+
+// wordStyle returns the glyph that carries the font and style of a word
+// node of a layout.
+func wordStyle(word *LayoutNode) Text {
+	if len(word.Children) == 0 {
+		return Text{}
+	}
+	return word.Children[0].Glyph
+}
+
+// nodeBounds returns the bounds of a layout node grown to cover its
+// children, since OCR formats require boxes to nest.
+func nodeBounds(n *LayoutNode) Rect {
+	r := n.Bounds
+	for _, c := range n.Children {
+		r = unionRect(r, c.Bounds)
+	}
+	return r
+}
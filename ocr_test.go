@@ -0,0 +1,331 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// The schemas of the OCR formats, transcribed into rules the tests can
+// check without an XSD processor.
+var (
+	//go:embed testdata/alto-4-2.json
+	altoSchemaJSON []byte
+
+	//go:embed testdata/hocr-1.2.json
+	hocrSchemaJSON []byte
+)
+
+// xmlNode is an element of a parsed XML document.
+type xmlNode struct {
+	name     xml.Name
+	attrs    map[string]string // by local name, without namespace declarations
+	children []*xmlNode
+	text     string
+	parent   *xmlNode
+}
+
+func (n *xmlNode) walk(f func(*xmlNode)) {
+	f(n)
+	for _, c := range n.children {
+		c.walk(f)
+	}
+}
+
+// parseXML parses a well-formed XML document into its root element.
+func parseXML(data []byte) (*xmlNode, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	d.Strict = true
+	var root, cur *xmlNode
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			if errors.Is(err, io.EOF) && root != nil && cur == nil {
+				return root, nil
+			}
+			return nil, err
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			n := &xmlNode{name: tok.Name, attrs: make(map[string]string), parent: cur}
+			for _, a := range tok.Attr {
+				if a.Name.Space == "xmlns" || a.Name.Local == "xmlns" {
+					continue
+				}
+				n.attrs[a.Name.Local] = a.Value
+			}
+			if cur == nil {
+				if root != nil {
+					return nil, fmt.Errorf("second root element %s", tok.Name.Local)
+				}
+				root = n
+			} else {
+				cur.children = append(cur.children, n)
+			}
+			cur = n
+		case xml.EndElement:
+			cur = cur.parent
+		case xml.CharData:
+			if cur != nil {
+				cur.text += string(tok)
+			}
+		}
+	}
+}
+
+// altoSchema holds the rules of testdata/alto-4-2.json.
+type altoSchema struct {
+	Namespace string
+	Types     map[string]string
+	Elements  map[string]struct {
+		Content    *string
+		Text       string
+		Attributes map[string]string
+	}
+}
+
+// validateALTO checks an ALTO document against the schema rules and
+// returns the violations found.
+func validateALTO(t *testing.T, data []byte) []string {
+	t.Helper()
+	var schema altoSchema
+	if err := json.Unmarshal(altoSchemaJSON, &schema); err != nil {
+		t.Fatal(err)
+	}
+	root, err := parseXML(data)
+	if err != nil {
+		return []string{"not well-formed: " + err.Error()}
+	}
+
+	pattern := func(typ string) *regexp.Regexp {
+		if p, ok := schema.Types[typ]; ok {
+			return regexp.MustCompile(p)
+		}
+		t.Fatalf("fixture lacks type %s", typ)
+		return nil
+	}
+	particle := regexp.MustCompile(`[A-Za-z]+`)
+
+	var errs []string
+	ids := make(map[string]bool)
+	var refs []string
+	root.walk(func(n *xmlNode) {
+		name := n.name.Local
+		el, ok := schema.Elements[name]
+		if !ok {
+			errs = append(errs, "undeclared element "+name)
+			return
+		}
+		if n.name.Space != schema.Namespace {
+			errs = append(errs, fmt.Sprintf("%s in namespace %q", name, n.name.Space))
+		}
+
+		for attr, value := range n.attrs {
+			if attr == "schemaLocation" {
+				continue
+			}
+			typ, ok := el.Attributes[attr]
+			if !ok {
+				errs = append(errs, fmt.Sprintf("%s: undeclared attribute %s", name, attr))
+				continue
+			}
+			typ = strings.TrimSuffix(typ, "!")
+			if !pattern(typ).MatchString(value) {
+				errs = append(errs, fmt.Sprintf("%s: %s=%q is not a valid %s", name, attr, value, typ))
+			}
+			switch typ {
+			case "ID":
+				if ids[value] {
+					errs = append(errs, "duplicate ID "+value)
+				}
+				ids[value] = true
+			case "IDREFS":
+				refs = append(refs, strings.Fields(value)...)
+			}
+		}
+		for attr, typ := range el.Attributes {
+			if _, ok := n.attrs[attr]; strings.HasSuffix(typ, "!") && !ok {
+				errs = append(errs, fmt.Sprintf("%s: missing required attribute %s", name, attr))
+			}
+		}
+
+		if el.Content == nil {
+			if len(n.children) > 0 || !pattern(el.Text).MatchString(n.text) {
+				errs = append(errs, fmt.Sprintf("%s: invalid simple content %q", name, n.text))
+			}
+			return
+		}
+		if strings.TrimSpace(n.text) != "" {
+			errs = append(errs, fmt.Sprintf("%s: text in element-only content", name))
+		}
+		// Turn the particles into a regular expression over child names
+		model := particle.ReplaceAllString(*el.Content, "<${0}_>")
+		model = strings.NewReplacer(" ", "", "_", " ", "<", "(?:", "(", "(?:", ">", ")").Replace(model)
+		re := regexp.MustCompile("^" + model + "$")
+		var seq strings.Builder
+		for _, c := range n.children {
+			seq.WriteString(c.name.Local + " ")
+		}
+		if !re.MatchString(seq.String()) {
+			errs = append(errs, fmt.Sprintf("%s: children %q do not match %q", name, seq.String(), *el.Content))
+		}
+	})
+	for _, ref := range refs {
+		if !ids[ref] {
+			errs = append(errs, "dangling IDREF "+ref)
+		}
+	}
+	return errs
+}
+
+// hocrSchema holds the rules of testdata/hocr-1.2.json.
+type hocrSchema struct {
+	Classes map[string]struct {
+		Elements []string
+		Parents  []string
+		Required []string
+	}
+	Properties map[string]struct {
+		Pattern    string
+		Capability string
+	}
+}
+
+// validateHOCR checks an hOCR document against the specification rules
+// and returns the violations found.
+func validateHOCR(t *testing.T, data []byte) []string {
+	t.Helper()
+	var schema hocrSchema
+	if err := json.Unmarshal(hocrSchemaJSON, &schema); err != nil {
+		t.Fatal(err)
+	}
+	root, err := parseXML(data)
+	if err != nil {
+		return []string{"not well-formed XHTML: " + err.Error()}
+	}
+
+	var errs []string
+	capabilities := make(map[string]bool)
+	used := make(map[string]bool)
+	ids := make(map[string]bool)
+	boxes := make(map[*xmlNode][4]int)
+	root.walk(func(n *xmlNode) {
+		if n.name.Space != "http://www.w3.org/1999/xhtml" {
+			errs = append(errs, fmt.Sprintf("%s in namespace %q", n.name.Local, n.name.Space))
+		}
+		if n.name.Local == "meta" && n.attrs["name"] == "ocr-capabilities" {
+			for _, c := range strings.Fields(n.attrs["content"]) {
+				capabilities[c] = true
+			}
+		}
+		if id := n.attrs["id"]; id != "" {
+			if ids[id] {
+				errs = append(errs, "duplicate id "+id)
+			}
+			ids[id] = true
+		}
+
+		class := n.attrs["class"]
+		rule, ok := schema.Classes[class]
+		if !ok {
+			if strings.HasPrefix(class, "ocr") {
+				errs = append(errs, "unknown class "+class)
+			}
+			return
+		}
+		used[class] = true
+		if !slices.Contains(rule.Elements, n.name.Local) {
+			errs = append(errs, fmt.Sprintf("%s on <%s>", class, n.name.Local))
+		}
+
+		var parent *xmlNode
+		for p := n.parent; p != nil; p = p.parent {
+			if _, ok := schema.Classes[p.attrs["class"]]; ok {
+				parent = p
+				break
+			}
+		}
+		switch {
+		case parent == nil && len(rule.Parents) > 0:
+			errs = append(errs, class+" outside of "+strings.Join(rule.Parents, " or "))
+		case parent != nil && !slices.Contains(rule.Parents, parent.attrs["class"]):
+			errs = append(errs, fmt.Sprintf("%s inside %s", class, parent.attrs["class"]))
+		}
+
+		props := make(map[string]string)
+		for _, p := range strings.Split(n.attrs["title"], ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(p), " ")
+			if key == "" {
+				continue
+			}
+			prop, ok := schema.Properties[key]
+			if !ok {
+				errs = append(errs, fmt.Sprintf("%s: unknown property %s", class, key))
+				continue
+			}
+			if !regexp.MustCompile(prop.Pattern).MatchString(value) {
+				errs = append(errs, fmt.Sprintf("%s: invalid %s %q", class, key, value))
+			}
+			if prop.Capability != "" {
+				used[prop.Capability] = true
+			}
+			props[key] = value
+		}
+		for _, req := range rule.Required {
+			if _, ok := props[req]; !ok {
+				errs = append(errs, fmt.Sprintf("%s: missing %s", class, req))
+			}
+		}
+
+		var box [4]int
+		if _, err := fmt.Sscanf(props["bbox"], "%d %d %d %d", &box[0], &box[1], &box[2], &box[3]); err != nil {
+			return
+		}
+		boxes[n] = box
+		if box[0] > box[2] || box[1] > box[3] {
+			errs = append(errs, fmt.Sprintf("%s: inverted bbox %v", class, box))
+		}
+		if pb, ok := boxes[parent]; ok && parent != nil {
+			if box[0] < pb[0] || box[1] < pb[1] || box[2] > pb[2] || box[3] > pb[3] {
+				errs = append(errs, fmt.Sprintf("%s: bbox %v outside its %s %v", class, box, parent.attrs["class"], pb))
+			}
+		}
+	})
+	for c := range used {
+		if !capabilities[c] {
+			errs = append(errs, "ocr-capabilities lacks "+c)
+		}
+	}
+	return errs
+}
+
+func TestOCRPixelBox(t *testing.T) {
+	page := Rect{Max: Point{612, 792}}
+	opts := OCROptions{DPI: 144}
+	if w, h := opts.pixelSize(page); w != 1224 || h != 1584 {
+		t.Errorf("pixelSize = %d, %d", w, h)
+	}
+	got := opts.pixelBox(Rect{Point{72, 698}, Point{97.3, 708}}, page)
+	if want := [4]int{144, 168, 195, 188}; got != want {
+		t.Errorf("pixelBox = %v, want %v", got, want)
+	}
+	// Boxes are clamped to the page
+	if got := opts.pixelBox(Rect{Point{-10, 780}, Point{700, 800}}, page); got != [4]int{0, 0, 1224, 24} {
+		t.Errorf("pixelBox off the page = %v", got)
+	}
+	if s := (OCROptions{}).scale(); s != 300.0/72 {
+		t.Errorf("default scale = %v", s)
+	}
+}
@@ -5,6 +5,7 @@
 package pdf
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -151,6 +152,26 @@ func TestAppendTextOptimized(t *testing.T) {
 	}
 }
 
+func TestAppendTextMultiByte(t *testing.T) {
+	ce := &contentExtractor{}
+	g := &gstate{
+		Tfs: 12.0,
+		Th:  1.0,
+		Tm:  matrix{{1, 0, 0}, {0, 1, 0}, {100, 200, 1}},
+		CTM: matrix{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}},
+	}
+
+	ce.appendText(g, &nopEncoder{}, "中文")
+	ce.appendText(g, &nopEncoder{}, "A")
+	var got []string
+	for _, t := range ce.text {
+		got = append(got, t.S)
+	}
+	if strings.Join(got, "|") != "中|文|A" {
+		t.Errorf("texts = %q, want one per rune", got)
+	}
+}
+
 func TestParseFontStylesOptimized(t *testing.T) {
 	tests := []struct {
 		font       string
@@ -212,7 +212,9 @@ func (p Page) OptimizedGetTextByRow() (Rows, error) {
 		currentRow.Content = append(currentRow.Content, text)
 	}
 
-	p.walkTextBlocks(showText)
+	if err := p.walkTextBlocks(showText); err != nil {
+		return nil, err
+	}
 
 	for _, row := range result {
 		sort.Sort(row.Content)
@@ -282,7 +284,9 @@ func (p Page) OptimizedGetTextByColumn() (Columns, error) {
 		currentColumn.Content = append(currentColumn.Content, text)
 	}
 
-	p.walkTextBlocks(showText)
+	if err := p.walkTextBlocks(showText); err != nil {
+		return nil, err
+	}
 
 	for _, column := range result {
 		sort.Sort(column.Content)
@@ -145,6 +145,9 @@ func (p *Page) SetFontCacheInterface(cache FontCacheInterface) {
 
 // GetPlainText returns all the text in the PDF file
 func (r *Reader) GetPlainText() (reader io.Reader, err error) {
+	if err := r.checkExtract(); err != nil {
+		return &bytes.Buffer{}, err
+	}
 	pages := r.NumPage()
 
 	// Set a reasonable object cache capacity to prevent unlimited growth
@@ -186,6 +189,9 @@ func (r *Reader) GetPlainText() (reader io.Reader, err error) {
 
 // GetStyledTexts returns list all sentences in an array, that are included styles
 func (r *Reader) GetStyledTexts() (sentences []Text, err error) {
+	if err := r.checkExtract(); err != nil {
+		return nil, err
+	}
 	totalPage := r.NumPage()
 	for pageIndex := 1; pageIndex <= totalPage; pageIndex++ {
 		p := r.Page(pageIndex)
@@ -344,13 +350,60 @@ func (f Font) Widths() []float64 {
 }
 
 // Width returns the width of the given code point.
+// If the font has no Widths array, the advance width is taken from the
+// embedded TrueType/OpenType program when one is present.
 func (f Font) Width(code int) float64 {
+	widths := f.V.Key("Widths")
+	if widths.Kind() != Array {
+		return f.embeddedWidth(code)
+	}
 	first := f.FirstChar()
 	last := f.LastChar()
 	if code < first || last < code {
 		return 0
 	}
-	return f.V.Key("Widths").Index(code - first).Float64()
+	return widths.Index(code - first).Float64()
+}
+
+// embeddedWidth returns the width of a single-byte code from the embedded font program.
+func (f Font) embeddedWidth(code int) float64 {
+	if code < 0 || code > 255 || f.subtype() == "Type0" {
+		return 0
+	}
+	tt := f.EmbeddedTrueType()
+	if tt == nil {
+		return 0
+	}
+	gid, ok := tt.CodeToGlyph(byte(code))
+	if !ok {
+		return 0
+	}
+	return tt.GlyphWidth(gid)
+}
+
+// fontDescriptor returns the FontDescriptor of the font; for Type0 fonts the
+// descriptor of the descendant CIDFont is returned.
+func (f Font) fontDescriptor() Value {
+	if f.subtype() == "Type0" {
+		return f.descendantFont().Key("FontDescriptor")
+	}
+	return f.V.Key("FontDescriptor")
+}
+
+// EmbeddedTrueType returns the embedded TrueType/OpenType font program
+// (FontFile2, or FontFile3 with Subtype OpenType), or nil if there is none
+// or it cannot be parsed.
+func (f Font) EmbeddedTrueType() *TrueTypeFont {
+	ff := trueTypeFontFile(f.fontDescriptor())
+	if ff.Kind() != Stream {
+		return nil
+	}
+	return ff.r.embeddedTrueType(ff)
+}
+
+// isSymbolic reports whether the Symbolic flag is set in the font descriptor.
+func (f Font) isSymbolic() bool {
+	return f.fontDescriptor().Key("Flags").Int64()&4 != 0
 }
 
 // Encoder returns the encoding between font code point sequences and UTF-8.
@@ -460,7 +513,25 @@ func (f *Font) type0Encoder() TextEncoding {
 			return enc
 		}
 	case Name:
+		if name := encoding.Name(); name == "Identity-H" || name == "Identity-V" {
+			// Identity codes are font-specific CIDs, not Unicode; prefer a
+			// descendant ToUnicode or the embedded font's own cmap
+			if enc := f.cmapEncodingFromValue(f.descendantFont().Key("ToUnicode")); enc != nil {
+				return enc
+			}
+			if enc := f.cidGlyphEncoder(); enc != nil {
+				return enc
+			}
+			if enc := f.cidCollectionEncoder(); enc != nil {
+				return enc
+			}
+		}
 		// Try enhanced CMAP encoding first (includes CJK support)
+		if err := cmapTableError(predefinedCMapTableName(encoding.Name())); err != nil {
+			f.V.r.warn(WarnCMap, f.V.ptr, -1, "CMap %s: %v", encoding.Name(), err)
+		} else if unsupportedCMaps[encoding.Name()] {
+			f.V.r.warn(WarnCMap, f.V.ptr, -1, "predefined CMap %s is not supported; its codes are not decoded through it", encoding.Name())
+		}
 		if enc := LookupPredefinedCMap(encoding.Name()); enc != nil {
 			return enc
 		}
@@ -483,6 +554,14 @@ func (f *Font) type0Encoder() TextEncoding {
 		}
 	}
 
+	// Recover Unicode from the embedded TrueType program before guessing
+	if enc := f.cidGlyphEncoder(); enc != nil {
+		return enc
+	}
+	if enc := f.cidCollectionEncoder(); enc != nil {
+		return enc
+	}
+
 	// Final fallback to Identity-H encoding
 	fallback := "Identity-H"
 	if f.writingMode() == 1 {
@@ -541,7 +620,44 @@ func (f *Font) type1Encoder() TextEncoding {
 // trueTypeEncoder returns a text encoding for TrueType fonts
 func (f *Font) trueTypeEncoder() TextEncoding {
 	// TrueType fonts use the same encoding logic as Type1 fonts
-	return f.type1Encoder()
+	base := f.type1Encoder()
+	if f.V.Key("ToUnicode").Kind() == Stream {
+		return base
+	}
+
+	// Symbolic fonts and fonts without /Encoding address glyphs directly,
+	// so recover Unicode from the embedded font's cmap and post tables
+	if f.V.Key("Encoding").Kind() == Null || f.isSymbolic() {
+		if tt := f.EmbeddedTrueType(); tt != nil {
+			return &trueTypeGlyphEncoder{font: tt, base: base}
+		}
+	}
+	return base
+}
+
+// cidGlyphEncoder returns an encoder that maps CIDs of a CIDFontType2 font to
+// Unicode through its CIDToGIDMap and embedded TrueType program.
+func (f *Font) cidGlyphEncoder() TextEncoding {
+	desc := f.descendantFont()
+	if desc.Key("Subtype").Name() != "CIDFontType2" {
+		return nil
+	}
+	tt := f.EmbeddedTrueType()
+	if tt == nil || (tt.unicodeCmap == nil && tt.glyphNames == nil) {
+		return nil
+	}
+	return &trueTypeCIDEncoder{font: tt, cidToGID: readCIDToGIDMap(desc.Key("CIDToGIDMap"))}
+}
+
+// cidCollectionEncoder decodes Identity-encoded CIDs through the embedded
+// CID to Unicode table of the descendant's Adobe character collection.
+func (f *Font) cidCollectionEncoder() TextEncoding {
+	info := f.descendantFont().Key("CIDSystemInfo")
+	registry, ordering := info.Key("Registry").RawString(), info.Key("Ordering").RawString()
+	if err := cmapTableError(registry + "-" + ordering); err != nil {
+		f.V.r.warn(WarnCMap, f.V.ptr, -1, "character collection %s-%s: %v", registry, ordering, err)
+	}
+	return LookupCIDToUnicode(registry, ordering)
 }
 
 // parseCustomEncoding parses a custom encoding dictionary
@@ -574,7 +690,20 @@ func (f Font) descendantFont() Value {
 	return desc.Index(0)
 }
 
+// writingMode returns 1 for fonts that write vertically. The mode comes
+// from the CMap named or embedded as the font's Encoding; some producers
+// also put it on the descendant font.
 func (f Font) writingMode() int {
+	switch enc := f.V.Key("Encoding"); enc.Kind() {
+	case Name:
+		if GetCMapWritingMode(enc.Name()) == 1 {
+			return 1
+		}
+	case Stream:
+		if enc.Key("WMode").Int64() == 1 {
+			return 1
+		}
+	}
 	desc := f.descendantFont()
 	if desc.Kind() != Dict {
 		return 0
@@ -582,6 +711,16 @@ func (f Font) writingMode() int {
 	return int(desc.Key("WMode").Int64())
 }
 
+// verticalAdvance returns the default vertical displacement w1 of a CIDFont
+// in glyph space units, from the descendant font's DW2 entry.
+func (f Font) verticalAdvance() float64 {
+	dw2 := f.descendantFont().Key("DW2")
+	if dw2.Kind() == Array && dw2.Len() >= 2 {
+		return dw2.Index(1).Float64()
+	}
+	return -1000
+}
+
 func (f *Font) charmapEncoding() TextEncoding {
 	if enc := f.cmapEncodingFromValue(f.V.Key("ToUnicode")); enc != nil {
 		return enc
@@ -920,6 +1059,9 @@ type Text struct {
 	Bold      bool    // whether the text is bold
 	Italic    bool    // whether the text is italic
 	Underline bool    // whether the text is underlined
+
+	Visibility TextVisibility // whether clipping paths or the crop box hide the text
+	Color      Color          // the fill colour
 }
 
 // A Rect represents a rectangle.
@@ -952,45 +1094,29 @@ type gstate struct {
 	Tlm   matrix
 	Trm   matrix
 	CTM   matrix
+
+	clip  *clipRegion // current clipping region, nil for none
+	fill  Color       // current fill colour
+	fillN int         // components of the fill colour space, 0 if it has no RGB conversion
 }
 
 // GetPlainText returns the page's all text without format.
 // fonts can be passed in (to improve parsing performance) or left nil
 // ctx can be used to cancel the extraction operation (pass context.Background() if not needed)
 func (p *Page) GetPlainText(ctx context.Context, fonts map[string]*Font) (string, error) {
-	// Check if context is cancelled before starting expensive operation
-	if ctx != nil {
-		select {
-		case <-ctx.Done():
-			return "", ctx.Err()
-		default:
-		}
-	}
-
-	// Handle in case the content page is empty
-	if p.V.IsNull() || p.V.Key("Contents").Kind() == Null {
-		return "", nil
-	}
-
-	content, err := p.contentWithFonts(fonts)
-	if err != nil {
-		return "", wrapError("extract page content", err)
-	}
-
-	text := textRunsToPlain(content.Text)
-
-	// CRITICAL FIX: Clear fontCache reference after extraction to prevent memory leak.
-	// Without this, each Page retains the entire fontCache indefinitely, causing
-	// memory to grow from 400MB to 20-40GB when processing large batches.
-	p.fontCache = nil
-
-	return text, nil
+	return p.plainText(ctx, fonts, textRunsToPlain, nil)
 }
 
 // GetPlainTextWithSmartOrdering extracts plain text using an improved text ordering algorithm
 // that handles multi-column layouts and complex reading orders.
 // ctx can be used to cancel the extraction operation (pass context.Background() if not needed)
 func (p *Page) GetPlainTextWithSmartOrdering(ctx context.Context, fonts map[string]*Font) (string, error) {
+	return p.plainText(ctx, fonts, SmartTextRunsToPlain, nil)
+}
+
+// plainText extracts the page's text runs, optionally collapses overprinted
+// copies, and joins them with toPlain.
+func (p *Page) plainText(ctx context.Context, fonts map[string]*Font, toPlain func([]Text) string, dedup *DedupOptions) (string, error) {
 	// Check if context is cancelled before starting expensive operation
 	if ctx != nil {
 		select {
@@ -1010,9 +1136,15 @@ func (p *Page) GetPlainTextWithSmartOrdering(ctx context.Context, fonts map[stri
 		return "", wrapError("extract page content", err)
 	}
 
-	text := SmartTextRunsToPlain(content.Text)
+	texts := content.Text
+	if dedup != nil {
+		texts = DeduplicateTexts(texts, *dedup)
+	}
+	text := toPlain(texts)
 
-	// CRITICAL FIX: Clear fontCache reference after extraction to prevent memory leak
+	// CRITICAL FIX: Clear fontCache reference after extraction to prevent memory leak.
+	// Without this, each Page retains the entire fontCache indefinitely, causing
+	// memory to grow from 400MB to 20-40GB when processing large batches.
 	p.fontCache = nil
 
 	return text, nil
@@ -1253,7 +1385,9 @@ type Column struct {
 // Columns is a list of column
 type Columns []*Column
 
-// GetTextByColumn returns the page's all text grouped by column
+// GetTextByColumn returns the page's all text grouped by column.
+// It is not a view over PageLayout; see Page.Layout for text grouped by
+// line and block.
 func (p Page) GetTextByColumn() (Columns, error) {
 	var result Columns
 	var err error
@@ -1301,7 +1435,9 @@ func (p Page) GetTextByColumn() (Columns, error) {
 		currentColumn.Content = append(currentColumn.Content, text)
 	}
 
-	p.walkTextBlocks(showText)
+	if err := p.walkTextBlocks(showText); err != nil {
+		return nil, err
+	}
 
 	for _, column := range result {
 		sort.Sort(column.Content)
@@ -1323,7 +1459,9 @@ type Row struct {
 // Rows is a list of rows
 type Rows []*Row
 
-// GetTextByRow returns the page's all text grouped by rows
+// GetTextByRow returns the page's all text grouped by rows.
+// It is not a view over PageLayout; see Page.Layout for text grouped by
+// line and block.
 func (p Page) GetTextByRow() (Rows, error) {
 	var result Rows
 	var err error
@@ -1375,7 +1513,9 @@ func (p Page) GetTextByRow() (Rows, error) {
 		currentRow.Content = append(currentRow.Content, text)
 	}
 
-	p.walkTextBlocks(showText)
+	if err := p.walkTextBlocks(showText); err != nil {
+		return nil, err
+	}
 
 	for _, row := range result {
 		sort.Sort(row.Content)
@@ -1388,10 +1528,15 @@ func (p Page) GetTextByRow() (Rows, error) {
 	return result, err
 }
 
-func (p Page) walkTextBlocks(walker func(enc TextEncoding, x, y float64, s string)) {
+// walkTextBlocks calls walker for each string the page shows, or returns
+// a *PermissionError if the reader does not allow extracting text.
+func (p Page) walkTextBlocks(walker func(enc TextEncoding, x, y float64, s string)) error {
+	if err := p.V.r.checkExtract(); err != nil {
+		return err
+	}
 	// Handle in case the content page is empty
 	if p.V.IsNull() || p.V.Key("Contents").Kind() == Null {
-		return
+		return nil
 	}
 
 	scope := p.buildFontScope(p.Resources(), nil, nil)
@@ -1400,6 +1545,7 @@ func (p Page) walkTextBlocks(walker func(enc TextEncoding, x, y float64, s strin
 		walker: walker,
 	}
 	processor.process(p.V.Key("Contents"), p.Resources(), scope, ident)
+	return nil
 }
 
 type textProcessor struct {
@@ -1508,13 +1654,17 @@ func (tp *textProcessor) handleDo(arg Value, resources Value, scope *fontScope,
 	tp.process(xobj, formRes, childScope, childCTM)
 }
 
-// Content returns the page's content.
+// Content returns the page's content. It is empty if the page cannot be
+// read or the reader does not allow extracting text.
 func (p Page) Content() Content {
 	content, _ := p.contentWithFonts(nil)
 	return content
 }
 
 func (p Page) contentWithFonts(fonts map[string]*Font) (Content, error) {
+	if err := p.V.r.checkExtract(); err != nil {
+		return Content{}, err
+	}
 	var content Content
 	var err error
 	var scope *fontScope
@@ -1550,11 +1700,19 @@ func (p Page) contentWithFonts(fonts map[string]*Font) (Content, error) {
 	}
 	scope = p.buildFontScope(p.Resources(), fonts, nil)
 	initial := gstate{
-		Th:  1,
-		CTM: ident,
+		Th:    1,
+		CTM:   ident,
+		fillN: 1,
+	}
+	if box, ok := p.cropBox(); ok {
+		initial.clip = newClipRegion(box)
 	}
 	extractor.process(p.V.Key("Contents"), p.Resources(), scope, initial)
-	content = Content{extractor.text, extractor.rect}
+	text := extractor.text
+	if r := p.V.r; r == nil || !r.keepClippedText.Load() {
+		text = dropClippedText(text)
+	}
+	content = Content{text, extractor.rect}
 	// Note: we don't return slices to pool here because they're now owned by Content
 	// The caller should call PutContentExtractorSlices when done if needed
 	return content, err
@@ -1581,6 +1739,8 @@ func (ce *contentExtractor) process(strm Value, resources Value, scope *fontScop
 	g := initial
 	var enc TextEncoding = &nopEncoder{}
 	var gstack []gstate
+	var path pathBuilder
+	var clipOp string // pending W or W* operator
 	Interpret(strm, func(stk *Stack, op string) {
 		args := stk.DrainTo(ce.argBuf)
 		ce.argBuf = args[:0] // keep buffer for reuse, avoid holding references
@@ -1605,6 +1765,67 @@ func (ce *contentExtractor) process(strm Value, resources Value, scope *fontScop
 			}
 			x, y, w, h := args[0].Float64(), args[1].Float64(), args[2].Float64(), args[3].Float64()
 			ce.rect = append(ce.rect, Rect{Point{x, y}, Point{x + w, y + h}})
+			path.rect(g.CTM, x, y, w, h)
+
+		case "m", "l":
+			if len(args) != 2 {
+				// Skip a malformed path operator rather than lose the page
+				break
+			}
+			if op == "m" {
+				path.moveTo(g.CTM, args[0].Float64(), args[1].Float64())
+			} else {
+				path.lineTo(g.CTM, args[0].Float64(), args[1].Float64())
+			}
+
+		case "c", "v", "y":
+			var f [6]float64
+			for i := range args {
+				if i < len(f) {
+					f[i] = args[i].Float64()
+				}
+			}
+			// Other operand counts are malformed and skipped
+			switch {
+			case op == "c" && len(args) == 6:
+				path.curveTo(g.CTM, f[0], f[1], f[2], f[3], f[4], f[5])
+			case op == "v" && len(args) == 4:
+				path.curveTo(g.CTM, path.last.X, path.last.Y, f[0], f[1], f[2], f[3])
+			case op == "y" && len(args) == 4:
+				path.curveTo(g.CTM, f[0], f[1], f[2], f[3], f[2], f[3])
+			}
+
+		case "h":
+			path.closeSubpath()
+
+		case "W", "W*":
+			// Takes effect when the path is ended by the next painting operator
+			clipOp = op
+
+		case "n", "S", "s", "f", "F", "f*", "B", "B*", "b", "b*":
+			polys := path.finish()
+			if clipOp != "" {
+				g.clip = g.clip.intersect(polys, clipOp == "W*")
+				clipOp = ""
+			}
+
+		case "g", "rg", "k":
+			g.fillN = len(args)
+			if c, ok := colorFromArgs(args, g.fillN); ok {
+				g.fill = c
+			}
+
+		case "sc", "scn":
+			// Colours in spaces without a conversion are left unknown
+			c, _ := colorFromArgs(args, g.fillN)
+			g.fill = c
+
+		case "cs":
+			// Every colour space starts out black
+			if len(args) == 1 {
+				g.fillN = colorSpaceComponents(resources, args[0])
+			}
+			g.fill = Color{}
 
 		case "q":
 			gstack = append(gstack, g)
@@ -1694,11 +1915,21 @@ func (ce *contentExtractor) process(strm Value, resources Value, scope *fontScop
 				if x.Kind() == String {
 					ce.appendText(&g, enc, x.RawString())
 				} else {
+					if g.Tf.writingMode() == 1 {
+						ty := -x.Float64() / 1000 * g.Tfs
+						g.Tm = matrix{{1, 0, 0}, {0, 1, 0}, {0, ty, 1}}.mul(g.Tm)
+						continue
+					}
 					tx := -x.Float64() / 1000 * g.Tfs * g.Th
 					g.Tm = matrix{{1, 0, 0}, {0, 1, 0}, {tx, 0, 1}}.mul(g.Tm)
 				}
 			}
-			ce.appendText(&g, enc, "\n")
+			// The line break marker is not font-encoded and must not move
+			// the text position, which matters for CMaps such as UTF-16
+			// and for vertical writing
+			tm := g.Tm
+			ce.appendText(&g, &nopEncoder{}, "\n")
+			g.Tm = tm
 
 		case "TL":
 			if len(args) != 1 {
@@ -1763,6 +1994,10 @@ func (ce *contentExtractor) appendText(g *gstate, enc TextEncoding, s string) {
 	}
 
 	vertical := g.Tf.writingMode() == 1
+	var w1 float64
+	if vertical {
+		w1 = g.Tf.verticalAdvance()
+	}
 
 	// Aggressive pre-allocation strategy to minimize reallocations
 	oldLen := len(ce.text)
@@ -1822,12 +2057,17 @@ func (ce *contentExtractor) appendText(g *gstate, enc TextEncoding, s string) {
 	// Cache CTM values for faster access
 	ctm := g.CTM
 
+	// Word spacing applies to the single-byte code 32 in simple fonts only
+	wordSpacing := g.Tw != 0 && g.Tf.subtype() != "Type0"
+
 	// Batch processing: fill slice directly instead of append
 	n := 0
-	for i, ch := range decoded {
+	for _, ch := range decoded {
 		var w0 float64
+		code := -1
 		if n < len(s) {
-			w0 = g.Tf.Width(int(s[n]))
+			code = int(s[n])
+			w0 = g.Tf.Width(code)
 		}
 		n++
 
@@ -1851,7 +2091,8 @@ func (ce *contentExtractor) appendText(g *gstate, enc TextEncoding, s string) {
 		trm21 := temp20*ctm[0][1] + temp21*ctm[1][1] + temp22*ctm[2][1]
 
 		// Direct assignment instead of append - no reallocation
-		ce.text[oldLen+i] = Text{
+		t := &ce.text[oldLen+n-1]
+		*t = Text{
 			f,
 			trm00,
 			trm20,
@@ -1862,15 +2103,39 @@ func (ce *contentExtractor) appendText(g *gstate, enc TextEncoding, s string) {
 			bold,
 			italic,
 			underline,
+			TextVisible,
+			g.fill,
+		}
+		if g.clip != nil {
+			t.Visibility = g.clip.visibility(glyphBounds(*t, math.Max(math.Abs(trm00), 1)))
+		}
+
+		if vertical {
+			// Vertical writing advances down the column instead
+			ty := w1/1000*g.Tfs + g.Tc
+			if wordSpacing && code == ' ' {
+				ty += g.Tw
+			}
+			g.Tm[2][0] += ty * g.Tm[1][0]
+			g.Tm[2][1] += ty * g.Tm[1][1]
+			g.Tm[2][2] += ty * g.Tm[1][2]
+			continue
 		}
 
 		tx := w0/1000*g.Tfs + g.Tc
+		if wordSpacing && code == ' ' {
+			tx += g.Tw
+		}
 		tx *= g.Th
 		// Update g.Tm inline: g.Tm = matrix{{1, 0, 0}, {0, 1, 0}, {tx, 0, 1}}.mul(g.Tm)
 		g.Tm[2][0] += tx * g.Tm[0][0]
 		g.Tm[2][1] += tx * g.Tm[0][1]
 		g.Tm[2][2] += tx * g.Tm[0][2]
 	}
+
+	// Multi-byte runes produce fewer entries than decoded bytes; drop the
+	// unused tail so no stale or empty Text values leak into the output
+	ce.text = ce.text[:oldLen+n]
 }
 
 func (ce *contentExtractor) handleDo(arg Value, resources Value, scope *fontScope, g gstate) {
@@ -1911,6 +2176,10 @@ func (ce *contentExtractor) handleDo(arg Value, resources Value, scope *fontScop
 	if m, ok := matrixFromValue(xobj.Key("Matrix")); ok {
 		childState.CTM = m.mul(childState.CTM)
 	}
+	// Forms are clipped to their bounding box
+	if bbox, ok := rectFromValue(xobj.Key("BBox")); ok {
+		childState.clip = childState.clip.intersect(rectPolygon(childState.CTM, bbox), false)
+	}
 
 	// Track this XObject visit and increment recursion depth
 	ce.visitedXObjects[name]++
@@ -305,6 +305,9 @@ func NewParallelTextExtractor(workers int) *ParallelTextExtractor {
 
 // ExtractWithParallelProcessing extracts text using multi-level parallel processing
 func (pte *ParallelTextExtractor) ExtractWithParallelProcessing(ctx context.Context, reader *Reader) ([]Text, error) {
+	if err := reader.checkExtract(); err != nil {
+		return nil, err
+	}
 	totalPages := reader.NumPage()
 	if totalPages == 0 {
 		return []Text{}, nil
@@ -0,0 +1,932 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PDFALevel is a part and conformance level of PDF/A (ISO 19005), such as
+// "2b": the part number and a lower-case a, b or u.
+type PDFALevel string
+
+const (
+	PDFA1B PDFALevel = "1b"
+	PDFA2B PDFALevel = "2b"
+	PDFA3B PDFALevel = "3b"
+	PDFA2U PDFALevel = "2u"
+	PDFA3U PDFALevel = "3u"
+)
+
+func (l PDFALevel) valid() bool {
+	return len(l) == 2 && l[0] >= '1' && l[0] <= '3' &&
+		(l[1] == 'a' || l[1] == 'b' || l[1] == 'u' && l[0] != '1')
+}
+
+func (l PDFALevel) part() int {
+	return int(l[0] - '0')
+}
+
+// unicode reports whether the level requires text to map to Unicode.
+func (l PDFALevel) unicode() bool {
+	return l[1] == 'a' || l[1] == 'u'
+}
+
+// pdfaClauses gives the clause of each rule CheckPDFA applies in ISO
+// 19005-1 and in ISO 19005-2, whose numbering ISO 19005-3 follows. An empty
+// clause means the part has no such rule.
+var pdfaClauses = map[string][2]string{
+	"trailer":        {"6.1.3", "6.1.3"},
+	"filters":        {"6.1.10", "6.1.7.2"},
+	"output-intent":  {"6.2.2", "6.2.3"},
+	"transparency":   {"6.4", ""},
+	"font-embedding": {"6.3.4", "6.2.11.4.1"},
+	"font-glyphs":    {"6.3.4", "6.2.11.4.1"},
+	"unicode":        {"6.3.8", "6.2.11.7.2"},
+	"actions":        {"6.6.1", "6.5.1"},
+	"trigger-events": {"6.6.2", "6.5.2"},
+	"metadata":       {"6.7.2", "6.6.2.1"},
+	"info":           {"6.7.3", "6.6.3"},
+	"identification": {"6.7.11", "6.6.4"},
+}
+
+var pdfaStandards = [...]string{1: "ISO 19005-1:2005", 2: "ISO 19005-2:2011", 3: "ISO 19005-3:2012"}
+
+// A PDFAFailure is a rule of PDF/A that a document breaks.
+type PDFAFailure struct {
+	Rule       string `json:"rule"`   // such as "font-embedding"
+	Clause     string `json:"clause"` // such as "ISO 19005-2:2011, 6.2.11.4.1"
+	Object     int    `json:"object"` // the offending object, or 0
+	Generation int    `json:"generation"`
+	Message    string `json:"message"`
+}
+
+func (f PDFAFailure) String() string {
+	if f.Object != 0 {
+		return fmt.Sprintf("%s: %d %d R: %s", f.Clause, f.Object, f.Generation, f.Message)
+	}
+	return fmt.Sprintf("%s: %s", f.Clause, f.Message)
+}
+
+// A PDFAReport is the result of CheckPDFA.
+type PDFAReport struct {
+	Level    PDFALevel // the level checked
+	Claimed  PDFALevel // the level the XMP metadata identifies, or ""
+	Failures []PDFAFailure
+}
+
+// Conforms reports whether the document passed every check.
+func (r *PDFAReport) Conforms() bool {
+	return len(r.Failures) == 0
+}
+
+// CheckPDFA checks the document against the rules of PDF/A level that can
+// be checked on its object graph, or against the level its XMP metadata
+// identifies if level is empty, or 2b if it identifies none. It checks:
+// that there is no encryption, LZW compression, JavaScript or other
+// forbidden action; that the document has a PDF/A output intent with an ICC
+// profile; that its metadata identifies the level and agrees with the
+// document information dictionary; that each font used on a page is
+// embedded, and that TrueType and Type 1 programs have a glyph for each
+// code used; for PDF/A-1, that there is no transparency; and for levels u
+// and a, that each code used maps to Unicode. It does not check the
+// logical structure that level a requires, nor colour spaces.
+func (r *Reader) CheckPDFA(level PDFALevel) (*PDFAReport, error) {
+	if level != "" && !level.valid() {
+		return nil, fmt.Errorf("unknown PDF/A level %q", level)
+	}
+	c := &pdfaChecker{r: r, report: &PDFAReport{Level: level}, fonts: make(map[pdfaFontKey]*pdfaFont), forms: make(map[objptr]bool)}
+	catalog := r.Trailer().Key("Root")
+	xmp := c.checkIdentification(catalog)
+	c.checkInfo(xmp)
+	c.checkTrailer()
+	c.checkOutputIntent(catalog)
+	c.checkObjects()
+	if catalog.Key("Names").Key("JavaScript").Kind() != Null {
+		c.fail("actions", objptr{}, "name dictionary has JavaScript")
+	}
+	c.checkFonts()
+	return c.report, nil
+}
+
+type pdfaChecker struct {
+	r      *Reader
+	report *PDFAReport
+	fonts  map[pdfaFontKey]*pdfaFont
+	used   []*pdfaFont // fonts in order of first use
+	forms  map[objptr]bool
+}
+
+func (c *pdfaChecker) fail(rule string, ptr objptr, format string, args ...interface{}) {
+	part := c.report.Level.part()
+	clause := pdfaClauses[rule][min(part, 2)-1]
+	if clause == "" {
+		return
+	}
+	c.report.Failures = append(c.report.Failures, PDFAFailure{
+		Rule:       rule,
+		Clause:     pdfaStandards[part] + ", " + clause,
+		Object:     int(ptr.id),
+		Generation: int(ptr.gen),
+		Message:    fmt.Sprintf(format, args...),
+	})
+}
+
+// XMP namespaces of the properties CheckPDFA reads.
+const (
+	xmpRDF    = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+	xmpPDFAID = "http://www.aiim.org/pdfa/ns/id/"
+	xmpDC     = "http://purl.org/dc/elements/1.1/"
+	xmpPDF    = "http://ns.adobe.com/pdf/1.3/"
+	xmpBasic  = "http://ns.adobe.com/xap/1.0/"
+)
+
+// checkIdentification reads the XMP metadata of the catalog and sets the
+// claimed level and, if not given, the level to check. It returns the
+// metadata properties.
+func (c *pdfaChecker) checkIdentification(catalog Value) map[string]string {
+	var props map[string]string
+	if md := catalog.Key("Metadata"); md.Kind() == Stream {
+		rc := md.Reader()
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err == nil {
+			props = xmpProperties(data)
+		}
+	}
+	part := props[xmpPDFAID+" part"]
+	conformance := strings.ToLower(props[xmpPDFAID+" conformance"])
+	claimed := PDFALevel(part + conformance)
+	if claimed.valid() {
+		c.report.Claimed = claimed
+	}
+	if c.report.Level == "" {
+		c.report.Level = PDFA2B
+		if c.report.Claimed != "" {
+			c.report.Level = c.report.Claimed
+		}
+	}
+
+	root, _ := c.r.trailer["Root"].(objptr)
+	switch {
+	case props == nil:
+		c.fail("metadata", root, "catalog has no readable XMP Metadata stream")
+		c.fail("identification", root, "no XMP metadata to identify the PDF/A level")
+	case part == "" || conformance == "":
+		c.fail("identification", root, "XMP metadata lacks pdfaid:part or pdfaid:conformance")
+	case !claimed.valid():
+		c.fail("identification", root, "XMP metadata identifies an invalid level: part %q, conformance %q", part, props[xmpPDFAID+" conformance"])
+	case claimed != c.report.Level:
+		c.fail("identification", root, "XMP metadata identifies PDF/A-%s, not PDF/A-%s", claimed, c.report.Level)
+	}
+	return props
+}
+
+// infoXMP pairs each text entry of the document information dictionary with
+// its XMP property.
+var infoXMP = []struct{ key, prop string }{
+	{"Title", xmpDC + " title"},
+	{"Author", xmpDC + " creator"},
+	{"Subject", xmpDC + " description"},
+	{"Keywords", xmpPDF + " Keywords"},
+	{"Creator", xmpBasic + " CreatorTool"},
+	{"Producer", xmpPDF + " Producer"},
+}
+
+// checkInfo checks that each entry of the document information dictionary
+// has the same value in the XMP metadata.
+func (c *pdfaChecker) checkInfo(props map[string]string) {
+	info := c.r.Trailer().Key("Info")
+	if info.Kind() != Dict || props == nil {
+		return
+	}
+	ptr, _ := c.r.trailer["Info"].(objptr)
+	for _, e := range infoXMP {
+		v := info.Key(e.key)
+		if v.Kind() != String {
+			continue
+		}
+		x, ok := props[e.prop]
+		if !ok {
+			c.fail("info", ptr, "Info %s has no equivalent XMP property", e.key)
+		} else if x != v.Text() {
+			c.fail("info", ptr, "Info %s is %q, but the XMP metadata has %q", e.key, v.Text(), x)
+		}
+	}
+	for _, e := range []struct{ key, prop string }{
+		{"CreationDate", xmpBasic + " CreateDate"},
+		{"ModDate", xmpBasic + " ModifyDate"},
+	} {
+		v := info.Key(e.key)
+		if v.Kind() != String {
+			continue
+		}
+		x, ok := props[e.prop]
+		if !ok {
+			c.fail("info", ptr, "Info %s has no equivalent XMP property", e.key)
+			continue
+		}
+		t, xt := parsePDFDate(v), parseXMPDate(x)
+		if !t.IsZero() && !xt.IsZero() && !t.Equal(xt) {
+			c.fail("info", ptr, "Info %s is %v, but the XMP metadata has %v", e.key, t, xt)
+		}
+	}
+}
+
+// xmpProperties returns the properties of an XMP packet, keyed by namespace
+// and name, such as xmpPDFAID+" part". The value of an array or alternative
+// is its first item.
+func xmpProperties(data []byte) map[string]string {
+	props := make(map[string]string)
+	d := xml.NewDecoder(bytes.NewReader(data))
+	d.Strict = false
+	var depth int
+	desc := -1 // depth of the rdf:Description
+	var prop string
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			break
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if t.Name.Space == xmpRDF && t.Name.Local == "Description" {
+				desc = depth
+				for _, a := range t.Attr {
+					if a.Name.Space != "" && a.Name.Space != "xmlns" && a.Name.Space != xmpRDF {
+						props[a.Name.Space+" "+a.Name.Local] = a.Value
+					}
+				}
+			} else if desc >= 0 && depth == desc+1 {
+				prop = t.Name.Space + " " + t.Name.Local
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+			switch {
+			case depth == desc:
+				desc = -1
+			case desc >= 0 && depth == desc+1:
+				prop = ""
+			}
+		case xml.CharData:
+			if s := strings.TrimSpace(string(t)); s != "" && prop != "" {
+				if _, ok := props[prop]; !ok {
+					props[prop] = s
+				}
+			}
+		}
+	}
+	return props
+}
+
+// parseXMPDate parses an XMP date, or returns the zero time.
+func parseXMPDate(s string) time.Time {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04Z07:00", "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (c *pdfaChecker) checkTrailer() {
+	if c.r.trailer["Encrypt"] != nil {
+		c.fail("trailer", objptr{}, "document is encrypted")
+	}
+	if c.r.Trailer().Key("ID").Len() != 2 {
+		c.fail("trailer", objptr{}, "trailer has no file identifier (ID)")
+	}
+}
+
+// checkOutputIntent checks for a PDF/A output intent with an ICC profile.
+func (c *pdfaChecker) checkOutputIntent(catalog Value) {
+	root, _ := c.r.trailer["Root"].(objptr)
+	intents := catalog.Key("OutputIntents")
+	for i := 0; i < intents.Len(); i++ {
+		intent := intents.Index(i)
+		if intent.Key("S").Name() != "GTS_PDFA1" {
+			continue
+		}
+		profile := intent.Key("DestOutputProfile")
+		if profile.Kind() != Stream {
+			c.fail("output-intent", root, "PDF/A output intent has no DestOutputProfile")
+			return
+		}
+		rc := profile.Reader()
+		header := make([]byte, 40)
+		_, err := io.ReadFull(rc, header)
+		rc.Close()
+		if err != nil || string(header[36:40]) != "acsp" {
+			c.fail("output-intent", profile.data.(stream).ptr, "DestOutputProfile is not an ICC profile")
+		}
+		return
+	}
+	c.fail("output-intent", root, "catalog has no PDF/A output intent (OutputIntents with S GTS_PDFA1)")
+}
+
+// forbiddenActions lists the action types PDF/A forbids, and the part that
+// first does.
+var forbiddenActions = map[string]int{
+	"Launch":      1,
+	"Sound":       1,
+	"Movie":       1,
+	"ResetForm":   1,
+	"ImportData":  1,
+	"JavaScript":  1,
+	"Hide":        2,
+	"SetOCGState": 2,
+	"Rendition":   2,
+	"Trans":       2,
+	"GoTo3DView":  2,
+}
+
+// checkObjects checks every object of the file for LZW compression,
+// forbidden actions and additional actions, and, for PDF/A-1, transparency.
+func (c *pdfaChecker) checkObjects() {
+	for i, x := range c.r.xref {
+		if x.ptr.id != uint32(i) || i == 0 || !x.inStream && x.offset == 0 {
+			continue
+		}
+		var v Value
+		safely(func() {
+			v = c.r.resolve(objptr{}, x.ptr)
+		})
+		c.checkObject(x.ptr, v.data)
+	}
+}
+
+func (c *pdfaChecker) checkObject(ptr objptr, x object) {
+	switch x := x.(type) {
+	case array:
+		for _, v := range x {
+			c.checkObject(ptr, v)
+		}
+	case stream:
+		c.checkObject(ptr, x.hdr)
+		filters := []object{x.hdr["Filter"]}
+		if a, ok := x.hdr["Filter"].(array); ok {
+			filters = a
+		}
+		for _, f := range filters {
+			if f == name("LZWDecode") {
+				c.fail("filters", ptr, "stream uses the LZWDecode filter")
+			}
+		}
+	case dict:
+		c.checkDict(ptr, x)
+		for _, k := range sortedKeys(x) {
+			c.checkObject(ptr, x[name(k)])
+		}
+	}
+}
+
+func (c *pdfaChecker) checkDict(ptr objptr, d dict) {
+	part := c.report.Level.part()
+	typ, _ := d["Type"].(name)
+	if s, ok := d["S"].(name); ok && typ != "Group" {
+		if p, ok := forbiddenActions[string(s)]; ok && part >= p {
+			c.fail("actions", ptr, "%s action", s)
+		}
+		if n, ok := d["N"].(name); ok && s == "Named" {
+			switch n {
+			case "NextPage", "PrevPage", "FirstPage", "LastPage":
+			default:
+				c.fail("actions", ptr, "named action %s", n)
+			}
+		}
+	}
+	if d["AA"] != nil && (typ == "Catalog" || typ == "Page" || d["Subtype"] == name("Widget") || d["FT"] != nil) {
+		c.fail("trigger-events", ptr, "%s has additional actions (AA)", pdfaDictKind(d))
+	}
+	if part != 1 {
+		return
+	}
+	if s, ok := d["SMask"]; ok && s != name("None") {
+		c.fail("transparency", ptr, "soft mask (SMask)")
+	}
+	if bm, ok := d["BM"]; ok && bm != name("Normal") && bm != name("Compatible") {
+		c.fail("transparency", ptr, "blend mode %v", objfmt(bm))
+	}
+	for _, k := range []name{"CA", "ca"} {
+		switch a := d[k].(type) {
+		case int64:
+			if a != 1 {
+				c.fail("transparency", ptr, "%s is %d", k, a)
+			}
+		case float64:
+			if a != 1 {
+				c.fail("transparency", ptr, "%s is %g", k, a)
+			}
+		}
+	}
+	if d["S"] == name("Transparency") {
+		c.fail("transparency", ptr, "transparency group")
+	}
+}
+
+func pdfaDictKind(d dict) string {
+	if t, ok := d["Type"].(name); ok {
+		return string(t)
+	}
+	if d["FT"] != nil {
+		return "form field"
+	}
+	return "annotation"
+}
+
+// A pdfaFontKey identifies a font: by its object, or, for a direct font
+// dictionary, by the object of its resources and its name there.
+type pdfaFontKey struct {
+	ptr  objptr
+	name string
+}
+
+// A pdfaFont is a font used on a page, with the codes shown in it.
+type pdfaFont struct {
+	font  Font
+	ptr   objptr
+	name  string
+	wide  bool // codes are two-byte CIDs of an Identity CMap
+	codes map[int]bool
+}
+
+func (u *pdfaFont) add(raw string) {
+	if u.font.subtype() == "Type0" {
+		switch u.font.V.Key("Encoding").Name() {
+		case "Identity-H", "Identity-V":
+		default:
+			return // codes cannot be split without the CMap
+		}
+		for i := 0; i+1 < len(raw); i += 2 {
+			u.codes[int(raw[i])<<8|int(raw[i+1])] = true
+		}
+		return
+	}
+	for i := 0; i < len(raw); i++ {
+		u.codes[int(raw[i])] = true
+	}
+}
+
+func (u *pdfaFont) sortedCodes() []int {
+	codes := make([]int, 0, len(u.codes))
+	for code := range u.codes {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	return codes
+}
+
+func (u *pdfaFont) raw(code int) string {
+	if u.wide {
+		return string([]byte{byte(code >> 8), byte(code)})
+	}
+	return string([]byte{byte(code)})
+}
+
+func (u *pdfaFont) format(codes []int) string {
+	var s []string
+	for i, code := range codes {
+		if i == 8 {
+			s = append(s, fmt.Sprintf("and %d more", len(codes)-i))
+			break
+		}
+		if u.wide {
+			s = append(s, fmt.Sprintf("0x%04X", code))
+		} else {
+			s = append(s, fmt.Sprintf("0x%02X", code))
+		}
+	}
+	return strings.Join(s, ", ")
+}
+
+// usedFont returns the font named in resources res.
+func (c *pdfaChecker) usedFont(res Value, fontName string) *pdfaFont {
+	fonts := res.Key("Font")
+	v := fonts.Key(fontName)
+	if v.Kind() != Dict {
+		return nil
+	}
+	var key pdfaFontKey
+	if d, ok := fonts.data.(dict); ok {
+		if ptr, ok := d[name(fontName)].(objptr); ok {
+			key.ptr = ptr
+		}
+	}
+	if key.ptr == (objptr{}) {
+		key = pdfaFontKey{fonts.ptr, fontName}
+	}
+	if u, ok := c.fonts[key]; ok {
+		return u
+	}
+	u := &pdfaFont{font: Font{V: v}, ptr: key.ptr, name: fontName, codes: make(map[int]bool)}
+	u.wide = u.font.subtype() == "Type0"
+	c.fonts[key] = u
+	c.used = append(c.used, u)
+	return u
+}
+
+// walkContent records the codes shown in the content stream strm, with
+// resources res, and in the forms it paints.
+func (c *pdfaChecker) walkContent(strm, res Value) {
+	var font *pdfaFont
+	var args []Value
+	show := func(s Value) {
+		if font != nil && s.Kind() == String {
+			font.add(s.RawString())
+		}
+	}
+	safely(func() {
+		Interpret(strm, func(stk *Stack, op string) {
+			args = stk.DrainTo(args)
+			if len(args) == 0 {
+				return
+			}
+			last := args[len(args)-1]
+			switch op {
+			case "Tf":
+				if len(args) == 2 {
+					font = c.usedFont(res, args[0].Name())
+				}
+			case "Tj", "'", "\"":
+				show(last)
+			case "TJ":
+				for i := 0; i < last.Len(); i++ {
+					show(last.Index(i))
+				}
+			case "Do":
+				c.walkForm(res.Key("XObject").Key(last.Name()), res)
+			}
+		})
+	})
+}
+
+// walkForm walks the form XObject or appearance stream v, once.
+func (c *pdfaChecker) walkForm(v, res Value) {
+	strm, ok := v.data.(stream)
+	if !ok || c.forms[strm.ptr] {
+		return
+	}
+	if t := v.Key("Subtype").Name(); t != "" && t != "Form" {
+		return
+	}
+	c.forms[strm.ptr] = true
+	if own := v.Key("Resources"); own.Kind() == Dict {
+		res = own
+	}
+	c.walkContent(v, res)
+}
+
+// checkFonts checks the fonts used on the pages and in the appearances of
+// their annotations.
+func (c *pdfaChecker) checkFonts() {
+	for i := 1; i <= c.r.NumPage(); i++ {
+		p := c.r.Page(i)
+		if p.V.Kind() != Dict {
+			continue
+		}
+		if contents := p.V.Key("Contents"); contents.Kind() != Null {
+			c.walkContent(contents, p.Resources())
+		}
+		annots := p.V.Key("Annots")
+		for j := 0; j < annots.Len(); j++ {
+			ap := annots.Index(j).Key("AP").Key("N")
+			if ap.Kind() == Stream {
+				c.walkForm(ap, p.Resources())
+				continue
+			}
+			for _, k := range ap.Keys() {
+				c.walkForm(ap.Key(k), p.Resources())
+			}
+		}
+	}
+	for _, u := range c.used {
+		if c.checkEmbedded(u) {
+			c.checkGlyphs(u)
+		}
+		if c.report.Level.unicode() {
+			c.checkUnicode(u)
+		}
+	}
+}
+
+// fontFile returns the embedded program of f and the key that holds it.
+func fontFile(f Font) (Value, string) {
+	desc := f.fontDescriptor()
+	for _, k := range []string{"FontFile", "FontFile2", "FontFile3"} {
+		if v := desc.Key(k); v.Kind() == Stream {
+			return v, k
+		}
+	}
+	return Value{}, ""
+}
+
+// checkEmbedded reports whether font u is embedded, or is a Type 3 font,
+// which needs no program.
+func (c *pdfaChecker) checkEmbedded(u *pdfaFont) bool {
+	if u.font.subtype() == "Type3" {
+		return true
+	}
+	if _, k := fontFile(u.font); k == "" {
+		c.fail("font-embedding", u.ptr, "font %s (%s) is not embedded", u.name, u.font.BaseFont())
+		return false
+	}
+	return true
+}
+
+// checkGlyphs checks that the program of font u has a glyph for each code
+// shown. Only TrueType, Type 1, CFF and Type 3 fonts are checked.
+func (c *pdfaChecker) checkGlyphs(u *pdfaFont) {
+	var missing []int
+	has := c.glyphChecker(u)
+	if has == nil {
+		return
+	}
+	for _, code := range u.sortedCodes() {
+		if !has(code) {
+			missing = append(missing, code)
+		}
+	}
+	if len(missing) > 0 {
+		c.fail("font-glyphs", u.ptr, "embedded program of font %s (%s) has no glyphs for codes %s", u.name, u.font.BaseFont(), u.format(missing))
+	}
+}
+
+// glyphChecker returns a function reporting whether font u has a glyph for
+// a code, or nil if it cannot tell.
+func (c *pdfaChecker) glyphChecker(u *pdfaFont) func(code int) bool {
+	f := u.font
+	ff, key := fontFile(f)
+	// OpenType programs with TrueType outlines are checked as TrueType
+	if tt := f.EmbeddedTrueType(); key == "FontFile3" && tt != nil && !tt.IsCFF {
+		key = "FontFile2"
+	}
+	switch {
+	case f.subtype() == "Type3":
+		procs := f.V.Key("CharProcs")
+		return func(code int) bool {
+			return procs.Key(differencesName(f.V.Key("Encoding"), byte(code))).Kind() == Stream
+		}
+
+	case f.subtype() == "Type0" && f.descendantFont().Key("Subtype").Name() == "CIDFontType0":
+		cff := c.embeddedCFF(u, ff)
+		if cff == nil {
+			return nil
+		}
+		if !cff.IsCID() {
+			// A simple CFF font in a CIDFont uses CIDs as glyph indexes
+			return func(cid int) bool { return cid < cff.NumGlyphs() }
+		}
+		return func(cid int) bool {
+			gid, ok := cff.CIDGlyph(cid)
+			return ok && gid < cff.NumGlyphs()
+		}
+
+	case f.subtype() == "Type0":
+		tt := f.EmbeddedTrueType()
+		desc := f.descendantFont()
+		if tt == nil || desc.Key("Subtype").Name() != "CIDFontType2" {
+			return nil
+		}
+		var cidToGID []uint16
+		if m := desc.Key("CIDToGIDMap"); m.Kind() == Stream {
+			cidToGID = readCIDToGIDMap(m)
+		}
+		return func(cid int) bool {
+			gid := cid
+			if cidToGID != nil {
+				gid = 0
+				if cid < len(cidToGID) {
+					gid = int(cidToGID[cid])
+				}
+			}
+			return (gid != 0 || cid == 0) && gid < tt.NumGlyphs
+		}
+
+	case key == "FontFile3":
+		cff := c.embeddedCFF(u, ff)
+		if cff == nil || cff.IsCID() {
+			return nil
+		}
+		enc := f.V.Key("Encoding")
+		return func(code int) bool {
+			glyph := differencesName(enc, byte(code))
+			if glyph == "" && enc.Kind() != Null && !f.isSymbolic() {
+				glyph = runeGlyphName(simpleCodeRune(f, byte(code)))
+			}
+			var gid int
+			var ok bool
+			if glyph != "" {
+				gid, ok = cff.GlyphIndex(glyph)
+			} else {
+				gid, ok = cff.CodeGlyph(byte(code))
+			}
+			return ok && gid != 0 && gid < cff.NumGlyphs()
+		}
+
+	case key == "FontFile2":
+		tt := f.EmbeddedTrueType()
+		if tt == nil {
+			c.fail("font-embedding", u.ptr, "embedded program of font %s (%s) cannot be read", u.name, f.BaseFont())
+			return nil
+		}
+		return func(code int) bool {
+			gid, ok := trueTypeGlyph(f, tt, byte(code))
+			return ok && gid != 0 && int(gid) < tt.NumGlyphs
+		}
+
+	case key == "FontFile":
+		rc := ff.Reader()
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		var t1 *Type1Font
+		if err == nil {
+			t1, err = NewType1Font(data)
+		}
+		if err != nil || len(t1.charStrings) == 0 {
+			c.fail("font-embedding", u.ptr, "embedded program of font %s (%s) cannot be read", u.name, f.BaseFont())
+			return nil
+		}
+		enc := f.V.Key("Encoding")
+		return func(code int) bool {
+			glyph := differencesName(enc, byte(code))
+			if glyph == "" && enc.Kind() != Null && !f.isSymbolic() {
+				glyph = runeGlyphName(simpleCodeRune(f, byte(code)))
+			}
+			if glyph == "" {
+				glyph = t1.GlyphName(byte(code))
+			}
+			_, ok := t1.charStrings[glyph]
+			return ok
+		}
+	}
+	return nil
+}
+
+// embeddedCFF returns the CFF program in ff, a FontFile3 stream holding a
+// bare CFF font or an OpenType font with CFF outlines. It reports a program
+// that cannot be read and returns nil, also when the font's charset is not
+// one it can read.
+func (c *pdfaChecker) embeddedCFF(u *pdfaFont, ff Value) *CFFFont {
+	var data []byte
+	if ff.Key("Subtype").Name() == "OpenType" {
+		if tt := u.font.EmbeddedTrueType(); tt != nil {
+			data = tt.tables["CFF "]
+		}
+	} else {
+		rc := ff.Reader()
+		data, _ = io.ReadAll(rc)
+		rc.Close()
+	}
+	var cff *CFFFont
+	var err error
+	if len(data) == 0 || !safely(func() { cff, err = NewCFFFont(data) }) || err != nil || cff.NumGlyphs() == 0 {
+		c.fail("font-embedding", u.ptr, "embedded program of font %s (%s) cannot be read", u.name, u.font.BaseFont())
+		return nil
+	}
+	if cff.charset == nil {
+		return nil
+	}
+	return cff
+}
+
+// trueTypeGlyph returns the glyph of code in the program tt of the simple
+// TrueType font f: through the Unicode cmap if the font is nonsymbolic and
+// has an encoding, as PDF 32000-1:2008, §9.6.6.4 describes, or else as a
+// symbolic font.
+func trueTypeGlyph(f Font, tt *TrueTypeFont, code byte) (uint16, bool) {
+	if !f.isSymbolic() && f.V.Key("Encoding").Kind() != Null {
+		if r := simpleCodeRune(f, code); r != 0 {
+			if gid, ok := tt.GlyphIndex(r); ok {
+				return gid, true
+			}
+		}
+	}
+	return tt.CodeToGlyph(code)
+}
+
+// differencesName returns the glyph name the Differences of encoding enc
+// give code, or "".
+func differencesName(enc Value, code byte) string {
+	diffs := enc.Key("Differences")
+	n := -1
+	glyph := ""
+	for i := 0; i < diffs.Len(); i++ {
+		x := diffs.Index(i)
+		switch x.Kind() {
+		case Integer:
+			n = int(x.Int64())
+		case Name:
+			if n == int(code) {
+				glyph = x.Name()
+			}
+			n++
+		}
+	}
+	return glyph
+}
+
+// simpleCodeRune returns the Unicode value of code in the simple font f,
+// from the Differences of its encoding or its base encoding, or 0. The
+// standard encoding is taken as WinAnsi, with which it agrees on letters
+// and digits.
+func simpleCodeRune(f Font, code byte) rune {
+	enc := f.V.Key("Encoding")
+	if glyph := differencesName(enc, code); glyph != "" {
+		if r, ok := nameToRune[glyph]; ok {
+			return r
+		}
+		return GlyphNameToRune(glyph)
+	}
+	base := enc.Name()
+	if enc.Kind() == Dict {
+		base = enc.Key("BaseEncoding").Name()
+	}
+	if base == "MacRomanEncoding" {
+		return macRomanEncoding[code]
+	}
+	return winAnsiEncoding[code]
+}
+
+var (
+	runeGlyphNamesOnce sync.Once
+	runeGlyphNames     map[rune]string
+)
+
+// runeGlyphName returns the glyph name for r, or "".
+func runeGlyphName(r rune) string {
+	runeGlyphNamesOnce.Do(func() {
+		names := make([]string, 0, len(nameToRune))
+		for glyph := range nameToRune {
+			names = append(names, glyph)
+		}
+		sort.Strings(names)
+		runeGlyphNames = make(map[rune]string, len(names))
+		for _, glyph := range names {
+			if _, ok := runeGlyphNames[nameToRune[glyph]]; !ok {
+				runeGlyphNames[nameToRune[glyph]] = glyph
+			}
+		}
+	})
+	return runeGlyphNames[r]
+}
+
+// checkUnicode checks that each code shown in font u maps to Unicode,
+// through the font's ToUnicode CMap or, failing that, its encoding.
+func (c *pdfaChecker) checkUnicode(u *pdfaFont) {
+	f := u.font
+	var mapped func(code int) bool
+	switch tu := f.V.Key("ToUnicode"); {
+	case tu.Kind() == Stream:
+		cm := readCmap(tu)
+		if cm == nil {
+			c.fail("unicode", u.ptr, "ToUnicode CMap of font %s (%s) cannot be read", u.name, f.BaseFont())
+			return
+		}
+		mapped = func(code int) bool {
+			s := cm.Decode(u.raw(code))
+			return s != "" && !strings.ContainsRune(s, noRune)
+		}
+	case f.subtype() == "Type0":
+		// A predefined CMap or an Adobe character collection maps codes
+		// to Unicode.
+		enc := f.V.Key("Encoding").Name()
+		info := f.descendantFont().Key("CIDSystemInfo")
+		switch info.Key("Ordering").RawString() {
+		case "GB1", "CNS1", "Japan1", "Korea1":
+			if info.Key("Registry").RawString() == "Adobe" {
+				return
+			}
+		}
+		if enc != "" && enc != "Identity-H" && enc != "Identity-V" {
+			return
+		}
+		c.fail("unicode", u.ptr, "composite font %s (%s) has no ToUnicode CMap", u.name, f.BaseFont())
+		return
+	case f.isSymbolic() && f.V.Key("Encoding").Key("Differences").Len() == 0:
+		c.fail("unicode", u.ptr, "symbolic font %s (%s) has no ToUnicode CMap", u.name, f.BaseFont())
+		return
+	default:
+		mapped = func(code int) bool {
+			return simpleCodeRune(f, byte(code)) != 0
+		}
+	}
+	var unmapped []int
+	for _, code := range u.sortedCodes() {
+		if !mapped(code) {
+			unmapped = append(unmapped, code)
+		}
+	}
+	if len(unmapped) > 0 {
+		c.fail("unicode", u.ptr, "codes %s of font %s (%s) do not map to Unicode", u.format(unmapped), u.name, f.BaseFont())
+	}
+}
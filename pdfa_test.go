@@ -0,0 +1,210 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func pdfaStream(dict, data string) string {
+	return fmt.Sprintf("<< %s /Length %d >>\nstream\n%s\nendstream", dict, len(data), data)
+}
+
+func pdfaXMP(part, conformance string) string {
+	return `<?xpacket begin="" id="W5M0MpCehiHzreSzNTczkc9d"?>
+<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+<rdf:Description rdf:about="" xmlns:pdfaid="http://www.aiim.org/pdfa/ns/id/" pdfaid:part="` + part + `" pdfaid:conformance="` + conformance + `"/>
+<rdf:Description rdf:about="" xmlns:dc="http://purl.org/dc/elements/1.1/">
+<dc:title><rdf:Alt><rdf:li xml:lang="x-default">Report</rdf:li></rdf:Alt></dc:title>
+</rdf:Description>
+</rdf:RDF></x:xmpmeta>
+<?xpacket end="w"?>`
+}
+
+// pdfaObjects returns the objects of a one-page PDF/A-2b document, for
+// buildPDF with pdfaTrailer. Its font is the TrueType test program, which
+// has glyphs for A and B.
+func pdfaObjects() []string {
+	icc := strings.Repeat("\x00", 36) + "acsp" + strings.Repeat("\x00", 88)
+	font := string(buildTestTrueType(testTrueTypeTables()))
+	return []string{
+		"<< /Type /Catalog /Pages 2 0 R /Metadata 7 0 R /OutputIntents [<< /Type /OutputIntent /S /GTS_PDFA1 " +
+			"/OutputConditionIdentifier (sRGB) /DestOutputProfile 8 0 R >>] >>",
+		"<< /Type /Pages /Count 1 /Kids [3 0 R] >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 4 0 R >> >> /Contents 5 0 R >>",
+		"<< /Type /Font /Subtype /TrueType /BaseFont /Test /FirstChar 65 /LastChar 67 /Widths [600 600 600] " +
+			"/Encoding /WinAnsiEncoding /FontDescriptor 6 0 R >>",
+		pdfaStream("", "BT /F1 12 Tf 72 700 Td (AB) Tj ET"),
+		"<< /Type /FontDescriptor /FontName /Test /Flags 32 /FontBBox [0 0 1000 1000] /ItalicAngle 0 " +
+			"/Ascent 800 /Descent -200 /CapHeight 700 /StemV 80 /FontFile2 9 0 R >>",
+		pdfaStream("/Type /Metadata /Subtype /XML", pdfaXMP("2", "B")),
+		pdfaStream("/N 3", icc),
+		pdfaStream(fmt.Sprintf("/Length1 %d", len(font)), font),
+		"<< /Title (Report) >>",
+	}
+}
+
+// useCFF replaces the font of pdfaObjects with a CFF program that has
+// glyphs for A and B, or, if cid is set, with a CID-keyed one that has
+// glyphs for CIDs 100 and 843.
+func useCFF(objs []string, cid bool) {
+	objs[5] = strings.Replace(objs[5], "/FontFile2", "/FontFile3", 1)
+	if !cid {
+		objs[3] = strings.Replace(objs[3], "/TrueType", "/Type1", 1)
+		objs[8] = pdfaStream("/Subtype /Type1C", string(buildTestCFF(3, nil, []byte{0, 0, 34, 0, 35}, nil, false)))
+		return
+	}
+	objs[3] = "<< /Type /Font /Subtype /Type0 /BaseFont /Test /Encoding /Identity-H /DescendantFonts [<< /Type /Font " +
+		"/Subtype /CIDFontType0 /BaseFont /Test /CIDSystemInfo << /Registry (Adobe) /Ordering (Japan1) /Supplement 6 >> " +
+		"/FontDescriptor 6 0 R >>] >>"
+	objs[4] = pdfaStream("", "BT /F1 12 Tf 72 700 Td <0064034B> Tj ET")
+	objs[8] = pdfaStream("/Subtype /CIDFontType0C", string(buildTestCFF(3, nil, []byte{0, 0, 100, 3, 75}, nil, true)))
+}
+
+const pdfaTrailer = "/Root 1 0 R /Info 10 0 R /ID [<0123456789abcdef> <0123456789abcdef>]"
+
+func checkPDFA(t *testing.T, data []byte, level PDFALevel) *PDFAReport {
+	t.Helper()
+	r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := r.CheckPDFA(level)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return report
+}
+
+func pdfaRules(report *PDFAReport) string {
+	var rules []string
+	for _, f := range report.Failures {
+		rules = append(rules, f.Rule)
+	}
+	sort.Strings(rules)
+	return strings.Join(rules, " ")
+}
+
+func TestCheckPDFA(t *testing.T) {
+	tests := []struct {
+		name  string
+		level PDFALevel
+		edit  func(objs []string)
+		want  string
+	}{
+		{name: "conforming", edit: func([]string) {}},
+		{name: "conforming 2u", level: PDFA2U, edit: func(objs []string) {
+			objs[6] = pdfaStream("/Type /Metadata /Subtype /XML", pdfaXMP("2", "U"))
+		}},
+		{name: "missing glyph", edit: func(objs []string) {
+			objs[4] = pdfaStream("", "BT /F1 12 Tf 72 700 Td [(AB) -20 (C)] TJ ET")
+		}, want: "font-glyphs"},
+		{name: "CFF font", edit: func(objs []string) {
+			useCFF(objs, false)
+		}},
+		{name: "CFF font missing glyph", edit: func(objs []string) {
+			useCFF(objs, false)
+			objs[4] = pdfaStream("", "BT /F1 12 Tf 72 700 Td (ABC) Tj ET")
+		}, want: "font-glyphs"},
+		{name: "CID-keyed CFF font", edit: func(objs []string) {
+			useCFF(objs, true)
+		}},
+		{name: "CID-keyed CFF font missing glyph", edit: func(objs []string) {
+			useCFF(objs, true)
+			objs[4] = pdfaStream("", "BT /F1 12 Tf 72 700 Td <0064034B0065> Tj ET")
+		}, want: "font-glyphs"},
+		{name: "unreadable CFF font", edit: func(objs []string) {
+			useCFF(objs, false)
+			objs[8] = pdfaStream("/Subtype /Type1C", "not a font")
+		}, want: "font-embedding"},
+		{name: "font not embedded", edit: func(objs []string) {
+			objs[5] = strings.Replace(objs[5], "/FontFile2 9 0 R", "", 1)
+		}, want: "font-embedding"},
+		{name: "LZW", edit: func(objs []string) {
+			objs[7] = pdfaStream("/N 3 /Filter [/LZWDecode]", "")
+		}, want: "filters output-intent"},
+		{name: "JavaScript", edit: func(objs []string) {
+			objs[0] = strings.Replace(objs[0], "/Pages 2 0 R", "/Pages 2 0 R /OpenAction << /S /JavaScript /JS (app.alert(1)) >>", 1)
+		}, want: "actions"},
+		{name: "page additional actions", edit: func(objs []string) {
+			objs[2] = strings.Replace(objs[2], "/Contents", "/AA << /O << /S /GoTo /D [3 0 R /Fit] >> >> /Contents", 1)
+		}, want: "trigger-events"},
+		{name: "no output intent", edit: func(objs []string) {
+			objs[0] = "<< /Type /Catalog /Pages 2 0 R /Metadata 7 0 R >>"
+		}, want: "output-intent"},
+		{name: "info differs", edit: func(objs []string) {
+			objs[9] = "<< /Title (Draft) /Author (Someone) >>"
+		}, want: "info info"},
+		{name: "transparency allowed in 2b", edit: func(objs []string) {
+			objs[2] = strings.Replace(objs[2], "/Font", "/ExtGState << /G1 << /ca 0.5 >> >> /Font", 1)
+		}},
+		{name: "transparency in 1b", edit: func(objs []string) {
+			objs[2] = strings.Replace(objs[2], "/Font", "/ExtGState << /G1 << /ca 0.5 >> >> /Font", 1)
+			objs[6] = pdfaStream("/Type /Metadata /Subtype /XML", pdfaXMP("1", "B"))
+		}, want: "transparency"},
+		{name: "claimed level differs", level: PDFA3B, edit: func([]string) {}, want: "identification"},
+		{name: "symbolic font in 2u", level: PDFA2U, edit: func(objs []string) {
+			objs[3] = strings.Replace(objs[3], "/Encoding /WinAnsiEncoding ", "", 1)
+			objs[5] = strings.Replace(objs[5], "/Flags 32", "/Flags 4", 1)
+			objs[6] = pdfaStream("/Type /Metadata /Subtype /XML", pdfaXMP("2", "U"))
+		}, want: "unicode"},
+		{name: "no metadata", edit: func(objs []string) {
+			objs[0] = strings.Replace(objs[0], "/Metadata 7 0 R", "", 1)
+		}, want: "identification metadata"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objs := pdfaObjects()
+			tt.edit(objs)
+			report := checkPDFA(t, buildPDF(pdfaTrailer, objs...), tt.level)
+			if got := pdfaRules(report); got != tt.want {
+				t.Errorf("failed rules = %q, want %q\n%v", got, tt.want, report.Failures)
+			}
+		})
+	}
+}
+
+func TestCheckPDFAClauses(t *testing.T) {
+	objs := pdfaObjects()
+	objs[4] = pdfaStream("", "BT /F1 12 Tf 72 700 Td (ABC) Tj ET")
+	report := checkPDFA(t, buildPDF(pdfaTrailer, objs...), "")
+	if report.Level != PDFA2B || report.Claimed != PDFA2B {
+		t.Errorf("Level, Claimed = %q, %q", report.Level, report.Claimed)
+	}
+	want := PDFAFailure{Rule: "font-glyphs", Clause: "ISO 19005-2:2011, 6.2.11.4.1", Object: 4,
+		Message: "embedded program of font F1 (Test) has no glyphs for codes 0x43"}
+	if len(report.Failures) != 1 || report.Failures[0] != want {
+		t.Errorf("Failures = %v, want %v", report.Failures, want)
+	}
+
+	objs[2] = strings.Replace(objs[2], "/Font", "/ExtGState << /G1 << /BM /Multiply >> >> /Font", 1)
+	report = checkPDFA(t, buildPDF(pdfaTrailer, objs...), PDFA1B)
+	var clauses []string
+	for _, f := range report.Failures {
+		clauses = append(clauses, f.Clause)
+	}
+	sort.Strings(clauses)
+	if got := strings.Join(clauses, "; "); got != "ISO 19005-1:2005, 6.3.4; ISO 19005-1:2005, 6.4; ISO 19005-1:2005, 6.7.11" {
+		t.Errorf("PDF/A-1b clauses = %s", got)
+	}
+
+	data := ownerPDF(-4, "owner")
+	report = checkPDFA(t, data, PDFA2B)
+	if !strings.Contains(fmt.Sprint(report.Failures), "ISO 19005-2:2011, 6.1.3: document is encrypted") {
+		t.Errorf("Failures of an encrypted document = %v", report.Failures)
+	}
+
+	r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.CheckPDFA("1u"); err == nil {
+		t.Error("CheckPDFA accepted level 1u")
+	}
+}
@@ -0,0 +1,341 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package pdfindex maintains an on-disk inverted index of the words of
+// many PDF files, and answers boolean and phrase queries against it with
+// ranked results that point back to pages and regions of pages.
+//
+// An index is a directory. Each document is stored in a segment file of
+// its own, keyed by the ID the caller gives it, and a manifest records the
+// SHA-256 hash of the content each segment was built from, so updating
+// the index only re-reads the documents that changed:
+//
+//	ix, err := pdfindex.Open("index")
+//	...
+//	for _, path := range files {
+//		if _, err := ix.AddFile(path, path); err != nil {
+//			log.Print(err)
+//		}
+//	}
+//	results, err := ix.Search(`"annual report" AND (revenue OR income) NOT draft`, 10)
+//
+// An Index is safe for use by several goroutines, but not by several
+// processes at once.
+package pdfindex
+
+import (
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/Geek0x0/pdf"
+)
+
+// formatVersion is the version of the manifest and segment encoding.
+// Opening an index written in another version fails with ErrFormat.
+const formatVersion = 1
+
+const manifestName = "manifest.gob"
+
+// ErrFormat is returned when an index directory was written in a format
+// this package does not read.
+var ErrFormat = errors.New("pdfindex: unsupported index format")
+
+// Position is an occurrence of a term in a document.
+type Position struct {
+	Page   int      // 1-based page number
+	Offset int      // index of the term among all terms of the document
+	Bounds pdf.Rect // bounding box of the word the term is in, in points
+}
+
+// DocInfo describes an indexed document.
+type DocInfo struct {
+	ID     string // ID the document was added under
+	Hash   string // hex SHA-256 hash of the content it was indexed from
+	Pages  int    // number of pages
+	Length int    // number of terms
+}
+
+// Index is an inverted index stored in a directory.
+type Index struct {
+	dir string
+
+	mu    sync.RWMutex
+	docs  map[string]*docEntry
+	terms map[string]map[string][]Position // term -> document ID -> positions
+	total int                              // sum of the lengths of all documents
+}
+
+// docEntry is the manifest record of a document.
+type docEntry struct {
+	DocInfo
+	File string // segment file name
+}
+
+// manifest is the encoding of manifestName.
+type manifest struct {
+	Version int
+	Docs    []docEntry
+}
+
+// segment is the encoding of a document's segment file.
+type segment struct {
+	Version int
+	Info    DocInfo
+	Terms   map[string][]Position
+}
+
+// Open opens the index in dir, creating the directory if it does not
+// exist, and loads its segments.
+func Open(dir string) (*Index, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	ix := &Index{
+		dir:   dir,
+		docs:  make(map[string]*docEntry),
+		terms: make(map[string]map[string][]Position),
+	}
+	var m manifest
+	if err := readGob(filepath.Join(dir, manifestName), &m); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return ix, nil
+		}
+		return nil, err
+	}
+	if m.Version != formatVersion {
+		return nil, fmt.Errorf("%w: manifest version %d", ErrFormat, m.Version)
+	}
+	for _, e := range m.Docs {
+		var seg segment
+		if err := readGob(filepath.Join(dir, e.File), &seg); err != nil {
+			return nil, fmt.Errorf("pdfindex: segment of %q: %w", e.ID, err)
+		}
+		if seg.Version != formatVersion {
+			return nil, fmt.Errorf("%w: segment version %d", ErrFormat, seg.Version)
+		}
+		// The segment is written before the manifest, so it describes
+		// the document even if the manifest update was lost.
+		e.DocInfo = seg.Info
+		ix.insert(&e, seg.Terms)
+	}
+	return ix, nil
+}
+
+// Dir returns the directory of the index.
+func (ix *Index) Dir() string {
+	return ix.dir
+}
+
+// AddFile indexes the PDF file at path under id. See Add.
+func (ix *Index) AddFile(id, path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+	return ix.Add(id, f, st.Size())
+}
+
+// Add indexes the PDF read from r under id, replacing any earlier version
+// of the document. If the document is already indexed from content with
+// the same hash it is not read again, and Add returns false.
+func (ix *Index) Add(id string, r io.ReaderAt, size int64) (bool, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, io.NewSectionReader(r, 0, size)); err != nil {
+		return false, err
+	}
+	hash := hex.EncodeToString(h.Sum(nil))
+
+	ix.mu.RLock()
+	old := ix.docs[id]
+	ix.mu.RUnlock()
+	if old != nil && old.Hash == hash {
+		return false, nil
+	}
+
+	doc, err := pdf.NewReader(r, size)
+	if err != nil {
+		return false, fmt.Errorf("pdfindex: %s: %w", id, err)
+	}
+	info := DocInfo{ID: id, Hash: hash, Pages: doc.NumPage()}
+	terms := make(map[string][]Position)
+	for num := 1; num <= info.Pages; num++ {
+		words, err := doc.Page(num).Words()
+		if err != nil {
+			return false, fmt.Errorf("pdfindex: %s: page %d: %w", id, num, err)
+		}
+		for _, w := range words {
+			for _, term := range Tokenize(w.Text) {
+				terms[term] = append(terms[term], Position{Page: num, Offset: info.Length, Bounds: w.Bounds})
+				info.Length++
+			}
+		}
+	}
+	if err := ix.put(info, terms); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// put stores the segment of a document and records it in the manifest.
+// The segment is written under ix.mu, so that concurrent calls for the
+// same ID, or a concurrent Remove, cannot leave the segment file and the
+// manifest describing different content.
+func (ix *Index) put(info DocInfo, terms map[string][]Position) error {
+	sum := sha256.Sum256([]byte(info.ID))
+	e := &docEntry{DocInfo: info, File: hex.EncodeToString(sum[:16]) + ".seg"}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if err := writeGob(filepath.Join(ix.dir, e.File), segment{formatVersion, info, terms}); err != nil {
+		return err
+	}
+	ix.remove(info.ID)
+	ix.insert(e, terms)
+	return ix.writeManifest()
+}
+
+// Remove removes the document with the given ID from the index. Removing
+// a document that is not indexed does nothing.
+func (ix *Index) Remove(id string) error {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	e := ix.docs[id]
+	if e == nil {
+		return nil
+	}
+	ix.remove(id)
+	if err := ix.writeManifest(); err != nil {
+		return err
+	}
+	err := os.Remove(filepath.Join(ix.dir, e.File))
+	if errors.Is(err, os.ErrNotExist) {
+		err = nil
+	}
+	return err
+}
+
+// Doc returns the description of the document with the given ID.
+func (ix *Index) Doc(id string) (DocInfo, bool) {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	e := ix.docs[id]
+	if e == nil {
+		return DocInfo{}, false
+	}
+	return e.DocInfo, true
+}
+
+// Docs returns the indexed documents, sorted by ID.
+func (ix *Index) Docs() []DocInfo {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	docs := make([]DocInfo, 0, len(ix.docs))
+	for _, e := range ix.docs {
+		docs = append(docs, e.DocInfo)
+	}
+	sort.Slice(docs, func(i, j int) bool { return docs[i].ID < docs[j].ID })
+	return docs
+}
+
+// insert adds a document to the in-memory index. The caller holds ix.mu
+// or has the index to itself.
+func (ix *Index) insert(e *docEntry, terms map[string][]Position) {
+	ix.docs[e.ID] = e
+	ix.total += e.Length
+	for term, pos := range terms {
+		m := ix.terms[term]
+		if m == nil {
+			m = make(map[string][]Position)
+			ix.terms[term] = m
+		}
+		m[e.ID] = pos
+	}
+}
+
+// remove drops a document from the in-memory index. The caller holds ix.mu.
+func (ix *Index) remove(id string) {
+	e := ix.docs[id]
+	if e == nil {
+		return
+	}
+	delete(ix.docs, id)
+	ix.total -= e.Length
+	for term, m := range ix.terms {
+		if _, ok := m[id]; ok {
+			delete(m, id)
+			if len(m) == 0 {
+				delete(ix.terms, term)
+			}
+		}
+	}
+}
+
+// writeManifest replaces the manifest with the current set of documents.
+// The caller holds ix.mu.
+func (ix *Index) writeManifest() error {
+	m := manifest{Version: formatVersion}
+	for _, e := range ix.docs {
+		m.Docs = append(m.Docs, *e)
+	}
+	sort.Slice(m.Docs, func(i, j int) bool { return m.Docs[i].ID < m.Docs[j].ID })
+	return writeGob(filepath.Join(ix.dir, manifestName), m)
+}
+
+// Tokenize splits text into the terms the index stores: maximal runs of
+// letters and digits, lower-cased.
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.Is(unicode.Mn, r)
+	})
+}
+
+func readGob(name string, v any) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := gob.NewDecoder(f).Decode(v); err != nil {
+		return fmt.Errorf("pdfindex: reading %s: %w", filepath.Base(name), err)
+	}
+	return nil
+}
+
+// writeGob writes v to name through a temporary file, so that a reader
+// never sees a partly written file.
+func writeGob(name string, v any) error {
+	f, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".tmp*")
+	if err != nil {
+		return err
+	}
+	if err := gob.NewEncoder(f).Encode(v); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Rename(f.Name(), name); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdfindex
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// buildPDF returns a PDF with a page for each of the given lines of text,
+// drawn in Helvetica at 10 points with glyphs 5 points wide. Each line of a
+// page is 14 points below the one before, from 72,700.
+func buildPDF(pages ...[]string) []byte {
+	var buf bytes.Buffer
+	var offsets []int
+	obj := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	widths := strings.TrimSpace(strings.Repeat("500 ", 95))
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	obj(fmt.Sprintf("<< /Type /Pages /Count %d /Kids [%s] >>", len(pages), strings.Join(kids, " ")))
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /FirstChar 32 /LastChar 126 /Widths [" + widths + "] >>")
+	for i, lines := range pages {
+		var c strings.Builder
+		c.WriteString("BT /F1 10 Tf 72 700 Td")
+		for j, line := range lines {
+			if j > 0 {
+				c.WriteString(" 0 -14 Td")
+			}
+			fmt.Fprintf(&c, " (%s) Tj", line)
+		}
+		c.WriteString(" ET")
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", 5+2*i))
+		obj(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", c.Len(), c.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return buf.Bytes()
+}
+
+func addPDF(t *testing.T, ix *Index, id string, pages ...[]string) bool {
+	t.Helper()
+	data := buildPDF(pages...)
+	changed, err := ix.Add(id, bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Add(%q): %v", id, err)
+	}
+	return changed
+}
+
+func TestIndexIncremental(t *testing.T) {
+	dir := t.TempDir()
+	ix, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !addPDF(t, ix, "a", []string{"Hello, world"}, []string{"second page"}) {
+		t.Error("first Add reported no change")
+	}
+	if addPDF(t, ix, "a", []string{"Hello, world"}, []string{"second page"}) {
+		t.Error("Add of the same content reported a change")
+	}
+	addPDF(t, ix, "b", []string{"another world"})
+
+	info, ok := ix.Doc("a")
+	if !ok || info.Pages != 2 || info.Length != 4 || len(info.Hash) != 64 {
+		t.Errorf("Doc(a) = %+v, %v", info, ok)
+	}
+
+	// Changed content replaces the terms of the document
+	if !addPDF(t, ix, "a", []string{"goodbye"}) {
+		t.Error("Add of new content reported no change")
+	}
+	if res, _ := ix.Search("hello", 0); len(res) != 0 {
+		t.Errorf("old terms still found: %+v", res)
+	}
+
+	// The index is read back from disk
+	ix, err = Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, d := range ix.Docs() {
+		ids = append(ids, d.ID)
+	}
+	if strings.Join(ids, " ") != "a b" {
+		t.Errorf("reopened index has documents %v", ids)
+	}
+	if res, _ := ix.Search("goodbye", 0); len(res) != 1 || res[0].ID != "a" {
+		t.Errorf("reopened index search = %+v", res)
+	}
+	if addPDF(t, ix, "b", []string{"another world"}) {
+		t.Error("reopened index re-read unchanged document")
+	}
+
+	if err := ix.Remove("a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ix.Remove("missing"); err != nil {
+		t.Errorf("Remove of missing document: %v", err)
+	}
+	segs, _ := filepath.Glob(filepath.Join(dir, "*.seg"))
+	if len(segs) != 1 {
+		t.Errorf("%d segments left after Remove, want 1", len(segs))
+	}
+	ix, err = Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ix.Doc("a"); ok {
+		t.Error("removed document reopened")
+	}
+}
+
+func TestIndexConcurrentAdd(t *testing.T) {
+	dir := t.TempDir()
+	ix, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := range 16 {
+		data := buildPDF([]string{fmt.Sprintf("version %d", i)})
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := ix.Add("doc", bytes.NewReader(data), int64(len(data))); err != nil {
+				t.Error(err)
+			}
+			if i%4 == 0 {
+				if err := ix.Remove("doc"); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// The manifest, the segment and the in-memory index agree
+	want, ok := ix.Doc("doc")
+	reopened, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, gotOK := reopened.Doc("doc"); got != want || gotOK != ok {
+		t.Errorf("reopened Doc = %+v, %v, want %+v, %v", got, gotOK, want, ok)
+	}
+	segs, _ := filepath.Glob(filepath.Join(dir, "*.seg"))
+	if ok != (len(segs) == 1) || len(segs) > 1 {
+		t.Errorf("%d segments for document present=%v", len(segs), ok)
+	}
+}
+
+func TestIndexAddFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "doc.pdf")
+	if err := os.WriteFile(path, buildPDF([]string{"file text"}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ix, err := Open(filepath.Join(dir, "index"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := ix.AddFile("doc", path); err != nil || !changed {
+		t.Fatalf("AddFile = %v, %v", changed, err)
+	}
+	if _, err := ix.AddFile("nope", filepath.Join(dir, "missing.pdf")); err == nil {
+		t.Error("AddFile of a missing file succeeded")
+	}
+	data := []byte("not a pdf")
+	if _, err := ix.Add("bad", bytes.NewReader(data), int64(len(data))); err == nil {
+		t.Error("Add of a non-PDF succeeded")
+	}
+	if len(ix.Docs()) != 1 {
+		t.Errorf("documents after failed adds: %+v", ix.Docs())
+	}
+}
+
+func TestIndexFormat(t *testing.T) {
+	dir := t.TempDir()
+	if err := writeGob(filepath.Join(dir, manifestName), manifest{Version: formatVersion + 1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(dir); !errors.Is(err, ErrFormat) {
+		t.Errorf("Open of a newer index = %v, want ErrFormat", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, manifestName), []byte("junk"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(dir); err == nil {
+		t.Error("Open of a corrupt manifest succeeded")
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	got := Tokenize("Hello, World! e-mail 2024 Café")
+	if want := "hello world e mail 2024 café"; strings.Join(got, " ") != want {
+		t.Errorf("Tokenize = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,435 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdfindex
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/Geek0x0/pdf"
+)
+
+// BM25 parameters.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// Result is a document matching a query.
+type Result struct {
+	ID    string    // document ID
+	Score float64   // BM25 relevance; higher is better
+	Pages []PageHit // pages with matches, in page order
+}
+
+// PageHit is a page of a document with matches of a query.
+type PageHit struct {
+	Page    int        // 1-based page number
+	Regions []pdf.Rect // boxes of the matched words, one per phrase and line, in document order
+}
+
+// Search returns the documents matching query, best first, and at most
+// limit of them if limit is positive. Documents with equal scores are
+// ordered by ID.
+//
+// A query is a sequence of terms and "quoted phrases", which all have to
+// match. Terms are compared after Tokenize, so matching ignores case and
+// punctuation; a term such as e-mail that splits into several is matched
+// as a phrase. Clauses combine with AND, OR and NOT, which must be written
+// in capitals, and with parentheses; AND binds tighter than OR, and
+// -term is the same as NOT term. For example:
+//
+//	"annual report" (revenue OR income) -draft
+//
+// Documents are ranked by the BM25 score of the terms and phrases of the
+// query that they match, outside NOT clauses.
+func (ix *Index) Search(query string, limit int) ([]Result, error) {
+	q, err := parseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	matches := q.eval(ix)
+
+	results := make([]Result, 0, len(matches))
+	for id, m := range matches {
+		results = append(results, Result{ID: id, Score: m.score, Pages: m.pages()})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// docMatch is how a document matches a clause of a query.
+type docMatch struct {
+	score float64
+	hits  [][]Position // occurrences of terms and phrases
+}
+
+// pages groups the occurrences by page and merges the words of each
+// occurrence that share a line into one region.
+func (m *docMatch) pages() []PageHit {
+	hits := append([][]Position(nil), m.hits...)
+	sort.Slice(hits, func(i, j int) bool { return hits[i][0].Offset < hits[j][0].Offset })
+
+	byPage := make(map[int]*PageHit)
+	var nums []int
+	seen := make(map[pageRect]bool)
+	for _, hit := range hits {
+		for _, r := range hitRegions(hit) {
+			ph := byPage[r.page]
+			if ph == nil {
+				ph = &PageHit{Page: r.page}
+				byPage[r.page] = ph
+				nums = append(nums, r.page)
+			}
+			if !seen[r] {
+				seen[r] = true
+				ph.Regions = append(ph.Regions, r.rect)
+			}
+		}
+	}
+	sort.Ints(nums)
+	pages := make([]PageHit, len(nums))
+	for i, num := range nums {
+		pages[i] = *byPage[num]
+	}
+	return pages
+}
+
+type pageRect struct {
+	page int
+	rect pdf.Rect
+}
+
+// hitRegions returns the regions of an occurrence: the union of the boxes
+// of consecutive words on the same line of the same page.
+func hitRegions(hit []Position) []pageRect {
+	var regions []pageRect
+	for _, p := range hit {
+		if n := len(regions); n > 0 {
+			last := &regions[n-1]
+			if last.rect == p.Bounds {
+				continue
+			}
+			if last.page == p.Page && sameLine(last.rect, p.Bounds) {
+				last.rect = union(last.rect, p.Bounds)
+				continue
+			}
+		}
+		regions = append(regions, pageRect{p.Page, p.Bounds})
+	}
+	return regions
+}
+
+// sameLine reports whether two boxes overlap vertically by at least half
+// the height of the smaller.
+func sameLine(a, b pdf.Rect) bool {
+	overlap := math.Min(a.Max.Y, b.Max.Y) - math.Max(a.Min.Y, b.Min.Y)
+	h := math.Min(a.Max.Y-a.Min.Y, b.Max.Y-b.Min.Y)
+	return overlap > 0 && overlap >= h/2
+}
+
+func union(a, b pdf.Rect) pdf.Rect {
+	return pdf.Rect{
+		Min: pdf.Point{X: math.Min(a.Min.X, b.Min.X), Y: math.Min(a.Min.Y, b.Min.Y)},
+		Max: pdf.Point{X: math.Max(a.Max.X, b.Max.X), Y: math.Max(a.Max.Y, b.Max.Y)},
+	}
+}
+
+// A queryNode is a clause of a parsed query.
+type queryNode interface {
+	eval(ix *Index) map[string]*docMatch
+	String() string
+}
+
+// phraseNode matches consecutive terms. A single term is a phrase of one.
+type phraseNode struct {
+	terms []string
+}
+
+type andNode struct {
+	left, right queryNode
+}
+
+type orNode struct {
+	left, right queryNode
+}
+
+type notNode struct {
+	x queryNode
+}
+
+func (n *phraseNode) String() string {
+	if len(n.terms) == 1 {
+		return n.terms[0]
+	}
+	return `"` + strings.Join(n.terms, " ") + `"`
+}
+
+func (n *andNode) String() string { return fmt.Sprintf("(%v AND %v)", n.left, n.right) }
+func (n *orNode) String() string  { return fmt.Sprintf("(%v OR %v)", n.left, n.right) }
+func (n *notNode) String() string { return fmt.Sprintf("NOT %v", n.x) }
+
+func (n *phraseNode) eval(ix *Index) map[string]*docMatch {
+	first := ix.terms[n.terms[0]]
+	// Offsets of each later term of the phrase, by document
+	rest := make([]map[string]map[int]Position, len(n.terms)-1)
+	for i, term := range n.terms[1:] {
+		rest[i] = make(map[string]map[int]Position)
+		for id, pos := range ix.terms[term] {
+			if _, ok := first[id]; !ok {
+				continue
+			}
+			offsets := make(map[int]Position, len(pos))
+			for _, p := range pos {
+				offsets[p.Offset] = p
+			}
+			rest[i][id] = offsets
+		}
+	}
+
+	matches := make(map[string]*docMatch)
+	for id, pos := range first {
+		var hits [][]Position
+	next:
+		for _, p := range pos {
+			hit := []Position{p}
+			for i := range rest {
+				q, ok := rest[i][id][p.Offset+i+1]
+				if !ok {
+					continue next
+				}
+				hit = append(hit, q)
+			}
+			hits = append(hits, hit)
+		}
+		if len(hits) > 0 {
+			matches[id] = &docMatch{hits: hits}
+		}
+	}
+
+	// BM25, with the phrase standing for a term
+	n64 := float64(len(ix.docs))
+	avg := float64(ix.total) / math.Max(n64, 1)
+	df := float64(len(matches))
+	idf := math.Log(1 + (n64-df+0.5)/(df+0.5))
+	for id, m := range matches {
+		tf := float64(len(m.hits))
+		length := float64(ix.docs[id].Length)
+		norm := 1 - bm25B
+		if avg > 0 {
+			norm += bm25B * length / avg
+		}
+		m.score = idf * tf * (bm25K1 + 1) / (tf + bm25K1*norm)
+	}
+	return matches
+}
+
+func (n *andNode) eval(ix *Index) map[string]*docMatch {
+	left, right := n.left.eval(ix), n.right.eval(ix)
+	for id, l := range left {
+		r, ok := right[id]
+		if !ok {
+			delete(left, id)
+			continue
+		}
+		l.score += r.score
+		l.hits = append(l.hits, r.hits...)
+	}
+	return left
+}
+
+func (n *orNode) eval(ix *Index) map[string]*docMatch {
+	left, right := n.left.eval(ix), n.right.eval(ix)
+	for id, r := range right {
+		if l, ok := left[id]; ok {
+			l.score += r.score
+			l.hits = append(l.hits, r.hits...)
+		} else {
+			left[id] = r
+		}
+	}
+	return left
+}
+
+func (n *notNode) eval(ix *Index) map[string]*docMatch {
+	x := n.x.eval(ix)
+	matches := make(map[string]*docMatch)
+	for id := range ix.docs {
+		if _, ok := x[id]; !ok {
+			matches[id] = &docMatch{}
+		}
+	}
+	return matches
+}
+
+// parseQuery parses the query syntax described at Index.Search.
+func parseQuery(query string) (queryNode, error) {
+	toks, err := lexQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &queryParser{toks: toks}
+	if len(toks) == 0 {
+		return nil, fmt.Errorf("pdfindex: empty query")
+	}
+	n, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.toks) {
+		return nil, fmt.Errorf("pdfindex: unexpected %s in query", p.toks[p.pos])
+	}
+	return n, nil
+}
+
+// queryToken is a token of a query: an operator or parenthesis, or a
+// term or phrase after tokenizing.
+type queryToken struct {
+	op    string // "AND", "OR", "NOT", "(" or ")"; empty for a phrase
+	terms []string
+}
+
+func (t queryToken) String() string {
+	if t.op != "" {
+		return t.op
+	}
+	return (&phraseNode{t.terms}).String()
+}
+
+func lexQuery(query string) ([]queryToken, error) {
+	var toks []queryToken
+	s := query
+	for {
+		s = strings.TrimLeft(s, " \t\r\n")
+		if s == "" {
+			return toks, nil
+		}
+		switch s[0] {
+		case '(', ')':
+			toks = append(toks, queryToken{op: s[:1]})
+			s = s[1:]
+			continue
+		case '"':
+			end := strings.IndexByte(s[1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("pdfindex: unterminated phrase in query %q", query)
+			}
+			if terms := Tokenize(s[1 : 1+end]); len(terms) > 0 {
+				toks = append(toks, queryToken{terms: terms})
+			}
+			s = s[end+2:]
+			continue
+		case '-':
+			toks = append(toks, queryToken{op: "NOT"})
+			s = s[1:]
+			continue
+		}
+		end := strings.IndexAny(s, " \t\r\n()\"")
+		if end < 0 {
+			end = len(s)
+		}
+		word := s[:end]
+		s = s[end:]
+		switch word {
+		case "AND", "OR", "NOT":
+			toks = append(toks, queryToken{op: word})
+		default:
+			if terms := Tokenize(word); len(terms) > 0 {
+				toks = append(toks, queryToken{terms: terms})
+			}
+		}
+	}
+}
+
+type queryParser struct {
+	toks []queryToken
+	pos  int
+}
+
+func (p *queryParser) peek() string {
+	if p.pos < len(p.toks) {
+		return p.toks[p.pos].op
+	}
+	return ")"
+}
+
+func (p *queryParser) or() (queryNode, error) {
+	n, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.pos < len(p.toks) && p.peek() == "OR" {
+		p.pos++
+		r, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		n = &orNode{n, r}
+	}
+	return n, nil
+}
+
+func (p *queryParser) and() (queryNode, error) {
+	n, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.pos < len(p.toks) {
+		switch p.peek() {
+		case "OR", ")":
+			return n, nil
+		case "AND":
+			p.pos++
+		}
+		r, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		n = &andNode{n, r}
+	}
+	return n, nil
+}
+
+func (p *queryParser) unary() (queryNode, error) {
+	if p.pos >= len(p.toks) {
+		return nil, fmt.Errorf("pdfindex: query ends after an operator")
+	}
+	t := p.toks[p.pos]
+	p.pos++
+	switch t.op {
+	case "":
+		return &phraseNode{t.terms}, nil
+	case "NOT":
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{x}, nil
+	case "(":
+		n, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.pos >= len(p.toks) || p.toks[p.pos].op != ")" {
+			return nil, fmt.Errorf("pdfindex: missing ) in query")
+		}
+		p.pos++
+		return n, nil
+	}
+	return nil, fmt.Errorf("pdfindex: unexpected %s in query", t.op)
+}
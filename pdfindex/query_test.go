@@ -0,0 +1,137 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdfindex
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Geek0x0/pdf"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		query, want string
+	}{
+		{"apple", "apple"},
+		{"Apple pie", "(apple AND pie)"},
+		{"apple AND pie OR tart", "((apple AND pie) OR tart)"},
+		{"apple (pie OR tart)", "(apple AND (pie OR tart))"},
+		{`"Apple Pie" -tart`, `("apple pie" AND NOT tart)`},
+		{"NOT NOT apple", "NOT NOT apple"},
+		{"e-mail", `"e mail"`},
+		{"apple , pie", "(apple AND pie)"},
+	}
+	for _, tt := range tests {
+		q, err := parseQuery(tt.query)
+		if err != nil {
+			t.Errorf("parseQuery(%q): %v", tt.query, err)
+			continue
+		}
+		if got := q.String(); got != tt.want {
+			t.Errorf("parseQuery(%q) = %s, want %s", tt.query, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", " ,, ", `"open`, "apple AND", "(apple", "apple)", "OR apple"} {
+		if _, err := parseQuery(bad); err == nil {
+			t.Errorf("parseQuery(%q) succeeded", bad)
+		}
+	}
+}
+
+func searchIDs(t *testing.T, ix *Index, query string) string {
+	t.Helper()
+	res, err := ix.Search(query, 0)
+	if err != nil {
+		t.Fatalf("Search(%q): %v", query, err)
+	}
+	ids := make([]string, len(res))
+	for i, r := range res {
+		ids[i] = r.ID
+	}
+	return strings.Join(ids, " ")
+}
+
+func TestSearch(t *testing.T) {
+	ix, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	addPDF(t, ix, "report", []string{"Annual report", "revenue grew"}, []string{"the annual", "report again"})
+	addPDF(t, ix, "draft", []string{"draft annual report", "income fell"})
+	addPDF(t, ix, "memo", []string{"report on the annual picnic", "lots of padding words here"})
+
+	tests := []struct {
+		query, want string
+	}{
+		{"annual", "report draft memo"},
+		{`"annual report"`, "report draft"},
+		{`"annual report" -draft`, "report"},
+		{`"annual report" AND (revenue OR income)`, "draft report"}, // draft is shorter
+		{"picnic OR income", "draft memo"},
+		{"NOT report", ""},
+		{"NOT revenue", "draft memo"},
+		{"missing", ""},
+		{`"report annual"`, ""},
+	}
+	for _, tt := range tests {
+		if got := searchIDs(t, ix, tt.query); got != tt.want {
+			t.Errorf("Search(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+
+	if res, _ := ix.Search("annual", 2); len(res) != 2 {
+		t.Errorf("Search with limit 2 returned %d results", len(res))
+	}
+}
+
+func TestSearchRegions(t *testing.T) {
+	ix, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	addPDF(t, ix, "doc", []string{"Annual report", "revenue grew"}, []string{"the annual", "report again"})
+
+	res, err := ix.Search(`"annual report"`, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 1 || res[0].Score <= 0 {
+		t.Fatalf("results = %+v", res)
+	}
+	// On page 1 the phrase is one line; on page 2 it breaks across two
+	got := fmt.Sprint(res[0].Pages)
+	want := fmt.Sprint([]PageHit{
+		{1, []pdf.Rect{{Min: pdf.Point{X: 72, Y: 698}, Max: pdf.Point{X: 137, Y: 708}}}},
+		{2, []pdf.Rect{
+			{Min: pdf.Point{X: 92, Y: 698}, Max: pdf.Point{X: 122, Y: 708}},
+			{Min: pdf.Point{X: 72, Y: 684}, Max: pdf.Point{X: 102, Y: 694}},
+		}},
+	})
+	if got != want {
+		t.Errorf("pages = %s\nwant %s", got, want)
+	}
+}
+
+func TestSearchRanking(t *testing.T) {
+	ix, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	addPDF(t, ix, "once", []string{"apple and many other words here"})
+	addPDF(t, ix, "twice", []string{"apple apple and other words"})
+	addPDF(t, ix, "none", []string{"pear"})
+	addPDF(t, ix, "rare", []string{"apple kiwi"})
+
+	if got := searchIDs(t, ix, "apple"); got != "twice rare once" {
+		t.Errorf("ranking by frequency and length = %q", got)
+	}
+	// The rarer term counts for more
+	if got := searchIDs(t, ix, "apple OR kiwi"); !strings.HasPrefix(got, "rare ") {
+		t.Errorf("ranking by rarity = %q", got)
+	}
+}
@@ -201,6 +201,8 @@ func PutPDFBuffer(b *buffer) {
 	b.ctxChecker = nil
 	b.limits = nil
 	b.readErr = nil
+	b.warnings = nil
+	b.warnAt = 0
 	pdfBufferPool.Put(b)
 }
 
@@ -0,0 +1,201 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Permissions are the operations an encrypted document allows, from the
+// P entry of its encryption dictionary. See PDF 32000-1:2008, Table 22.
+type Permissions uint32
+
+const (
+	PermissionPrint            Permissions = 1 << 2  // print the document
+	PermissionModify           Permissions = 1 << 3  // modify it other than by the operations below
+	PermissionCopy             Permissions = 1 << 4  // copy or otherwise extract text and graphics
+	PermissionAnnotate         Permissions = 1 << 5  // add or modify annotations and fill in form fields
+	PermissionFillForms        Permissions = 1 << 8  // fill in existing form fields
+	PermissionAccessibility    Permissions = 1 << 9  // extract text and graphics for accessibility
+	PermissionAssemble         Permissions = 1 << 10 // insert, rotate or delete pages and make outlines
+	PermissionPrintHighQuality Permissions = 1 << 11 // print at full quality
+
+	// PermissionAll is every permission, as an unencrypted document or
+	// the owner password grants.
+	PermissionAll = PermissionPrint | PermissionModify | PermissionCopy | PermissionAnnotate |
+		PermissionFillForms | PermissionAccessibility | PermissionAssemble | PermissionPrintHighQuality
+)
+
+var permissionNames = []struct {
+	p    Permissions
+	name string
+}{
+	{PermissionPrint, "print"},
+	{PermissionModify, "modify"},
+	{PermissionCopy, "copy"},
+	{PermissionAnnotate, "annotate"},
+	{PermissionFillForms, "fill-forms"},
+	{PermissionAccessibility, "accessibility"},
+	{PermissionAssemble, "assemble"},
+	{PermissionPrintHighQuality, "print-high-quality"},
+}
+
+// Has reports whether p includes every permission in q.
+func (p Permissions) Has(q Permissions) bool {
+	return p&q == q
+}
+
+func (p Permissions) String() string {
+	var names []string
+	for _, n := range permissionNames {
+		if p.Has(n.p) {
+			names = append(names, n.name)
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, "|")
+}
+
+// standardPermissions returns the permissions of the P entry of a
+// standard security handler of revision R. Revision 2 has no bits 9 to
+// 12; the operations they control follow the older bits.
+func standardPermissions(P uint32, R int64) Permissions {
+	p := Permissions(P) & PermissionAll
+	if R == 2 {
+		p &^= PermissionFillForms | PermissionAccessibility | PermissionAssemble | PermissionPrintHighQuality
+		if p.Has(PermissionPrint) {
+			p |= PermissionPrintHighQuality
+		}
+		if p.Has(PermissionModify) {
+			p |= PermissionAssemble
+		}
+		if p.Has(PermissionCopy) {
+			p |= PermissionAccessibility
+		}
+		if p.Has(PermissionAnnotate) {
+			p |= PermissionFillForms
+		}
+	}
+	return p
+}
+
+// Authentication is how an encrypted document was opened.
+type Authentication int
+
+const (
+	AuthNone      Authentication = iota // the document is not encrypted
+	AuthUser                            // with the user password, subject to the permissions
+	AuthOwner                           // with the owner password, which grants every permission
+	AuthRecipient                       // with a recipient's certificate, subject to its permissions
+)
+
+func (a Authentication) String() string {
+	switch a {
+	case AuthNone:
+		return "none"
+	case AuthUser:
+		return "user"
+	case AuthOwner:
+		return "owner"
+	case AuthRecipient:
+		return "recipient"
+	}
+	return "unknown"
+}
+
+// Permissions returns the operations the document allows and how it was
+// opened. An unencrypted document, or one opened with the owner password,
+// allows everything.
+func (r *Reader) Permissions() (Permissions, Authentication) {
+	if r.auth == AuthNone || r.auth == AuthOwner {
+		return PermissionAll, r.auth
+	}
+	return r.perms, r.auth
+}
+
+// PermissionError reports an operation that the permissions of an
+// encrypted document do not allow. It matches ErrNotPermitted.
+type PermissionError struct {
+	Op         string      // the operation, such as "extract text"
+	Permission Permissions // the permission it needs
+}
+
+func (e *PermissionError) Error() string {
+	return fmt.Sprintf("pdf: %s: not permitted by document (needs %v permission)", e.Op, e.Permission)
+}
+
+func (e *PermissionError) Is(target error) bool {
+	return target == ErrNotPermitted
+}
+
+// checkExtract returns a *PermissionError if the reader enforces
+// permissions and the document does not allow extracting its text.
+func (r *Reader) checkExtract() error {
+	if r == nil || !r.enforcePermissions {
+		return nil
+	}
+	if p, _ := r.Permissions(); !p.Has(PermissionCopy) {
+		return &PermissionError{Op: "extract text", Permission: PermissionCopy}
+	}
+	return nil
+}
+
+// ReaderOptions configures NewReaderWithOptions.
+type ReaderOptions struct {
+	// Password is tried on an encrypted document before the empty
+	// password, so that an owner password takes effect even when the user
+	// password is empty.
+	Password string
+
+	// EnforcePermissions makes the text extraction methods return a
+	// *PermissionError when the document does not allow copying its
+	// content. Document permissions are otherwise only reported.
+	EnforcePermissions bool
+
+	// Warnings, if not nil, collects the malformations the reader recovers
+	// from while opening the file and reading its objects.
+	Warnings *WarningCollector
+
+	// Strict lists the warnings that make NewReaderWithOptions fail with a
+	// *StrictError instead of reading the file. When it is not empty, every
+	// object is read before NewReaderWithOptions returns.
+	Strict []WarningCode
+}
+
+// NewReaderWithOptions opens a file for reading, using the data in f with
+// the given total size, as opts configures.
+func NewReaderWithOptions(f io.ReaderAt, size int64, opts ReaderOptions) (*Reader, error) {
+	warnings := opts.Warnings
+	if warnings == nil && len(opts.Strict) > 0 {
+		warnings = new(WarningCollector)
+	}
+	start := warnings.Len()
+	r, err := openReader(f, size, warnings)
+	if err != nil {
+		return nil, err
+	}
+	if r.trailer["Encrypt"] != nil {
+		if opts.Password == "" || r.initEncrypt(opts.Password) != nil {
+			if err := r.initEncrypt(""); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(opts.Strict) > 0 {
+		r.readAll()
+		if w, ok := warnings.first(start, opts.Strict); ok {
+			return nil, &StrictError{w}
+		}
+	}
+	r.enforcePermissions = opts.EnforcePermissions
+	if closer, ok := f.(io.Closer); ok {
+		r.closer = closer
+	}
+	return r, nil
+}
@@ -0,0 +1,183 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// ownerPDF returns a document encrypted with RC4 by the standard security
+// handler, revision 3, with permissions p, an empty user password and the
+// given owner password.
+func ownerPDF(p int32, owner string) []byte {
+	pad := func(pw string) []byte {
+		return append([]byte(pw), passwordPad[:32-len(pw)]...)
+	}
+	k := md5.Sum(pad(owner))
+	for range 50 {
+		k = md5.Sum(k[:])
+	}
+	o := xorRC4(k[:], pad(""), 0, 19)
+
+	h := md5.New()
+	h.Write(pad(""))
+	h.Write(o)
+	h.Write([]byte{byte(p), byte(p >> 8), byte(p >> 16), byte(p >> 24)})
+	h.Write(cryptTestID)
+	key := h.Sum(nil)
+	for range 50 {
+		sum := md5.Sum(key)
+		key = sum[:]
+	}
+	h.Reset()
+	h.Write(passwordPad)
+	h.Write(cryptTestID)
+	u := xorRC4(key, h.Sum(nil), 0, 19)
+
+	content := encryptObject(key, false, 5, []byte("BT /F1 10 Tf 72 700 Td (Secret text) Tj ET"))
+	widths := strings.TrimSpace(strings.Repeat("500 ", 95))
+	return buildPDF(fmt.Sprintf("/Root 1 0 R /Encrypt 6 0 R /ID [<%x> <%x>]", cryptTestID, cryptTestID),
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Count 1 /Kids [3 0 R] >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 4 0 R >> >> /Contents 5 0 R >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /FirstChar 32 /LastChar 126 /Widths ["+widths+"] >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+		fmt.Sprintf("<< /Filter /Standard /V 2 /R 3 /Length 128 /P %d /O <%x> /U <%x> >>", p, o, append(u, make([]byte, 16)...)),
+	)
+}
+
+func TestPermissions(t *testing.T) {
+	// Everything but copying and modifying
+	const p = -4 &^ int32(PermissionCopy|PermissionModify)
+	data := ownerPDF(p, "secret")
+
+	r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	perms, auth := r.Permissions()
+	if auth != AuthUser || perms.Has(PermissionCopy) || perms.Has(PermissionModify) || !perms.Has(PermissionPrint|PermissionAccessibility) {
+		t.Errorf("Permissions = %v, %v", perms, auth)
+	}
+	// Not enforced by default
+	if got := readerText(t, r); got != "Secret text" {
+		t.Errorf("text = %q", got)
+	}
+
+	r, err = NewReaderWithOptions(bytes.NewReader(data), int64(len(data)), ReaderOptions{EnforcePermissions: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.Page(1).Words()
+	var perr *PermissionError
+	if !errors.As(err, &perr) || perr.Permission != PermissionCopy || !errors.Is(err, ErrNotPermitted) {
+		t.Errorf("Words with enforced permissions: %v", err)
+	}
+	if _, err := r.GetPlainText(); !errors.Is(err, ErrNotPermitted) {
+		t.Errorf("GetPlainText with enforced permissions: %v", err)
+	}
+	page := r.Page(1)
+	sp := NewStreamProcessor(0, 4096, 0)
+	for name, f := range map[string]func() error{
+		"GetTextByRow":             func() error { _, err := page.GetTextByRow(); return err },
+		"GetTextByColumn":          func() error { _, err := page.GetTextByColumn(); return err },
+		"OptimizedGetTextByRow":    func() error { _, err := page.OptimizedGetTextByRow(); return err },
+		"OptimizedGetTextByColumn": func() error { _, err := page.OptimizedGetTextByColumn(); return err },
+		"ClassifyTextBlocks":       func() error { _, err := page.ClassifyTextBlocks(); return err },
+		"ExtractStyledTexts":       func() error { _, err := NewExtractor(r).ExtractStyledTexts(); return err },
+		"ExtractStructured":        func() error { _, err := NewExtractor(r).ExtractStructured(); return err },
+		"ProcessTextStream": func() error {
+			return sp.ProcessTextStream(r, func(TextStream) error { return nil })
+		},
+		"ProcessPageStream": func() error {
+			return sp.ProcessPageStream(r, func(PageStream) error { return nil })
+		},
+		"ExtractTextToWriter": func() error {
+			return NewMemoryEfficientExtractor(0, 4096, 0).ExtractTextToWriter(r, io.Discard)
+		},
+		"ExtractTextByLanguage": func() error { _, err := NewLanguageTextExtractor().ExtractTextByLanguage(r); return err },
+		"ExtractWithParallelProcessing": func() error {
+			_, err := NewParallelTextExtractor(2).ExtractWithParallelProcessing(context.Background(), r)
+			return err
+		},
+	} {
+		if err := f(); !errors.Is(err, ErrNotPermitted) {
+			t.Errorf("%s with enforced permissions: %v", name, err)
+		}
+	}
+
+	// The owner password grants everything
+	r, err = NewReaderWithOptions(bytes.NewReader(data), int64(len(data)), ReaderOptions{Password: "secret", EnforcePermissions: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perms, auth := r.Permissions(); perms != PermissionAll || auth != AuthOwner {
+		t.Errorf("Permissions with owner password = %v, %v", perms, auth)
+	}
+	if got := readerText(t, r); got != "Secret text" {
+		t.Errorf("text with owner password = %q", got)
+	}
+
+	// A wrong password falls back to the empty user password
+	r, err = NewReaderWithOptions(bytes.NewReader(data), int64(len(data)), ReaderOptions{Password: "wrong"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, auth := r.Permissions(); auth != AuthUser {
+		t.Errorf("authentication with wrong password = %v", auth)
+	}
+
+	plain, _ := revisedPDF()
+	r, err = NewReaderWithOptions(bytes.NewReader(plain), int64(len(plain)), ReaderOptions{EnforcePermissions: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perms, auth := r.Permissions(); perms != PermissionAll || auth != AuthNone {
+		t.Errorf("Permissions of unencrypted document = %v, %v", perms, auth)
+	}
+	if got := readerText(t, r); got != "Version three" {
+		t.Errorf("text of unencrypted document = %q", got)
+	}
+}
+
+func TestPermissionsRecipient(t *testing.T) {
+	pki := newTestPKI(t)
+	data := pubSecPDF(t, "<< /Filter /Adobe.PubSec /SubFilter /adbe.pkcs7.s3 /V 2 /Length 128 >>", 128, pki.rsaLeaf)
+	r, err := NewReaderWithCertificate(bytes.NewReader(data), int64(len(data)), pki.rsaLeaf, pki.rsaKey.(crypto.Decrypter))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perms, auth := r.Permissions(); perms != PermissionAll || auth != AuthRecipient {
+		t.Errorf("Permissions = %v, %v", perms, auth)
+	}
+}
+
+func TestStandardPermissions(t *testing.T) {
+	tests := []struct {
+		P    int32
+		R    int64
+		want string
+	}{
+		{-4, 4, "print|modify|copy|annotate|fill-forms|accessibility|assemble|print-high-quality"},
+		{-3904, 4, "none"},
+		{-3904 | 4 | 16, 3, "print|copy"},
+		// Revision 2 derives the later bits from the earlier ones
+		{-3904 | 4 | 16, 2, "print|copy|accessibility|print-high-quality"},
+		{-4 &^ 4, 2, "modify|copy|annotate|fill-forms|accessibility|assemble"},
+	}
+	for _, tt := range tests {
+		if got := standardPermissions(uint32(tt.P), tt.R).String(); got != tt.want {
+			t.Errorf("standardPermissions(%d, %d) = %s, want %s", tt.P, tt.R, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"crypto"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+)
+
+// NewReaderWithCertificate opens a file encrypted with the public-key
+// security handler (Adobe.PubSec, SubFilter adbe.pkcs7.s3, s4 or s5) for
+// reading, as the recipient with certificate cert and private key key.
+// It returns ErrNotRecipient if the file was not encrypted to cert. A file
+// that is not encrypted, or that uses the standard security handler, is
+// opened as by NewReaderEncrypted with no password.
+func NewReaderWithCertificate(f io.ReaderAt, size int64, cert *x509.Certificate, key crypto.Decrypter) (*Reader, error) {
+	r, err := openReader(f, size, nil)
+	if err != nil {
+		return nil, err
+	}
+	if r.trailer["Encrypt"] == nil {
+		return r, nil
+	}
+	if r.Trailer().Key("Encrypt").Key("Filter").Name() != "Adobe.PubSec" {
+		if err := r.initEncrypt(""); err != nil {
+			return nil, err
+		}
+		return r, nil
+	}
+	if err := r.initPubSec(cert, key); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// initPubSec sets the file key of a document encrypted with the public-key
+// security handler. See PDF 32000-1:2008, §7.6.4.
+func (r *Reader) initPubSec(cert *x509.Certificate, key crypto.Decrypter) error {
+	encrypt := r.Trailer().Key("Encrypt")
+	if encrypt.Kind() != Dict {
+		return fmt.Errorf("malformed PDF: cannot resolve Encrypt dictionary (xref may be corrupted)")
+	}
+
+	// V 1 and 2 list the recipients in the encryption dictionary and use
+	// RC4; V 4 and 5 list them in the crypt filter, which names the cipher.
+	V := encrypt.Key("V").Int64()
+	recipients := encrypt.Key("Recipients")
+	bits := int64(40)
+	cfm := "V2"
+	switch V {
+	case 1:
+	case 2:
+		if n := encrypt.Key("Length").Int64(); n != 0 {
+			bits = n
+		}
+	case 4, 5:
+		filterName := encrypt.Key("StmF").Name()
+		if filterName == "" || filterName == "Identity" {
+			filterName = encrypt.Key("StrF").Name()
+		}
+		filter := encrypt.Key("CF").Key(filterName)
+		if filter.Kind() != Dict {
+			return fmt.Errorf("malformed PDF: missing crypt filter %q", filterName)
+		}
+		recipients = filter.Key("Recipients")
+		cfm = filter.Key("CFM").Name()
+		bits = filter.Key("Length").Int64()
+		if bits > 0 && bits <= 32 {
+			bits *= 8 // some writers give the length in bytes
+		}
+		switch cfm {
+		case "V2":
+			if bits == 0 {
+				bits = 128
+			}
+		case "AESV2":
+			bits = 128
+		case "AESV3":
+			bits = 256
+		default:
+			return fmt.Errorf("unsupported PDF: crypt filter method %q", cfm)
+		}
+	default:
+		return fmt.Errorf("unsupported PDF: encryption version V=%d", V)
+	}
+	if bits%8 != 0 || bits < 40 || bits > 128 && cfm != "AESV3" {
+		return fmt.Errorf("malformed PDF: %d-bit encryption key", bits)
+	}
+
+	var envelopes []string
+	if recipients.Kind() == String {
+		envelopes = append(envelopes, recipients.RawString())
+	}
+	for i := 0; i < recipients.Len(); i++ {
+		envelopes = append(envelopes, recipients.Index(i).RawString())
+	}
+	if len(envelopes) == 0 {
+		return fmt.Errorf("malformed PDF: missing Recipients in public-key encryption")
+	}
+
+	var seed []byte
+	for _, env := range envelopes {
+		content, err := openCMSEnvelope([]byte(env), cert, key)
+		if errors.Is(err, ErrNotRecipient) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("encrypted PDF: recipient: %v", err)
+		}
+		// 20 bytes of seed, then 4 of permissions
+		if len(content) < 20 {
+			return fmt.Errorf("malformed PDF: recipient seed of %d bytes", len(content))
+		}
+		seed = content[:20]
+		r.perms = PermissionAll
+		if len(content) >= 24 {
+			r.perms &= Permissions(binary.BigEndian.Uint32(content[20:24]))
+		}
+		break
+	}
+	if seed == nil {
+		return ErrNotRecipient
+	}
+	r.auth = AuthRecipient
+
+	// The file key is a digest of the seed and all the recipient
+	// envelopes, as they are in the file.
+	var h hash.Hash
+	if cfm == "AESV3" {
+		h = sha256.New()
+	} else {
+		h = sha1.New()
+	}
+	h.Write(seed)
+	for _, env := range envelopes {
+		h.Write([]byte(env))
+	}
+	if V >= 4 && encrypt.Key("EncryptMetadata").Kind() == Bool && !encrypt.Key("EncryptMetadata").Bool() {
+		h.Write([]byte{0xff, 0xff, 0xff, 0xff})
+	}
+	r.key = h.Sum(nil)[:bits/8]
+	return r.initCryptFilters(encrypt)
+}
@@ -0,0 +1,191 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/rc4"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+// sealCMSEnvelope returns a DER EnvelopedData of content, encrypted with
+// AES-128-CBC under a key transported to each RSA certificate in to.
+func sealCMSEnvelope(t *testing.T, content []byte, to ...*x509.Certificate) []byte {
+	t.Helper()
+	cek := make([]byte, 16)
+	iv := make([]byte, aes.BlockSize)
+	rand.Read(cek)
+	rand.Read(iv)
+	block, _ := aes.NewCipher(cek)
+	pad := aes.BlockSize - len(content)%aes.BlockSize
+	enc := append(append([]byte(nil), content...), bytes.Repeat([]byte{byte(pad)}, pad)...)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(enc, enc)
+
+	var ris []asn1.RawValue
+	for _, c := range to {
+		ek, err := rsa.EncryptPKCS1v15(rand.Reader, c.PublicKey.(*rsa.PublicKey), cek)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ri := cmsKeyTransRecipientInfo{
+			RID: asn1.RawValue{FullBytes: mustMarshal(t, cmsIssuerAndSerial{
+				Issuer: asn1.RawValue{FullBytes: c.RawIssuer},
+				Serial: c.SerialNumber,
+			})},
+			KeyEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidRSAEncryption},
+			EncryptedKey:           ek,
+		}
+		ris = append(ris, asn1.RawValue{FullBytes: mustMarshal(t, ri)})
+	}
+	ed := cmsEnvelopedData{
+		RecipientInfos: ris,
+		EncryptedContentInfo: cmsEncryptedContentInfo{
+			ContentType: oidData,
+			ContentEncryptionAlgorithm: pkix.AlgorithmIdentifier{
+				Algorithm:  oidAES128CBC,
+				Parameters: asn1.RawValue{FullBytes: mustMarshal(t, iv)},
+			},
+			EncryptedContent: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, Bytes: enc},
+		},
+	}
+	return mustMarshal(t, cmsContentInfo{
+		ContentType: oidEnvelopedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: mustMarshal(t, ed)},
+	})
+}
+
+// encryptObject encrypts data of object num, generation 0, with file key
+// key as PDF 32000-1:2008 §7.6.2 describes, and AES-256 with the key
+// itself.
+func encryptObject(key []byte, aesMode bool, num int, data []byte) []byte {
+	objKey := key
+	if !aesMode || len(key) != 32 {
+		h := md5.New()
+		h.Write(key)
+		h.Write([]byte{byte(num), byte(num >> 8), byte(num >> 16), 0, 0})
+		if aesMode {
+			h.Write([]byte("sAlT"))
+		}
+		objKey = h.Sum(nil)[:min(len(key)+5, 16)]
+	}
+	if !aesMode {
+		c, _ := rc4.NewCipher(objKey)
+		out := make([]byte, len(data))
+		c.XORKeyStream(out, data)
+		return out
+	}
+	block, _ := aes.NewCipher(objKey)
+	iv := make([]byte, aes.BlockSize)
+	rand.Read(iv)
+	pad := aes.BlockSize - len(data)%aes.BlockSize
+	out := append(append([]byte(nil), data...), bytes.Repeat([]byte{byte(pad)}, pad)...)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(out, out)
+	return append(iv, out...)
+}
+
+// pubSecPDF returns a document encrypted to the certificates in to with
+// the public-key security handler. encrypt is the encryption dictionary
+// less the Recipients, which are added to the dictionary itself for V 1
+// and 2 and to the crypt filter DefaultCryptFilter otherwise.
+func pubSecPDF(t *testing.T, encrypt string, keyBits int, to ...*x509.Certificate) []byte {
+	t.Helper()
+	seed := make([]byte, 20)
+	rand.Read(seed)
+	env := sealCMSEnvelope(t, append(seed, 0xff, 0xff, 0xff, 0xfc), to...)
+	recipients := "/Recipients [<" + hex.EncodeToString(env) + ">]"
+	if strings.Contains(encrypt, "DefaultCryptFilter") {
+		encrypt = strings.Replace(encrypt, "/CFM", recipients+" /CFM", 1)
+	} else {
+		encrypt = strings.Replace(encrypt, ">>", recipients+" >>", 1)
+	}
+
+	var h = sha1.New()
+	if keyBits == 256 {
+		h = sha256.New()
+	}
+	h.Write(seed)
+	h.Write(env)
+	key := h.Sum(nil)[:keyBits/8]
+	aesMode := strings.Contains(encrypt, "/AESV")
+
+	content := []byte("BT /F1 10 Tf 72 700 Td (Secret text) Tj ET")
+	stream := encryptObject(key, aesMode, 5, content)
+	title := encryptObject(key, aesMode, 6, []byte("Confidential"))
+	widths := strings.TrimSpace(strings.Repeat("500 ", 95))
+	return buildPDF("/Root 1 0 R /Info 6 0 R /Encrypt 7 0 R /ID [<0123456789abcdef0123456789abcdef> <0123456789abcdef0123456789abcdef>]",
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Count 1 /Kids [3 0 R] >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 4 0 R >> >> /Contents 5 0 R >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /FirstChar 32 /LastChar 126 /Widths ["+widths+"] >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(stream), stream),
+		"<< /Title <"+hex.EncodeToString(title)+"> >>",
+		encrypt,
+	)
+}
+
+func TestNewReaderWithCertificate(t *testing.T) {
+	pki := newTestPKI(t)
+	// Another recipient, told apart from the leaf by serial number
+	other := *pki.rsaLeaf
+	other.SerialNumber = new(big.Int).Neg(pki.rsaLeaf.SerialNumber)
+	tests := []struct {
+		name    string
+		encrypt string
+		bits    int
+	}{
+		{"s3 RC4 40", "<< /Filter /Adobe.PubSec /SubFilter /adbe.pkcs7.s3 /V 1 >>", 40},
+		{"s3 RC4 128", "<< /Filter /Adobe.PubSec /SubFilter /adbe.pkcs7.s3 /V 2 /Length 128 >>", 128},
+		{"s4 RC4", "<< /Filter /Adobe.PubSec /SubFilter /adbe.pkcs7.s4 /V 4 /StmF /DefaultCryptFilter /StrF /DefaultCryptFilter " +
+			"/CF << /DefaultCryptFilter << /CFM /V2 /Length 16 >> >> >>", 128},
+		{"s5 AESV2", "<< /Filter /Adobe.PubSec /SubFilter /adbe.pkcs7.s5 /V 4 /StmF /DefaultCryptFilter /StrF /DefaultCryptFilter " +
+			"/CF << /DefaultCryptFilter << /CFM /AESV2 /Length 128 >> >> >>", 128},
+		{"s5 AESV3", "<< /Filter /Adobe.PubSec /SubFilter /adbe.pkcs7.s5 /V 5 /StmF /DefaultCryptFilter /StrF /DefaultCryptFilter " +
+			"/CF << /DefaultCryptFilter << /CFM /AESV3 /Length 256 >> >> >>", 256},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := pubSecPDF(t, tt.encrypt, tt.bits, &other, pki.rsaLeaf)
+			r, err := NewReaderWithCertificate(bytes.NewReader(data), int64(len(data)), pki.rsaLeaf, pki.rsaKey.(crypto.Decrypter))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := readerText(t, r); got != "Secret text" {
+				t.Errorf("text = %q, want %q", got, "Secret text")
+			}
+			if got := r.Trailer().Key("Info").Key("Title").Text(); got != "Confidential" {
+				t.Errorf("title = %q, want %q", got, "Confidential")
+			}
+		})
+	}
+
+	data := pubSecPDF(t, tests[1].encrypt, 128, pki.rsaLeaf)
+	if _, err := NewReaderWithCertificate(bytes.NewReader(data), int64(len(data)), &other, pki.rsaKey.(crypto.Decrypter)); !errors.Is(err, ErrNotRecipient) {
+		t.Errorf("NewReaderWithCertificate as another certificate = %v, want ErrNotRecipient", err)
+	}
+	if _, err := NewReader(bytes.NewReader(data), int64(len(data))); err == nil {
+		t.Error("NewReader of a public-key encrypted file succeeded")
+	}
+
+	plain, _ := revisedPDF()
+	if _, err := NewReaderWithCertificate(bytes.NewReader(plain), int64(len(plain)), pki.rsaLeaf, pki.rsaKey.(crypto.Decrypter)); err != nil {
+		t.Errorf("NewReaderWithCertificate of an unencrypted file: %v", err)
+	}
+}
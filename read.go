@@ -75,6 +75,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 )
 
 // toLatin1 converts a UTF-8 string to Latin-1 (ISO-8859-1) encoding.
@@ -175,7 +176,6 @@ type Reader struct {
 	trailer       dict
 	trailerptr    objptr
 	key           []byte
-	useAES        bool
 	cacheMu       sync.RWMutex
 	objCache      map[objptr]*list.Element
 	cacheList     *list.List
@@ -186,6 +186,32 @@ type Reader struct {
 	// Cache for object streams to avoid re-parsing
 	objStreamCache   map[uint32]map[int64]int64
 	objStreamCacheMu sync.RWMutex
+
+	// Parsed embedded TrueType programs keyed by font file stream
+	embeddedFonts sync.Map
+
+	// Whether extraction keeps text that is entirely clipped away
+	keepClippedText atomic.Bool
+
+	// Offset of the last cross-reference section, or 0 if the
+	// cross-reference table was recovered
+	startxref int64
+
+	// Crypt filters of an encrypted document: the defaults for streams,
+	// strings and embedded files, the named ones, and whether the
+	// metadata streams are left in clear text
+	stmF, strF, effF cryptFilter
+	cryptFilters     map[string]cryptFilter
+	clearMetadata    bool
+
+	// How an encrypted document was opened and what it allows, and
+	// whether extraction checks the permissions
+	auth               Authentication
+	perms              Permissions
+	enforcePermissions bool
+
+	// Where malformations recovered from are recorded, or nil
+	warnings *WarningCollector
 }
 
 type xref struct {
@@ -336,6 +362,36 @@ func NewReader(f io.ReaderAt, size int64) (*Reader, error) {
 // to try. If pw returns the empty string, NewReaderEncrypted stops trying to decrypt
 // the file and returns an error.
 func NewReaderEncrypted(f io.ReaderAt, size int64, pw func() string) (*Reader, error) {
+	r, err := openReader(f, size, nil)
+	if err != nil {
+		return nil, err
+	}
+	if r.trailer["Encrypt"] == nil {
+		return r, nil
+	}
+	err = r.initEncrypt("")
+	if err == nil {
+		return r, nil
+	}
+	if pw == nil || err != ErrInvalidPassword {
+		return nil, err
+	}
+	for {
+		next := pw()
+		if next == "" {
+			break
+		}
+		if r.initEncrypt(next) == nil {
+			return r, nil
+		}
+	}
+	return nil, err
+}
+
+// openReader reads the header and cross-reference data of the file,
+// recovering them if damaged, without setting up decryption. Recovered
+// malformations are recorded in warnings, which may be nil.
+func openReader(f io.ReaderAt, size int64, warnings *WarningCollector) (*Reader, error) {
 	const headerSearchLimit = 4096
 	headerProbe := headerSearchLimit
 	if size < int64(headerProbe) {
@@ -360,9 +416,7 @@ func NewReaderEncrypted(f io.ReaderAt, size int64, pw func() string) (*Reader, e
 		if sigIdx < 0 {
 			return nil, fmt.Errorf("not a PDF file: invalid header")
 		}
-		if DebugOn {
-			fmt.Println("warning: PDF header missing leading %, attempting recovery")
-		}
+		recordWarning(warnings, WarnHeader, objptr{}, int64(sigIdx), "header lacks the leading %%")
 		// Adjust sigIdx to account for missing %
 		sigIdx-- // We'll treat the position before PDF- as if % was there
 		if sigIdx < 0 {
@@ -377,9 +431,7 @@ func NewReaderEncrypted(f io.ReaderAt, size int64, pw func() string) (*Reader, e
 		// File too short, but try to continue if we at least have the PDF- marker
 		if sigIdx+4 <= len(buf) && bytes.Equal(buf[sigIdx:sigIdx+4], []byte("%PDF")) {
 			// We have minimal header, set a default version and continue
-			if DebugOn {
-				fmt.Println("warning: PDF header truncated, assuming version 1.4")
-			}
+			recordWarning(warnings, WarnHeader, objptr{}, int64(sigIdx), "header is truncated")
 			// Continue with minimal validation
 		} else {
 			return nil, fmt.Errorf("not a PDF file: invalid header")
@@ -394,9 +446,7 @@ func NewReaderEncrypted(f io.ReaderAt, size int64, pw func() string) (*Reader, e
 	// We need at least sigIdx+8 bytes to have the complete version string
 	if sigIdx+8 > len(buf) {
 		// Truncated version, try to continue with what we have
-		if DebugOn {
-			fmt.Println("warning: PDF version string truncated, attempting to parse partial version")
-		}
+		recordWarning(warnings, WarnHeader, objptr{}, int64(sigIdx), "version in header is truncated")
 		// Don't fail immediately, let parsePDFVersion handle it
 	}
 
@@ -422,9 +472,7 @@ func NewReaderEncrypted(f io.ReaderAt, size int64, pw func() string) (*Reader, e
 		c := buf[sigIdx+8]
 		if c != '\r' && c != '\n' && c != ' ' && c != '%' && c != '\t' {
 			// Log but don't fail - some non-conforming PDFs still work
-			if DebugOn {
-				fmt.Printf("warning: unexpected character after PDF version: %q\n", c)
-			}
+			recordWarning(warnings, WarnHeader, objptr{}, int64(sigIdx+8), "unexpected %q after the version in the header", c)
 		}
 	}
 
@@ -452,9 +500,7 @@ func NewReaderEncrypted(f io.ReaderAt, size int64, pw func() string) (*Reader, e
 	if eofIdx < 0 {
 		// Try to continue without %%EOF for damaged files
 		// This is a recovery mechanism
-		if DebugOn {
-			fmt.Println("warning: PDF missing EOF marker, attempting recovery")
-		}
+		recordWarning(warnings, WarnMissingEOF, objptr{}, -1, "no %%%%EOF at the end of the file")
 		// Still try to find startxref
 	} else {
 		// Use content up to and including %%EOF for finding startxref
@@ -498,9 +544,7 @@ func NewReaderEncrypted(f io.ReaderAt, size int64, pw func() string) (*Reader, e
 		if bigIdx >= 0 {
 			buf = bigBuf
 			i = bigIdx
-			if DebugOn {
-				fmt.Println("Found startxref in larger buffer")
-			}
+			recordWarning(warnings, WarnStartxref, objptr{}, readOffset+int64(bigIdx), "startxref found only in the last %d bytes of the file", chunkSize)
 		}
 	}
 
@@ -516,9 +560,7 @@ func NewReaderEncrypted(f io.ReaderAt, size int64, pw func() string) (*Reader, e
 			buf = make([]byte, readLen)
 			f.ReadAt(buf, backwardIdx)
 			i = 0 // startxref is at the beginning of our new buffer
-			if DebugOn {
-				fmt.Printf("Found startxref via backward search at offset %d\n", backwardIdx)
-			}
+			recordWarning(warnings, WarnStartxref, objptr{}, backwardIdx, "startxref found by searching back from the end of the file")
 		}
 	}
 
@@ -530,15 +572,11 @@ func NewReaderEncrypted(f io.ReaderAt, size int64, pw func() string) (*Reader, e
 			// Verify it's on its own line by checking context
 			if simpleIdx > 0 && (buf[simpleIdx-1] == '\n' || buf[simpleIdx-1] == '\r') {
 				i = simpleIdx
-				if DebugOn {
-					fmt.Println("Found startxref using fallback simple search")
-				}
+				recordWarning(warnings, WarnStartxref, objptr{}, -1, "startxref found by searching for it anywhere")
 			} else if simpleIdx == 0 {
 				// startxref is at the very beginning of buffer
 				i = 0
-				if DebugOn {
-					fmt.Println("Found startxref at buffer start")
-				}
+				recordWarning(warnings, WarnStartxref, objptr{}, -1, "startxref found by searching for it anywhere")
 			}
 		}
 	}
@@ -561,6 +599,7 @@ func NewReaderEncrypted(f io.ReaderAt, size int64, pw func() string) (*Reader, e
 		// A capacity of 2000 objects provides good performance while limiting memory.
 		cacheCap:       2000,
 		objStreamCache: make(map[uint32]map[int64]int64),
+		warnings:       warnings,
 	}
 
 	// Initialize compatibility information
@@ -590,8 +629,10 @@ func NewReaderEncrypted(f io.ReaderAt, size int64, pw func() string) (*Reader, e
 		return nil, fmt.Errorf("malformed PDF file: startxref not followed by integer")
 	}
 	b = newBuffer(io.NewSectionReader(r.f, startxref, r.end-startxref), startxref)
+	b.warnings = warnings
 	xref, trailerptr, trailer, err := readXref(r, b)
 	if err != nil {
+		r.warn(WarnXrefRecovered, objptr{}, startxref, "cross-reference data at startxref cannot be read (%v); rebuilding it", err)
 		// Recovery strategy chain:
 		// 1. Try searchAndParseXref (search for xref/XRef in file)
 		// 2. Try rebuildXrefTable (scan for all objects)
@@ -641,27 +682,9 @@ func NewReaderEncrypted(f io.ReaderAt, size int64, pw func() string) (*Reader, e
 		r.xref = xref
 		r.trailer = trailer
 		r.trailerptr = trailerptr
+		r.startxref = startxref
 	}
-	if trailer["Encrypt"] == nil {
-		return r, nil
-	}
-	err = r.initEncrypt("")
-	if err == nil {
-		return r, nil
-	}
-	if pw == nil || err != ErrInvalidPassword {
-		return nil, err
-	}
-	for {
-		next := pw()
-		if next == "" {
-			break
-		}
-		if r.initEncrypt(next) == nil {
-			return r, nil
-		}
-	}
-	return nil, err
+	return r, nil
 }
 
 // NewReaderEncryptedWithMmap opens a file for reading with memory mapping for large files.
@@ -742,11 +765,9 @@ func readXref(r *Reader, b *buffer) (xr []xref, trailerptr objptr, trailer dict,
 	// Special handling for offset 116 which is extremely common in corrupted PDFs
 	// This offset often indicates a systematic corruption pattern
 	if offset == 116 {
-		if DebugOn {
-			fmt.Println("Detected offset 116 corruption pattern, attempting enhanced recovery")
-		}
 		// Try multiple recovery strategies specific to offset 116
 		if xr, trailerptr, trailer, err = tryRecoverFromOffset116(r); err == nil {
+			r.warn(WarnXrefRecovered, objptr{}, offset, "cross-reference data for startxref offset 116 found by searching the file")
 			return
 		}
 	}
@@ -770,17 +791,20 @@ func readXref(r *Reader, b *buffer) (xr []xref, trailerptr objptr, trailer dict,
 		// This might be the dict part of an xref stream object
 		// Try to find the object header before this position
 		if xr, trailerptr, trailer, err = tryRecoverXrefFromDict(r, d, offset); err == nil {
+			r.warn(WarnXrefRecovered, objptr{}, offset, "startxref points into a cross-reference stream; read from its object header")
 			return
 		}
 
 		// If tryRecoverXrefFromDict failed, try global search for xref
 		// This handles cases where startxref points to a non-XRef stream dict
 		if searchErr := r.searchAndParseXref(); searchErr == nil {
+			r.warn(WarnXrefRecovered, objptr{}, offset, "startxref points to a dictionary; cross-reference data found by searching the file")
 			return r.xref, r.trailerptr, r.trailer, nil
 		}
 
 		// Try rebuilding xref by scanning all objects
 		if rebuildErr := r.rebuildXrefTable(); rebuildErr == nil {
+			r.warn(WarnXrefRecovered, objptr{}, offset, "startxref points to a dictionary; cross-reference table rebuilt")
 			return r.xref, r.trailerptr, r.trailer, nil
 		}
 	}
@@ -1189,9 +1213,7 @@ func readXrefStreamData(r *Reader, strm stream, table []xref, size int64) ([]xre
 					fmt.Printf("xref entry %d: object in stream (obj %d index %d)\n", x, v2, v3)
 				}
 			default:
-				if DebugOn {
-					fmt.Printf("invalid xref stream type %d: %x\n", v1, buf)
-				}
+				r.warn(WarnXrefEntry, objptr{uint32(x), 0}, strm.offset, "cross-reference stream entry of unknown type %d", v1)
 			}
 		}
 	}
@@ -1483,7 +1505,7 @@ func (r *Reader) rebuildXrefTable() error {
 			if id64, err1 := strconv.ParseUint(line[0], 10, 32); err1 == nil {
 				if gen64, err2 := strconv.ParseUint(line[1], 10, 16); err2 == nil {
 					ptr := objptr{uint32(id64), uint16(gen64)}
-					if _, ok := entries[ptr.id]; !ok {
+					if old, ok := entries[ptr.id]; !ok || ptr.gen >= old.ptr.gen {
 						entries[ptr.id] = xref{ptr: ptr, offset: int64(lineStart)}
 					}
 				}
@@ -1504,7 +1526,7 @@ func (r *Reader) rebuildXrefTable() error {
 	for id, entry := range entries {
 		table[id] = entry
 	}
-	r.xref = table
+	r.xref = r.indexObjectStreams(data, table)
 	if err := r.recoverTrailer(data); err != nil {
 		return fmt.Errorf("failed to recover trailer: %w", err)
 	}
@@ -1521,6 +1543,12 @@ func (r *Reader) recoverTrailer(data []byte) error {
 			obj := buf.readObject()
 			PutPDFBuffer(buf)
 			if d, ok := obj.(dict); ok {
+				if d["Root"] == nil {
+					if rootRef := r.findRoot(data); rootRef != (objptr{}) {
+						d["Root"] = rootRef
+						r.warn(WarnTrailer, objptr{}, int64(idx), "trailer lacks Root; using catalog %d %d R", rootRef.id, rootRef.gen)
+					}
+				}
 				r.trailer = d
 				r.trailerptr = objptr{}
 				return nil
@@ -1537,14 +1565,12 @@ func (r *Reader) recoverTrailer(data []byte) error {
 	}
 
 	// Last resort: try to synthesize a minimal trailer by finding Root object
-	if rootRef := findRootObject(data); rootRef != (objptr{}) {
+	if rootRef := r.findRoot(data); rootRef != (objptr{}) {
 		r.trailer = make(dict)
 		r.trailer["Size"] = int64(len(r.xref))
 		r.trailer["Root"] = rootRef
 		r.trailerptr = objptr{}
-		if DebugOn {
-			fmt.Printf("Synthesized minimal trailer with Root=%v\n", rootRef)
-		}
+		r.warn(WarnTrailer, objptr{}, -1, "no trailer found; synthesized one with Root %d %d R", rootRef.id, rootRef.gen)
 		return nil
 	}
 
@@ -2067,16 +2093,20 @@ func (r *Reader) resolve(parent objptr, x interface{}) Value {
 			for {
 				if strm.Kind() != Stream {
 					// Tolerate corrupted xref stream reference
+					r.warn(WarnObjectStream, ptr, -1, "object stream %d is missing", currentStreamID)
 					return Value{}
 				}
+				strmOffset := strm.data.(stream).offset
 				if strm.Key("Type").Name() != "ObjStm" {
 					// Not an object stream, return empty
+					r.warn(WarnObjectStream, ptr, strmOffset, "stream %d is not an object stream", currentStreamID)
 					return Value{}
 				}
 				n := int(strm.Key("N").Int64())
 				first := strm.Key("First").Int64()
 				if first == 0 {
 					// Missing First entry, return empty
+					r.warn(WarnObjectStream, ptr, strmOffset, "object stream %d has no First", currentStreamID)
 					return Value{}
 				}
 
@@ -2140,6 +2170,9 @@ func (r *Reader) resolve(parent objptr, x interface{}) Value {
 
 				if found {
 					b := newBuffer(strm.Reader(), 0)
+					b.warnings = r.warnings
+					b.warnAt = strmOffset
+					b.objptr = ptr
 					b.seekForward(offset)
 					x = b.readObject()
 					r.storeCachedObject(ptr, x)
@@ -2150,6 +2183,7 @@ func (r *Reader) resolve(parent objptr, x interface{}) Value {
 				ext := strm.Key("Extends")
 				if ext.Kind() != Stream {
 					// Cannot find object in stream, return empty
+					r.warn(WarnObjectStream, ptr, strmOffset, "object is not in its object stream")
 					return Value{}
 				}
 				strm = ext
@@ -2157,18 +2191,23 @@ func (r *Reader) resolve(parent objptr, x interface{}) Value {
 			}
 		} else {
 			b := newBuffer(io.NewSectionReader(r.f, xref.offset, r.end-xref.offset), xref.offset)
-			b.key = r.key
-			b.useAES = r.useAES
+			b.warnings = r.warnings
+			if r.strF != cryptIdentity {
+				b.key = r.key
+				b.useAES = r.strF == cryptAES
+			}
 			obj = b.readObject()
 			def, ok := obj.(objdef)
 			if !ok {
 				// Tolerate corrupted object definition
 				PutPDFBuffer(b)
+				r.warn(WarnBadObject, ptr, xref.offset, "no object definition at the cross-reference offset")
 				return Value{}
 			}
 			if def.ptr != ptr {
 				// Object pointer mismatch, tolerate and use what we found
 				// This can happen in corrupted PDFs where xref table is inconsistent
+				r.warn(WarnObjectMismatch, ptr, xref.offset, "object %d %d is at the cross-reference offset", def.ptr.id, def.ptr.gen)
 			}
 			x = def.obj
 			r.storeCachedObject(ptr, x)
@@ -2211,7 +2250,13 @@ func (v Value) Reader() io.ReadCloser {
 	var rd io.Reader
 	rd = io.NewSectionReader(v.r.f, x.offset, v.Key("Length").Int64())
 	if v.r.key != nil {
-		rd = decryptStream(v.r.key, v.r.useAES, x.ptr, rd)
+		crypt, err := v.r.streamCrypt(v)
+		if err != nil {
+			return &errorReadCloser{err}
+		}
+		if crypt != cryptIdentity {
+			rd = decryptStream(v.r.key, crypt == cryptAES, x.ptr, rd)
+		}
 	}
 	filter := v.Key("Filter")
 	param := v.Key("DecodeParms")
@@ -2267,10 +2312,8 @@ func applyFilter(rd io.Reader, name string, param Value) io.Reader {
 
 		switch param.Keys() {
 		default:
-			if DebugOn {
-				fmt.Println("param=", param)
-			}
 			// Unexpected DecodeParms, but continue with decoder
+			param.r.warn(WarnFilter, param.ptr, -1, "ASCII85Decode ignores DecodeParms %v", param)
 			return decoder
 		case nil:
 			return decoder
@@ -2286,6 +2329,9 @@ func applyFilter(rd io.Reader, name string, param Value) io.Reader {
 		return rd
 	case "RunLengthDecode":
 		return newRunLengthReader(rd)
+	case "Crypt":
+		// Value.Reader decrypts before applying the filters
+		return rd
 	}
 }
 
@@ -2307,10 +2353,8 @@ func applyPredictor(rd io.Reader, param Value) io.Reader {
 		}
 		return &pngUpReader{r: rd, hist: make([]byte, 1+columns), tmp: make([]byte, 1+columns)}
 	default:
-		if DebugOn {
-			fmt.Println("unknown predictor", pred)
-		}
 		// Unknown predictor, return original reader
+		param.r.warn(WarnFilter, param.ptr, -1, "unknown predictor %v; data left undecoded", pred)
 		return rd
 	}
 }
@@ -2606,65 +2650,80 @@ func (r *Reader) initEncrypt(password string) error {
 	p, _ := encrypt["P"].(int64)
 	P := uint32(p)
 
-	// TODO: Password should be converted to Latin-1.
-	pw := toLatin1(password)
-	h := md5.New()
-	if len(pw) >= 32 {
-		h.Write(pw[:32])
-	} else {
-		h.Write(pw)
-		h.Write(passwordPad[:32-len(pw)])
-	}
-	h.Write([]byte(O))
-	h.Write([]byte{byte(P), byte(P >> 8), byte(P >> 16), byte(P >> 24)})
-	h.Write([]byte(ID))
-	key := h.Sum(nil)
-
+	keyLen := 40 / 8
 	if R >= 3 {
-		for i := 0; i < 50; i++ {
-			h.Reset()
-			h.Write(key[:n/8])
-			key = h.Sum(key[:0])
-		}
-		key = key[:n/8]
-	} else {
-		key = key[:40/8]
+		keyLen = int(n / 8)
 	}
-
-	c, err := rc4.NewCipher(key)
-	if err != nil {
-		return fmt.Errorf("malformed PDF: invalid RC4 key: %v", err)
+	pad := func(pw []byte) []byte {
+		return append(pw[:min(len(pw), 32):min(len(pw), 32)], passwordPad[:32-min(len(pw), 32)]...)
 	}
 
-	var u []byte
-	if R == 2 {
-		u = make([]byte, 32)
-		copy(u, passwordPad)
-		c.XORKeyStream(u, u)
-	} else {
-		h.Reset()
-		h.Write(passwordPad)
+	// userKey computes the file key from a user password and reports
+	// whether it is the right one (Algorithms 2, 4 and 5).
+	userKey := func(pw []byte) ([]byte, bool) {
+		h := md5.New()
+		h.Write(pad(pw))
+		h.Write([]byte(O))
+		h.Write([]byte{byte(P), byte(P >> 8), byte(P >> 16), byte(P >> 24)})
 		h.Write([]byte(ID))
-		u = h.Sum(nil)
-		c.XORKeyStream(u, u)
-
-		for i := 1; i <= 19; i++ {
-			key1 := make([]byte, len(key))
-			copy(key1, key)
-			for j := range key1 {
-				key1[j] ^= byte(i)
+		if m, ok := encrypt["EncryptMetadata"].(bool); R >= 4 && ok && !m {
+			h.Write([]byte{0xff, 0xff, 0xff, 0xff})
+		}
+		key := h.Sum(nil)
+		if R >= 3 {
+			for i := 0; i < 50; i++ {
+				h.Reset()
+				h.Write(key[:keyLen])
+				key = h.Sum(key[:0])
 			}
-			c, _ = rc4.NewCipher(key1)
+		}
+		key = key[:keyLen]
+
+		var u []byte
+		if R == 2 {
+			u = pad(nil)
+			c, _ := rc4.NewCipher(key)
 			c.XORKeyStream(u, u)
+		} else {
+			h.Reset()
+			h.Write(passwordPad)
+			h.Write([]byte(ID))
+			u = h.Sum(nil)
+			u = xorRC4(key, u, 0, 19)
+		}
+		return key, bytes.HasPrefix([]byte(U), u)
+	}
+
+	// ownerToUser decrypts O with a key from the owner password, which
+	// gives the user password (Algorithm 7).
+	ownerToUser := func(pw []byte) []byte {
+		k := md5.Sum(pad(pw))
+		if R >= 3 {
+			for i := 0; i < 50; i++ {
+				k = md5.Sum(k[:])
+			}
+		}
+		if R == 2 {
+			return xorRC4(k[:keyLen], []byte(O), 0, 0)
 		}
+		return xorRC4(k[:keyLen], []byte(O), 19, 0)
 	}
 
-	if !bytes.HasPrefix([]byte(U), u) {
+	// TODO: Password should be converted to Latin-1.
+	pw := toLatin1(password)
+	key, ok := userKey(ownerToUser(pw))
+	r.auth = AuthOwner
+	if !ok {
+		key, ok = userKey(pw)
+		r.auth = AuthUser
+	}
+	if !ok {
+		r.auth = AuthNone
 		return ErrInvalidPassword
 	}
+	r.perms = standardPermissions(P, R)
 
 	r.key = key
-	r.useAES = V == 4
 
 	// Handle V=5 encryption (AES-256)
 	if V == 5 {
@@ -2698,16 +2757,35 @@ func (r *Reader) initEncrypt(password string) error {
 		}
 
 		r.key = key
-		r.useAES = true // V=5 always uses AES-256
-
-		return nil
 	}
 
-	return nil
+	return r.initCryptFilters(encryptVal)
 }
 
 var ErrInvalidPassword = fmt.Errorf("encrypted PDF: invalid password")
 
+// xorRC4 encrypts b with RC4 once for each i from first to last, with the
+// key whose bytes are XORed with i, as the standard security handler does.
+func xorRC4(key, b []byte, first, last int) []byte {
+	out := append([]byte(nil), b...)
+	k := make([]byte, len(key))
+	for i := first; ; {
+		for j := range k {
+			k[j] = key[j] ^ byte(i)
+		}
+		c, _ := rc4.NewCipher(k)
+		c.XORKeyStream(out, out)
+		if i == last {
+			return out
+		}
+		if first < last {
+			i++
+		} else {
+			i--
+		}
+	}
+}
+
 func okayV4(encrypt dict) bool {
 	cf, ok := encrypt["CF"].(dict)
 	if !ok {
@@ -2741,13 +2819,17 @@ func okayV4(encrypt dict) bool {
 }
 
 func cryptKey(key []byte, useAES bool, ptr objptr) []byte {
+	if useAES && len(key) == 32 {
+		// AES-256 uses the file key for every object
+		return key
+	}
 	h := md5.New()
 	h.Write(key)
 	h.Write([]byte{byte(ptr.id), byte(ptr.id >> 8), byte(ptr.id >> 16), byte(ptr.gen), byte(ptr.gen >> 8)})
 	if useAES {
 		h.Write([]byte("sAlT"))
 	}
-	return h.Sum(nil)
+	return h.Sum(nil)[:min(len(key)+5, 16)]
 }
 
 func decryptString(key []byte, useAES bool, ptr objptr, x string) string {
@@ -2841,16 +2923,39 @@ type cbcReader struct {
 	rd   io.Reader
 	buf  []byte
 	pend []byte
+	next []byte // ciphertext of the block after buf, read ahead to find the last one
+	done bool
 }
 
 func (r *cbcReader) Read(b []byte) (n int, err error) {
 	if len(r.pend) == 0 {
-		_, err = io.ReadFull(r.rd, r.buf)
-		if err != nil {
-			return 0, err
+		if r.done {
+			return 0, io.EOF
 		}
+		if r.next == nil {
+			r.next = make([]byte, len(r.buf))
+			if _, err := io.ReadFull(r.rd, r.next); err != nil {
+				return 0, err
+			}
+		}
+		copy(r.buf, r.next)
 		r.cbc.CryptBlocks(r.buf, r.buf)
 		r.pend = r.buf
+		switch _, err := io.ReadFull(r.rd, r.next); err {
+		case nil:
+		case io.EOF:
+			// The last block ends with PKCS#7 padding
+			r.done = true
+			pad := int(r.buf[len(r.buf)-1])
+			if pad > 0 && pad <= len(r.buf) && bytes.Count(r.buf[len(r.buf)-pad:], r.buf[len(r.buf)-1:]) == pad {
+				r.pend = r.buf[:len(r.buf)-pad]
+			}
+			if len(r.pend) == 0 {
+				return 0, io.EOF
+			}
+		default:
+			return 0, err
+		}
 	}
 	n = copy(b, r.pend)
 	r.pend = r.pend[n:]
@@ -10,6 +10,7 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -357,7 +358,9 @@ func findXrefStreamDirect(data []byte) (offset int64, err error) {
 	return int64(searchStart + lineStart), nil
 }
 
-// rebuildXrefFromObjects scans the file and builds xref from object markers
+// rebuildXrefFromObjects scans the file and builds xref from object markers.
+// Of several definitions of an object, the one with the highest generation
+// wins, and of those the last in the file.
 func rebuildXrefFromObjects(data []byte) ([]xref, error) {
 	entries := make(map[uint32]xref)
 
@@ -387,7 +390,7 @@ func rebuildXrefFromObjects(data []byte) ([]xref, error) {
 			if id, err := strconv.ParseUint(fields[len(fields)-2], 10, 32); err == nil {
 				if gen, err := strconv.ParseUint(fields[len(fields)-1], 10, 16); err == nil {
 					ptr := objptr{uint32(id), uint16(gen)}
-					if _, exists := entries[ptr.id]; !exists {
+					if old, exists := entries[ptr.id]; !exists || ptr.gen >= old.ptr.gen {
 						entries[ptr.id] = xref{ptr: ptr, offset: int64(lineStart)}
 					}
 				}
@@ -417,6 +420,166 @@ func rebuildXrefFromObjects(data []byte) ([]xref, error) {
 	return table, nil
 }
 
+// indexObjectStreams adds the objects stored in the object streams of a
+// table rebuilt from object markers, which can only find the objects
+// outside them. A compressed object replaces an entry of generation 0 for
+// the same number defined earlier in the file than its object stream.
+func (r *Reader) indexObjectStreams(data []byte, table []xref) []xref {
+	// Indirect Lengths of the object streams resolve through the table
+	r.xref = table
+	defer r.ClearCache()
+
+	// The object streams are the entries that contain /ObjStm
+	direct := make([]xref, 0, len(table))
+	for _, x := range table {
+		if x.ptr.id != 0 && !x.inStream {
+			direct = append(direct, x)
+		}
+	}
+	sort.Slice(direct, func(i, j int) bool { return direct[i].offset < direct[j].offset })
+	var streams []xref
+	for search := 0; ; {
+		idx := bytes.Index(data[search:], []byte("/ObjStm"))
+		if idx < 0 {
+			break
+		}
+		search += idx + len("/ObjStm")
+		i := sort.Search(len(direct), func(i int) bool { return direct[i].offset > int64(search) }) - 1
+		if i >= 0 && (len(streams) == 0 || streams[len(streams)-1] != direct[i]) {
+			streams = append(streams, direct[i])
+		}
+	}
+	isStream := make(map[uint32]bool, len(streams))
+	for _, x := range streams {
+		isStream[x.ptr.id] = true
+	}
+
+	// where holds the file offset of each entry, or of its object stream
+	where := make(map[uint32]int64, len(table))
+	for _, x := range direct {
+		where[x.ptr.id] = x.offset
+	}
+	streamIDs := make([][]uint32, len(streams))
+	n := len(direct)
+	for i, strm := range streams {
+		safely(func() {
+			streamIDs[i] = r.objectStreamIDs(strm)
+		})
+		n += len(streamIDs[i])
+	}
+	// Object numbers are dense in practice, so a number far beyond the
+	// objects found is damage that would only bloat the table
+	maxID := max(len(table), 4*n+16)
+	for si, strm := range streams {
+		for i, id := range streamIDs[si] {
+			if id == 0 || isStream[id] {
+				continue
+			}
+			if int(id) >= maxID {
+				r.warn(WarnObjectStream, strm.ptr, strm.offset, "object stream lists object %d, beyond the %d objects found; skipped", id, n)
+				continue
+			}
+			if int(id) < len(table) {
+				if old := table[id]; old.ptr.gen > 0 || old.ptr.id == id && where[id] > strm.offset {
+					continue
+				}
+			} else {
+				table = append(table, make([]xref, int(id)+1-len(table))...)
+			}
+			table[id] = xref{ptr: objptr{id, 0}, inStream: true, stream: strm.ptr, offset: int64(i)}
+			where[id] = strm.offset
+		}
+	}
+	r.xref = table
+	return table
+}
+
+// objectStreamIDs returns the numbers of the objects in the object stream
+// defined at x, in order, or nil if x is not a well-formed object stream.
+func (r *Reader) objectStreamIDs(x xref) []uint32 {
+	b := newBuffer(io.NewSectionReader(r.f, x.offset, r.end-x.offset), x.offset)
+	def, ok := b.readObject().(objdef)
+	PutPDFBuffer(b)
+	if !ok || def.ptr != x.ptr {
+		return nil
+	}
+	strm, ok := def.obj.(stream)
+	if !ok || strm.hdr["Type"] != name("ObjStm") {
+		return nil
+	}
+	v := Value{r, x.ptr, strm}
+	n := v.Key("N").Int64()
+	first := v.Key("First").Int64()
+	if n <= 0 || first <= 0 {
+		return nil
+	}
+	rd := v.Reader()
+	defer rd.Close()
+	b = newBuffer(rd, 0)
+	b.allowEOF = true
+	defer PutPDFBuffer(b)
+	ids := make([]uint32, 0, min(n, 1<<16))
+	prev := int64(-1)
+	for range n {
+		id, ok1 := b.readToken().(int64)
+		off, ok2 := b.readToken().(int64)
+		// Reject the stream, such as one still encrypted, if its header
+		// is not pairs of numbers and increasing offsets
+		if !ok1 || !ok2 || id <= 0 || id != int64(uint32(id)) || off <= prev {
+			return nil
+		}
+		ids = append(ids, uint32(id))
+		prev = off
+	}
+	return ids
+}
+
+// findCatalog returns the object of type Catalog that is defined last in
+// the file, reading every object in the cross-reference table, so that the
+// Root of a document whose trailer is lost is found even in an object
+// stream.
+func (r *Reader) findCatalog() objptr {
+	// Nothing read before decryption is set up may be kept
+	defer func() {
+		r.ClearCache()
+		r.objStreamCacheMu.Lock()
+		r.objStreamCache = make(map[uint32]map[int64]int64)
+		r.objStreamCacheMu.Unlock()
+	}()
+	var root objptr
+	at := int64(-1)
+	for i, x := range r.xref {
+		if i == 0 || x.ptr.id != uint32(i) || !x.inStream && x.offset == 0 {
+			continue
+		}
+		offset := x.offset
+		if x.inStream {
+			if int(x.stream.id) >= len(r.xref) {
+				continue
+			}
+			offset = r.xref[x.stream.id].offset
+		}
+		if offset <= at {
+			continue
+		}
+		safely(func() {
+			if r.resolve(objptr{}, x.ptr).Key("Type").Name() == "Catalog" {
+				root, at = x.ptr, offset
+			}
+		})
+	}
+	return root
+}
+
+// findRoot returns the document catalog of a file whose trailer lacks it,
+// by type if possible and else by searching data for /Type /Catalog.
+func (r *Reader) findRoot(data []byte) objptr {
+	if root := r.findCatalog(); root != (objptr{}) {
+		return root
+	}
+	return findRootObject(data)
+}
+
 // findTrailerDict searches for trailer dictionary in data
 func findTrailerDict(data []byte) (dict, error) {
 	// Look for "trailer" keyword
@@ -567,7 +730,7 @@ func RecoverPDF(f io.ReaderAt, size int64, opts *RecoveryOptions) (*Reader, erro
 		}
 
 		if xr, err := rebuildXrefFromObjects(data); err == nil {
-			xrefTable = xr
+			xrefTable = r.indexObjectStreams(data, xr)
 		}
 	}
 
@@ -602,9 +765,11 @@ func RecoverPDF(f io.ReaderAt, size int64, opts *RecoveryOptions) (*Reader, erro
 
 		trailer = make(dict)
 		trailer["Size"] = int64(len(xrefTable))
+	}
 
-		// Try to find Root object
-		if rootRef := findRootObject(data); rootRef != (objptr{}) {
+	// Find the Root object of a trailer without one
+	if trailer != nil && trailer["Root"] == nil {
+		if rootRef := r.findRoot(data); rootRef != (objptr{}) {
 			trailer["Root"] = rootRef
 		}
 	}
@@ -670,13 +835,13 @@ func recoverPDFInternal(r *Reader, opts *RecoveryOptions) error {
 				fmt.Printf("Found xref table at offset %d\n", xrefOffset)
 			}
 
+			// readXref returns buf to the pool itself
 			buf := newBuffer(bytes.NewReader(data[xrefOffset:]), xrefOffset)
 			buf.allowEOF = true
 			if xr, _, tr, err := readXref(r, buf); err == nil {
 				xrefTable = xr
 				trailer = tr
 			}
-			PutPDFBuffer(buf)
 		}
 	}
 
@@ -685,8 +850,11 @@ func recoverPDFInternal(r *Reader, opts *RecoveryOptions) error {
 		if opts.Verbose {
 			fmt.Println("Rebuilding xref from object markers...")
 		}
-		xrefTable, _ = rebuildXrefFromObjects(data)
+		if xr, err := rebuildXrefFromObjects(data); err == nil {
+			xrefTable = r.indexObjectStreams(data, xr)
+		}
 	}
+	r.xref = xrefTable
 
 	// Strategy 4: Try to recover trailer
 	if trailer == nil && opts.AllowMissingTrailer {
@@ -710,9 +878,11 @@ func recoverPDFInternal(r *Reader, opts *RecoveryOptions) error {
 	if trailer == nil && len(xrefTable) > 0 {
 		trailer = make(dict)
 		trailer["Size"] = int64(len(xrefTable))
+	}
 
-		// Try to find Root object
-		if rootRef := findRootObject(data); rootRef != (objptr{}) {
+	// Find the Root object of a trailer without one
+	if trailer != nil && trailer["Root"] == nil {
+		if rootRef := r.findRoot(data); rootRef != (objptr{}) {
 			trailer["Root"] = rootRef
 		}
 	}
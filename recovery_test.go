@@ -6,6 +6,8 @@ package pdf
 
 import (
 	"bytes"
+	"compress/zlib"
+	"fmt"
 	"strings"
 	"testing"
 )
@@ -356,3 +358,145 @@ func TestIntegrityStatusIssues(t *testing.T) {
 		t.Error("Should report EOF issue")
 	}
 }
+
+// TestRecoverPDFInternalBufferReuse tests that recovery through an xref
+// table returns its buffer to the pool only once.
+func TestRecoverPDFInternalBufferReuse(t *testing.T) {
+	data := []byte(strings.Join([]string{
+		"%PDF-1.4",
+		"1 0 obj",
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"endobj",
+		"2 0 obj",
+		"<< /Type /Pages /Count 0 /Kids [] >>",
+		"endobj",
+		"xref",
+		"0 3",
+		"0000000000 65535 f ",
+		"0000000009 00000 n ",
+		"0000000058 00000 n ",
+		"trailer",
+		"<< /Root 1 0 R /Size 3 >>",
+		"startxref",
+		"113",
+		"%%EOF",
+	}, "\n"))
+	r := &Reader{f: bytes.NewReader(data), end: int64(len(data))}
+	if err := recoverPDFInternal(r, nil); err != nil {
+		t.Fatal(err)
+	}
+	a, b := GetPDFBuffer(), GetPDFBuffer()
+	defer PutPDFBuffer(a)
+	if a == b {
+		t.Fatal("pool returned the same buffer twice")
+	}
+	PutPDFBuffer(b)
+}
+
+// damagedObjStmPDF returns a document whose catalog, page tree and title are
+// compressed in object stream 1, and which has neither a cross-reference
+// table nor a trailer. Object 4 is also defined, stale, before the object
+// stream, and object 6 again, current, after it. The object stream lists
+// the title as object titleID.
+func damagedObjStmPDF(titleID int) []byte {
+	objs := []string{
+		"<< /Type /Catalog /Pages 4 0 R >>",
+		"<< /Type /Pages /Count 1 /Kids [2 0 R] >>",
+		"<< /Title (old) >>",
+	}
+	var header, body bytes.Buffer
+	for i, obj := range objs {
+		fmt.Fprintf(&header, "%d %d ", []int{3, 4, titleID}[i], body.Len())
+		body.WriteString(obj + "\n")
+	}
+	var compressed bytes.Buffer
+	w := zlib.NewWriter(&compressed)
+	w.Write(header.Bytes())
+	w.Write(body.Bytes())
+	w.Close()
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.7\n")
+	buf.WriteString("4 0 obj\n<< /Type /Pages /Count 0 /Kids [] >>\nendobj\n")
+	fmt.Fprintf(&buf, "1 0 obj\n<< /Type /ObjStm /N 3 /First %d /Filter /FlateDecode /Length 5 0 R >>\nstream\n", header.Len())
+	buf.Write(compressed.Bytes())
+	buf.WriteString("\nendstream\nendobj\n")
+	fmt.Fprintf(&buf, "5 0 obj\n%d\nendobj\n", compressed.Len())
+	buf.WriteString("2 0 obj\n<< /Type /Page /Parent 4 0 R /MediaBox [0 0 612 792] >>\nendobj\n")
+	buf.WriteString("6 0 obj\n<< /Title (new) >>\nendobj\n")
+	buf.WriteString("startxref\n999999\n%%EOF\n")
+	return buf.Bytes()
+}
+
+// TestRecoverObjectStreams tests that recovery indexes the objects in
+// object streams and finds the catalog among them.
+func TestRecoverObjectStreams(t *testing.T) {
+	data := damagedObjStmPDF(6)
+	check := func(name string, r *Reader) {
+		t.Helper()
+		if got := r.Trailer().Key("Root"); got.Key("Type").Name() != "Catalog" {
+			t.Errorf("%s: Root = %v, want the catalog", name, got)
+		}
+		if n := r.NumPage(); n != 1 {
+			t.Errorf("%s: NumPage = %d, want 1", name, n)
+		}
+		if got := r.resolve(objptr{}, objptr{6, 0}).Key("Title").Text(); got != "new" {
+			t.Errorf("%s: Title = %q, want new", name, got)
+		}
+		if x := r.xref[3]; !x.inStream || x.stream != (objptr{1, 0}) || x.offset != 0 {
+			t.Errorf("%s: xref of object 3 = %+v", name, x)
+		}
+	}
+
+	r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("NewReader", r)
+
+	r, err = RecoverPDF(bytes.NewReader(data), int64(len(data)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("RecoverPDF", r)
+}
+
+// TestRecoverObjectStreamsHugeID tests that an object number far beyond
+// the objects found is skipped rather than grow the table to it.
+func TestRecoverObjectStreamsHugeID(t *testing.T) {
+	data := damagedObjStmPDF(4000000000)
+	var c WarningCollector
+	r, err := NewReaderWithOptions(bytes.NewReader(data), int64(len(data)), ReaderOptions{Warnings: &c})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(r.xref); n > 100 {
+		t.Errorf("len(xref) = %d", n)
+	}
+	if n := r.NumPage(); n != 1 {
+		t.Errorf("NumPage = %d, want 1", n)
+	}
+	var found bool
+	for _, w := range c.Warnings() {
+		found = found || w.Code == WarnObjectStream && w.Object == 1
+	}
+	if !found {
+		t.Errorf("Warnings = %v, want object-stream in object 1", c.Warnings())
+	}
+}
+
+// TestRebuildXrefFromObjectsGeneration tests that the highest generation,
+// and then the last definition, of an object wins.
+func TestRebuildXrefFromObjectsGeneration(t *testing.T) {
+	data := []byte("%PDF-1.4\n1 1 obj\n(a)\nendobj\n1 0 obj\n(b)\nendobj\n2 0 obj\n(c)\nendobj\n2 0 obj\n(d)\nendobj\n")
+	table, err := rebuildXrefFromObjects(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x := table[1]; x.ptr != (objptr{1, 1}) || x.offset != 9 {
+		t.Errorf("xref of object 1 = %+v, want generation 1 at 9", x)
+	}
+	if x, want := table[2], int64(bytes.LastIndex(data, []byte("2 0 obj"))); x.offset != want {
+		t.Errorf("xref of object 2 = %+v, want offset %d", x, want)
+	}
+}
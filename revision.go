@@ -0,0 +1,336 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// XrefType is how a revision stores its cross-reference section.
+type XrefType int
+
+const (
+	XrefTable  XrefType = iota // A classic xref table and trailer
+	XrefStream                 // An XRef stream (PDF 1.5)
+	XrefHybrid                 // A table with an XRefStm stream for compressed objects
+)
+
+func (t XrefType) String() string {
+	switch t {
+	case XrefTable:
+		return "table"
+	case XrefStream:
+		return "stream"
+	case XrefHybrid:
+		return "hybrid"
+	}
+	return "unknown"
+}
+
+// Revision is a version of a document. The original document is the first
+// revision, and each incremental update appended to it adds one.
+type Revision struct {
+	Number     int      // 1-based, oldest first
+	Start, End int64    // byte range the revision added to the file; the document as of the revision is bytes [0, End)
+	XrefOffset int64    // offset of the revision's cross-reference section
+	XrefType   XrefType // how the section is stored
+	Added      []int    // numbers of the objects the revision created
+	Modified   []int    // numbers of the objects the revision replaced
+	Deleted    []int    // numbers of the objects the revision freed
+}
+
+// revEntry is an entry of one cross-reference section.
+type revEntry struct {
+	free bool
+	x    xref
+}
+
+// xrefSection is a cross-reference section as it is in the file, without
+// the sections before it merged in.
+type xrefSection struct {
+	offset  int64
+	typ     XrefType
+	entries map[int]revEntry
+	prev    int64 // offset of the previous section; -1 if none
+}
+
+// Revisions returns the revisions of the document, oldest first, from the
+// chain of cross-reference sections linked by /Prev. The sections of a
+// linearized file, whose first-page section points forward to the main
+// one, count as a single revision.
+func (r *Reader) Revisions() (revs []Revision, err error) {
+	if r.startxref <= 0 {
+		return nil, ErrNoRevisions
+	}
+	defer func() {
+		if rec := recover(); rec != nil {
+			revs, err = nil, fmt.Errorf("malformed PDF: %v", rec)
+		}
+	}()
+
+	// Read the chain, newest first, grouping each section that points
+	// forward with the one it points to.
+	var groups [][]*xrefSection
+	seen := make(map[int64]bool)
+	forward := false
+	for off := r.startxref; off >= 0; {
+		if seen[off] {
+			return nil, fmt.Errorf("malformed PDF: xref Prev chain contains cycle at offset %d", off)
+		}
+		seen[off] = true
+		sec, err := r.readXrefSection(off, seen)
+		if err != nil {
+			return nil, err
+		}
+		if forward {
+			groups[len(groups)-1] = append(groups[len(groups)-1], sec)
+		} else {
+			groups = append(groups, []*xrefSection{sec})
+		}
+		forward = sec.prev > off
+		off = sec.prev
+	}
+
+	live := make(map[int]xref)
+	var start int64
+	for i := len(groups) - 1; i >= 0; i-- {
+		group := groups[i]
+		rev := Revision{
+			Number:     len(revs) + 1,
+			Start:      start,
+			XrefOffset: group[0].offset,
+			XrefType:   group[0].typ,
+		}
+		// Sections earlier in the group override later ones
+		entries := make(map[int]revEntry)
+		last := group[0].offset
+		for j := len(group) - 1; j >= 0; j-- {
+			for num, e := range group[j].entries {
+				entries[num] = e
+			}
+			last = max(last, group[j].offset)
+		}
+		nums := make([]int, 0, len(entries))
+		for num := range entries {
+			nums = append(nums, num)
+		}
+		sort.Ints(nums)
+		for _, num := range nums {
+			e := entries[num]
+			old, ok := live[num]
+			switch {
+			case e.free && ok:
+				rev.Deleted = append(rev.Deleted, num)
+				delete(live, num)
+			case e.free:
+			case !ok:
+				rev.Added = append(rev.Added, num)
+				live[num] = e.x
+			case old != e.x:
+				rev.Modified = append(rev.Modified, num)
+				live[num] = e.x
+			}
+		}
+		rev.End = r.revisionEnd(last)
+		if i > 0 {
+			// The next revision's sections follow this one
+			for _, sec := range groups[i-1] {
+				rev.End = min(rev.End, sec.offset)
+			}
+		}
+		rev.End = max(rev.End, start)
+		start = rev.End
+		revs = append(revs, rev)
+	}
+	return revs, nil
+}
+
+// readXrefSection reads the cross-reference section at off by itself. The
+// cross-reference stream a hybrid table names is read without following
+// its own links, and its offset is added to seen.
+func (r *Reader) readXrefSection(off int64, seen map[int64]bool) (*xrefSection, error) {
+	if off < 0 || off >= r.end {
+		return nil, fmt.Errorf("malformed PDF: xref offset out of range: %d", off)
+	}
+	sec := &xrefSection{offset: off, entries: make(map[int]revEntry), prev: -1}
+	b := newBuffer(io.NewSectionReader(r.f, off, r.end-off), off)
+	defer PutPDFBuffer(b)
+
+	var trailer dict
+	tok := b.readToken()
+	if tok == keyword("xref") {
+		sec.typ = XrefTable
+		for {
+			tok := b.readToken()
+			if tok == keyword("trailer") {
+				break
+			}
+			start, ok1 := tok.(int64)
+			n, ok2 := b.readToken().(int64)
+			if !ok1 || !ok2 {
+				return nil, fmt.Errorf("malformed PDF: malformed xref table at offset %d", off)
+			}
+			for i := range int(n) {
+				offset, ok1 := b.readToken().(int64)
+				gen, ok2 := b.readToken().(int64)
+				alloc, ok3 := b.readToken().(keyword)
+				if !ok1 || !ok2 || !ok3 || alloc != keyword("f") && alloc != keyword("n") {
+					return nil, fmt.Errorf("malformed PDF: malformed xref table at offset %d", off)
+				}
+				num := int(start) + i
+				if num == 0 {
+					continue
+				}
+				if alloc == keyword("f") {
+					sec.entries[num] = revEntry{free: true}
+				} else {
+					sec.entries[num] = revEntry{x: xref{ptr: objptr{uint32(num), uint16(gen)}, offset: offset}}
+				}
+			}
+		}
+		var ok bool
+		if trailer, ok = b.readObject().(dict); !ok {
+			return nil, fmt.Errorf("malformed PDF: xref table at offset %d not followed by trailer dictionary", off)
+		}
+		if stmOff, ok := trailer["XRefStm"].(int64); ok {
+			sec.typ = XrefHybrid
+			if seen[stmOff] {
+				return nil, fmt.Errorf("malformed PDF: xref XRefStm at offset %d names a section already read", stmOff)
+			}
+			seen[stmOff] = true
+			if stmOff < 0 || stmOff >= r.end {
+				return nil, fmt.Errorf("malformed PDF: xref offset out of range: %d", stmOff)
+			}
+			sb := newBuffer(io.NewSectionReader(r.f, stmOff, r.end-stmOff), stmOff)
+			entries, _, err := readXrefStreamSection(r, sb, stmOff)
+			PutPDFBuffer(sb)
+			if err != nil {
+				return nil, err
+			}
+			// The stream adds the compressed objects the table lists as free
+			for num, e := range entries {
+				if old, ok := sec.entries[num]; !ok || old.free {
+					sec.entries[num] = e
+				}
+			}
+		}
+	} else {
+		b.unreadToken(tok)
+		var err error
+		if sec.entries, trailer, err = readXrefStreamSection(r, b, off); err != nil {
+			return nil, err
+		}
+		sec.typ = XrefStream
+	}
+	if prev, ok := trailer["Prev"].(int64); ok {
+		sec.prev = prev
+	}
+	return sec, nil
+}
+
+// readXrefStreamSection reads the entries and dictionary of the
+// cross-reference stream b starts with, at off.
+func readXrefStreamSection(r *Reader, b *buffer, off int64) (map[int]revEntry, dict, error) {
+	def, ok := b.readObject().(objdef)
+	if !ok {
+		return nil, nil, fmt.Errorf("malformed PDF: no xref section at offset %d", off)
+	}
+	strm, ok := def.obj.(stream)
+	if !ok || strm.hdr["Type"] != name("XRef") {
+		return nil, nil, fmt.Errorf("malformed PDF: no xref section at offset %d", off)
+	}
+	size, _ := strm.hdr["Size"].(int64)
+	table, err := readXrefStreamData(r, strm, nil, size)
+	if err != nil {
+		return nil, nil, fmt.Errorf("malformed PDF: %v", err)
+	}
+	// readXrefStreamData leaves the entries a stream does not list zero
+	// and marks free ones with object 0, generation 65535.
+	entries := make(map[int]revEntry)
+	for num, x := range table {
+		switch {
+		case num == 0 || x.ptr == (objptr{}):
+		case x.ptr == (objptr{0, 65535}):
+			entries[num] = revEntry{free: true}
+		default:
+			entries[num] = revEntry{x: x}
+		}
+	}
+	return entries, strm.hdr, nil
+}
+
+// revisionEnd returns the offset just past the end-of-file marker that
+// follows the cross-reference section at off, and its end-of-line, or the
+// end of the file if there is none.
+func (r *Reader) revisionEnd(off int64) int64 {
+	const chunk = 4096
+	marker := []byte("%%EOF")
+	buf := make([]byte, chunk+len(marker)+2)
+	for pos := off; pos < r.end; pos += chunk {
+		n, _ := r.f.ReadAt(buf[:min(int64(len(buf)), r.end-pos)], pos)
+		i := bytes.Index(buf[:n], marker)
+		if i < 0 || i+len(marker) > n {
+			continue
+		}
+		end := i + len(marker)
+		if end < n && buf[end] == '\r' {
+			end++
+		}
+		if end < n && buf[end] == '\n' {
+			end++
+		}
+		return pos + int64(end)
+	}
+	return r.end
+}
+
+// OpenRevision returns a Reader that sees the document as it was at
+// revision n, numbered as by Revisions: it reads only the bytes up to the
+// end of that revision. An encrypted document is read with the key the
+// receiver was opened with.
+func (r *Reader) OpenRevision(n int) (*Reader, error) {
+	revs, err := r.Revisions()
+	if err != nil {
+		return nil, err
+	}
+	if n < 1 || n > len(revs) {
+		return nil, fmt.Errorf("pdf: no revision %d in document with %d", n, len(revs))
+	}
+	rev := revs[n-1]
+	f := io.NewSectionReader(r.f, 0, rev.End)
+	rr := &Reader{
+		f:              f,
+		end:            rev.End,
+		fontCache:      NewFontCache(),
+		cacheCap:       r.cacheCap,
+		objStreamCache: make(map[uint32]map[int64]int64),
+		compatibility:  r.compatibility,
+		startxref:      rev.XrefOffset,
+	}
+	rr.keepClippedText.Store(r.keepClippedText.Load())
+	rr.enforcePermissions = r.enforcePermissions
+	rr.warnings = r.warnings
+	b := newBuffer(io.NewSectionReader(f, rev.XrefOffset, rev.End-rev.XrefOffset), rev.XrefOffset)
+	rr.xref, rr.trailerptr, rr.trailer, err = readXref(rr, b)
+	if err != nil {
+		return nil, fmt.Errorf("pdf: revision %d: %v", n, err)
+	}
+	if rr.trailer["Encrypt"] != nil {
+		if r.key == nil {
+			if err := rr.initEncrypt(""); err != nil {
+				return nil, fmt.Errorf("pdf: revision %d: %w", n, err)
+			}
+		} else {
+			rr.key = r.key
+			rr.stmF, rr.strF, rr.effF = r.stmF, r.strF, r.effF
+			rr.cryptFilters, rr.clearMetadata = r.cryptFilters, r.clearMetadata
+			rr.auth, rr.perms = r.auth, r.perms
+		}
+	}
+	return rr, nil
+}
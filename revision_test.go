@@ -0,0 +1,265 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var startxrefRE = regexp.MustCompile(`startxref\s+(\d+)\s+%%EOF\s*$`)
+
+// appendUpdate appends an incremental update to data that writes the given
+// objects and frees the objects in free, with an xref stream if stream is
+// true and an xref table otherwise. size is the /Size of the document
+// before the update, and the xref stream, if any, takes object number size.
+func appendUpdate(data []byte, size int, trailer string, objs map[int]string, free []int, stream bool) []byte {
+	m := startxrefRE.FindSubmatch(data)
+	if m == nil {
+		panic("appendUpdate: no startxref")
+	}
+	prev, _ := strconv.Atoi(string(m[1]))
+
+	buf := bytes.NewBuffer(append([]byte(nil), data...))
+	offsets := make(map[int]int)
+	var nums []int
+	for num := range objs {
+		nums = append(nums, num)
+	}
+	sort.Ints(nums)
+	for _, num := range nums {
+		offsets[num] = buf.Len()
+		fmt.Fprintf(buf, "%d 0 obj\n%s\nendobj\n", num, objs[num])
+	}
+	for _, num := range free {
+		offsets[num] = -1
+	}
+	nums = append(nums, free...)
+	sort.Ints(nums)
+	for _, num := range nums {
+		size = max(size, num+1)
+	}
+
+	xref := buf.Len()
+	if !stream {
+		buf.WriteString("xref\n")
+		for _, num := range nums {
+			if offsets[num] < 0 {
+				fmt.Fprintf(buf, "%d 1\n0000000000 00001 f \n", num)
+			} else {
+				fmt.Fprintf(buf, "%d 1\n%010d 00000 n \n", num, offsets[num])
+			}
+		}
+		fmt.Fprintf(buf, "trailer\n<< /Size %d /Prev %d %s >>\nstartxref\n%d\n%%%%EOF\n", size, prev, trailer, xref)
+		return buf.Bytes()
+	}
+
+	self := size
+	offsets[self] = xref
+	nums = append(nums, self)
+	var rows bytes.Buffer
+	var index []string
+	for _, num := range nums {
+		index = append(index, fmt.Sprintf("%d 1", num))
+		row := [7]byte{1}
+		if offsets[num] < 0 {
+			row = [7]byte{0, 0, 0, 0, 0, 0, 1}
+		} else {
+			binary.BigEndian.PutUint32(row[1:5], uint32(offsets[num]))
+		}
+		rows.Write(row[:])
+	}
+	fmt.Fprintf(buf, "%d 0 obj\n<< /Type /XRef /Size %d /W [1 4 2] /Index [%s] /Prev %d %s /Length %d >>\nstream\n",
+		self, self+1, strings.Join(index, " "), prev, trailer, rows.Len())
+	buf.Write(rows.Bytes())
+	fmt.Fprintf(buf, "\nendstream\nendobj\nstartxref\n%d\n%%%%EOF\n", xref)
+	return buf.Bytes()
+}
+
+// textContent returns a content stream object showing s in F1 at 72,700.
+func textContent(s string) string {
+	c := "BT /F1 10 Tf 72 700 Td (" + s + ") Tj ET"
+	return fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(c), c)
+}
+
+// revisedPDF returns a document in three revisions: the original, an
+// update that changes the page text and adds an information dictionary,
+// and an update that changes the text again and deletes the information
+// dictionary. It also returns the lengths of the file at each revision.
+func revisedPDF() ([]byte, []int) {
+	widths := strings.TrimSpace(strings.Repeat("500 ", 95))
+	data := buildPDF("/Root 1 0 R",
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Count 1 /Kids [3 0 R] >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 4 0 R >> >> /Contents 5 0 R >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /FirstChar 32 /LastChar 126 /Widths ["+widths+"] >>",
+		textContent("Version one"))
+	lens := []int{len(data)}
+	data = appendUpdate(data, 6, "/Root 1 0 R /Info 6 0 R", map[int]string{
+		5: textContent("Version two"),
+		6: "<< /Title (Draft) >>",
+	}, nil, false)
+	lens = append(lens, len(data))
+	data = appendUpdate(data, 7, "/Root 1 0 R", map[int]string{
+		5: textContent("Version three"),
+	}, []int{6}, false)
+	return data, append(lens, len(data))
+}
+
+func readerText(t *testing.T, r *Reader) string {
+	t.Helper()
+	content, err := r.Page(1).contentWithFonts(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sb strings.Builder
+	for _, txt := range content.Text {
+		sb.WriteString(txt.S)
+	}
+	return sb.String()
+}
+
+func TestRevisions(t *testing.T) {
+	data, lens := revisedPDF()
+	r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	revs, err := r.Revisions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, rev := range revs {
+		got = append(got, fmt.Sprintf("%d %d-%d %v added %v modified %v deleted %v",
+			rev.Number, rev.Start, rev.End, rev.XrefType, rev.Added, rev.Modified, rev.Deleted))
+	}
+	want := []string{
+		fmt.Sprintf("1 0-%d table added [1 2 3 4 5] modified [] deleted []", lens[0]),
+		fmt.Sprintf("2 %d-%d table added [6] modified [5] deleted []", lens[0], lens[1]),
+		fmt.Sprintf("3 %d-%d table added [] modified [5] deleted [6]", lens[1], lens[2]),
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Revisions:\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	for _, rev := range revs {
+		if !bytes.HasPrefix(data[rev.XrefOffset:], []byte("xref")) {
+			t.Errorf("revision %d xref offset %d points at %q", rev.Number, rev.XrefOffset, data[rev.XrefOffset:rev.XrefOffset+8])
+		}
+	}
+
+	for n, want := range []string{"Version one", "Version two", "Version three"} {
+		rr, err := r.OpenRevision(n + 1)
+		if err != nil {
+			t.Fatalf("OpenRevision(%d): %v", n+1, err)
+		}
+		if got := readerText(t, rr); got != want {
+			t.Errorf("revision %d text = %q, want %q", n+1, got, want)
+		}
+		hasInfo := !rr.Trailer().Key("Info").IsNull()
+		if hasInfo != (n == 1) {
+			t.Errorf("revision %d has Info %v", n+1, hasInfo)
+		}
+		if sub, err := rr.Revisions(); err != nil || len(sub) != n+1 {
+			t.Errorf("revision %d has %d revisions (%v)", n+1, len(sub), err)
+		}
+	}
+	for _, n := range []int{0, 4} {
+		if _, err := r.OpenRevision(n); err == nil {
+			t.Errorf("OpenRevision(%d) succeeded", n)
+		}
+	}
+}
+
+func TestRevisionsXrefStream(t *testing.T) {
+	data := createMinimalXrefStreamPDF()
+	data = appendUpdate(data, 4, "/Root 1 0 R", map[int]string{
+		2: "<< /Type /Pages /Kids [] /Count 0 /Updated true >>",
+	}, nil, true)
+	r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	revs, err := r.Revisions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revs) != 2 {
+		t.Fatalf("%d revisions, want 2", len(revs))
+	}
+	got := fmt.Sprintf("%v %v %v / %v %v %v %v", revs[0].XrefType, revs[0].Added, revs[0].Modified,
+		revs[1].XrefType, revs[1].Added, revs[1].Modified, revs[1].Deleted)
+	if want := "stream [1 2 3] [] / stream [4] [2] []"; got != want {
+		t.Errorf("revisions = %s, want %s", got, want)
+	}
+	if revs[1].End != int64(len(data)) || revs[0].End != revs[1].Start {
+		t.Errorf("byte ranges %d-%d and %d-%d of %d bytes", revs[0].Start, revs[0].End, revs[1].Start, revs[1].End, len(data))
+	}
+	old, err := r.OpenRevision(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if old.Trailer().Key("Root").Key("Pages").Key("Updated").Bool() {
+		t.Error("revision 1 sees the update")
+	}
+	if !r.Trailer().Key("Root").Key("Pages").Key("Updated").Bool() {
+		t.Error("latest revision lacks the update")
+	}
+}
+
+func TestRevisionsUnavailable(t *testing.T) {
+	data, _ := revisedPDF()
+	// Point startxref into the middle of an object so the table is rebuilt
+	m := startxrefRE.FindSubmatchIndex(data)
+	data = append(append(append([]byte(nil), data[:m[2]]...), "20"...), data[m[3]:]...)
+	r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Skipf("damaged file not recovered: %v", err)
+	}
+	if _, err := r.Revisions(); !errors.Is(err, ErrNoRevisions) {
+		t.Errorf("Revisions of a recovered file = %v, want ErrNoRevisions", err)
+	}
+	if _, err := r.OpenRevision(1); !errors.Is(err, ErrNoRevisions) {
+		t.Errorf("OpenRevision of a recovered file = %v, want ErrNoRevisions", err)
+	}
+}
+
+func TestRevisionsXRefStmCycle(t *testing.T) {
+	// The original table names itself as its XRefStm
+	data := buildPDF("/Root 1 0 R",
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Count 0 /Kids [] >>",
+	)
+	m := startxrefRE.FindSubmatch(data)
+	i := bytes.LastIndex(data, []byte("/Root 1 0 R"))
+	data = append(append(append([]byte(nil), data[:i]...), "/XRefStm "+string(m[1])+" "...), data[i:]...)
+	data = appendUpdate(data, 3, "/Root 1 0 R", map[int]string{3: "<< /Title (v2) >>"}, nil, false)
+
+	r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := r.Revisions()
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("Revisions accepted a cyclic XRefStm")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Revisions does not return")
+	}
+}
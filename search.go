@@ -0,0 +1,453 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// defaultSearchContext is the number of characters of surrounding text
+// in each hit unless SearchOptions sets one.
+const defaultSearchContext = 40
+
+// SearchOptions configures Reader.Search.
+type SearchOptions struct {
+	IgnoreCase       bool // Match regardless of case
+	IgnoreDiacritics bool // Match regardless of accents, so "resume" finds "résumé"
+	WholeWord        bool // Match only at word boundaries
+	Regexp           bool // Treat the query as a regular expression (RE2 syntax)
+
+	// Characters of text before and after each hit (0 = default 40,
+	// negative = none)
+	ContextLength int
+
+	// Pages to search (nil means all pages)
+	Pages []int
+
+	// Number of concurrent workers (0 = NumCPU, at most 4)
+	Workers int
+
+	// Context for cancellation
+	Context context.Context
+
+	// Collapse fake-bold and shadow copies of text before searching, if set
+	Dedup *DedupOptions
+}
+
+// A Quad is a quadrilateral covering part of a hit, with its corners in
+// the order of the QuadPoints of PDF markup annotations: upper left,
+// upper right, lower left, lower right.
+type Quad [4]Point
+
+// Bounds returns the bounding box of the quad.
+func (q Quad) Bounds() Rect {
+	r := Rect{q[0], q[0]}
+	for _, p := range q[1:] {
+		r = unionRect(r, Rect{p, p})
+	}
+	return r
+}
+
+// SearchHit is a match found by Reader.Search, or an error searching a
+// page.
+type SearchHit struct {
+	Page   int    // Page number (1-based)
+	Text   string // The matched text, with line breaks as in the page text
+	Quads  []Quad // Areas covering the match, one for each line it spans
+	Before string // Text preceding the match on the page
+	After  string // Text following the match on the page
+	Error  error  // Set instead of a match if the page could not be searched
+}
+
+// searcher finds the matches of a compiled query in pages.
+type searcher struct {
+	re         *regexp.Regexp
+	wholeWord  bool
+	diacritics bool // fold diacritics
+	context    int
+}
+
+func newSearcher(query string, opts SearchOptions) (*searcher, error) {
+	s := &searcher{
+		wholeWord:  opts.WholeWord,
+		diacritics: opts.IgnoreDiacritics,
+		context:    opts.ContextLength,
+	}
+	if s.context == 0 {
+		s.context = defaultSearchContext
+	}
+	if s.diacritics {
+		query = foldDiacritics(query)
+	}
+
+	pattern := query
+	if !opts.Regexp {
+		// Any run of white space in the query matches any other, so
+		// phrases are found across runs and line breaks
+		words := strings.Fields(query)
+		for i, w := range words {
+			words[i] = regexp.QuoteMeta(w)
+		}
+		pattern = strings.Join(words, `\s+`)
+	}
+	if pattern == "" {
+		return nil, errors.New("empty search query")
+	}
+	if opts.IgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	s.re = re
+	return s, nil
+}
+
+// searchRune is a rune of the searched text of a page.
+type searchRune struct {
+	pos      int // byte offset in the searched text
+	src, end int // byte range of the rune in the page text
+	glyph    int // index into searchText.glyphs, or -1 for inserted white space
+}
+
+// searchText is the text of a page in reading order with the glyph each
+// rune came from.
+type searchText struct {
+	text   string // page text: words, lines and blocks joined by white space
+	folded string // the text searched, with diacritics folded if requested
+	runes  []searchRune
+	glyphs []*LayoutNode
+	lines  []int // line number of each glyph
+}
+
+func newSearchText(layout *PageLayout, fold bool) *searchText {
+	st := &searchText{}
+	var text, folded strings.Builder
+	add := func(s string, glyph int) {
+		for _, r := range s {
+			start := text.Len()
+			text.WriteRune(r)
+			f := string(r)
+			if fold {
+				f = foldDiacritics(f)
+			}
+			if f == "" {
+				// A combining mark folded away belongs to the rune before
+				if n := len(st.runes); n > 0 {
+					st.runes[n-1].end = text.Len()
+				}
+				continue
+			}
+			for _, fr := range f {
+				st.runes = append(st.runes, searchRune{pos: folded.Len(), src: start, end: text.Len(), glyph: glyph})
+				folded.WriteRune(fr)
+			}
+		}
+	}
+
+	line := 0
+	for b, block := range layout.Nodes(LayoutBlock) {
+		if b > 0 {
+			add("\n\n", -1)
+		}
+		for l, ln := range block.Children {
+			if l > 0 {
+				add("\n", -1)
+			}
+			for w, word := range ln.Children {
+				if w > 0 {
+					add(" ", -1)
+				}
+				for _, g := range word.Children {
+					st.glyphs = append(st.glyphs, g)
+					st.lines = append(st.lines, line)
+					add(g.Text, len(st.glyphs)-1)
+				}
+			}
+			line++
+		}
+	}
+	st.text = text.String()
+	st.folded = folded.String()
+	return st
+}
+
+// runeAt returns the index of the rune at byte offset pos of the searched
+// text.
+func (st *searchText) runeAt(pos int) int {
+	return sort.Search(len(st.runes), func(i int) bool { return st.runes[i].pos >= pos })
+}
+
+// isWordRune reports whether r is part of a word for whole-word matching.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) || r == '_'
+}
+
+// matches returns the byte ranges of the matches in the searched text.
+func (s *searcher) matches(text string) [][2]int {
+	var out [][2]int
+	for pos := 0; pos <= len(text); {
+		loc := s.re.FindStringIndex(text[pos:])
+		if loc == nil {
+			break
+		}
+		start, end := pos+loc[0], pos+loc[1]
+		if end == start {
+			// Empty matches find nothing
+			_, size := utf8.DecodeRuneInString(text[start:])
+			pos = start + max(size, 1)
+			continue
+		}
+		if s.wholeWord {
+			before, _ := utf8.DecodeLastRuneInString(text[:start])
+			after, _ := utf8.DecodeRuneInString(text[end:])
+			first, _ := utf8.DecodeRuneInString(text[start:])
+			last, _ := utf8.DecodeLastRuneInString(text[:end])
+			if isWordRune(first) && start > 0 && isWordRune(before) ||
+				isWordRune(last) && end < len(text) && isWordRune(after) {
+				// Retry from the next rune, which may start a match of a
+				// whole word overlapping this one
+				_, size := utf8.DecodeRuneInString(text[start:])
+				pos = start + size
+				continue
+			}
+		}
+		out = append(out, [2]int{start, end})
+		pos = end
+	}
+	return out
+}
+
+// page returns the hits in page p, numbered num, in reading order.
+func (s *searcher) page(num int, p Page, dedup *DedupOptions) ([]SearchHit, error) {
+	content, err := p.contentWithFonts(nil)
+	if err != nil {
+		return nil, err
+	}
+	texts := content.Text
+	if dedup != nil {
+		texts = DeduplicateTexts(texts, *dedup)
+	}
+	return s.layoutHits(num, NewPageLayout(texts, p.bounds())), nil
+}
+
+// layoutHits returns the hits in the layout of page num.
+func (s *searcher) layoutHits(num int, layout *PageLayout) []SearchHit {
+	st := newSearchText(layout, s.diacritics)
+	var hits []SearchHit
+	for _, m := range s.matches(st.folded) {
+		first, last := st.runeAt(m[0]), st.runeAt(m[1])-1
+		src, end := st.runes[first].src, st.runes[last].end
+		hit := SearchHit{Page: num, Text: st.text[src:end]}
+		if s.context > 0 {
+			hit.Before = contextBefore(st.text[:src], s.context)
+			hit.After = contextAfter(st.text[end:], s.context)
+		}
+
+		// One quad for each line the match covers
+		var box Rect
+		line := -1
+		for i := first; i <= last; i++ {
+			g := st.runes[i].glyph
+			if g < 0 || i > first && g == st.runes[i-1].glyph {
+				continue
+			}
+			b := st.glyphs[g].Bounds
+			if st.lines[g] != line {
+				if line >= 0 {
+					hit.Quads = append(hit.Quads, quadOf(box))
+				}
+				line, box = st.lines[g], b
+				continue
+			}
+			box = unionRect(box, b)
+		}
+		if line >= 0 {
+			hit.Quads = append(hit.Quads, quadOf(box))
+		}
+		hits = append(hits, hit)
+	}
+	return hits
+}
+
+func quadOf(r Rect) Quad {
+	return Quad{{r.Min.X, r.Max.Y}, {r.Max.X, r.Max.Y}, {r.Min.X, r.Min.Y}, {r.Max.X, r.Min.Y}}
+}
+
+// contextBefore returns the last n runes of s with line breaks as spaces.
+func contextBefore(s string, n int) string {
+	i := len(s)
+	for ; n > 0 && i > 0; n-- {
+		_, size := utf8.DecodeLastRuneInString(s[:i])
+		i -= size
+	}
+	return strings.ReplaceAll(s[i:], "\n", " ")
+}
+
+// contextAfter returns the first n runes of s with line breaks as spaces.
+func contextAfter(s string, n int) string {
+	i := 0
+	for ; n > 0 && i < len(s); n-- {
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+	}
+	return strings.ReplaceAll(s[:i], "\n", " ")
+}
+
+// Search finds query in the text of the document. Phrases match across
+// text runs and line breaks, with any white space in the query matching
+// any other. Pages are searched concurrently, so the hits of different
+// pages arrive in no particular order, while those of a page arrive in
+// reading order. The channel is closed when the search completes or
+// opts.Context is cancelled. An error is returned only for an invalid
+// query; errors searching a page are reported as hits with Error set.
+func (r *Reader) Search(query string, opts SearchOptions) (<-chan SearchHit, error) {
+	s, err := newSearcher(query, opts)
+	if err != nil {
+		return nil, fmt.Errorf("pdf: search: %w", err)
+	}
+
+	// Set defaults
+	if opts.Workers <= 0 {
+		opts.Workers = min(max(runtime.NumCPU(), 1), 4)
+	}
+	if opts.Context == nil {
+		opts.Context = context.Background()
+	}
+	pages := opts.Pages
+	if len(pages) == 0 {
+		pages = make([]int, r.NumPage())
+		for i := range pages {
+			pages[i] = i + 1
+		}
+	}
+
+	results := make(chan SearchHit, min(opts.Workers*2, 64))
+	go func() {
+		defer close(results)
+
+		jobs := make(chan int, min(opts.Workers*2, len(pages)))
+		go func() {
+			defer close(jobs)
+			for _, pageNum := range pages {
+				select {
+				case jobs <- pageNum:
+				case <-opts.Context.Done():
+					return
+				}
+			}
+		}()
+
+		var wg sync.WaitGroup
+		for w := 0; w < opts.Workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				searchWorker(r, s, jobs, results, opts)
+			}()
+		}
+		wg.Wait()
+	}()
+	return results, nil
+}
+
+// searchWorker searches the pages from the job queue
+func searchWorker(r *Reader, s *searcher, jobs <-chan int, results chan<- SearchHit, opts SearchOptions) {
+	for pageNum := range jobs {
+		select {
+		case <-opts.Context.Done():
+			return
+		default:
+		}
+
+		page := r.Page(pageNum)
+		hits, err := s.page(pageNum, page, opts.Dedup)
+		page.Cleanup()
+		if err != nil {
+			hits = []SearchHit{{Page: pageNum, Error: &PDFError{Op: "search", Page: pageNum, Err: err}}}
+		}
+		for _, hit := range hits {
+			select {
+			case results <- hit:
+			case <-opts.Context.Done():
+				return
+			}
+		}
+	}
+}
+
+// accentSuffixes are the accent names that end the glyph names of
+// accented letters, longest first where one ends another.
+var accentSuffixes = []string{
+	"dblgrave", "invertedbreve", "hungarumlaut", "commaaccent", "circumflex",
+	"dotaccent", "hookabove", "dieresis", "dotbelow", "cedilla", "macron",
+	"ogonek", "tonos", "breve", "caron", "acute", "grave", "tilde", "slash", "horn", "ring",
+}
+
+// unaccentedNames are glyph names of letters that only look like they end
+// in an accent name: þ (thorn) is a letter of its own, not a horned t.
+var unaccentedNames = map[string]bool{"thorn": true, "Thorn": true}
+
+var (
+	diacriticFoldOnce sync.Once
+	diacriticFold     map[rune]rune
+)
+
+// foldDiacritics removes the accents from the letters of s, and drops
+// combining marks. The base letters of precomposed letters come from
+// their glyph names, so Ǻ (Aringacute) becomes A and ΐ
+// (iotadieresistonos) becomes ι.
+func foldDiacritics(s string) string {
+	diacriticFoldOnce.Do(func() {
+		diacriticFold = make(map[rune]rune)
+		for name, r := range nameToRune {
+			if unaccentedNames[name] {
+				continue
+			}
+			base := name
+			for stripped := true; stripped && len(base) > 1; {
+				stripped = false
+				for _, suffix := range accentSuffixes {
+					if b, ok := strings.CutSuffix(base, suffix); ok && b != "" {
+						base, stripped = b, true
+						break
+					}
+				}
+			}
+			if base == name || !unicode.IsLetter(r) {
+				continue
+			}
+			if b, ok := nameToRune[base]; ok && unicode.IsLetter(b) {
+				diacriticFold[r] = b
+			} else if len(base) == 1 && unicode.IsLetter(rune(base[0])) {
+				diacriticFold[r] = rune(base[0])
+			}
+		}
+		// Letters whose names do not spell out their accents
+		for r, base := range map[rune]rune{'ı': 'i', 'ł': 'l', 'Ł': 'L', 'đ': 'd', 'Đ': 'D', 'ħ': 'h', 'Ħ': 'H'} {
+			diacriticFold[r] = base
+		}
+	})
+
+	return strings.Map(func(r rune) rune {
+		if unicode.Is(unicode.Mn, r) {
+			return -1
+		}
+		if base, ok := diacriticFold[r]; ok {
+			return base
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,171 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// searchLine searches the text of glyph runs, one per line 20 points
+// apart.
+func searchLines(t *testing.T, query string, opts SearchOptions, lines ...string) []SearchHit {
+	t.Helper()
+	s, err := newSearcher(query, opts)
+	if err != nil {
+		t.Fatalf("newSearcher(%q): %v", query, err)
+	}
+	var texts []Text
+	for i, line := range lines {
+		texts = append(texts, glyphRun(line, 72, 700-20*float64(i))...)
+	}
+	return s.layoutHits(1, NewPageLayout(texts, Rect{}))
+}
+
+func hitTexts(hits []SearchHit) string {
+	s := make([]string, len(hits))
+	for i, h := range hits {
+		s[i] = h.Text
+	}
+	return strings.Join(s, "|")
+}
+
+func TestSearchPhraseAcrossLines(t *testing.T) {
+	r := openTextPDF(t, "BT /F1 10 Tf 72 700 Td (The quick brown) Tj 0 -14 Td (fox jumps over) Tj ET")
+	hits, err := r.Search("brown  fox", SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []SearchHit
+	for h := range hits {
+		got = append(got, h)
+	}
+	if len(got) != 1 {
+		t.Fatalf("got %d hits, want 1", len(got))
+	}
+	h := got[0]
+	want := SearchHit{
+		Page: 1,
+		Text: "brown\nfox",
+		Quads: []Quad{
+			{{122, 708}, {147, 708}, {122, 698}, {147, 698}},
+			{{72, 694}, {87, 694}, {72, 684}, {87, 684}},
+		},
+		Before: "The quick ",
+		After:  " jumps over",
+	}
+	if fmt.Sprint(h) != fmt.Sprint(want) {
+		t.Errorf("hit = %+v\nwant %+v", h, want)
+	}
+	if b := h.Quads[0].Bounds(); b != (Rect{Point{122, 698}, Point{147, 708}}) {
+		t.Errorf("Quad.Bounds = %v", b)
+	}
+}
+
+func TestSearchModes(t *testing.T) {
+	tests := []struct {
+		query string
+		opts  SearchOptions
+		lines []string
+		want  string
+	}{
+		{"QUICK", SearchOptions{}, []string{"the quick fox"}, ""},
+		{"QUICK", SearchOptions{IgnoreCase: true}, []string{"the Quick fox"}, "Quick"},
+		{"ΣΟΦΙΑ", SearchOptions{IgnoreCase: true}, []string{"η σοφία"}, ""},
+		{"σοφια", SearchOptions{IgnoreDiacritics: true}, []string{"η σοφία"}, "σοφία"},
+		{"resume", SearchOptions{}, []string{"my résumé"}, ""},
+		{"resume", SearchOptions{IgnoreDiacritics: true}, []string{"my résumé"}, "résumé"},
+		{"RESUME", SearchOptions{IgnoreDiacritics: true, IgnoreCase: true}, []string{"my Résumé"}, "Résumé"},
+		// Decomposed accents are dropped, and belong to the letter before
+		{"cafe", SearchOptions{IgnoreDiacritics: true}, []string{"the café is"}, "café"},
+		{"Angstrom", SearchOptions{IgnoreDiacritics: true}, []string{"Ǻngström"}, "Ǻngström"},
+		{"Lodz", SearchOptions{IgnoreDiacritics: true}, []string{"Łódź"}, "Łódź"},
+		// Thorn is a letter of its own, not an accented t
+		{"torn", SearchOptions{IgnoreDiacritics: true}, []string{"þorn"}, ""},
+		{"þorn", SearchOptions{IgnoreDiacritics: true, IgnoreCase: true}, []string{"Þorn"}, "Þorn"},
+		{"cat", SearchOptions{}, []string{"cat concatenate cat."}, "cat|cat|cat"},
+		{"cat", SearchOptions{WholeWord: true}, []string{"cat concatenate cat."}, "cat|cat"},
+		{"cat", SearchOptions{WholeWord: true}, []string{"catcat cat"}, "cat"},
+		{"ab", SearchOptions{WholeWord: true}, []string{"aab ab"}, "ab"},
+		{`\d{3}-\d{4}`, SearchOptions{Regexp: true}, []string{"call 555-1234 or 555-9876"}, "555-1234|555-9876"},
+		{`x*`, SearchOptions{Regexp: true}, []string{"abc"}, ""},
+		{`end\s+start`, SearchOptions{Regexp: true}, []string{"the end", "start again"}, "end\nstart"},
+		{"a.b", SearchOptions{}, []string{"axb a.b"}, "a.b"},
+	}
+	for _, tt := range tests {
+		got := hitTexts(searchLines(t, tt.query, tt.opts, tt.lines...))
+		if got != tt.want {
+			t.Errorf("search %q %+v in %q = %q, want %q", tt.query, tt.opts, tt.lines, got, tt.want)
+		}
+	}
+}
+
+func TestSearchContext(t *testing.T) {
+	hits := searchLines(t, "needle", SearchOptions{ContextLength: 5}, "a haystack with a needle in it")
+	if len(hits) != 1 || hits[0].Before != "th a " || hits[0].After != " in i" {
+		t.Errorf("hits = %+v", hits)
+	}
+	hits = searchLines(t, "needle", SearchOptions{ContextLength: -1}, "a needle")
+	if len(hits) != 1 || hits[0].Before != "" || hits[0].After != "" {
+		t.Errorf("hits without context = %+v", hits)
+	}
+}
+
+func TestReaderSearch(t *testing.T) {
+	r := openTextPDF(t,
+		"BT /F1 10 Tf 72 700 Td (a needle here) Tj ET",
+		"BT /F1 10 Tf 72 700 Td (only hay) Tj ET",
+		"BT /F1 10 Tf 72 700 Td (needle and needle) Tj ET")
+
+	hits, err := r.Search("needle", SearchOptions{Workers: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pages []int
+	for h := range hits {
+		if h.Error != nil {
+			t.Fatal(h.Error)
+		}
+		pages = append(pages, h.Page)
+	}
+	sort.Ints(pages)
+	if fmt.Sprint(pages) != "[1 3 3]" {
+		t.Errorf("hits on pages %v, want [1 3 3]", pages)
+	}
+
+	hits, err = r.Search("needle", SearchOptions{Pages: []int{2, 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for range hits {
+		n++
+	}
+	if n != 2 {
+		t.Errorf("%d hits in pages 2 and 3, want 2", n)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	hits, err = r.Search("needle", SearchOptions{Context: ctx})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for h := range hits {
+		t.Errorf("hit after cancellation: %+v", h)
+	}
+
+	for _, bad := range []struct {
+		query string
+		opts  SearchOptions
+	}{{"  ", SearchOptions{}}, {"(", SearchOptions{Regexp: true}}} {
+		if _, err := r.Search(bad.query, bad.opts); err == nil {
+			t.Errorf("Search(%q) succeeded", bad.query)
+		}
+	}
+}
@@ -0,0 +1,339 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Signature is a digital signature in a signature field of the document.
+type Signature struct {
+	Field       string    // fully qualified name of the signature field
+	Filter      string    // preferred signature handler, such as Adobe.PPKLite
+	SubFilter   string    // adbe.pkcs7.detached, ETSI.CAdES.detached, adbe.x509.rsa_sha1 or another encoding
+	Name        string    // name of the signer
+	Reason      string    // reason for signing
+	Location    string    // where the document was signed
+	ContactInfo string    // how to reach the signer
+	SigningTime time.Time // from the signed attributes, or else /M; zero if neither is given
+
+	// ByteRange is the offset and length pairs of the signed bytes of the
+	// file. Normally it is two ranges, before and after Contents.
+	ByteRange []int64
+
+	// Contents is the signature: DER CMS SignedData, or for
+	// adbe.x509.rsa_sha1 a DER OCTET STRING holding a PKCS#1 signature.
+	Contents []byte
+
+	// Certificates are the embedded certificates, the signer's first.
+	Certificates []*x509.Certificate
+
+	// Revision is the revision of the document whose bytes the signature
+	// covers, as numbered by Reader.Revisions; 0 if no revision ends where
+	// the ByteRange does.
+	Revision int
+
+	// LaterRevisions are the revisions appended after the signed one.
+	// Their changes are not covered by the signature.
+	LaterRevisions []Revision
+
+	r   *Reader
+	cms *cmsSignature
+}
+
+// CoversWholeFile reports whether the signed bytes reach the end of the
+// file, so that nothing was appended after signing.
+func (s Signature) CoversWholeFile() bool {
+	n := len(s.ByteRange)
+	return n >= 2 && n%2 == 0 && s.ByteRange[n-2]+s.ByteRange[n-1] == s.r.end
+}
+
+// Signatures returns the signatures in the signature fields of the
+// document's interactive form. A signature whose Contents cannot be
+// parsed is still returned, with no Certificates; Verify reports why.
+func (r *Reader) Signatures() ([]Signature, error) {
+	revs, err := r.Revisions()
+	if err != nil && !errors.Is(err, ErrNoRevisions) {
+		return nil, err
+	}
+	var sigs []Signature
+	fields := r.Trailer().Key("Root").Key("AcroForm").Key("Fields")
+	for i := 0; i < fields.Len(); i++ {
+		r.findSignatures(fields.Index(i), "", "", revs, &sigs, 0)
+	}
+	return sigs, nil
+}
+
+// maxFieldDepth bounds the recursion into the field tree, which a damaged
+// file can make cyclic.
+const maxFieldDepth = 32
+
+func (r *Reader) findSignatures(field Value, parent, ft string, revs []Revision, sigs *[]Signature, depth int) {
+	if field.Kind() != Dict || depth > maxFieldDepth {
+		return
+	}
+	name := parent
+	if t := field.Key("T").Text(); t != "" {
+		if name != "" {
+			name += "."
+		}
+		name += t
+	}
+	if v := field.Key("FT"); v.Kind() == Name {
+		ft = v.Name()
+	}
+	if v := field.Key("V"); ft == "Sig" && v.Kind() == Dict {
+		*sigs = append(*sigs, r.parseSignature(name, v, revs))
+	}
+	kids := field.Key("Kids")
+	for i := 0; i < kids.Len(); i++ {
+		r.findSignatures(kids.Index(i), name, ft, revs, sigs, depth+1)
+	}
+}
+
+func (r *Reader) parseSignature(field string, v Value, revs []Revision) Signature {
+	s := Signature{
+		Field:       field,
+		Filter:      v.Key("Filter").Name(),
+		SubFilter:   v.Key("SubFilter").Name(),
+		Name:        v.Key("Name").Text(),
+		Reason:      v.Key("Reason").Text(),
+		Location:    v.Key("Location").Text(),
+		ContactInfo: v.Key("ContactInfo").Text(),
+		r:           r,
+	}
+	s.SigningTime = parsePDFDate(v.Key("M"))
+	br := v.Key("ByteRange")
+	for i := 0; i < br.Len(); i++ {
+		s.ByteRange = append(s.ByteRange, br.Index(i).Int64())
+	}
+
+	// Read Contents from the hole in the signed bytes, as it is in the
+	// file; the hole is not encrypted, even in an encrypted document.
+	if hole, err := s.hole(); err == nil {
+		s.Contents = hole
+	} else {
+		s.Contents = []byte(v.Key("Contents").RawString())
+	}
+
+	if s.SubFilter == "adbe.x509.rsa_sha1" {
+		var ders []string
+		if cert := v.Key("Cert"); cert.Kind() == String {
+			ders = append(ders, cert.RawString())
+		} else {
+			for i := 0; i < cert.Len(); i++ {
+				ders = append(ders, cert.Index(i).RawString())
+			}
+		}
+		for _, der := range ders {
+			if c, err := x509.ParseCertificate([]byte(der)); err == nil {
+				s.Certificates = append(s.Certificates, c)
+			}
+		}
+	} else if cms, err := parseCMSSignature(s.Contents); err == nil {
+		s.cms = cms
+		s.Certificates = cms.certs
+		if !cms.signingTime.IsZero() {
+			s.SigningTime = cms.signingTime
+		}
+	}
+
+	if n := len(s.ByteRange); n >= 2 && n%2 == 0 {
+		end := s.ByteRange[n-2] + s.ByteRange[n-1]
+		for i, rev := range revs {
+			if rev.Start < end && end <= rev.End {
+				s.Revision = rev.Number
+				s.LaterRevisions = revs[i+1:]
+				break
+			}
+		}
+	}
+	return s
+}
+
+// hole returns the bytes of the hex string that fills the gap between the
+// two signed ranges of the file.
+func (s Signature) hole() ([]byte, error) {
+	br := s.ByteRange
+	if len(br) != 4 {
+		return nil, fmt.Errorf("ByteRange has %d entries, want 4", len(br))
+	}
+	start, end := br[0]+br[1], br[2]
+	if br[0] != 0 || br[1] <= 0 || br[3] < 0 || end <= start+1 || br[2]+br[3] > s.r.end {
+		return nil, fmt.Errorf("ByteRange %v does not split the file around one gap", br)
+	}
+	buf := make([]byte, end-start)
+	if _, err := s.r.f.ReadAt(buf, start); err != nil {
+		return nil, err
+	}
+	if buf[0] != '<' || buf[len(buf)-1] != '>' {
+		return nil, errors.New("gap in ByteRange is not exactly the Contents hex string")
+	}
+	digits := strings.Map(func(c rune) rune {
+		if isSpace(byte(c)) {
+			return -1
+		}
+		return c
+	}, string(buf[1:len(buf)-1]))
+	if len(digits)%2 == 1 {
+		digits += "0"
+	}
+	b, err := hex.DecodeString(digits)
+	if err != nil {
+		return nil, errors.New("gap in ByteRange is not exactly the Contents hex string")
+	}
+	return b, nil
+}
+
+// SignatureVerifyOptions configures Signature.Verify.
+type SignatureVerifyOptions struct {
+	// Roots are the trusted root certificates. If nil, the certificate
+	// chain is not checked and SignatureCheck.Chain reports so.
+	Roots *x509.CertPool
+
+	// CurrentTime is when the certificates must be valid. Zero means the
+	// signing time, or now if the signature has none.
+	CurrentTime time.Time
+}
+
+// SignatureCheck is the outcome of verifying a signature. Each field is nil
+// if its check passed.
+type SignatureCheck struct {
+	ByteRange error // the ByteRange starts the file and leaves out only the Contents hex string
+	Digest    error // the digest of the signed bytes matches the one the signer signed
+	Signature error // the signature verifies with the signer's certificate, which any signing-certificate attribute names
+	Chain     error // the signer's certificate chains to one of the roots
+
+	// Chains are the verified certificate chains, each from the signer's
+	// certificate to a root.
+	Chains [][]*x509.Certificate
+}
+
+// Valid reports whether every check passed.
+func (c *SignatureCheck) Valid() bool {
+	return c.ByteRange == nil && c.Digest == nil && c.Signature == nil && c.Chain == nil
+}
+
+// Verify checks the signature offline: that its ByteRange covers the file
+// but for the Contents hole, that the digest of those bytes is the signed
+// one, that the signature verifies against the embedded signer
+// certificate, and that the certificate chains to opts.Roots. CAdES
+// signatures must also name the signer certificate in a signed
+// signing-certificate attribute. It does not check revocation. Whether the signature covers the whole file is
+// reported by CoversWholeFile and LaterRevisions, not by Verify.
+func (s Signature) Verify(opts SignatureVerifyOptions) *SignatureCheck {
+	c := new(SignatureCheck)
+	if _, err := s.hole(); err != nil {
+		c.ByteRange = err
+	}
+
+	switch {
+	case s.SubFilter == "adbe.x509.rsa_sha1":
+		c.Digest, c.Signature = s.verifyRSASHA1()
+	case s.cms == nil:
+		_, err := parseCMSSignature(s.Contents)
+		if err == nil {
+			err = errors.New("signature not parsed")
+		}
+		c.Digest, c.Signature = err, err
+	default:
+		h, err := s.cms.hash()
+		if err != nil {
+			c.Digest, c.Signature = err, err
+			break
+		}
+		if s.SubFilter == "adbe.pkcs7.sha1" {
+			// The encapsulated content is the SHA-1 digest of the bytes
+			h = crypto.SHA1
+		}
+		digest, err := s.digest(h)
+		if err != nil {
+			c.Digest, c.Signature = err, err
+			break
+		}
+		c.Digest, c.Signature = s.cms.verify(digest)
+		if c.Signature == nil && s.SubFilter == "ETSI.CAdES.detached" {
+			// CAdES requires the attribute binding the signer certificate
+			c.Signature = s.cms.checkSigningCert()
+		}
+	}
+
+	if len(s.Certificates) == 0 {
+		c.Chain = errors.New("no signer certificate")
+	} else if opts.Roots == nil {
+		c.Chain = errors.New("no trusted roots given")
+	} else {
+		inter := x509.NewCertPool()
+		for _, cert := range s.Certificates[1:] {
+			inter.AddCert(cert)
+		}
+		now := opts.CurrentTime
+		if now.IsZero() {
+			now = s.SigningTime
+		}
+		if now.IsZero() {
+			now = time.Now()
+		}
+		c.Chains, c.Chain = s.Certificates[0].Verify(x509.VerifyOptions{
+			Roots:         opts.Roots,
+			Intermediates: inter,
+			CurrentTime:   now,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		})
+	}
+	return c
+}
+
+// digest hashes the signed bytes of the file.
+func (s Signature) digest(h crypto.Hash) ([]byte, error) {
+	br := s.ByteRange
+	if len(br) == 0 || len(br)%2 != 0 {
+		return nil, fmt.Errorf("ByteRange %v is not offset and length pairs", br)
+	}
+	hh := h.New()
+	for i := 0; i < len(br); i += 2 {
+		if br[i] < 0 || br[i+1] < 0 || br[i]+br[i+1] > s.r.end {
+			return nil, fmt.Errorf("ByteRange %v is outside the file", br)
+		}
+		if _, err := io.Copy(hh, io.NewSectionReader(s.r.f, br[i], br[i+1])); err != nil {
+			return nil, err
+		}
+	}
+	return hh.Sum(nil), nil
+}
+
+// verifyRSASHA1 verifies an adbe.x509.rsa_sha1 signature: a PKCS#1
+// signature of the digest of the signed bytes. Despite the name, the
+// digest may be SHA-1 or a SHA-2 hash.
+func (s Signature) verifyRSASHA1() (digestErr, sigErr error) {
+	var sig []byte
+	if _, err := asn1.Unmarshal(s.Contents, &sig); err != nil {
+		err = fmt.Errorf("malformed PKCS#1 signature: %v", err)
+		return err, err
+	}
+	if len(s.Certificates) == 0 {
+		err := errors.New("signer certificate not embedded")
+		return nil, err
+	}
+	var err error
+	for _, h := range []crypto.Hash{crypto.SHA1, crypto.SHA256, crypto.SHA384, crypto.SHA512} {
+		var digest []byte
+		if digest, err = s.digest(h); err != nil {
+			return err, err
+		}
+		if err = verifySignature(s.Certificates[0].PublicKey, nil, h, digest, nil, sig); err == nil {
+			return nil, nil
+		}
+	}
+	return err, err
+}
@@ -0,0 +1,198 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const byteRangePlaceholder = "[0 0000000000 0000000000 0000000000]"
+
+// signedPDF returns a one-page document whose field Approvals.Manager holds
+// a signature with the given SubFilter. sign returns the signature
+// Contents for the bytes the ByteRange covers.
+func signedPDF(t *testing.T, subFilter, cert string, sign func(signed []byte) []byte) []byte {
+	t.Helper()
+	data := buildPDF("/Root 1 0 R",
+		"<< /Type /Catalog /Pages 2 0 R /AcroForm << /Fields [4 0 R] /SigFlags 3 >> >>",
+		"<< /Type /Pages /Count 1 /Kids [3 0 R] >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Annots [5 0 R] >>",
+		"<< /FT /Sig /T (Approvals) /Kids [5 0 R] >>",
+		"<< /Type /Annot /Subtype /Widget /Rect [0 0 0 0] /P 3 0 R /Parent 4 0 R /T (Manager) /V 6 0 R >>",
+		"<< /Type /Sig /Filter /Adobe.PPKLite /SubFilter /"+subFilter+" /Name (Alice) /Reason (Approval) "+
+			"/Location (Berlin) /M (D:20240102030405Z) "+cert+"/ByteRange "+byteRangePlaceholder+
+			" /Contents <"+strings.Repeat("0", 8192)+"> >>",
+	)
+	i := bytes.Index(data, []byte("/Contents <")) + len("/Contents ")
+	j := i + bytes.IndexByte(data[i:], '>') + 1
+	br := fmt.Sprintf("[0 %010d %010d %010d]", i, j, len(data)-j)
+	k := bytes.Index(data, []byte(byteRangePlaceholder))
+	copy(data[k:], br)
+
+	sig := hex.EncodeToString(sign(append(append([]byte(nil), data[:i]...), data[j:]...)))
+	if len(sig) > j-i-2 {
+		t.Fatalf("signature of %d hex digits does not fit", len(sig))
+	}
+	copy(data[i+1:], sig)
+	return data
+}
+
+func sha256Of(b []byte) []byte {
+	h := crypto.SHA256.New()
+	h.Write(b)
+	return h.Sum(nil)
+}
+
+func openSignature(t *testing.T, data []byte) Signature {
+	t.Helper()
+	r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sigs, err := r.Signatures()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sigs) != 1 {
+		t.Fatalf("%d signatures, want 1", len(sigs))
+	}
+	return sigs[0]
+}
+
+func TestSignatures(t *testing.T) {
+	pki := newTestPKI(t)
+	roots := x509.NewCertPool()
+	roots.AddCert(pki.ca)
+
+	tests := []struct {
+		name      string
+		subFilter string
+		cert      string
+		leaf      *x509.Certificate
+		sign      func(signed []byte) []byte
+	}{
+		{"pkcs7 RSA", "adbe.pkcs7.detached", "", pki.rsaLeaf, func(b []byte) []byte {
+			return signCMS(t, pki.rsaKey, pki.rsaLeaf, []*x509.Certificate{pki.rsaLeaf, pki.ca}, sha256Of(b), true, false)
+		}},
+		{"pkcs7 sha1", "adbe.pkcs7.sha1", "", pki.rsaLeaf, func(b []byte) []byte {
+			h := crypto.SHA1.New()
+			h.Write(b)
+			digest := h.Sum(nil)
+			return encapsulate(t, signCMS(t, pki.rsaKey, pki.rsaLeaf, []*x509.Certificate{pki.rsaLeaf}, sha256Of(digest), true, false), digest)
+		}},
+		{"CAdES ECDSA", "ETSI.CAdES.detached", "", pki.ecLeaf, func(b []byte) []byte {
+			return signCMS(t, pki.ecKey, pki.ecLeaf, []*x509.Certificate{pki.ecLeaf}, sha256Of(b), true, true)
+		}},
+		{"x509 RSA", "adbe.x509.rsa_sha1", "/Cert [<" + hex.EncodeToString(pki.rsaLeaf.Raw) + ">] ", pki.rsaLeaf, func(b []byte) []byte {
+			sig, err := pki.rsaKey.Sign(rand.Reader, sha256Of(b), crypto.SHA256)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return mustMarshal(t, sig)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := signedPDF(t, tt.subFilter, tt.cert, tt.sign)
+			s := openSignature(t, data)
+			got := fmt.Sprintf("%s %s %s %s %s %s", s.Field, s.Filter, s.SubFilter, s.Name, s.Reason, s.Location)
+			if want := "Approvals.Manager Adobe.PPKLite " + tt.subFilter + " Alice Approval Berlin"; got != want {
+				t.Errorf("signature = %s, want %s", got, want)
+			}
+			if !s.SigningTime.Equal(testCertTime) {
+				t.Errorf("signing time %v, want %v", s.SigningTime, testCertTime)
+			}
+			if len(s.Certificates) == 0 || !s.Certificates[0].Equal(tt.leaf) {
+				t.Error("signer certificate not first")
+			}
+			if s.Revision != 1 || len(s.LaterRevisions) != 0 || !s.CoversWholeFile() {
+				t.Errorf("revision %d, %d later, whole file %v", s.Revision, len(s.LaterRevisions), s.CoversWholeFile())
+			}
+			c := s.Verify(SignatureVerifyOptions{Roots: roots})
+			if !c.Valid() {
+				t.Fatalf("Verify = %+v", c)
+			}
+			if len(c.Chains) == 0 || !c.Chains[0][len(c.Chains[0])-1].Equal(pki.ca) {
+				t.Error("chain does not end at the root")
+			}
+			if c := s.Verify(SignatureVerifyOptions{}); c.Chain == nil || c.Digest != nil || c.Signature != nil {
+				t.Errorf("Verify without roots = %+v", c)
+			}
+			if c := s.Verify(SignatureVerifyOptions{Roots: x509.NewCertPool()}); c.Chain == nil {
+				t.Error("Verify with an empty pool found a chain")
+			}
+
+			// Change a signed byte
+			tampered := bytes.Replace(data, []byte("(Berlin)"), []byte("(Munich)"), 1)
+			if c := openSignature(t, tampered).Verify(SignatureVerifyOptions{Roots: roots}); c.ByteRange != nil || c.Digest == nil {
+				t.Errorf("Verify of a tampered file = %+v", c)
+			}
+
+			// Append an update the signature does not cover
+			updated := appendUpdate(data, 7, "/Root 1 0 R /Info 7 0 R", map[int]string{7: "<< /Title (Later) >>"}, nil, false)
+			s = openSignature(t, updated)
+			if s.Revision != 1 || len(s.LaterRevisions) != 1 || s.LaterRevisions[0].Number != 2 || s.CoversWholeFile() {
+				t.Errorf("after update: revision %d, later %v, whole file %v", s.Revision, s.LaterRevisions, s.CoversWholeFile())
+			}
+			if c := s.Verify(SignatureVerifyOptions{Roots: roots}); !c.Valid() {
+				t.Errorf("Verify after update = %+v", c)
+			}
+		})
+	}
+}
+
+func TestSignatureCAdESSigningCert(t *testing.T) {
+	pki := newTestPKI(t)
+	data := signedPDF(t, "ETSI.CAdES.detached", "", func(b []byte) []byte {
+		return signCMS(t, pki.ecKey, pki.ecLeaf, []*x509.Certificate{pki.ecLeaf}, sha256Of(b), true, false)
+	})
+	s := openSignature(t, data)
+	s.cms.signingCert = nil
+	if c := s.Verify(SignatureVerifyOptions{}); c.Signature == nil {
+		t.Error("CAdES signature without a signing certificate attribute verified")
+	}
+}
+
+func TestSignatureByteRange(t *testing.T) {
+	pki := newTestPKI(t)
+	data := signedPDF(t, "adbe.pkcs7.detached", "", func(b []byte) []byte {
+		return signCMS(t, pki.ecKey, pki.ecLeaf, []*x509.Certificate{pki.ecLeaf}, sha256Of(b), true, false)
+	})
+	m := bytes.Index(data, []byte("/ByteRange ["))
+	fields := strings.Fields(string(data[m+len("/ByteRange [") : m+len("/ByteRange ")+len(byteRangePlaceholder)-1]))
+
+	// Widen the hole to take in bytes that are outside the hex string
+	hole, _ := strconv.ParseInt(fields[1], 10, 64)
+	bad := append([]byte(nil), data...)
+	copy(bad[m+len("/ByteRange [0 "):], fmt.Sprintf("%010d", hole-2))
+	c := openSignature(t, bad).Verify(SignatureVerifyOptions{})
+	if c.ByteRange == nil {
+		t.Error("ByteRange leaving out more than Contents accepted")
+	}
+
+	s := openSignature(t, data)
+	s.Contents = []byte("garbage")
+	s.cms = nil
+	if c := s.Verify(SignatureVerifyOptions{}); c.Signature == nil || c.Digest == nil {
+		t.Errorf("Verify of malformed Contents = %+v", c)
+	}
+
+	unsigned, _ := revisedPDF()
+	r, err := NewReader(bytes.NewReader(unsigned), int64(len(unsigned)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sigs, err := r.Signatures(); err != nil || len(sigs) != 0 {
+		t.Errorf("Signatures of an unsigned document = %v, %v", sigs, err)
+	}
+}
@@ -86,6 +86,9 @@ type TextStream struct {
 
 // ProcessTextStream processes text in a streaming fashion
 func (sp *StreamProcessor) ProcessTextStream(reader *Reader, handler func(TextStream) error) error {
+	if err := reader.checkExtract(); err != nil {
+		return err
+	}
 	totalPages := reader.NumPage()
 
 	for pageNum := 1; pageNum <= totalPages; pageNum++ {
@@ -221,6 +224,9 @@ type PageStream struct {
 
 // ProcessPageStream processes pages in a streaming fashion
 func (sp *StreamProcessor) ProcessPageStream(reader *Reader, handler func(PageStream) error) error {
+	if err := reader.checkExtract(); err != nil {
+		return err
+	}
 	totalPages := reader.NumPage()
 
 	for pageNum := 1; pageNum <= totalPages; pageNum++ {
@@ -297,6 +303,10 @@ func (mee *MemoryEfficientExtractor) ExtractTextStream(reader *Reader) (<-chan T
 
 // ExtractTextToWriter extracts text directly to an io.Writer to minimize memory usage
 func (mee *MemoryEfficientExtractor) ExtractTextToWriter(reader *Reader, writer io.Writer) (err error) {
+	if err := reader.checkExtract(); err != nil {
+		return err
+	}
+
 	bufWriter := bufio.NewWriterSize(writer, mee.processor.bufferSize)
 
 	chunkThreshold := mee.processor.chunkSize
@@ -0,0 +1,253 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// SVGLayer selects a layer of the SVG debug rendering.
+type SVGLayer int
+
+const (
+	SVGRuns    SVGLayer = 1 << iota // Text runs as labelled rectangles
+	SVGBlocks                       // TextBlocks found by clustering
+	SVGBands                        // YBands the blocks are grouped into
+	SVGColumns                      // Columns detected within each band
+	SVGClasses                      // Classified blocks, coloured by type
+	SVGOrder                        // Reading order of the blocks as numbered arrows
+
+	SVGAll = SVGRuns | SVGBlocks | SVGBands | SVGColumns | SVGClasses | SVGOrder
+)
+
+// SVGOptions configures RenderSVG.
+type SVGOptions struct {
+	Layers  SVGLayer                  // Layers to draw; zero means SVGAll
+	Cluster func([]Text) []*TextBlock // Clustering to draw, such as ClusterTextBlocksV3; nil means the one smart ordering uses
+	Scale   float64                   // Pixels per point; zero means 1
+}
+
+// svgClassColors are the fills of the classified block types.
+var svgClassColors = map[BlockType]string{
+	BlockUnknown:   "#9e9e9e",
+	BlockTitle:     "#e53935",
+	BlockParagraph: "#1e88e5",
+	BlockList:      "#43a047",
+	BlockCaption:   "#8e24aa",
+	BlockFootnote:  "#6d4c41",
+	BlockHeader:    "#fb8c00",
+	BlockFooter:    "#00897b",
+}
+
+const svgStyle = `text{font-family:sans-serif}
+.page{fill:#fff;stroke:#000;stroke-width:0.5}
+.band{fill:#fdd835;fill-opacity:0.12;stroke:none}
+.band text,.column text,.block text,.class text{font-size:5px}
+.class rect{fill-opacity:0.15;stroke-width:0.5}
+.column rect{fill:none;stroke:#2e7d32;stroke-width:0.75;stroke-dasharray:4 2}
+.block rect{fill:none;stroke:#f4511e;stroke-width:0.75}
+.run rect{fill:none;stroke:#90caf9;stroke-width:0.25}
+.run text{fill:#0d47a1}
+.order line{stroke:#d81b60;stroke-width:0.75;marker-end:url(#arrow)}
+.order circle{fill:#d81b60}
+.order text{fill:#fff;font-size:5px;text-anchor:middle;dominant-baseline:central}`
+
+// RenderSVG draws how the text runs of a page are analysed, for
+// debugging: the page box, the runs, the blocks found by clustering, the
+// YBands and columns smart ordering groups them into, the classified
+// blocks, and the reading order of the blocks. Each layer is a group
+// whose class names it.
+func RenderSVG(w io.Writer, texts []Text, page Rect, opts SVGOptions) error {
+	if page.Max.X <= page.Min.X || page.Max.Y <= page.Min.Y {
+		page = Rect{Max: Point{612, 792}}
+	}
+	layers := opts.Layers
+	if layers == 0 {
+		layers = SVGAll
+	}
+	scale := opts.Scale
+	if scale <= 0 {
+		scale = 1
+	}
+
+	var runs []Text
+	for _, t := range texts {
+		if t.S != "" {
+			runs = append(runs, t)
+		}
+	}
+
+	s := &svgCanvas{page: page}
+	width, height := page.Max.X-page.Min.X, page.Max.Y-page.Min.Y
+	fmt.Fprintf(&s.sb, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%s\" height=\"%s\" viewBox=\"0 0 %s %s\">\n",
+		svgNum(width*scale), svgNum(height*scale), svgNum(width), svgNum(height))
+	s.sb.WriteString("<defs><marker id=\"arrow\" viewBox=\"0 0 10 10\" refX=\"10\" refY=\"5\" markerWidth=\"6\" markerHeight=\"6\" orient=\"auto\">" +
+		"<path d=\"M0,0L10,5L0,10z\" fill=\"#d81b60\"/></marker></defs>\n")
+	fmt.Fprintf(&s.sb, "<style>\n%s\n</style>\n", svgStyle)
+	s.rect("page", page, "")
+
+	var blocks []*TextBlock
+	if len(runs) > 0 && layers&(SVGBlocks|SVGBands|SVGColumns|SVGOrder) != 0 {
+		if opts.Cluster != nil {
+			blocks = opts.Cluster(runs)
+		} else {
+			blocks = clusterTextBlocks(runs)
+			defer PutTextBlocks(blocks)
+		}
+	}
+	bounds := calculatePageBounds(blocks)
+	bands := groupBlocksByYRange(blocks, bounds)
+
+	if layers&SVGBands != 0 {
+		s.begin("bands")
+		for i, band := range bands {
+			r := Rect{Point{page.Min.X, band.MinY}, Point{page.Max.X, band.MaxY}}
+			s.sb.WriteString("<g class=\"band\">")
+			s.rect("", r, "")
+			s.label(Point{page.Min.X + 2, band.MaxY}, fmt.Sprintf("band %d", i+1))
+			s.sb.WriteString("</g>\n")
+		}
+		s.end()
+	}
+
+	if layers&SVGClasses != 0 && len(runs) > 0 {
+		s.begin("classes")
+		for _, b := range NewPageLayout(runs, page).ClassifiedBlocks() {
+			color := svgClassColors[b.Type]
+			name := b.Type.String()
+			if b.Type == BlockTitle && b.Level > 0 {
+				name += fmt.Sprintf(" h%d", b.Level)
+			}
+			fmt.Fprintf(&s.sb, "<g class=\"class\" data-type=\"%s\">", b.Type)
+			s.rect("", b.Bounds, fmt.Sprintf(" fill=\"%s\" stroke=\"%s\"", color, color))
+			s.label(Point{b.Bounds.Max.X, b.Bounds.Max.Y}, name, " text-anchor=\"end\" fill=\""+color+"\"")
+			s.sb.WriteString("</g>\n")
+		}
+		s.end()
+	}
+
+	if layers&SVGColumns != 0 {
+		s.begin("columns")
+		n := 0
+		for _, band := range bands {
+			for _, col := range detectColumnsInBand(band.Blocks, bounds) {
+				n++
+				r := col[0].Bounds()
+				for _, b := range col[1:] {
+					r = unionRect(r, b.Bounds())
+				}
+				s.sb.WriteString("<g class=\"column\">")
+				s.rect("", r, "")
+				s.label(Point{r.Min.X, r.Min.Y - 6}, fmt.Sprintf("col %d", n), " fill=\"#2e7d32\"")
+				s.sb.WriteString("</g>\n")
+			}
+		}
+		s.end()
+	}
+
+	if layers&SVGBlocks != 0 {
+		s.begin("blocks")
+		for i, b := range blocks {
+			s.sb.WriteString("<g class=\"block\">")
+			s.rect("", b.Bounds(), "")
+			s.label(Point{b.MinX, b.MaxY + 1}, fmt.Sprintf("B%d", i+1), " fill=\"#f4511e\"")
+			s.sb.WriteString("</g>\n")
+		}
+		s.end()
+	}
+
+	if layers&SVGRuns != 0 {
+		s.begin("runs")
+		for _, t := range runs {
+			size := math.Max(math.Abs(t.FontSize), 1)
+			s.sb.WriteString("<g class=\"run\">")
+			fmt.Fprintf(&s.sb, "<title>%s (%s %s at %s,%s)</title>",
+				xmlEscape(t.S), xmlEscape(t.Font), svgNum(t.FontSize), svgNum(t.X), svgNum(t.Y))
+			s.rect("", glyphBounds(t, size), "")
+			fmt.Fprintf(&s.sb, "<text x=\"%s\" y=\"%s\" font-size=\"%s\">%s</text>",
+				svgNum(t.X-page.Min.X), svgNum(page.Max.Y-t.Y), svgNum(size*0.8), xmlEscape(t.S))
+			s.sb.WriteString("</g>\n")
+		}
+		s.end()
+	}
+
+	if layers&SVGOrder != 0 && len(blocks) > 0 {
+		order := detectReadingOrder(blocks)
+		s.begin("order")
+		for i := 1; i < len(order); i++ {
+			from, to := s.point(order[i-1].Center()), s.point(order[i].Center())
+			fmt.Fprintf(&s.sb, "<line x1=\"%s\" y1=\"%s\" x2=\"%s\" y2=\"%s\"/>\n",
+				svgNum(from.X), svgNum(from.Y), svgNum(to.X), svgNum(to.Y))
+		}
+		for i, b := range order {
+			c := s.point(b.Center())
+			fmt.Fprintf(&s.sb, "<circle cx=\"%s\" cy=\"%s\" r=\"4\"/><text x=\"%s\" y=\"%s\">%d</text>\n",
+				svgNum(c.X), svgNum(c.Y), svgNum(c.X), svgNum(c.Y), i+1)
+		}
+		s.end()
+	}
+
+	s.sb.WriteString("</svg>\n")
+	_, err := io.WriteString(w, s.sb.String())
+	return err
+}
+
+// svgCanvas draws in SVG coordinates, which run down from the top left
+// of the page.
+type svgCanvas struct {
+	sb   strings.Builder
+	page Rect
+}
+
+func (s *svgCanvas) point(p Point) Point {
+	return Point{p.X - s.page.Min.X, s.page.Max.Y - p.Y}
+}
+
+func (s *svgCanvas) begin(class string) {
+	fmt.Fprintf(&s.sb, "<g class=\"%s\">\n", class)
+}
+
+func (s *svgCanvas) end() {
+	s.sb.WriteString("</g>\n")
+}
+
+func (s *svgCanvas) rect(class string, r Rect, attrs string) {
+	p := s.point(Point{r.Min.X, r.Max.Y})
+	if class != "" {
+		attrs = fmt.Sprintf(" class=\"%s\"%s", class, attrs)
+	}
+	fmt.Fprintf(&s.sb, "<rect x=\"%s\" y=\"%s\" width=\"%s\" height=\"%s\"%s/>",
+		svgNum(p.X), svgNum(p.Y), svgNum(r.Max.X-r.Min.X), svgNum(r.Max.Y-r.Min.Y), attrs)
+}
+
+// label writes text with its baseline at p, in page coordinates.
+func (s *svgCanvas) label(p Point, text string, attrs ...string) {
+	q := s.point(p)
+	fmt.Fprintf(&s.sb, "<text x=\"%s\" y=\"%s\"%s>%s</text>",
+		svgNum(q.X), svgNum(q.Y), strings.Join(attrs, ""), xmlEscape(text))
+}
+
+// svgNum formats a coordinate to two decimal places without trailing zeros.
+func svgNum(v float64) string {
+	s := fmt.Sprintf("%.2f", v)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+// RenderSVG draws how the text of the page is analysed. See the RenderSVG
+// function.
+func (p Page) RenderSVG(w io.Writer, opts SVGOptions) error {
+	content, err := p.contentWithFonts(nil)
+	if err != nil {
+		return err
+	}
+	return RenderSVG(w, content.Text, p.bounds(), opts)
+}
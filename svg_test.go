@@ -0,0 +1,129 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"strings"
+	"testing"
+)
+
+// svgGroups returns the layer groups of an SVG document by class.
+func svgGroups(t *testing.T, out string) map[string]*xmlNode {
+	t.Helper()
+	root, err := parseXML([]byte(out))
+	if err != nil {
+		t.Fatalf("not well-formed: %v\n%s", err, out)
+	}
+	if root.name.Local != "svg" || root.name.Space != "http://www.w3.org/2000/svg" {
+		t.Fatalf("root element %v", root.name)
+	}
+	groups := make(map[string]*xmlNode)
+	for _, c := range root.children {
+		if c.name.Local == "g" {
+			groups[c.attrs["class"]] = c
+		}
+	}
+	return groups
+}
+
+func TestRenderSVG(t *testing.T) {
+	// A heading over two columns
+	var texts []Text
+	texts = append(texts, glyphRun("Heading", 200, 740)...)
+	for i := range 3 {
+		y := 700 - 14*float64(i)
+		texts = append(texts, glyphRun("left column", 72, y)...)
+		texts = append(texts, glyphRun("right column", 340, y)...)
+	}
+	texts = append(texts, Text{Font: "Helvetica", FontSize: 12, X: 72, Y: 600, W: 18, S: "a<&"})
+	page := Rect{Max: Point{612, 792}}
+
+	var sb strings.Builder
+	if err := RenderSVG(&sb, texts, page, SVGOptions{Scale: 2}); err != nil {
+		t.Fatal(err)
+	}
+	out := sb.String()
+	groups := svgGroups(t, out)
+	for _, layer := range []string{"bands", "classes", "columns", "blocks", "runs", "order"} {
+		if groups[layer] == nil {
+			t.Errorf("no %s layer", layer)
+		}
+	}
+	if !strings.Contains(out, `width="1224" height="1584" viewBox="0 0 612 792"`) {
+		t.Error("page size not scaled")
+	}
+
+	if n := len(groups["runs"].children); n != len(texts) {
+		t.Errorf("%d runs drawn, want %d", n, len(texts))
+	}
+	// The first run of the heading sits 740 points up a 792 point page
+	if !strings.Contains(out, `<rect x="200" y="42.4" width="6" height="12"/><text x="200" y="52" font-size="9.6">H</text>`) {
+		t.Error("heading run misplaced")
+	}
+	if !strings.Contains(out, ">a&lt;&amp;</text>") {
+		t.Error("run text not escaped")
+	}
+
+	blocks := clusterTextBlocks(texts)
+	nblocks := len(blocks)
+	PutTextBlocks(blocks)
+	if n := len(groups["blocks"].children); n != nblocks {
+		t.Errorf("%d blocks drawn, want %d", n, nblocks)
+	}
+	order := groups["order"]
+	var lines, marks int
+	for _, c := range order.children {
+		switch c.name.Local {
+		case "line":
+			lines++
+		case "circle":
+			marks++
+		}
+	}
+	if lines != nblocks-1 || marks != nblocks {
+		t.Errorf("order has %d arrows and %d marks for %d blocks", lines, marks, nblocks)
+	}
+	if len(groups["columns"].children) < 2 {
+		t.Errorf("%d columns drawn, want the two of the body", len(groups["columns"].children))
+	}
+	// The heading at the top of the page is taken for a page header
+	var types []string
+	for _, c := range groups["classes"].children {
+		types = append(types, c.attrs["data-type"])
+	}
+	if got := strings.Join(types, " "); got != "Header Paragraph Paragraph Paragraph" {
+		t.Errorf("classified types = %s", got)
+	}
+	if !strings.Contains(out, `fill="#fb8c00" stroke="#fb8c00"`) {
+		t.Error("header not coloured")
+	}
+}
+
+func TestRenderSVGOptions(t *testing.T) {
+	texts := glyphRun("one two", 72, 700)
+
+	var sb strings.Builder
+	if err := RenderSVG(&sb, texts, Rect{}, SVGOptions{Layers: SVGRuns | SVGBlocks, Cluster: ClusterTextBlocksV3}); err != nil {
+		t.Fatal(err)
+	}
+	groups := svgGroups(t, sb.String())
+	if len(groups) != 2 || groups["runs"] == nil || groups["blocks"] == nil {
+		t.Errorf("layers drawn: %v", groups)
+	}
+	if n, want := len(groups["blocks"].children), len(ClusterTextBlocksV3(texts)); n != want {
+		t.Errorf("%d blocks drawn, want the %d of the given clustering", n, want)
+	}
+
+	// A page without text is just its box
+	sb.Reset()
+	if err := openTextPDF(t, "").Page(1).RenderSVG(&sb, SVGOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	for class, g := range svgGroups(t, sb.String()) {
+		if len(g.children) > 0 {
+			t.Errorf("%s layer of an empty page has %d elements", class, len(g.children))
+		}
+	}
+}
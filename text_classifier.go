@@ -54,6 +54,7 @@ type ClassifiedBlock struct {
 	Content []Text    // Text runs in this block
 	Bounds  Rect      // Bounding box
 	Text    string    // Concatenated text content
+	Page    int       // Page number (1-based), if known
 }
 
 // TextClassifier classifies text runs into semantic blocks
@@ -409,25 +410,23 @@ func (tc *TextClassifier) getTitleLevel(fontSize float64) int {
 
 // ClassifyTextBlocks is a convenience function that creates a classifier and runs classification
 func (p Page) ClassifyTextBlocks() ([]ClassifiedBlock, error) {
-	content := p.Content()
-	if len(content.Text) == 0 {
-		return nil, nil
-	}
+	return p.classifyTextBlocks(nil)
+}
 
-	// Get page dimensions
-	mediaBox := p.V.Key("MediaBox")
-	var pageWidth, pageHeight float64
-	if mediaBox.Kind() == Array && mediaBox.Len() >= 4 {
-		pageWidth = mediaBox.Index(2).Float64()
-		pageHeight = mediaBox.Index(3).Float64()
-	} else {
-		// Default to letter size if no MediaBox
-		pageWidth = 612.0  // 8.5 inches * 72 dpi
-		pageHeight = 792.0 // 11 inches * 72 dpi
+// classifyTextBlocks classifies the page's blocks after optionally
+// collapsing overprinted copies of text.
+func (p Page) classifyTextBlocks(dedup *DedupOptions) ([]ClassifiedBlock, error) {
+	if err := p.V.r.checkExtract(); err != nil {
+		return nil, err
 	}
-
-	classifier := NewTextClassifier(content.Text, pageWidth, pageHeight)
-	return classifier.ClassifyBlocks(), nil
+	texts := p.Content().Text
+	if dedup != nil {
+		texts = DeduplicateTexts(texts, *dedup)
+	}
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	return NewPageLayout(texts, p.bounds()).ClassifiedBlocks(), nil
 }
 
 // GetTextByType returns all text blocks of a specific type
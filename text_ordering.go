@@ -87,6 +87,25 @@ func clusterTextBlocks(texts []Text) []*TextBlock {
 		return nil
 	}
 
+	// Vertical writing is clustered on its own, so vertical body text and
+	// horizontal headlines end up in separate blocks
+	var vertical []Text
+	for _, t := range texts {
+		if t.Vertical {
+			vertical = append(vertical, t)
+		}
+	}
+	if len(vertical) > 0 {
+		horizontal := make([]Text, 0, len(texts)-len(vertical))
+		for _, t := range texts {
+			if !t.Vertical {
+				horizontal = append(horizontal, t)
+			}
+		}
+		blocks := ClusterTextBlocksV4(horizontal)
+		return append(blocks, clusterVerticalTextBlocks(vertical)...)
+	}
+
 	// Use V4 which automatically selects the best algorithm based on input size
 	// - Small (<50): Simple O(n²) algorithm
 	// - Medium (50-500): V3 spatial grid with union-find
@@ -94,6 +113,55 @@ func clusterTextBlocks(texts []Text) []*TextBlock {
 	return ClusterTextBlocksV4(texts)
 }
 
+// clusterVerticalTextBlocks clusters vertically written texts by turning
+// them on their side, so that their top-to-bottom lines run left to right
+// and lines further right come first, and mapping the blocks back.
+func clusterVerticalTextBlocks(texts []Text) []*TextBlock {
+	top := texts[0].Y
+	for _, t := range texts {
+		top = math.Max(top, t.Y)
+	}
+
+	turned := make([]Text, len(texts))
+	index := make(map[Text][]int, len(texts))
+	for i, t := range texts {
+		u := t
+		u.X, u.Y, u.W = top-t.Y, t.X, math.Abs(t.FontSize)
+		u.Vertical = false
+		turned[i] = u
+		index[u] = append(index[u], i)
+	}
+
+	clusters := ClusterTextBlocksV4(turned)
+	blocks := make([]*TextBlock, 0, len(clusters))
+	for _, c := range clusters {
+		b := GetTextBlock()
+		b.AvgFontSize = c.AvgFontSize
+		for _, u := range c.Texts {
+			idx := index[u]
+			if len(idx) == 0 {
+				continue
+			}
+			index[u] = idx[1:]
+			t := texts[idx[0]]
+
+			// The glyph hangs below its origin, centred on the line
+			half := math.Abs(t.FontSize) / 2
+			minX, maxX, minY, maxY := t.X-half, t.X+half, t.Y-2*half, t.Y
+			if len(b.Texts) == 0 {
+				b.MinX, b.MaxX, b.MinY, b.MaxY = minX, maxX, minY, maxY
+			} else {
+				b.MinX, b.MaxX = math.Min(b.MinX, minX), math.Max(b.MaxX, maxX)
+				b.MinY, b.MaxY = math.Min(b.MinY, minY), math.Max(b.MaxY, maxY)
+			}
+			b.Texts = append(b.Texts, t)
+		}
+		blocks = append(blocks, b)
+	}
+	PutTextBlocks(clusters)
+	return blocks
+}
+
 // clusterTextBlocksSimple Simple clustering method (for small datasets)
 func clusterTextBlocksSimple(texts []Text) []*TextBlock {
 	if len(texts) == 0 {
@@ -482,6 +550,13 @@ func detectColumnsInBand(blocks []*TextBlock, pageBounds Rect) [][]*TextBlock {
 		columns = append(columns, currentColumn)
 	}
 
+	// Vertical writing reads its columns right to left
+	if allVerticalBlocks(blocks) {
+		for i, j := 0, len(columns)-1; i < j; i, j = i+1, j-1 {
+			columns[i], columns[j] = columns[j], columns[i]
+		}
+	}
+
 	return columns
 }
 
@@ -564,9 +639,16 @@ func sortBlocksInBand(blocks []*TextBlock) []*TextBlock {
 	// First, identify the main flow direction in this band
 	// This could be left-to-right columns, but also more complex flows
 
-	// Simple approach: sort by X position (left to right)
+	// Simple approach: sort by X position (left to right), or right to
+	// left when the whole band is written vertically
 	sorted := make([]*TextBlock, len(blocks))
 	copy(sorted, blocks)
+	if allVerticalBlocks(sorted) {
+		sort.Slice(sorted, func(i, j int) bool {
+			return sorted[i].MaxX > sorted[j].MaxX
+		})
+		return sorted
+	}
 	sort.Slice(sorted, func(i, j int) bool {
 		return sorted[i].MinX < sorted[j].MinX
 	})
@@ -574,6 +656,27 @@ func sortBlocksInBand(blocks []*TextBlock) []*TextBlock {
 	return sorted
 }
 
+// isVerticalTexts reports whether most of the texts are written vertically.
+func isVerticalTexts(texts []Text) bool {
+	n := 0
+	for _, t := range texts {
+		if t.Vertical {
+			n++
+		}
+	}
+	return n*2 > len(texts)
+}
+
+// allVerticalBlocks reports whether every block is mostly vertical text.
+func allVerticalBlocks(blocks []*TextBlock) bool {
+	for _, b := range blocks {
+		if !isVerticalTexts(b.Texts) {
+			return false
+		}
+	}
+	return len(blocks) > 0
+}
+
 // sortWithinBlock sorts texts within a single block in reading order
 func sortWithinBlock(texts []Text) []Text {
 	if len(texts) == 0 {
@@ -742,6 +845,9 @@ func sortWithinBlockOptimized(texts []Text) []Text {
 	if len(texts) == 0 {
 		return texts
 	}
+	if isVerticalTexts(texts) {
+		return sortVerticalWithinBlock(texts)
+	}
 
 	const lineTolerance = 3.0
 
@@ -778,3 +884,18 @@ func sortWithinBlockOptimized(texts []Text) []Text {
 
 	return texts
 }
+
+// sortVerticalWithinBlock sorts vertically written texts into top-to-bottom
+// lines, read from right to left. Texts of one line share an X position.
+func sortVerticalWithinBlock(texts []Text) []Text {
+	const lineTolerance = 3.0
+
+	sort.SliceStable(texts, func(i, j int) bool {
+		deltaX := texts[i].X - texts[j].X
+		if deltaX > lineTolerance || deltaX < -lineTolerance {
+			return texts[i].X > texts[j].X
+		}
+		return texts[i].Y > texts[j].Y
+	})
+	return texts
+}
@@ -327,3 +327,59 @@ func TestSmartTextRunsToPlain(t *testing.T) {
 		t.Errorf("Expected 'hello world', got '%s'", plain)
 	}
 }
+
+// verticalColumn returns one vertical text per rune of s, going down from
+// (x, y) in steps of size.
+func verticalColumn(s string, x, y, size float64) []Text {
+	var texts []Text
+	for _, r := range s {
+		texts = append(texts, Text{Font: "Mincho", FontSize: size, X: x, Y: y, S: string(r), Vertical: true})
+		y -= size
+	}
+	return texts
+}
+
+func textString(texts []Text) string {
+	var s string
+	for _, t := range texts {
+		s += t.S
+	}
+	return s
+}
+
+// TestSmartTextOrderingVertical tests that vertical lines are read top to
+// bottom and right to left, below a horizontal headline
+func TestSmartTextOrderingVertical(t *testing.T) {
+	var texts []Text
+	// Drawn left line first to make sure ordering does not follow the stream
+	texts = append(texts, verticalColumn("三四", 380, 600, 12)...)
+	texts = append(texts, verticalColumn("一二", 400, 600, 12)...)
+	texts = append(texts, Text{Font: "Helvetica", FontSize: 18, X: 300, Y: 660, W: 72, S: "Head"})
+
+	if got := textString(smartTextOrdering(texts)); got != "Head一二三四" {
+		t.Errorf("smartTextOrdering = %q, want %q", got, "Head一二三四")
+	}
+}
+
+// TestVerticalColumnsRightToLeft tests that blocks of vertical text are
+// ordered right to left by both column detection and reading order
+func TestVerticalColumnsRightToLeft(t *testing.T) {
+	left := &TextBlock{Texts: verticalColumn("左", 100, 600, 12), MinX: 94, MaxX: 106, MinY: 400, MaxY: 600}
+	right := &TextBlock{Texts: verticalColumn("右", 400, 600, 12), MinX: 394, MaxX: 406, MinY: 400, MaxY: 600}
+
+	columns := detectColumns([]*TextBlock{left, right})
+	if len(columns) != 2 || columns[0][0] != right || columns[1][0] != left {
+		t.Errorf("detectColumns did not order vertical columns right to left: %v", columns)
+	}
+
+	ordered := detectReadingOrder([]*TextBlock{left, right})
+	if len(ordered) != 2 || ordered[0] != right {
+		t.Errorf("detectReadingOrder did not start with the right block")
+	}
+
+	// Horizontal blocks keep left to right order
+	left.Texts[0].Vertical, right.Texts[0].Vertical = false, false
+	if ordered := detectReadingOrder([]*TextBlock{right, left}); ordered[0] != left {
+		t.Errorf("horizontal blocks should be ordered left to right")
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// A WarningCode identifies a kind of malformation the reader recovers from.
+type WarningCode string
+
+const (
+	WarnHeader          WarningCode = "header"           // the %PDF- header is damaged or truncated
+	WarnMissingEOF      WarningCode = "missing-eof"      // the file does not end with %%EOF
+	WarnStartxref       WarningCode = "startxref"        // startxref was found only by searching the file
+	WarnXrefRecovered   WarningCode = "xref-recovered"   // the cross-reference table was damaged and rebuilt
+	WarnXrefEntry       WarningCode = "xref-entry"       // a cross-reference stream entry has an unknown type
+	WarnTrailer         WarningCode = "trailer"          // the trailer is missing or lacks Root, and was rebuilt
+	WarnMissingEndobj   WarningCode = "missing-endobj"   // an object definition lacks endobj
+	WarnUnexpectedToken WarningCode = "unexpected-token" // a keyword or delimiter where an object belongs, read as null
+	WarnUnterminated    WarningCode = "unterminated"     // an array, dictionary or string runs to the end of the data
+	WarnBadHexString    WarningCode = "bad-hex-string"   // a hex string with invalid digits, which are skipped
+	WarnStreamEOL       WarningCode = "stream-eol"       // no end-of-line marker after the stream keyword
+	WarnObjectMismatch  WarningCode = "object-mismatch"  // the object at an xref offset has another number
+	WarnBadObject       WarningCode = "bad-object"       // no object definition where the xref says, read as null
+	WarnObjectStream    WarningCode = "object-stream"    // an object stream is missing or lacks the object, read as null
+	WarnFilter          WarningCode = "filter"           // stream filter parameters are not supported; the data is passed on as it is
+	WarnFont            WarningCode = "font"             // an embedded font program cannot be parsed
+	WarnCMap            WarningCode = "cmap"             // the CMap data for a font's text is damaged or missing
+)
+
+// A Warning records a malformation the reader recovered from.
+type Warning struct {
+	Code       WarningCode `json:"code"`
+	Object     int         `json:"object"` // the object being read, or 0
+	Generation int         `json:"generation"`
+	Offset     int64       `json:"offset"` // where in the file it was noticed, or -1 if unknown
+	Message    string      `json:"message"`
+}
+
+func (w Warning) String() string {
+	var where string
+	if w.Object != 0 {
+		where = fmt.Sprintf(" %d %d R", w.Object, w.Generation)
+	}
+	if w.Offset >= 0 {
+		where += fmt.Sprintf(" @%d", w.Offset)
+	}
+	return fmt.Sprintf("[%s]%s: %s", w.Code, where, w.Message)
+}
+
+// A WarningCollector gathers the warnings of the readers it is attached to
+// with ReaderOptions. It is safe for concurrent use.
+type WarningCollector struct {
+	mu       sync.Mutex
+	warnings []Warning
+}
+
+// Warnings returns the warnings collected so far, in the order they
+// occurred.
+func (c *WarningCollector) Warnings() []Warning {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Clone(c.warnings)
+}
+
+// Len returns the number of warnings collected so far.
+func (c *WarningCollector) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.warnings)
+}
+
+func (c *WarningCollector) add(w Warning) {
+	c.mu.Lock()
+	c.warnings = append(c.warnings, w)
+	c.mu.Unlock()
+}
+
+// first returns the first warning from the start'th on whose code is one
+// of codes.
+func (c *WarningCollector) first(start int, codes []WarningCode) (Warning, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, w := range c.warnings[start:] {
+		if slices.Contains(codes, w.Code) {
+			return w, true
+		}
+	}
+	return Warning{}, false
+}
+
+// recordWarning adds a warning to c, if not nil, and prints it if DebugOn
+// is set.
+func recordWarning(c *WarningCollector, code WarningCode, ptr objptr, offset int64, format string, args ...interface{}) {
+	if c == nil && !DebugOn {
+		return
+	}
+	w := Warning{
+		Code:       code,
+		Object:     int(ptr.id),
+		Generation: int(ptr.gen),
+		Offset:     offset,
+		Message:    fmt.Sprintf(format, args...),
+	}
+	if DebugOn {
+		fmt.Println("warning:", w)
+	}
+	if c != nil {
+		c.add(w)
+	}
+}
+
+// warn records a warning of the reader, which may be nil.
+func (r *Reader) warn(code WarningCode, ptr objptr, offset int64, format string, args ...interface{}) {
+	var c *WarningCollector
+	if r != nil {
+		c = r.warnings
+	}
+	recordWarning(c, code, ptr, offset, format, args...)
+}
+
+// warn records a warning at the current position of the buffer, in the
+// object it is reading.
+func (b *buffer) warn(code WarningCode, format string, args ...interface{}) {
+	if b.warnings == nil && !DebugOn {
+		return
+	}
+	offset := b.warnAt
+	if offset == 0 {
+		offset = b.readOffset()
+	}
+	recordWarning(b.warnings, code, b.objptr, offset, format, args...)
+}
+
+// StrictError reports a malformation that ReaderOptions.Strict makes an
+// error. It matches ErrStrict.
+type StrictError struct {
+	Warning Warning
+}
+
+func (e *StrictError) Error() string {
+	return fmt.Sprintf("pdf: rejected in strict mode: %v", e.Warning)
+}
+
+func (e *StrictError) Is(target error) bool {
+	return target == ErrStrict
+}
+
+// readAll reads every object in the cross-reference table, so that the
+// warnings of reading them are recorded.
+func (r *Reader) readAll() {
+	for i, x := range r.xref {
+		if i == 0 || x.ptr.id != uint32(i) || !x.inStream && x.offset == 0 {
+			continue
+		}
+		safely(func() {
+			r.resolve(objptr{}, x.ptr)
+		})
+	}
+}
@@ -0,0 +1,193 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// malformedPDF returns a document whose object 3 lacks endobj and whose
+// object 4 has a hex string with invalid digits.
+func malformedPDF() []byte {
+	data := buildPDF("/Root 1 0 R /Info 4 0 R",
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Count 0 /Kids [] >>",
+		"<< /Producer (test) >>",
+		"<< /Title <4G6F> >>",
+	)
+	i := bytes.Index(data, []byte("4 0 obj"))
+	j := bytes.LastIndex(data[:i], []byte("endobj"))
+	copy(data[j:], "      ")
+	return data
+}
+
+func TestWarnings(t *testing.T) {
+	data := malformedPDF()
+	var c WarningCollector
+	r, err := NewReaderWithOptions(bytes.NewReader(data), int64(len(data)), ReaderOptions{Warnings: &c})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := c.Len(); n != 0 {
+		t.Errorf("warnings before reading objects = %v", c.Warnings())
+	}
+	r.Trailer().Key("Info").Key("Title")
+	r.resolve(objptr{}, objptr{3, 0})
+
+	got := c.Warnings()
+	if len(got) != 2 {
+		t.Fatalf("Warnings = %v, want 2", got)
+	}
+	if w := got[0]; w.Code != WarnBadHexString || w.Object != 4 {
+		t.Errorf("first warning = %v, want bad-hex-string in object 4", w)
+	}
+	// The missing endobj is noticed after reading the 4 of "4 0 obj".
+	endobj := int64(bytes.Index(data, []byte("4 0 obj")) + 1)
+	if w := got[1]; w.Code != WarnMissingEndobj || w.Object != 3 || w.Offset != endobj {
+		t.Errorf("second warning = %v, want missing-endobj in object 3 at %d", w, endobj)
+	}
+	if s := got[1].String(); !strings.HasPrefix(s, "[missing-endobj] 3 0 R @") {
+		t.Errorf("String = %q", s)
+	}
+
+	c = WarningCollector{}
+	data = buildPDF("/Root 1 0 R",
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Count 0 /Kids [] >>",
+	)
+	if _, err := NewReaderWithOptions(bytes.NewReader(data), int64(len(data)), ReaderOptions{Warnings: &c, Strict: []WarningCode{WarnMissingEndobj}}); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Warnings(); got != nil {
+		t.Errorf("Warnings of a well-formed document = %v", got)
+	}
+}
+
+func TestWarningsRecoveredXref(t *testing.T) {
+	data := buildPDF("/Root 1 0 R",
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Count 0 /Kids [] >>",
+	)
+	i := bytes.LastIndex(data, []byte("startxref\n")) + len("startxref\n")
+	data = append(data[:i:i], []byte("9\n%%EOF\n")...)
+	var c WarningCollector
+	r, err := NewReaderWithOptions(bytes.NewReader(data), int64(len(data)), ReaderOptions{Warnings: &c})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Trailer().Key("Root").Key("Type").Name() != "Catalog" {
+		t.Error("Root not recovered")
+	}
+	got := c.Warnings()
+	if len(got) == 0 || got[0].Code != WarnXrefRecovered || got[0].Offset != 9 {
+		t.Errorf("Warnings = %v, want xref-recovered at 9 first", got)
+	}
+}
+
+func TestStrict(t *testing.T) {
+	data := malformedPDF()
+	_, err := NewReaderWithOptions(bytes.NewReader(data), int64(len(data)), ReaderOptions{Strict: []WarningCode{WarnMissingEndobj}})
+	if !errors.Is(err, ErrStrict) {
+		t.Fatalf("err = %v, want ErrStrict", err)
+	}
+	var se *StrictError
+	if !errors.As(err, &se) || se.Warning.Code != WarnMissingEndobj || se.Warning.Object != 3 {
+		t.Errorf("err = %v, want missing-endobj in object 3", err)
+	}
+
+	if _, err := NewReaderWithOptions(bytes.NewReader(data), int64(len(data)), ReaderOptions{Strict: []WarningCode{WarnXrefRecovered}}); err != nil {
+		t.Errorf("strict about xref-recovered: %v", err)
+	}
+}
+
+func TestWarningsSynthesizedTrailer(t *testing.T) {
+	data := buildPDF("/Root 1 0 R",
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Count 0 /Kids [] >>",
+	)
+	i := bytes.LastIndex(data, []byte("trailer"))
+	copy(data[i:], "       ")
+	var c WarningCollector
+	r, err := NewReaderWithOptions(bytes.NewReader(data), int64(len(data)), ReaderOptions{Warnings: &c})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Trailer().Key("Root").Key("Type").Name() != "Catalog" {
+		t.Error("Root not recovered")
+	}
+	var found bool
+	for _, w := range c.Warnings() {
+		found = found || w.Code == WarnTrailer && strings.Contains(w.Message, "synthesized")
+	}
+	if !found {
+		t.Errorf("Warnings = %v, want a synthesized trailer", c.Warnings())
+	}
+}
+
+func TestWarningsContent(t *testing.T) {
+	// A damaged embedded character collection
+	broken := &cmapTableEntry{err: errors.New("damaged table")}
+	broken.once.Do(func() {})
+	cmapTables.Store("Adobe-Broken", broken)
+	defer cmapTables.Delete("Adobe-Broken")
+
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	zw.Write([]byte("data"))
+	zw.Close()
+	data := buildPDF("/Root 1 0 R",
+		"<< /Type /Catalog /Pages 2 0 R /Predicted 8 0 R /A85 9 0 R >>",
+		"<< /Type /Pages /Count 1 /Kids [3 0 R] >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 4 0 R /F2 5 0 R >> >> >>",
+		"<< /Type /Font /Subtype /TrueType /BaseFont /Broken /FontDescriptor 6 0 R >>",
+		"<< /Type /Font /Subtype /Type0 /BaseFont /Broken /Encoding /Identity-H /DescendantFonts [<< /Type /Font /Subtype /CIDFontType0 "+
+			"/BaseFont /Broken /CIDSystemInfo << /Registry (Adobe) /Ordering (Broken) /Supplement 0 >> >>] >>",
+		"<< /Type /FontDescriptor /FontName /Broken /FontFile2 7 0 R >>",
+		"<< /Length 9 >>\nstream\nnot a ttf\nendstream",
+		fmt.Sprintf("<< /Length %d /Filter /FlateDecode /DecodeParms << /Predictor 99 >> >>\nstream\n%s\nendstream", z.Len(), z.Bytes()),
+		"<< /Length 2 /Filter /ASCII85Decode /DecodeParms << /Columns 4 >> >>\nstream\n~>\nendstream",
+	)
+	var c WarningCollector
+	r, err := NewReaderWithOptions(bytes.NewReader(data), int64(len(data)), ReaderOptions{Warnings: &c})
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := r.Page(1)
+	f1 := page.Font("F1")
+	if f1.EmbeddedTrueType() != nil {
+		t.Error("garbage font program parsed")
+	}
+	f2 := page.Font("F2")
+	f2.Encoder()
+	root := r.Trailer().Key("Root")
+	if b, err := io.ReadAll(root.Key("Predicted").Reader()); err != nil || string(b) != "data" {
+		t.Errorf("stream with an unknown predictor = %q, %v", b, err)
+	}
+	io.ReadAll(root.Key("A85").Reader())
+
+	for _, want := range []struct {
+		code WarningCode
+		text string
+	}{
+		{WarnFont, "TrueType"},
+		{WarnCMap, "Adobe-Broken"},
+		{WarnFilter, "predictor 99"},
+		{WarnFilter, "ASCII85Decode"},
+	} {
+		var found bool
+		for _, w := range c.Warnings() {
+			found = found || w.Code == want.code && strings.Contains(w.Message, want.text)
+		}
+		if !found {
+			t.Errorf("no %s warning mentioning %q in %v", want.code, want.text, c.Warnings())
+		}
+	}
+}
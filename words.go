@@ -0,0 +1,193 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A Word is a run of glyphs on one line with no space between them,
+// together with its bounding box, like the output of pdftotext -bbox.
+type Word struct {
+	Text      string  // the UTF-8 text of the word
+	Bounds    Rect    // the bounding box, in points
+	Font      string  // the font of the first glyph
+	FontSize  float64 // the font size of the first glyph, in points
+	Vertical  bool    // whether the word is drawn vertically
+	Bold      bool    // whether the word is bold
+	Italic    bool    // whether the word is italic
+	Underline bool    // whether the word is underlined
+	Index     int     // position of the word in the page's reading order
+}
+
+const (
+	// wordGapRatio is the gap between glyphs, as a fraction of the font
+	// size, above which they belong to separate words. TJ kerning used for
+	// tracking stays well below it while explicit word gaps exceed it.
+	wordGapRatio = 0.15
+
+	// Approximate ascent and descent of a glyph box, as fractions of the
+	// font size, when no font metrics are at hand.
+	wordAscent  = 0.8
+	wordDescent = 0.2
+)
+
+// Words returns the words on the page in reading order.
+// Runs are split at spaces and merged across glyphs using their advances,
+// so the result does not depend on how the content stream chunked the text.
+func (p Page) Words() ([]Word, error) {
+	layout, err := p.Layout()
+	if err != nil {
+		return nil, err
+	}
+	return layout.Words(), nil
+}
+
+// buildWords groups text runs into words in reading order and assigns each
+// word its reading-order index.
+func buildWords(texts []Text) []Word {
+	return NewPageLayout(texts, Rect{}).Words()
+}
+
+// wordBuilder accumulates glyphs into the current word.
+type wordBuilder struct {
+	words  []Word
+	glyphs [][]Text // glyphs of each word, parallel to words
+	cur    Word
+	curGly []Text
+	sb     strings.Builder
+	open   bool
+	// end of the previous glyph along the writing direction
+	lastEnd   float64
+	lastCross float64
+}
+
+// addText splits a run into glyphs, spreading its width evenly.
+func (wb *wordBuilder) addText(t Text) {
+	n := utf8.RuneCountInString(t.S)
+	if n == 0 {
+		return
+	}
+	advance := t.W / float64(n)
+	x, y := t.X, t.Y
+	for _, r := range t.S {
+		g := t
+		g.X, g.Y, g.W = x, y, advance
+		wb.addGlyph(g, r)
+		if t.Vertical {
+			y -= verticalExtent(g, r, math.Abs(t.FontSize))
+		} else {
+			x += advance
+		}
+	}
+}
+
+func (wb *wordBuilder) addGlyph(g Text, r rune) {
+	if unicode.IsSpace(r) || r == 0 {
+		wb.flush()
+		return
+	}
+	g.S = string(r)
+
+	size := math.Abs(g.FontSize)
+	if size == 0 {
+		size = 1
+	}
+	box := glyphBounds(g, size)
+
+	if wb.open && wb.breaksWord(g, box, size) {
+		wb.flush()
+	}
+
+	if !wb.open {
+		wb.cur = Word{
+			Bounds:    box,
+			Font:      g.Font,
+			FontSize:  g.FontSize,
+			Vertical:  g.Vertical,
+			Bold:      g.Bold,
+			Italic:    g.Italic,
+			Underline: g.Underline,
+		}
+		wb.open = true
+	} else {
+		wb.cur.Bounds = unionRect(wb.cur.Bounds, box)
+	}
+	wb.sb.WriteRune(r)
+	wb.curGly = append(wb.curGly, g)
+
+	if g.Vertical {
+		wb.lastEnd, wb.lastCross = box.Min.Y, g.X
+	} else {
+		wb.lastEnd, wb.lastCross = box.Max.X, g.Y
+	}
+}
+
+// breaksWord reports whether g starts a new word after the current one.
+func (wb *wordBuilder) breaksWord(g Text, box Rect, size float64) bool {
+	if g.Vertical != wb.cur.Vertical {
+		return true
+	}
+	if g.Vertical {
+		if math.Abs(g.X-wb.lastCross) > size*0.5 {
+			return true
+		}
+		gap := wb.lastEnd - box.Max.Y
+		return gap > size*wordGapRatio || gap < -size*0.5
+	}
+	if math.Abs(g.Y-wb.lastCross) > size*0.5 {
+		return true
+	}
+	gap := box.Min.X - wb.lastEnd
+	return gap > size*wordGapRatio || gap < -size*0.5
+}
+
+func (wb *wordBuilder) flush() {
+	if !wb.open {
+		return
+	}
+	wb.cur.Text = wb.sb.String()
+	wb.words = append(wb.words, wb.cur)
+	wb.glyphs = append(wb.glyphs, wb.curGly)
+	wb.curGly = nil
+	wb.sb.Reset()
+	wb.open = false
+}
+
+// glyphBounds returns the box of a single glyph.
+func glyphBounds(g Text, size float64) Rect {
+	if g.Vertical {
+		r, _ := utf8.DecodeRuneInString(g.S)
+		return Rect{
+			Min: Point{g.X - size/2, g.Y - verticalExtent(g, r, size)},
+			Max: Point{g.X + size/2, g.Y},
+		}
+	}
+	return Rect{
+		Min: Point{g.X, g.Y - size*wordDescent},
+		Max: Point{g.X + g.W, g.Y + size*wordAscent},
+	}
+}
+
+// verticalExtent returns how far glyph r reaches down a vertical line.
+// Upright glyphs fill one em; glyphs turned sideways, such as Latin letters
+// without a vertical presentation form, span their horizontal advance.
+func verticalExtent(g Text, r rune, size float64) float64 {
+	if g.W > 0 && ShouldRotateGlyph(r) && GetVerticalVariant(r) == r {
+		return g.W
+	}
+	return size
+}
+
+// unionRect returns the smallest rectangle containing a and b.
+func unionRect(a, b Rect) Rect {
+	return Rect{
+		Min: Point{math.Min(a.Min.X, b.Min.X), math.Min(a.Min.Y, b.Min.Y)},
+		Max: Point{math.Max(a.Max.X, b.Max.X), math.Max(a.Max.Y, b.Max.Y)},
+	}
+}
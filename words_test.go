@@ -0,0 +1,152 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+// buildTextPDF builds a PDF with one page per content stream. Pages share
+// the fonts /F1 (Helvetica) and /F2 (Helvetica-Bold), both 500 units wide
+// for every code from 32 to 126, and /F3, a vertical Japanese CID font
+// shown with UTF-16 codes.
+func buildTextPDF(contents ...string) []byte {
+	var buf bytes.Buffer
+	var offsets []int
+	obj := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+	widths := strings.TrimSpace(strings.Repeat("500 ", 95))
+	kids := make([]string, len(contents))
+	for i := range contents {
+		kids[i] = fmt.Sprintf("%d 0 R", 6+2*i)
+	}
+
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	obj(fmt.Sprintf("<< /Type /Pages /Count %d /Kids [%s] >>", len(contents), strings.Join(kids, " ")))
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /FirstChar 32 /LastChar 126 /Widths [" + widths + "] >>")
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /FirstChar 32 /LastChar 126 /Widths [" + widths + "] >>")
+	obj("<< /Type /Font /Subtype /Type0 /BaseFont /KozMinPro-Regular /Encoding /UniJIS-UCS2-V /DescendantFonts [<< /Type /Font /Subtype /CIDFontType0 /BaseFont /KozMinPro-Regular /CIDSystemInfo << /Registry (Adobe) /Ordering (Japan1) /Supplement 4 >> >>] >>")
+	for i, c := range contents {
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 3 0 R /F2 4 0 R /F3 5 0 R >> >> /Contents %d 0 R >>", 7+2*i))
+		obj(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(c), c))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return buf.Bytes()
+}
+
+func openTextPDF(t *testing.T, contents ...string) *Reader {
+	t.Helper()
+	data := buildTextPDF(contents...)
+	r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	return r
+}
+
+func wordTexts(words []Word) []string {
+	s := make([]string, len(words))
+	for i, w := range words {
+		s[i] = w.Text
+	}
+	return s
+}
+
+func TestPageWords(t *testing.T) {
+	r := openTextPDF(t, "BT /F1 10 Tf 72 700 Td (Hello world) Tj ET BT /F2 10 Tf 72 680 Td [(Bo) -20 (ld) -400 (text)] TJ ET")
+	words, err := r.Page(1).Words()
+	if err != nil {
+		t.Fatalf("Words: %v", err)
+	}
+
+	want := []string{"Hello", "world", "Bold", "text"}
+	if got := wordTexts(words); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("words = %q, want %q", got, want)
+	}
+
+	for i, w := range words {
+		if w.Index != i {
+			t.Errorf("word %q has Index %d, want %d", w.Text, w.Index, i)
+		}
+	}
+
+	hello := words[0]
+	if hello.Font != "Helvetica" || hello.FontSize != 10 || hello.Bold {
+		t.Errorf("unexpected style for Hello: %+v", hello)
+	}
+	// 5 glyphs of 500 units at 10pt
+	if math.Abs(hello.Bounds.Min.X-72) > 0.01 || math.Abs(hello.Bounds.Max.X-97) > 0.01 {
+		t.Errorf("Hello bounds = %+v", hello.Bounds)
+	}
+	if hello.Bounds.Min.Y >= 700 || hello.Bounds.Max.Y <= 700 {
+		t.Errorf("Hello bounds should straddle the baseline: %+v", hello.Bounds)
+	}
+	if words[1].Bounds.Min.X <= hello.Bounds.Max.X {
+		t.Errorf("world should start after Hello: %+v", words[1].Bounds)
+	}
+
+	// Small TJ kerning keeps "Bo"+"ld" together; a large gap splits words
+	if !words[2].Bold || words[2].Font != "Helvetica-Bold" {
+		t.Errorf("Bold word style = %+v", words[2])
+	}
+}
+
+func TestPageWordsSpacingOperators(t *testing.T) {
+	// Tw widens the space; Tc adds tracking but glyphs stay one word
+	r := openTextPDF(t, "BT /F1 10 Tf 0.5 Tc 20 Tw 72 700 Td (ab cd) Tj ET")
+	words, err := r.Page(1).Words()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := wordTexts(words); strings.Join(got, "|") != "ab|cd" {
+		t.Fatalf("words = %q", got)
+	}
+	// a, b and the space each advance 5.5, plus 20 of word spacing
+	if x := words[1].Bounds.Min.X; math.Abs(x-(72+3*5.5+20)) > 0.01 {
+		t.Errorf("cd starts at %.2f, want %.2f", x, 72+3*5.5+20)
+	}
+}
+
+func TestBuildWordsReadingOrder(t *testing.T) {
+	// Drawn bottom line first; reading order must still start at the top
+	texts := []Text{
+		{Font: "F", FontSize: 10, X: 72, Y: 680, W: 25, S: "second"},
+		{Font: "F", FontSize: 10, X: 72, Y: 700, W: 25, S: "first"},
+	}
+	words := buildWords(texts)
+	if got := wordTexts(words); strings.Join(got, "|") != "first|second" {
+		t.Fatalf("words = %q", got)
+	}
+}
+
+func TestBuildWordsSplitsRuns(t *testing.T) {
+	// One run carrying several words is split, spreading its width evenly
+	texts := []Text{{Font: "F", FontSize: 10, X: 0, Y: 0, W: 70, S: "ab cdef"}}
+	words := buildWords(texts)
+	if got := wordTexts(words); strings.Join(got, "|") != "ab|cdef" {
+		t.Fatalf("words = %q", got)
+	}
+	if words[1].Bounds.Min.X != 30 || words[1].Bounds.Max.X != 70 {
+		t.Errorf("cdef bounds = %+v", words[1].Bounds)
+	}
+
+	if words := buildWords(nil); len(words) != 0 {
+		t.Errorf("expected no words, got %v", words)
+	}
+}